@@ -0,0 +1,32 @@
+package main
+
+import (
+	"log"
+
+	"github.com/fall-out-bug/demo-adserver/src/bootstrap"
+	"github.com/fall-out-bug/demo-adserver/src/config"
+)
+
+// The event consumer stores impressions and clicks the servers publish to
+// the Redis event stream (EVENTS_STREAM_ENABLED=true) in Postgres
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	consumer, err := bootstrap.NewConsumer(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize consumer: %v", err)
+	}
+
+	// Wait for interrupt signal in separate goroutine
+	go func() {
+		consumer.WaitForShutdown()
+		consumer.Shutdown()
+	}()
+
+	if err := consumer.Run(); err != nil {
+		log.Fatalf("Consumer error: %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"log"
+	_ "time/tzdata" // Dayparting time zones without relying on the OS zoneinfo
 
 	"github.com/fall-out-bug/demo-adserver/src/bootstrap"
 	"github.com/fall-out-bug/demo-adserver/src/config"
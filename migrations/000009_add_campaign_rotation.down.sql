@@ -0,0 +1,2 @@
+-- Rollback: Remove banner rotation mode from campaigns
+ALTER TABLE campaigns DROP COLUMN IF EXISTS rotation_mode;
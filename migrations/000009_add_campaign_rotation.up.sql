@@ -0,0 +1,3 @@
+-- Migration: Add banner rotation mode to campaigns
+ALTER TABLE campaigns
+    ADD COLUMN IF NOT EXISTS rotation_mode VARCHAR(50) NOT NULL DEFAULT 'weighted';
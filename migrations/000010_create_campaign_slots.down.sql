@@ -0,0 +1,5 @@
+-- Rollback: Drop campaign_slots table and campaign advertisers
+DROP INDEX IF EXISTS idx_campaign_slots_slot_id;
+DROP TABLE IF EXISTS campaign_slots;
+DROP INDEX IF EXISTS idx_campaigns_advertiser;
+ALTER TABLE campaigns DROP COLUMN IF EXISTS advertiser_id;
@@ -0,0 +1,21 @@
+-- Migration: Create campaign_slots table (campaign-to-placement bookings) and link
+-- campaigns to the advertisers who book them
+CREATE TABLE IF NOT EXISTS campaign_slots (
+    campaign_id UUID NOT NULL REFERENCES campaigns(id) ON DELETE CASCADE,
+    slot_id VARCHAR(255) NOT NULL, -- '*' books the campaign run-of-network
+    rule VARCHAR(10) NOT NULL DEFAULT 'include' CHECK (rule IN ('include', 'exclude')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (campaign_id, slot_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_campaign_slots_slot_id ON campaign_slots(slot_id);
+
+-- Keep existing campaigns serving everywhere, as they did before bookings existed
+INSERT INTO campaign_slots (campaign_id, slot_id, rule)
+SELECT id, '*', 'include' FROM campaigns
+ON CONFLICT DO NOTHING;
+
+-- Advertisers book slots for their own campaigns only; house campaigns have none
+ALTER TABLE campaigns ADD COLUMN IF NOT EXISTS advertiser_id UUID REFERENCES advertisers(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_campaigns_advertiser ON campaigns(advertiser_id);
@@ -0,0 +1,3 @@
+-- Rollback: Remove frequency caps from campaigns and banners
+ALTER TABLE banners DROP COLUMN IF EXISTS frequency_caps;
+ALTER TABLE campaigns DROP COLUMN IF EXISTS frequency_caps;
@@ -0,0 +1,6 @@
+-- Migration: Add per-viewer frequency caps to campaigns and banners
+ALTER TABLE campaigns
+    ADD COLUMN IF NOT EXISTS frequency_caps JSONB NOT NULL DEFAULT '[]';
+
+ALTER TABLE banners
+    ADD COLUMN IF NOT EXISTS frequency_caps JSONB NOT NULL DEFAULT '[]';
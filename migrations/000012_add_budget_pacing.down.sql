@@ -0,0 +1,4 @@
+-- Rollback: Remove campaign spend tracking, bid and pacing
+DROP TABLE IF EXISTS campaign_spend;
+ALTER TABLE campaigns DROP COLUMN IF EXISTS pacing;
+ALTER TABLE campaigns DROP COLUMN IF EXISTS bid;
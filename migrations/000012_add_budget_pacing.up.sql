@@ -0,0 +1,12 @@
+-- Migration: Add bid and pacing to campaigns and track daily spend
+ALTER TABLE campaigns
+    ADD COLUMN IF NOT EXISTS bid DECIMAL(10, 4) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS pacing VARCHAR(50) NOT NULL DEFAULT 'asap';
+
+CREATE TABLE IF NOT EXISTS campaign_spend (
+    campaign_id UUID NOT NULL REFERENCES campaigns(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    amount DECIMAL(14, 6) NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (campaign_id, day)
+);
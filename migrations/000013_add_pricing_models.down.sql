@@ -0,0 +1,3 @@
+-- Rollback: Remove billable events and campaign pricing models
+DROP TABLE IF EXISTS billable_events;
+ALTER TABLE campaigns DROP COLUMN IF EXISTS pricing_model;
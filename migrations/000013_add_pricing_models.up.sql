@@ -0,0 +1,19 @@
+-- Migration: Add pricing models to campaigns and record billable events
+ALTER TABLE campaigns
+    ADD COLUMN IF NOT EXISTS pricing_model VARCHAR(50) NOT NULL DEFAULT 'cpm'
+        CHECK (pricing_model IN ('cpm', 'cpc', 'cpa', 'flat'));
+
+CREATE TABLE IF NOT EXISTS billable_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    event_type VARCHAR(50) NOT NULL CHECK (event_type IN ('impression', 'click', 'conversion')),
+    campaign_id UUID NOT NULL REFERENCES campaigns(id) ON DELETE CASCADE,
+    banner_id UUID,
+    impression_id UUID NOT NULL,
+    pricing_model VARCHAR(50) NOT NULL,
+    cost DECIMAL(14, 6) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    -- Each impression is billed at most once per event type
+    UNIQUE (impression_id, event_type)
+);
+
+CREATE INDEX IF NOT EXISTS idx_billable_events_campaign ON billable_events(campaign_id, created_at);
@@ -0,0 +1,2 @@
+-- Rollback: Remove campaign priority tiers
+ALTER TABLE campaigns DROP COLUMN IF EXISTS priority;
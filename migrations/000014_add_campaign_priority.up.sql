@@ -0,0 +1,4 @@
+-- Migration: Add priority tiers to campaigns
+ALTER TABLE campaigns
+    ADD COLUMN IF NOT EXISTS priority VARCHAR(50) NOT NULL DEFAULT 'standard'
+        CHECK (priority IN ('sponsorship', 'standard', 'house'));
@@ -0,0 +1,2 @@
+-- Rollback: Drop audience segments
+DROP TABLE IF EXISTS audience_segments;
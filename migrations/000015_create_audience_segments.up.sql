@@ -0,0 +1,11 @@
+-- Migration: Create audience segments (retargeting lists); memberships live in Redis
+CREATE TABLE IF NOT EXISTS audience_segments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    advertiser_id UUID NOT NULL REFERENCES advertisers(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    membership_ttl_seconds INTEGER NOT NULL CHECK (membership_ttl_seconds > 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audience_segments_advertiser ON audience_segments(advertiser_id);
@@ -0,0 +1,2 @@
+-- Rollback: Remove campaign category
+ALTER TABLE campaigns DROP COLUMN IF EXISTS category;
@@ -0,0 +1,2 @@
+-- Migration: Categorize campaigns for competitive separation
+ALTER TABLE campaigns ADD COLUMN IF NOT EXISTS category VARCHAR(100) NOT NULL DEFAULT '';
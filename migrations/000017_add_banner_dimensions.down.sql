@@ -0,0 +1,12 @@
+-- Rollback: Restore banner size names
+ALTER TABLE banners ADD COLUMN IF NOT EXISTS size VARCHAR(50) NOT NULL DEFAULT 'responsive';
+
+UPDATE banners
+SET size = width || 'x' || height
+WHERE width > 0 AND height > 0;
+
+DROP INDEX IF EXISTS idx_banners_dimensions;
+ALTER TABLE banners DROP COLUMN IF EXISTS height;
+ALTER TABLE banners DROP COLUMN IF EXISTS width;
+
+CREATE INDEX IF NOT EXISTS idx_banners_size ON banners(size);
@@ -0,0 +1,15 @@
+-- Migration: Store banner sizes as dimensions instead of fixed size names
+ALTER TABLE banners
+    ADD COLUMN IF NOT EXISTS width INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS height INTEGER NOT NULL DEFAULT 0;
+
+-- "300x250" becomes 300 x 250; "responsive" stays 0 x 0
+UPDATE banners
+SET width = split_part(size, 'x', 1)::INTEGER,
+    height = split_part(size, 'x', 2)::INTEGER
+WHERE size ~ '^[0-9]+x[0-9]+$';
+
+DROP INDEX IF EXISTS idx_banners_size;
+ALTER TABLE banners DROP COLUMN IF EXISTS size;
+
+CREATE INDEX IF NOT EXISTS idx_banners_dimensions ON banners(width, height);
@@ -0,0 +1,5 @@
+-- Rollback: Remove image creatives
+ALTER TABLE banners ALTER COLUMN html DROP DEFAULT;
+ALTER TABLE banners DROP COLUMN IF EXISTS alt_text;
+ALTER TABLE banners DROP COLUMN IF EXISTS image_url;
+ALTER TABLE banners DROP COLUMN IF EXISTS creative_type;
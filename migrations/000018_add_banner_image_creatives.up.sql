@@ -0,0 +1,8 @@
+-- Migration: Support image creatives with server-generated markup
+ALTER TABLE banners
+    ADD COLUMN IF NOT EXISTS creative_type VARCHAR(20) NOT NULL DEFAULT 'html',
+    ADD COLUMN IF NOT EXISTS image_url TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS alt_text TEXT NOT NULL DEFAULT '';
+
+-- Image creatives have no HTML of their own
+ALTER TABLE banners ALTER COLUMN html SET DEFAULT '';
@@ -0,0 +1,2 @@
+-- Rollback: Remove native creative assets
+ALTER TABLE banners DROP COLUMN IF EXISTS native_assets;
@@ -0,0 +1,2 @@
+-- Migration: Store native creative assets per banner
+ALTER TABLE banners ADD COLUMN IF NOT EXISTS native_assets JSONB;
@@ -0,0 +1,5 @@
+-- Rollback: Remove video creatives and their playback events
+DROP INDEX IF EXISTS idx_video_events_campaign;
+DROP INDEX IF EXISTS idx_video_events_impression;
+DROP TABLE IF EXISTS video_events;
+ALTER TABLE banners DROP COLUMN IF EXISTS video_assets;
@@ -0,0 +1,19 @@
+-- Migration: Video creatives and their playback events
+ALTER TABLE banners ADD COLUMN IF NOT EXISTS video_assets JSONB;
+
+-- Playback events are reported by players before or after the impression is
+-- stored, so they reference it by ID only
+CREATE TABLE IF NOT EXISTS video_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    impression_id UUID NOT NULL,
+    banner_id UUID REFERENCES banners(id) ON DELETE SET NULL,
+    campaign_id UUID REFERENCES campaigns(id) ON DELETE SET NULL,
+    slot_id VARCHAR(255) NOT NULL DEFAULT '',
+    event VARCHAR(20) NOT NULL,
+    timestamp TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    ip VARCHAR(45) NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_video_events_impression ON video_events(impression_id);
+CREATE INDEX IF NOT EXISTS idx_video_events_campaign ON video_events(campaign_id, event, timestamp);
@@ -0,0 +1,7 @@
+-- Rollback: Remove the ledger of served ads
+ALTER TABLE clicks ADD CONSTRAINT clicks_impression_id_fkey
+    FOREIGN KEY (impression_id) REFERENCES impressions(id) ON DELETE SET NULL NOT VALID;
+
+DROP INDEX IF EXISTS idx_served_ads_campaign;
+DROP INDEX IF EXISTS idx_served_ads_served_at;
+DROP TABLE IF EXISTS served_ads;
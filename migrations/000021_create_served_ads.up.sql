@@ -0,0 +1,30 @@
+-- Migration: Ledger of served ads
+-- Every delivered ad is recorded with the impression ID of its tracking URLs;
+-- impression tracking confirms it as rendered, clicks resolve through it.
+CREATE TABLE IF NOT EXISTS served_ads (
+    id UUID PRIMARY KEY,
+    banner_id UUID REFERENCES banners(id) ON DELETE SET NULL,
+    campaign_id UUID REFERENCES campaigns(id) ON DELETE SET NULL,
+    slot_id VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'served' CHECK (status IN ('served', 'rendered')),
+    price DECIMAL(10, 4) NOT NULL DEFAULT 0,
+    pricing_model VARCHAR(50) NOT NULL DEFAULT '',
+    bid DECIMAL(10, 4) NOT NULL DEFAULT 0,
+    clearing_price DECIMAL(10, 4) NOT NULL DEFAULT 0,
+    auction BOOLEAN NOT NULL DEFAULT FALSE,
+    user_id VARCHAR(255) NOT NULL DEFAULT '',
+    ip VARCHAR(45) NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    referer TEXT NOT NULL DEFAULT '',
+    country VARCHAR(2) NOT NULL DEFAULT '',
+    device VARCHAR(50) NOT NULL DEFAULT '',
+    served_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    won_at TIMESTAMP WITH TIME ZONE,
+    rendered_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_served_ads_served_at ON served_ads(served_at);
+CREATE INDEX IF NOT EXISTS idx_served_ads_campaign ON served_ads(campaign_id, served_at);
+
+-- Clicks resolve through the ledger and may arrive before the impression is confirmed
+ALTER TABLE clicks DROP CONSTRAINT IF EXISTS clicks_impression_id_fkey;
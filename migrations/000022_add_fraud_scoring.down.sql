@@ -0,0 +1,10 @@
+-- Rollback: Remove invalid traffic scoring
+DROP INDEX IF EXISTS idx_impressions_invalid;
+
+ALTER TABLE clicks DROP COLUMN IF EXISTS fraud_reasons;
+ALTER TABLE clicks DROP COLUMN IF EXISTS invalid;
+ALTER TABLE clicks DROP COLUMN IF EXISTS fraud_score;
+ALTER TABLE clicks DROP COLUMN IF EXISTS user_agent;
+
+ALTER TABLE impressions DROP COLUMN IF EXISTS fraud_reasons;
+ALTER TABLE impressions DROP COLUMN IF EXISTS invalid;
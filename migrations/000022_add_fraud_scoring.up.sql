@@ -0,0 +1,12 @@
+-- Migration: Invalid traffic scoring of impressions and clicks
+-- Invalid events are kept for reporting but not billed; fraud_reasons lists
+-- the rules they matched, comma-separated.
+ALTER TABLE impressions ADD COLUMN IF NOT EXISTS invalid BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE impressions ADD COLUMN IF NOT EXISTS fraud_reasons TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE clicks ADD COLUMN IF NOT EXISTS user_agent TEXT NOT NULL DEFAULT '';
+ALTER TABLE clicks ADD COLUMN IF NOT EXISTS fraud_score DECIMAL(5, 2) NOT NULL DEFAULT 0.00;
+ALTER TABLE clicks ADD COLUMN IF NOT EXISTS invalid BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE clicks ADD COLUMN IF NOT EXISTS fraud_reasons TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_impressions_invalid ON impressions(campaign_id, timestamp) WHERE invalid;
@@ -0,0 +1,108 @@
+package audience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/fall-out-bug/demo-adserver/src/domain/repositories"
+)
+
+// ErrSegmentNotFound is returned for unknown segments or segments of another advertiser
+var ErrSegmentNotFound = errors.New("segment not found")
+
+// MembershipStore keeps which segments each viewer belongs to
+type MembershipStore interface {
+	Add(ctx context.Context, userID, segmentID string, ttl time.Duration) error
+	Segments(ctx context.Context, userID string) ([]string, error)
+}
+
+// Service manages audience segments and their memberships
+type Service struct {
+	segmentRepo repositories.SegmentRepository
+	store       MembershipStore
+}
+
+// NewService creates a new audience service
+func NewService(segmentRepo repositories.SegmentRepository, store MembershipStore) *Service {
+	return &Service{segmentRepo: segmentRepo, store: store}
+}
+
+// CreateSegment creates a segment owned by an advertiser
+func (s *Service) CreateSegment(ctx context.Context, advertiserID, name string, ttl time.Duration) (*entities.Segment, error) {
+	segment, err := entities.NewSegment(advertiserID, name, ttl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid segment: %w", err)
+	}
+
+	if err := s.segmentRepo.Create(ctx, segment); err != nil {
+		return nil, fmt.Errorf("failed to create segment: %w", err)
+	}
+
+	return segment, nil
+}
+
+// ListSegments returns the segments of an advertiser
+func (s *Service) ListSegments(ctx context.Context, advertiserID string) ([]*entities.Segment, error) {
+	segments, err := s.segmentRepo.FindByAdvertiserID(ctx, advertiserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load segments: %w", err)
+	}
+	return segments, nil
+}
+
+// GetSegment returns a segment of an advertiser
+func (s *Service) GetSegment(ctx context.Context, advertiserID, segmentID string) (*entities.Segment, error) {
+	segment, err := s.findSegment(ctx, segmentID)
+	if err != nil {
+		return nil, err
+	}
+	if segment.AdvertiserID != advertiserID {
+		return nil, ErrSegmentNotFound
+	}
+	return segment, nil
+}
+
+// DeleteSegment deletes a segment of an advertiser. Memberships expire on their own.
+func (s *Service) DeleteSegment(ctx context.Context, advertiserID, segmentID string) error {
+	if _, err := s.GetSegment(ctx, advertiserID, segmentID); err != nil {
+		return err
+	}
+
+	if err := s.segmentRepo.Delete(ctx, segmentID); err != nil {
+		return fmt.Errorf("failed to delete segment: %w", err)
+	}
+	return nil
+}
+
+// Track adds a viewer to a segment, e.g. when the segment pixel fires on the advertiser's site
+func (s *Service) Track(ctx context.Context, segmentID, userID string) error {
+	segment, err := s.findSegment(ctx, segmentID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.store.Add(ctx, userID, segment.ID, segment.MembershipTTL); err != nil {
+		return fmt.Errorf("failed to add segment membership: %w", err)
+	}
+	return nil
+}
+
+// Segments returns the segments a viewer currently belongs to
+func (s *Service) Segments(ctx context.Context, userID string) ([]string, error) {
+	return s.store.Segments(ctx, userID)
+}
+
+// findSegment loads a segment by ID
+func (s *Service) findSegment(ctx context.Context, segmentID string) (*entities.Segment, error) {
+	segment, err := s.segmentRepo.FindByID(ctx, segmentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load segment: %w", err)
+	}
+	if segment == nil {
+		return nil, ErrSegmentNotFound
+	}
+	return segment, nil
+}
@@ -0,0 +1,103 @@
+package audience
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+)
+
+type mockSegmentRepo struct {
+	segments map[string]*entities.Segment
+}
+
+func (m *mockSegmentRepo) Create(ctx context.Context, segment *entities.Segment) error {
+	m.segments[segment.ID] = segment
+	return nil
+}
+
+func (m *mockSegmentRepo) FindByID(ctx context.Context, id string) (*entities.Segment, error) {
+	return m.segments[id], nil
+}
+
+func (m *mockSegmentRepo) FindByAdvertiserID(ctx context.Context, advertiserID string) ([]*entities.Segment, error) {
+	var result []*entities.Segment
+	for _, s := range m.segments {
+		if s.AdvertiserID == advertiserID {
+			result = append(result, s)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockSegmentRepo) Delete(ctx context.Context, id string) error {
+	delete(m.segments, id)
+	return nil
+}
+
+type membership struct {
+	segmentID string
+	ttl       time.Duration
+}
+
+type mockStore struct {
+	members map[string][]membership
+}
+
+func (m *mockStore) Add(ctx context.Context, userID, segmentID string, ttl time.Duration) error {
+	m.members[userID] = append(m.members[userID], membership{segmentID, ttl})
+	return nil
+}
+
+func (m *mockStore) Segments(ctx context.Context, userID string) ([]string, error) {
+	var ids []string
+	for _, ms := range m.members[userID] {
+		ids = append(ids, ms.segmentID)
+	}
+	return ids, nil
+}
+
+func newTestService() (*Service, *mockStore) {
+	store := &mockStore{members: map[string][]membership{}}
+	return NewService(&mockSegmentRepo{segments: map[string]*entities.Segment{}}, store), store
+}
+
+func TestService_Track(t *testing.T) {
+	ctx := context.Background()
+	service, store := newTestService()
+
+	segment, err := service.CreateSegment(ctx, "adv-1", "Visitors", 7*24*time.Hour)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if err := service.Track(ctx, segment.ID, "alice"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := store.members["alice"]; len(got) != 1 || got[0].ttl != 7*24*time.Hour {
+		t.Errorf("Expected membership with the segment TTL, got %v", got)
+	}
+
+	if err := service.Track(ctx, "unknown", "alice"); !errors.Is(err, ErrSegmentNotFound) {
+		t.Errorf("Expected ErrSegmentNotFound, got %v", err)
+	}
+}
+
+func TestService_SegmentsAreScopedToAdvertiser(t *testing.T) {
+	ctx := context.Background()
+	service, _ := newTestService()
+
+	segment, _ := service.CreateSegment(ctx, "adv-1", "Visitors", 0)
+
+	if _, err := service.GetSegment(ctx, "adv-2", segment.ID); !errors.Is(err, ErrSegmentNotFound) {
+		t.Errorf("Expected other advertiser to get ErrSegmentNotFound, got %v", err)
+	}
+	if err := service.DeleteSegment(ctx, "adv-2", segment.ID); !errors.Is(err, ErrSegmentNotFound) {
+		t.Errorf("Expected other advertiser not to delete the segment, got %v", err)
+	}
+	if err := service.DeleteSegment(ctx, "adv-1", segment.ID); err != nil {
+		t.Errorf("Expected owner to delete the segment, got %v", err)
+	}
+}
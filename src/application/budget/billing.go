@@ -0,0 +1,57 @@
+package budget
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/fall-out-bug/demo-adserver/src/domain/repositories"
+)
+
+// Biller turns tracked events into billable events priced at the campaign's
+// pricing model and debits their cost from the campaign's budget
+type Biller struct {
+	campaignRepo repositories.CampaignRepository
+	eventRepo    repositories.BillableEventRepository
+	tracker      *Tracker
+}
+
+// NewBiller creates a new biller
+func NewBiller(
+	campaignRepo repositories.CampaignRepository,
+	eventRepo repositories.BillableEventRepository,
+	tracker *Tracker,
+) *Biller {
+	return &Biller{
+		campaignRepo: campaignRepo,
+		eventRepo:    eventRepo,
+		tracker:      tracker,
+	}
+}
+
+// Bill prices and records the event, then charges the campaign.
+// Events carrying the pricing their ad was served at are priced without
+// loading the campaign. Events already billed for the same impression are
+// not charged again.
+func (b *Biller) Bill(ctx context.Context, event *entities.BillableEvent) error {
+	if !event.PriceAsServed() {
+		campaign, err := b.campaignRepo.FindByID(ctx, event.CampaignID)
+		if err != nil {
+			return err
+		}
+		if campaign == nil {
+			return fmt.Errorf("campaign %s not found", event.CampaignID)
+		}
+		event.Price(campaign)
+	}
+
+	created, err := b.eventRepo.Create(ctx, event)
+	if err != nil {
+		return err
+	}
+	if !created {
+		return nil // Duplicate event
+	}
+
+	return b.tracker.Charge(ctx, event.CampaignID, event.Cost)
+}
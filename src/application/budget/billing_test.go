@@ -0,0 +1,138 @@
+package budget
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/shopspring/decimal"
+)
+
+type mockCampaignRepo struct {
+	campaigns map[string]*entities.Campaign
+}
+
+func (m *mockCampaignRepo) FindByID(ctx context.Context, id string) (*entities.Campaign, error) {
+	return m.campaigns[id], nil
+}
+
+func (m *mockCampaignRepo) FindActive(ctx context.Context) ([]*entities.Campaign, error) {
+	return nil, nil
+}
+
+func (m *mockCampaignRepo) FindBySlotID(ctx context.Context, slotID string) ([]*entities.Campaign, error) {
+	return nil, nil
+}
+
+func (m *mockCampaignRepo) Create(ctx context.Context, campaign *entities.Campaign) error {
+	return nil
+}
+
+func (m *mockCampaignRepo) Update(ctx context.Context, campaign *entities.Campaign) error {
+	return nil
+}
+
+type mockBillableEventRepo struct {
+	events map[string]*entities.BillableEvent
+}
+
+func (m *mockBillableEventRepo) Create(ctx context.Context, event *entities.BillableEvent) (bool, error) {
+	if m.events == nil {
+		m.events = make(map[string]*entities.BillableEvent)
+	}
+	key := event.ImpressionID + ":" + string(event.Type)
+	if _, ok := m.events[key]; ok {
+		return false, nil
+	}
+	m.events[key] = event
+	return true, nil
+}
+
+func newTestBiller(events *mockBillableEventRepo, tracker *Tracker, campaigns ...*entities.Campaign) *Biller {
+	campaignRepo := &mockCampaignRepo{campaigns: make(map[string]*entities.Campaign)}
+	for _, c := range campaigns {
+		campaignRepo.campaigns[c.ID] = c
+	}
+	return NewBiller(campaignRepo, events, tracker)
+}
+
+func TestBiller_Bill_PricesByModel(t *testing.T) {
+	ctx := context.Background()
+	cpm := &entities.Campaign{ID: "cmp-cpm", Pricing: entities.PricingCPM, Bid: decimal.NewFromInt(2)}
+	cpc := &entities.Campaign{ID: "cmp-cpc", Pricing: entities.PricingCPC, Bid: decimal.NewFromFloat(0.5)}
+
+	events := &mockBillableEventRepo{}
+	tracker := newTestTracker(newMemoryStore(), &mockSpendRepo{})
+	biller := newTestBiller(events, tracker, cpm, cpc)
+
+	for i := 0; i < 500; i++ {
+		impression := &entities.Impression{ID: entities.NewImpression("", "", "").ID, CampaignID: "cmp-cpm"}
+		if err := biller.Bill(ctx, entities.NewBillableEvent(entities.EventImpression, impression)); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	impression := &entities.Impression{ID: "imp-1", CampaignID: "cmp-cpc"}
+	biller.Bill(ctx, entities.NewBillableEvent(entities.EventImpression, impression))
+	click := entities.NewBillableEvent(entities.EventClick, impression)
+	biller.Bill(ctx, click)
+
+	if click.Pricing != entities.PricingCPC || !click.Cost.Equal(decimal.NewFromFloat(0.5)) {
+		t.Errorf("Expected click priced at CPC 0.5, got %s %s", click.Pricing, click.Cost)
+	}
+
+	cpmSpend, _ := tracker.Spend(ctx, "cmp-cpm")
+	if !cpmSpend.Total.Equal(decimal.NewFromInt(1)) {
+		t.Errorf("Expected 500 impressions at $2 CPM to cost 1, got %s", cpmSpend.Total)
+	}
+	cpcSpend, _ := tracker.Spend(ctx, "cmp-cpc")
+	if !cpcSpend.Total.Equal(decimal.NewFromFloat(0.5)) {
+		t.Errorf("Expected CPC campaign to pay only for the click, got %s", cpcSpend.Total)
+	}
+}
+
+func TestBiller_Bill_DuplicateNotCharged(t *testing.T) {
+	ctx := context.Background()
+	campaign := &entities.Campaign{ID: "cmp-1", Pricing: entities.PricingCPC, Bid: decimal.NewFromInt(1)}
+	tracker := newTestTracker(newMemoryStore(), &mockSpendRepo{})
+	biller := newTestBiller(&mockBillableEventRepo{}, tracker, campaign)
+
+	impression := &entities.Impression{ID: "imp-1", CampaignID: "cmp-1"}
+	biller.Bill(ctx, entities.NewBillableEvent(entities.EventClick, impression))
+	biller.Bill(ctx, entities.NewBillableEvent(entities.EventClick, impression))
+
+	spend, _ := tracker.Spend(ctx, "cmp-1")
+	if !spend.Total.Equal(decimal.NewFromInt(1)) {
+		t.Errorf("Expected a repeated click to be billed once, got %s", spend.Total)
+	}
+}
+
+func TestBiller_Bill_UnknownCampaign(t *testing.T) {
+	biller := newTestBiller(&mockBillableEventRepo{}, newTestTracker(newMemoryStore(), &mockSpendRepo{}))
+
+	err := biller.Bill(context.Background(), entities.NewBillableEvent(entities.EventClick, &entities.Impression{ID: "imp-1", CampaignID: "missing"}))
+	if err == nil {
+		t.Errorf("Expected error for unknown campaign")
+	}
+}
+
+func TestBiller_Bill_PricedAsServed(t *testing.T) {
+	ctx := context.Background()
+	tracker := newTestTracker(newMemoryStore(), &mockSpendRepo{})
+	// No campaign to load: the impression carries the pricing it was served at
+	biller := newTestBiller(&mockBillableEventRepo{}, tracker)
+
+	impression := &entities.Impression{ID: "imp-1", CampaignID: "cmp-1", Pricing: entities.PricingCPC, Bid: decimal.NewFromFloat(0.5)}
+	click := entities.NewBillableEvent(entities.EventClick, impression)
+	if err := biller.Bill(ctx, click); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !click.Cost.Equal(decimal.NewFromFloat(0.5)) {
+		t.Errorf("Expected click priced at the served CPC 0.5, got %s", click.Cost)
+	}
+	spend, _ := tracker.Spend(ctx, "cmp-1")
+	if !spend.Total.Equal(decimal.NewFromFloat(0.5)) {
+		t.Errorf("Expected campaign charged 0.5, got %s", spend.Total)
+	}
+}
@@ -0,0 +1,197 @@
+package budget
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/fall-out-bug/demo-adserver/src/domain/repositories"
+	"github.com/shopspring/decimal"
+)
+
+// microsPerUnit converts currency amounts to the integer micro-units kept in the store
+const microsPerUnit = 6
+
+// reconcileBatch bounds how many dirty days are flushed per round trip
+const reconcileBatch = 100
+
+// dayFormat is the layout of budget days in store keys
+const dayFormat = "2006-01-02"
+
+// SpendStore keeps live spend counters shared by all server instances
+type SpendStore interface {
+	Add(ctx context.Context, campaignID, day string, micros int64) error
+	Get(ctx context.Context, campaignID, day string) (total, today int64, ok bool, err error)
+	GetMany(ctx context.Context, campaignIDs []string, day string) (totals, today map[string]int64, err error)
+	Seed(ctx context.Context, campaignID, day string, total, today int64) error
+	TakeDirty(ctx context.Context, count int64) ([]string, error)
+	MarkDirty(ctx context.Context, members ...string) error
+}
+
+// Tracker keeps campaign spend and decides whether campaigns may still serve. Live counters are kept in the SpendStore and
+// periodically reconciled to the SpendRepository, which seeds the counters
+// again after they are lost.
+type Tracker struct {
+	store     SpendStore
+	spendRepo repositories.SpendRepository
+	now       func() time.Time
+}
+
+// NewTracker creates a new spend tracker
+func NewTracker(store SpendStore, spendRepo repositories.SpendRepository) *Tracker {
+	return &Tracker{
+		store:     store,
+		spendRepo: spendRepo,
+		now:       time.Now,
+	}
+}
+
+// Charge debits an amount from the campaign's total and daily spend
+func (t *Tracker) Charge(ctx context.Context, campaignID string, amount decimal.Decimal) error {
+	if !amount.IsPositive() {
+		return nil
+	}
+
+	day := entities.SpendDay(t.now())
+	if _, err := t.spend(ctx, campaignID, day); err != nil {
+		return err
+	}
+
+	return t.store.Add(ctx, campaignID, day.Format(dayFormat), toMicros(amount))
+}
+
+// Spend returns what the campaign has spent in total and today
+func (t *Tracker) Spend(ctx context.Context, campaignID string) (entities.Spend, error) {
+	return t.spend(ctx, campaignID, entities.SpendDay(t.now()))
+}
+
+// CanServe reports which of the campaigns are within budget and on pace.
+// The counters of all campaigns are read in one round trip; only campaigns
+// whose counters were lost are seeded one by one. Campaigns whose spend
+// can't be read are allowed, so an outage of the spend store doesn't stop
+// delivery.
+func (t *Tracker) CanServe(ctx context.Context, campaigns []*entities.Campaign) map[string]bool {
+	now := t.now()
+	day := entities.SpendDay(now)
+
+	allowed := make(map[string]bool, len(campaigns))
+	if len(campaigns) == 0 {
+		return allowed
+	}
+
+	ids := make([]string, len(campaigns))
+	for i, c := range campaigns {
+		ids[i] = c.ID
+	}
+	totals, today, err := t.store.GetMany(ctx, ids, day.Format(dayFormat))
+	if err != nil {
+		for _, c := range campaigns {
+			allowed[c.ID] = true
+		}
+		return allowed
+	}
+
+	for _, c := range campaigns {
+		spend := entities.Spend{Total: fromMicros(totals[c.ID]), Today: fromMicros(today[c.ID])}
+		if _, ok := totals[c.ID]; !ok {
+			if spend, err = t.spend(ctx, c.ID, day); err != nil {
+				allowed[c.ID] = true
+				continue
+			}
+		}
+		allowed[c.ID] = c.CanSpend(spend, now)
+	}
+	return allowed
+}
+
+// Reconcile writes the daily spend of every campaign debited since the last
+// run to durable storage. Writes are absolute, so repeating them is safe.
+func (t *Tracker) Reconcile(ctx context.Context) error {
+	for {
+		members, err := t.store.TakeDirty(ctx, reconcileBatch)
+		if err != nil {
+			return err
+		}
+		if len(members) == 0 {
+			return nil
+		}
+
+		for i, member := range members {
+			if err := t.reconcileDay(ctx, member); err != nil {
+				// Put back what wasn't written so the next run retries it
+				t.store.MarkDirty(ctx, members[i:]...)
+				return err
+			}
+		}
+	}
+}
+
+// Run reconciles spend every interval until ctx is cancelled, then once more
+func (t *Tracker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			t.Reconcile(context.Background())
+			return
+		case <-ticker.C:
+			t.Reconcile(ctx)
+		}
+	}
+}
+
+// reconcileDay persists one "campaignID/day" counter
+func (t *Tracker) reconcileDay(ctx context.Context, member string) error {
+	campaignID, dayKey, found := strings.Cut(member, "/")
+	if !found {
+		return nil // Malformed member, drop it
+	}
+	day, err := time.Parse(dayFormat, dayKey)
+	if err != nil {
+		return nil
+	}
+
+	_, today, ok, err := t.store.Get(ctx, campaignID, dayKey)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return nil // Counters expired; the last reconciled amount stands
+	}
+
+	return t.spendRepo.SaveDaily(ctx, campaignID, day, fromMicros(today))
+}
+
+// spend reads the live counters, seeding them from durable storage on a miss
+func (t *Tracker) spend(ctx context.Context, campaignID string, day time.Time) (entities.Spend, error) {
+	dayKey := day.Format(dayFormat)
+
+	total, today, ok, err := t.store.Get(ctx, campaignID, dayKey)
+	if err != nil {
+		return entities.Spend{}, err
+	}
+	if ok {
+		return entities.Spend{Total: fromMicros(total), Today: fromMicros(today)}, nil
+	}
+
+	stored, err := t.spendRepo.FindSpend(ctx, campaignID, day)
+	if err != nil {
+		return entities.Spend{}, err
+	}
+	if err := t.store.Seed(ctx, campaignID, dayKey, toMicros(stored.Total), toMicros(stored.Today)); err != nil {
+		return entities.Spend{}, err
+	}
+
+	return stored, nil
+}
+
+func toMicros(amount decimal.Decimal) int64 {
+	return amount.Shift(microsPerUnit).Round(0).IntPart()
+}
+
+func fromMicros(micros int64) decimal.Decimal {
+	return decimal.New(micros, -microsPerUnit)
+}
@@ -0,0 +1,200 @@
+package budget
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/shopspring/decimal"
+)
+
+type memoryStore struct {
+	counters map[string]int64
+	dirty    map[string]bool
+	fail     bool
+	getMany  int
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{counters: make(map[string]int64), dirty: make(map[string]bool)}
+}
+
+func (m *memoryStore) Add(ctx context.Context, campaignID, day string, micros int64) error {
+	m.counters[campaignID+":total"] += micros
+	m.counters[campaignID+":"+day] += micros
+	m.dirty[campaignID+"/"+day] = true
+	return nil
+}
+
+func (m *memoryStore) Get(ctx context.Context, campaignID, day string) (int64, int64, bool, error) {
+	if m.fail {
+		return 0, 0, false, errors.New("store unavailable")
+	}
+	total, ok := m.counters[campaignID+":total"]
+	return total, m.counters[campaignID+":"+day], ok, nil
+}
+
+func (m *memoryStore) GetMany(ctx context.Context, campaignIDs []string, day string) (map[string]int64, map[string]int64, error) {
+	m.getMany++
+	if m.fail {
+		return nil, nil, errors.New("store unavailable")
+	}
+	totals, today := make(map[string]int64), make(map[string]int64)
+	for _, id := range campaignIDs {
+		if total, ok := m.counters[id+":total"]; ok {
+			totals[id], today[id] = total, m.counters[id+":"+day]
+		}
+	}
+	return totals, today, nil
+}
+
+func (m *memoryStore) Seed(ctx context.Context, campaignID, day string, total, today int64) error {
+	if _, ok := m.counters[campaignID+":total"]; !ok {
+		m.counters[campaignID+":total"] = total
+		m.counters[campaignID+":"+day] = today
+	}
+	return nil
+}
+
+func (m *memoryStore) TakeDirty(ctx context.Context, count int64) ([]string, error) {
+	var members []string
+	for member := range m.dirty {
+		members = append(members, member)
+		delete(m.dirty, member)
+	}
+	return members, nil
+}
+
+func (m *memoryStore) MarkDirty(ctx context.Context, members ...string) error {
+	for _, member := range members {
+		m.dirty[member] = true
+	}
+	return nil
+}
+
+type mockSpendRepo struct {
+	daily map[string]decimal.Decimal
+	fail  bool
+}
+
+func (m *mockSpendRepo) SaveDaily(ctx context.Context, campaignID string, day time.Time, amount decimal.Decimal) error {
+	if m.fail {
+		return errors.New("database unavailable")
+	}
+	if m.daily == nil {
+		m.daily = make(map[string]decimal.Decimal)
+	}
+	m.daily[campaignID+"/"+day.Format(dayFormat)] = amount
+	return nil
+}
+
+func (m *mockSpendRepo) FindSpend(ctx context.Context, campaignID string, day time.Time) (entities.Spend, error) {
+	var spend entities.Spend
+	for key, amount := range m.daily {
+		if !strings.HasPrefix(key, campaignID+"/") {
+			continue
+		}
+		spend.Total = spend.Total.Add(amount)
+		if key == campaignID+"/"+day.Format(dayFormat) {
+			spend.Today = amount
+		}
+	}
+	return spend, nil
+}
+
+var testNow = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func newTestTracker(store *memoryStore, repo *mockSpendRepo) *Tracker {
+	tracker := NewTracker(store, repo)
+	tracker.now = func() time.Time { return testNow }
+	return tracker
+}
+
+func TestTracker_SeedsFromRepositoryAfterCounterLoss(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockSpendRepo{daily: map[string]decimal.Decimal{
+		"cmp-1/2024-04-30": decimal.NewFromInt(40),
+		"cmp-1/2024-05-01": decimal.NewFromInt(10),
+	}}
+	tracker := newTestTracker(newMemoryStore(), repo)
+
+	tracker.Charge(ctx, "cmp-1", decimal.NewFromInt(5))
+
+	spend, _ := tracker.Spend(ctx, "cmp-1")
+	if !spend.Total.Equal(decimal.NewFromInt(55)) || !spend.Today.Equal(decimal.NewFromInt(15)) {
+		t.Errorf("Expected seeded spend total 55 / today 15, got %+v", spend)
+	}
+}
+
+func TestTracker_CanServe(t *testing.T) {
+	ctx := context.Background()
+	exhausted := &entities.Campaign{ID: "cmp-1", BudgetTotal: decimal.NewFromInt(10)}
+	funded := &entities.Campaign{ID: "cmp-2", BudgetTotal: decimal.NewFromInt(10)}
+	// Spent its budget before its counters were lost
+	unseeded := &entities.Campaign{ID: "cmp-3", BudgetTotal: decimal.NewFromInt(10)}
+	store := newMemoryStore()
+	tracker := newTestTracker(store, &mockSpendRepo{daily: map[string]decimal.Decimal{
+		"cmp-3/2024-04-30": decimal.NewFromInt(10),
+	}})
+
+	tracker.Charge(ctx, "cmp-1", decimal.NewFromInt(10))
+	tracker.Charge(ctx, "cmp-2", decimal.NewFromInt(3))
+
+	allowed := tracker.CanServe(ctx, []*entities.Campaign{exhausted, funded, unseeded})
+	if allowed["cmp-1"] {
+		t.Errorf("Expected exhausted campaign to be excluded")
+	}
+	if !allowed["cmp-2"] {
+		t.Errorf("Expected funded campaign to serve")
+	}
+	if allowed["cmp-3"] {
+		t.Errorf("Expected campaign with lost counters to be checked against stored spend")
+	}
+	if store.getMany != 1 {
+		t.Errorf("Expected the counters of all campaigns to be read at once, got %d reads", store.getMany)
+	}
+}
+
+func TestTracker_CanServe_StoreErrorFailsOpen(t *testing.T) {
+	store := newMemoryStore()
+	store.fail = true
+	tracker := newTestTracker(store, &mockSpendRepo{})
+
+	allowed := tracker.CanServe(context.Background(), []*entities.Campaign{{ID: "cmp-1", BudgetTotal: decimal.NewFromInt(1)}})
+	if !allowed["cmp-1"] {
+		t.Errorf("Expected campaign to serve while spend is unavailable")
+	}
+}
+
+func TestTracker_Reconcile(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore()
+	repo := &mockSpendRepo{}
+	tracker := newTestTracker(store, repo)
+
+	tracker.Charge(ctx, "cmp-1", decimal.NewFromFloat(1.5))
+	tracker.Charge(ctx, "cmp-1", decimal.NewFromFloat(0.25))
+
+	// A failed write keeps the day queued
+	repo.fail = true
+	if err := tracker.Reconcile(ctx); err == nil {
+		t.Fatalf("Expected reconcile error")
+	}
+	if !store.dirty["cmp-1/2024-05-01"] {
+		t.Fatalf("Expected failed day to stay dirty")
+	}
+
+	repo.fail = false
+	if err := tracker.Reconcile(ctx); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := repo.daily["cmp-1/2024-05-01"]; !got.Equal(decimal.NewFromFloat(1.75)) {
+		t.Errorf("Expected reconciled spend 1.75, got %s", got)
+	}
+	if len(store.dirty) != 0 {
+		t.Errorf("Expected no dirty days left, got %v", store.dirty)
+	}
+}
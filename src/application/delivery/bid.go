@@ -0,0 +1,54 @@
+package delivery
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/shopspring/decimal"
+)
+
+// Bid is the offer for an impression put up for auction by an exchange
+type Bid struct {
+	ImpressionID string
+	Campaign     *entities.Campaign
+	Banner       *entities.Banner
+	Price        decimal.Decimal // CPM
+	Response     *GetBannerResponse
+	WinURL       string // Win notice (nurl) without the clearing price
+	BillURL      string // Billing notice (burl) without the clearing price
+}
+
+// DeliverBid runs campaign selection for a programmatic impression and bids
+// the winner's eCPM. It returns nil when no campaign is worth the floor; demo
+// and fallback creatives never bid.
+func (s *Service) DeliverBid(ctx context.Context, slotID string, req *DeliveryRequest) (*Bid, error) {
+	req.Programmatic = true
+
+	set := s.loadCandidates(ctx, slotID)
+	if len(set.Candidates) == 0 {
+		return nil, nil
+	}
+
+	chosen, err := s.selectBanner(ctx, set.Candidates, req)
+	if err != nil {
+		return nil, nil
+	}
+
+	return &Bid{
+		ImpressionID: chosen.ad.ID,
+		Campaign:     chosen.campaign,
+		Banner:       chosen.banner,
+		Price:        chosen.ad.Price,
+		Response:     s.bannerToResponse(chosen.banner, chosen.ad.ID, req),
+		WinURL:       s.noticeURL("win", chosen.ad.ID, chosen.banner, req.SlotID),
+		BillURL:      s.noticeURL("bill", chosen.ad.ID, chosen.banner, req.SlotID),
+	}, nil
+}
+
+// noticeURL generates the URL of a win or billing notice. Like the other
+// tracking URLs it carries the banner, campaign and slot, signed when a
+// signer is set, so notices can't be sent for impressions that were never bid.
+func (s *Service) noticeURL(kind, impressionID string, banner *entities.Banner, slotID string) string {
+	return fmt.Sprintf("/api/v1/track/%s/%s?%s", kind, impressionID, s.trackingQuery(impressionID, banner, slotID).Encode())
+}
@@ -0,0 +1,33 @@
+package delivery
+
+import "github.com/fall-out-bug/demo-adserver/src/domain/entities"
+
+// Formats a slot can request
+const (
+	FormatDisplay       = ""               // HTML and image banners
+	FormatNative        = "native"         // Native assets as plain JSON
+	FormatOpenRTBNative = "openrtb-native" // Native assets as an OpenRTB Native 1.2 response
+	FormatVideo         = "video"          // Linear video for VAST
+)
+
+// IsNativeFormat reports whether the format asks for native creatives
+func IsNativeFormat(format string) bool {
+	return format == FormatNative || format == FormatOpenRTBNative
+}
+
+// IsValidFormat reports whether a slot may request the format
+func IsValidFormat(format string) bool {
+	return format == FormatDisplay || format == FormatVideo || IsNativeFormat(format)
+}
+
+// acceptsCreative reports whether a slot of the format can show the creative type
+func acceptsCreative(format string, creative entities.CreativeType) bool {
+	switch {
+	case IsNativeFormat(format):
+		return creative == entities.CreativeNative
+	case format == FormatVideo:
+		return creative == entities.CreativeVideo
+	default:
+		return creative == entities.CreativeHTML || creative == entities.CreativeImage
+	}
+}
@@ -0,0 +1,185 @@
+package delivery
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+)
+
+// FrequencyCounter counts how often each viewer was shown a campaign or banner.
+// It backs frequency capping.
+type FrequencyCounter interface {
+	// Counts returns the current count of each key (0 for unknown or expired keys)
+	Counts(ctx context.Context, keys []string) ([]int64, error)
+	// Record adds one exposure to each key, counted over the key's window.
+	// A window starts with the first exposure.
+	Record(ctx context.Context, windows map[string]time.Duration) error
+}
+
+// applyFrequencyCaps drops banners and campaigns the viewer has already seen as
+// often as their caps allow. Counter errors fail open so delivery keeps working.
+func (s *Service) applyFrequencyCaps(ctx context.Context, candidates []Candidate, req *DeliveryRequest) []Candidate {
+	viewer := viewerKey(req)
+	if viewer == "" {
+		return candidates
+	}
+
+	windows := make(map[string]time.Duration)
+	for _, c := range candidates {
+		addFrequencyWindows(windows, "campaign", c.Campaign.ID, c.Campaign.FrequencyCaps, viewer)
+		for _, b := range c.Banners {
+			addFrequencyWindows(windows, "banner", b.ID, b.FrequencyCaps, viewer)
+		}
+	}
+	if len(windows) == 0 {
+		return candidates
+	}
+
+	keys := make([]string, 0, len(windows))
+	for key := range windows {
+		keys = append(keys, key)
+	}
+	values, err := s.frequency.Counts(ctx, keys)
+	if err != nil || len(values) != len(keys) {
+		return candidates
+	}
+	counts := make(map[string]int64, len(keys))
+	for i, key := range keys {
+		counts[key] = values[i]
+	}
+
+	// Build new candidates rather than filtering in place: the input may be shared via the cache
+	var allowed []Candidate
+	for _, c := range candidates {
+		if capReached("campaign", c.Campaign.ID, c.Campaign.FrequencyCaps, viewer, counts) {
+			continue
+		}
+
+		var banners []*entities.Banner
+		for _, b := range c.Banners {
+			if !capReached("banner", b.ID, b.FrequencyCaps, viewer, counts) {
+				banners = append(banners, b)
+			}
+		}
+		if len(banners) > 0 {
+			// Keep the rest of the candidate, such as its stats for ranking
+			c.Banners = banners
+			allowed = append(allowed, c)
+		}
+	}
+
+	return allowed
+}
+
+// recordExposure counts a served banner against its own and its campaign's caps
+func (s *Service) recordExposure(ctx context.Context, campaign *entities.Campaign, banner *entities.Banner, req *DeliveryRequest) {
+	viewer := viewerKey(req)
+	if viewer == "" {
+		return
+	}
+
+	windows := make(map[string]time.Duration)
+	addFrequencyWindows(windows, "campaign", campaign.ID, campaign.FrequencyCaps, viewer)
+	addFrequencyWindows(windows, "banner", banner.ID, banner.FrequencyCaps, viewer)
+	if len(windows) == 0 {
+		return
+	}
+
+	// Best effort: a lost count only lets one extra exposure through
+	s.frequency.Record(ctx, windows)
+}
+
+// RecordExposure counts a won bid against the frequency caps of its banner
+// and campaign. Bids are not counted when they are placed, as most lose.
+func (s *Service) RecordExposure(ctx context.Context, ad *entities.ServedAd) {
+	campaign, err := s.campaignRepo.FindByID(ctx, ad.CampaignID)
+	if err != nil || campaign == nil {
+		return
+	}
+	banner, err := s.bannerRepo.FindByID(ctx, ad.BannerID)
+	if err != nil || banner == nil {
+		return
+	}
+
+	s.recordExposure(ctx, campaign, banner, &DeliveryRequest{UserID: ad.UserID, IP: ad.IP})
+}
+
+// addFrequencyWindows adds the counter keys of an entity's caps to windows.
+// Keys don't include the limit, so changing a limit keeps the counts.
+func addFrequencyWindows(windows map[string]time.Duration, scope, id string, caps []entities.FrequencyCap, viewer string) {
+	for _, c := range caps {
+		if window := c.Period.Duration(); window > 0 {
+			windows[frequencyKey(scope, id, c.Period, viewer)] = window
+		}
+	}
+}
+
+// capReached reports whether any of the entity's caps is exhausted for the viewer
+func capReached(scope, id string, caps []entities.FrequencyCap, viewer string, counts map[string]int64) bool {
+	for _, c := range caps {
+		if c.Limit <= 0 || c.Period.Duration() == 0 {
+			continue
+		}
+		if counts[frequencyKey(scope, id, c.Period, viewer)] >= int64(c.Limit) {
+			return true
+		}
+	}
+	return false
+}
+
+// frequencyKey names the counter of one viewer, entity and period
+func frequencyKey(scope, id string, period entities.FrequencyPeriod, viewer string) string {
+	return fmt.Sprintf("%s:%s:%s:%s", scope, id, period, viewer)
+}
+
+// memoryFrequencyCounter is an in-process FrequencyCounter used when no shared store is configured
+type memoryFrequencyCounter struct {
+	mu      sync.Mutex
+	entries map[string]*frequencyEntry
+}
+
+type frequencyEntry struct {
+	count   int64
+	expires time.Time
+}
+
+// NewMemoryFrequencyCounter creates an in-process frequency counter.
+// State is per instance, so caps are only enforced within a single server.
+func NewMemoryFrequencyCounter() FrequencyCounter {
+	return &memoryFrequencyCounter{entries: make(map[string]*frequencyEntry)}
+}
+
+// Counts implements FrequencyCounter
+func (c *memoryFrequencyCounter) Counts(ctx context.Context, keys []string) ([]int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	counts := make([]int64, len(keys))
+	for i, key := range keys {
+		if e, ok := c.entries[key]; ok && now.Before(e.expires) {
+			counts[i] = e.count
+		}
+	}
+	return counts, nil
+}
+
+// Record implements FrequencyCounter
+func (c *memoryFrequencyCounter) Record(ctx context.Context, windows map[string]time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for key, window := range windows {
+		e, ok := c.entries[key]
+		if !ok || !now.Before(e.expires) {
+			e = &frequencyEntry{expires: now.Add(window)}
+			c.entries[key] = e
+		}
+		e.count++
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/shopspring/decimal"
+)
+
+type failingFrequencyCounter struct{}
+
+func (f *failingFrequencyCounter) Counts(ctx context.Context, keys []string) ([]int64, error) {
+	return nil, errors.New("counter unavailable")
+}
+
+func (f *failingFrequencyCounter) Record(ctx context.Context, windows map[string]time.Duration) error {
+	return errors.New("counter unavailable")
+}
+
+func cappedService(campaign *entities.Campaign, banners ...*entities.Banner) *Service {
+	return NewService(
+		&mockCampaignRepo{campaigns: []*entities.Campaign{campaign}},
+		&mockBannerRepo{banners: banners},
+		nil, nil, &mockCache{},
+	)
+}
+
+func activeCampaign(caps ...entities.FrequencyCap) *entities.Campaign {
+	return &entities.Campaign{
+		ID:            "cmp-1",
+		Status:        entities.CampaignStatusActive,
+		StartDate:     time.Now().Add(-1 * time.Hour),
+		FrequencyCaps: caps,
+	}
+}
+
+func TestService_DeliverBanner_CampaignFrequencyCap(t *testing.T) {
+	ctx := context.Background()
+	campaign := activeCampaign(entities.FrequencyCap{Limit: 2, Period: entities.FrequencyPerDay})
+	banner := &entities.Banner{ID: "ban-1", CampaignID: "cmp-1", Status: entities.BannerStatusActive, HTML: "<div>Ad</div>"}
+	service := cappedService(campaign, banner)
+
+	alice := &DeliveryRequest{SlotID: "slot-1", UserID: "alice"}
+	for i := 0; i < 2; i++ {
+		response, _ := service.DeliverBanner(ctx, "slot-1", alice)
+		if response.Creative == nil {
+			t.Fatalf("Expected banner within cap on delivery %d", i+1)
+		}
+	}
+
+	response, _ := service.DeliverBanner(ctx, "slot-1", alice)
+	if response.Fallback == nil {
+		t.Errorf("Expected fallback once the campaign cap is reached")
+	}
+
+	response, _ = service.DeliverBanner(ctx, "slot-1", &DeliveryRequest{SlotID: "slot-1", UserID: "bob"})
+	if response.Creative == nil {
+		t.Errorf("Expected caps to be counted per viewer")
+	}
+}
+
+func TestService_DeliverBanner_BannerFrequencyCap(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	campaign := activeCampaign()
+	campaign.Rotation = entities.RotationSequential
+
+	capped := &entities.Banner{
+		ID: "ban-1", CampaignID: "cmp-1", Status: entities.BannerStatusActive, HTML: "capped", CreatedAt: now,
+		FrequencyCaps: []entities.FrequencyCap{{Limit: 1, Period: entities.FrequencyPerHour}},
+	}
+	other := &entities.Banner{ID: "ban-2", CampaignID: "cmp-1", Status: entities.BannerStatusActive, HTML: "other", CreatedAt: now.Add(time.Minute)}
+	service := cappedService(campaign, capped, other)
+
+	req := &DeliveryRequest{SlotID: "slot-1", UserID: "alice"}
+	var served []string
+	for i := 0; i < 4; i++ {
+		response, _ := service.DeliverBanner(ctx, "slot-1", req)
+		served = append(served, response.Creative.HTML)
+	}
+
+	count := 0
+	for _, html := range served {
+		if html == "capped" {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Errorf("Expected capped banner to be served once, got %v", served)
+	}
+}
+
+func TestService_DeliverBanner_FrequencyCounterError_FailsOpen(t *testing.T) {
+	ctx := context.Background()
+	campaign := activeCampaign(entities.FrequencyCap{Limit: 1, Period: entities.FrequencyPerDay})
+	banner := &entities.Banner{ID: "ban-1", CampaignID: "cmp-1", Status: entities.BannerStatusActive}
+	service := cappedService(campaign, banner).WithFrequencyCounter(&failingFrequencyCounter{})
+
+	for i := 0; i < 3; i++ {
+		response, _ := service.DeliverBanner(ctx, "slot-1", &DeliveryRequest{SlotID: "slot-1", UserID: "alice"})
+		if response.Creative == nil {
+			t.Fatalf("Expected delivery to continue when the counter is unavailable")
+		}
+	}
+}
+
+func TestService_DeliverBid_CountsExposureOnWin(t *testing.T) {
+	ctx := context.Background()
+	campaign := activeCampaign(entities.FrequencyCap{Limit: 1, Period: entities.FrequencyPerDay})
+	campaign.Bid = decimal.NewFromInt(2)
+	banner := &entities.Banner{ID: "ban-1", CampaignID: "cmp-1", Status: entities.BannerStatusActive, HTML: "<div>Ad</div>"}
+	ledger := &mockServedAdRepo{}
+	service := cappedService(campaign, banner).WithLedger(ledger)
+
+	// Bids that may still lose neither count against the cap nor fill the page
+	page := NewPageExclusions()
+	for i := 0; i < 2; i++ {
+		bid, _ := service.DeliverBid(ctx, "slot-1", &DeliveryRequest{SlotID: "slot-1", UserID: "alice", Page: page})
+		if bid == nil {
+			t.Fatalf("Expected bid %d within the cap", i+1)
+		}
+	}
+
+	service.RecordExposure(ctx, ledger.ads[0])
+	if bid, _ := service.DeliverBid(ctx, "slot-1", &DeliveryRequest{SlotID: "slot-1", UserID: "alice"}); bid != nil {
+		t.Errorf("Expected no bid once a won bid reached the cap")
+	}
+}
+
+func TestMemoryFrequencyCounter_WindowExpires(t *testing.T) {
+	ctx := context.Background()
+	counter := NewMemoryFrequencyCounter()
+
+	counter.Record(ctx, map[string]time.Duration{"short": time.Millisecond, "long": time.Hour})
+	counter.Record(ctx, map[string]time.Duration{"long": time.Hour})
+	time.Sleep(5 * time.Millisecond)
+
+	counts, err := counter.Counts(ctx, []string{"short", "long", "unknown"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if counts[0] != 0 || counts[1] != 2 || counts[2] != 0 {
+		t.Errorf("Expected counts [0 2 0], got %v", counts)
+	}
+}
@@ -0,0 +1,71 @@
+package delivery
+
+import (
+	"context"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/fall-out-bug/demo-adserver/src/domain/repositories"
+)
+
+// InvalidateSlot drops the cached candidate set of a single slot
+func (s *Service) InvalidateSlot(ctx context.Context, slotID string) error {
+	return s.cache.InvalidateSlot(ctx, slotID)
+}
+
+// InvalidateAll drops every cached candidate set, e.g. after a campaign
+// or banner change that may affect any number of slots
+func (s *Service) InvalidateAll(ctx context.Context) error {
+	return s.cache.InvalidateAll(ctx)
+}
+
+// invalidatingCampaignRepository invalidates the candidate cache on campaign writes
+type invalidatingCampaignRepository struct {
+	repositories.CampaignRepository
+	cache Cache
+}
+
+// NewInvalidatingCampaignRepository wraps a campaign repository so that
+// creating or updating a campaign invalidates all cached candidate sets
+func NewInvalidatingCampaignRepository(repo repositories.CampaignRepository, cache Cache) repositories.CampaignRepository {
+	return &invalidatingCampaignRepository{CampaignRepository: repo, cache: cache}
+}
+
+func (r *invalidatingCampaignRepository) Create(ctx context.Context, campaign *entities.Campaign) error {
+	if err := r.CampaignRepository.Create(ctx, campaign); err != nil {
+		return err
+	}
+	return r.cache.InvalidateAll(ctx)
+}
+
+func (r *invalidatingCampaignRepository) Update(ctx context.Context, campaign *entities.Campaign) error {
+	if err := r.CampaignRepository.Update(ctx, campaign); err != nil {
+		return err
+	}
+	return r.cache.InvalidateAll(ctx)
+}
+
+// invalidatingBannerRepository invalidates the candidate cache on banner writes
+type invalidatingBannerRepository struct {
+	repositories.BannerRepository
+	cache Cache
+}
+
+// NewInvalidatingBannerRepository wraps a banner repository so that
+// creating or updating a banner invalidates all cached candidate sets
+func NewInvalidatingBannerRepository(repo repositories.BannerRepository, cache Cache) repositories.BannerRepository {
+	return &invalidatingBannerRepository{BannerRepository: repo, cache: cache}
+}
+
+func (r *invalidatingBannerRepository) Create(ctx context.Context, banner *entities.Banner) error {
+	if err := r.BannerRepository.Create(ctx, banner); err != nil {
+		return err
+	}
+	return r.cache.InvalidateAll(ctx)
+}
+
+func (r *invalidatingBannerRepository) Update(ctx context.Context, banner *entities.Banner) error {
+	if err := r.BannerRepository.Update(ctx, banner); err != nil {
+		return err
+	}
+	return r.cache.InvalidateAll(ctx)
+}
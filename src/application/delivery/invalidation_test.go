@@ -0,0 +1,49 @@
+package delivery
+
+import (
+	"context"
+	"testing"
+)
+
+func TestInvalidatingRepositories_InvalidateOnWrite(t *testing.T) {
+	ctx := context.Background()
+	cache := &mockCache{sets: map[string]*CandidateSet{"slot-1": {}}}
+
+	campaigns := NewInvalidatingCampaignRepository(&mockCampaignRepo{}, cache)
+	banners := NewInvalidatingBannerRepository(&mockBannerRepo{}, cache)
+
+	if _, err := campaigns.FindBySlotID(ctx, "slot-1"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cache.invalidations != 0 {
+		t.Errorf("Expected reads not to invalidate, got %d invalidations", cache.invalidations)
+	}
+
+	campaigns.Create(ctx, nil)
+	campaigns.Update(ctx, nil)
+	banners.Create(ctx, nil)
+	banners.Update(ctx, nil)
+
+	if cache.invalidations != 4 {
+		t.Errorf("Expected 4 invalidations, got %d", cache.invalidations)
+	}
+	if cache.sets["slot-1"] != nil {
+		t.Errorf("Expected cached slot to be dropped")
+	}
+}
+
+func TestService_InvalidateSlot(t *testing.T) {
+	cache := &mockCache{sets: map[string]*CandidateSet{"slot-1": {}, "slot-2": {}}}
+	service := NewService(nil, nil, nil, nil, cache)
+
+	if err := service.InvalidateSlot(context.Background(), "slot-1"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if _, ok := cache.sets["slot-1"]; ok {
+		t.Errorf("Expected slot-1 to be invalidated")
+	}
+	if _, ok := cache.sets["slot-2"]; !ok {
+		t.Errorf("Expected slot-2 to stay cached")
+	}
+}
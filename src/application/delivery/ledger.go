@@ -0,0 +1,38 @@
+package delivery
+
+import (
+	"context"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+)
+
+// recordServed writes the ledger entry of a delivered banner. Tracking can't
+// resolve an impression missing from the ledger, so the banner must not be
+// served when the write fails; failures are counted (LedgerFailures).
+func (s *Service) recordServed(ctx context.Context, chosen Candidate, banner *entities.Banner, req *DeliveryRequest) (*entities.ServedAd, error) {
+	ad := entities.NewServedAd(banner.ID, banner.CampaignID, req.SlotID)
+	ad.Price = chosen.Campaign.ECPM(chosen.Stats)
+	ad.Pricing = chosen.Campaign.PricingModel()
+	ad.Bid = chosen.Campaign.Bid
+	ad.Auction = req.Programmatic
+	ad.UserID = req.UserID
+	ad.IP = req.IP
+	ad.UserAgent = req.UserAgent
+	ad.Referer = req.Referer
+	ad.Country = req.Country
+	ad.Device = req.Device
+
+	if s.ledger != nil {
+		if err := s.ledger.Create(ctx, ad); err != nil {
+			s.ledgerFailures.Add(1)
+			return nil, err
+		}
+	}
+	return ad, nil
+}
+
+// LedgerFailures is how many banners were not served because their ledger
+// entry could not be written
+func (s *Service) LedgerFailures() uint64 {
+	return s.ledgerFailures.Load()
+}
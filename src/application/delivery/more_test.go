@@ -2,6 +2,7 @@ package delivery
 
 import (
 	"context"
+	"reflect"
 	"testing"
 	"time"
 
@@ -37,6 +38,28 @@ func TestService_matchesTargeting_GeoTargeting(t *testing.T) {
 			},
 			expected: false,
 		},
+		{
+			name: "Match country-region targeting",
+			targeting: entities.Targeting{
+				Geo: []string{"US-CA"},
+			},
+			request: DeliveryRequest{
+				Country: "US",
+				Region:  "CA",
+			},
+			expected: true,
+		},
+		{
+			name: "No match for other region",
+			targeting: entities.Targeting{
+				Geo: []string{"US-CA"},
+			},
+			request: DeliveryRequest{
+				Country: "US",
+				Region:  "NY",
+			},
+			expected: false,
+		},
 		{
 			name: "No geo targeting - always match",
 			targeting: entities.Targeting{
@@ -151,47 +174,69 @@ func TestService_matchesTargeting_OSTargeting(t *testing.T) {
 	}
 }
 
-func TestService_extractWidth(t *testing.T) {
+func TestService_matchesTargeting_BrowserTargeting(t *testing.T) {
 	service := &Service{}
+	targeting := entities.Targeting{Browsers: []string{"chrome", "edge"}}
 
-	tests := []struct {
-		size     entities.BannerSize
-		expected int
-	}{
-		{entities.BannerSize300x250, 300},
-		{entities.BannerSize728x90, 728},
-		{entities.BannerSize160x600, 160},
-		{entities.BannerSizeResponsive, 300},
+	if !service.matchesTargeting(targeting, &DeliveryRequest{Browser: "edge"}) {
+		t.Errorf("Expected targeted browser to match")
 	}
+	if service.matchesTargeting(targeting, &DeliveryRequest{Browser: "firefox"}) {
+		t.Errorf("Expected other browser not to match")
+	}
+	if service.matchesTargeting(targeting, &DeliveryRequest{}) {
+		t.Errorf("Expected unknown browser not to match browser targeting")
+	}
+}
 
-	for _, tt := range tests {
-		t.Run(string(tt.size), func(t *testing.T) {
-			result := service.extractWidth(tt.size)
-			if result != tt.expected {
-				t.Errorf("Expected %d, got %d", tt.expected, result)
-			}
-		})
+func TestService_matchesTargeting_PageTargeting(t *testing.T) {
+	service := &Service{}
+	targeting := entities.Targeting{Domains: []string{"example.com"}, ExcludedURLs: []string{"/comments/*"}}
+
+	if !service.matchesTargeting(targeting, &DeliveryRequest{Referer: "https://news.example.com/story"}) {
+		t.Errorf("Expected page on targeted domain to match")
+	}
+	if service.matchesTargeting(targeting, &DeliveryRequest{Referer: "https://news.example.com/comments/1"}) {
+		t.Errorf("Expected excluded URL not to match")
+	}
+	if service.matchesTargeting(targeting, &DeliveryRequest{}) {
+		t.Errorf("Expected unknown page not to match domain targeting")
+	}
+}
+
+func TestService_matchesTargeting_KeywordTargeting(t *testing.T) {
+	service := &Service{}
+	targeting := entities.Targeting{Keywords: []string{"football"}, ExcludedKeywords: []string{"injury"}}
+
+	if !service.matchesTargeting(targeting, &DeliveryRequest{Keywords: []string{"sports", "football"}}) {
+		t.Errorf("Expected page with targeted keyword to match")
+	}
+	if service.matchesTargeting(targeting, &DeliveryRequest{Keywords: []string{"football", "injury"}}) {
+		t.Errorf("Expected page with excluded keyword not to match")
 	}
 }
 
-func TestService_extractHeight(t *testing.T) {
+func TestService_creativeSize(t *testing.T) {
 	service := &Service{}
 
 	tests := []struct {
-		size     entities.BannerSize
-		expected int
+		name     string
+		banner   entities.Size
+		slots    []entities.Size
+		expected entities.Size
 	}{
-		{entities.BannerSize300x250, 250},
-		{entities.BannerSize728x90, 90},
-		{entities.BannerSize160x600, 600},
-		{entities.BannerSizeResponsive, 250},
+		{"fixed banner", entities.Size{Width: 728, Height: 90}, nil, entities.Size{Width: 728, Height: 90}},
+		{"fixed banner in multi-size slot", entities.Size{Width: 160, Height: 600}, []entities.Size{{Width: 300, Height: 250}, {Width: 160, Height: 600}}, entities.Size{Width: 160, Height: 600}},
+		{"responsive banner takes slot size", entities.Size{}, []entities.Size{{Width: 300, Height: 250}}, entities.Size{Width: 300, Height: 250}},
+		{"responsive banner in fluid slot", entities.Size{}, []entities.Size{{Width: 970}}, entities.Size{Width: 970}},
+		{"responsive banner without slot size", entities.Size{}, nil, entities.Size{}},
 	}
 
 	for _, tt := range tests {
-		t.Run(string(tt.size), func(t *testing.T) {
-			result := service.extractHeight(tt.size)
-			if result != tt.expected {
-				t.Errorf("Expected %d, got %d", tt.expected, result)
+		t.Run(tt.name, func(t *testing.T) {
+			banner := &entities.Banner{Width: tt.banner.Width, Height: tt.banner.Height}
+			if result := service.creativeSize(banner, tt.slots); result != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, result)
 			}
 		})
 	}
@@ -200,8 +245,9 @@ func TestService_extractHeight(t *testing.T) {
 func TestService_impressionURL(t *testing.T) {
 	service := &Service{}
 
-	result := service.impressionURL("imp-123")
-	expected := "/api/v1/track/impression?id=imp-123"
+	banner := &entities.Banner{ID: "ban-1", CampaignID: "cmp-1"}
+	result := service.impressionURL("imp-123", banner, "slot-1")
+	expected := "/api/v1/track/impression?id=imp-123&banner_id=ban-1&campaign_id=cmp-1&slot_id=slot-1"
 
 	if result != expected {
 		t.Errorf("Expected %s, got %s", expected, result)
@@ -216,13 +262,14 @@ func TestService_bannerToResponse(t *testing.T) {
 		CampaignID: "cmp-1",
 		Name:       "Test Banner",
 		Status:     entities.BannerStatusActive,
-		Size:       entities.BannerSize300x250,
+		Width:      300,
+		Height:     250,
 		HTML:       "<div>Ad</div>",
 		ClickURL:   "https://example.com",
 		Weight:     1,
 	}
 
-	response := service.bannerToResponse(banner, "imp-123")
+	response := service.bannerToResponse(banner, "imp-123", &DeliveryRequest{})
 
 	if response.Creative == nil {
 		t.Fatal("Expected creative, got nil")
@@ -244,108 +291,116 @@ func TestService_bannerToResponse(t *testing.T) {
 		t.Fatal("Expected tracking, got nil")
 	}
 
-	if response.Tracking.Impression != "/api/v1/track/impression?id=imp-123" {
-		t.Errorf("Expected impression URL '/api/v1/track/impression?id=imp-123', got %s", response.Tracking.Impression)
+	if response.Tracking.Impression != "/api/v1/track/impression?id=imp-123&banner_id=ban-1&campaign_id=cmp-1&slot_id=" {
+		t.Errorf("Expected impression URL for imp-123, got %s", response.Tracking.Impression)
 	}
 
-	if response.Tracking.Click != "https://example.com" {
-		t.Errorf("Expected click URL 'https://example.com', got %s", response.Tracking.Click)
+	// Clicks go through the click tracker, which redirects to the banner's click URL
+	if response.Tracking.Click != "/api/v1/track/click/imp-123" {
+		t.Errorf("Expected click tracking URL for imp-123, got %s", response.Tracking.Click)
 	}
 }
 
-func TestService_cachedToResponse(t *testing.T) {
+func TestService_bannerToResponse_Image(t *testing.T) {
 	service := &Service{}
 
-	cached := &CachedBanner{
-		HTML:       "<div>Cached Ad</div>",
-		Width:      728,
-		Height:     90,
-		ClickURL:   "https://cached.com",
-		Impression: "/api/v1/track/impression?id=cached-123",
-		CampaignID: "cmp-1",
-	}
-
-	response := service.cachedToResponse(cached)
-
-	if response.Creative == nil {
-		t.Fatal("Expected creative, got nil")
-	}
-
-	if response.Creative.HTML != "<div>Cached Ad</div>" {
-		t.Errorf("Expected HTML '<div>Cached Ad</div>', got %s", response.Creative.HTML)
+	banner := &entities.Banner{
+		ID:       "ban-1",
+		Type:     entities.CreativeImage,
+		Width:    728,
+		Height:   90,
+		ImageURL: "https://cdn.example.com/ad.png",
+		AltText:  "Spring sale",
+		ClickURL: "https://shop.example.com",
 	}
 
-	if response.Creative.Width != 728 {
-		t.Errorf("Expected width 728, got %d", response.Creative.Width)
-	}
+	response := service.bannerToResponse(banner, "imp-123", &DeliveryRequest{})
 
-	if response.Creative.Height != 90 {
-		t.Errorf("Expected height 90, got %d", response.Creative.Height)
+	if response.Creative.Type != entities.CreativeImage {
+		t.Errorf("Expected image creative, got %s", response.Creative.Type)
 	}
-
-	if response.Tracking.Impression != "/api/v1/track/impression?id=cached-123" {
-		t.Errorf("Expected impression URL '/api/v1/track/impression?id=cached-123', got %s", response.Tracking.Impression)
+	if response.Creative.ImageURL != banner.ImageURL || response.Creative.AltText != banner.AltText {
+		t.Errorf("Expected image metadata, got %+v", response.Creative)
 	}
-
-	if response.Tracking.Click != "https://cached.com" {
-		t.Errorf("Expected click URL 'https://cached.com', got %s", response.Tracking.Click)
+	if response.Creative.HTML != banner.Markup() || response.Creative.HTML == "" {
+		t.Errorf("Expected generated markup, got %q", response.Creative.HTML)
 	}
 }
 
-func TestService_weightedRandomSelect_EmptyBanners(t *testing.T) {
+func TestService_bannerToResponse_Native(t *testing.T) {
 	service := &Service{}
 
-	result := service.weightedRandomSelect([]*entities.Banner{})
-
-	if result != nil {
-		t.Errorf("Expected nil for empty banners, got %v", result)
+	banner := &entities.Banner{
+		ID:       "ban-1",
+		Type:     entities.CreativeNative,
+		ClickURL: "https://shop.example.com",
+		Native: &entities.NativeAssets{
+			Title:   "Spring sale",
+			Image:   &entities.NativeImage{URL: "https://cdn.example.com/main.jpg", Width: 1200, Height: 627},
+			Sponsor: "Example Shop",
+			CTA:     "Shop now",
+		},
 	}
-}
 
-func TestService_weightedRandomSelect_SingleBanner(t *testing.T) {
-	service := &Service{}
-
-	banner := &entities.Banner{
-		ID:   "ban-1",
-		Name: "Single Banner",
+	response := service.bannerToResponse(banner, "imp-123", &DeliveryRequest{Format: FormatNative})
+	if response.Creative.Native != banner.Native || response.Creative.HTML != "" {
+		t.Errorf("Expected native assets without markup, got %+v", response.Creative)
+	}
+	if response.Creative.OpenRTB != nil {
+		t.Errorf("Expected no OpenRTB response unless requested")
 	}
 
-	result := service.weightedRandomSelect([]*entities.Banner{banner})
+	response = service.bannerToResponse(banner, "imp-123", &DeliveryRequest{Format: FormatOpenRTBNative})
+	native := response.Creative.OpenRTB
+	if native == nil {
+		t.Fatal("Expected OpenRTB native response")
+	}
 
-	if result != banner {
-		t.Errorf("Expected single banner to be returned")
+	expected := OpenRTBNative{
+		Ver: "1.2",
+		Assets: []OpenRTBNativeAsset{
+			{ID: 1, Title: &OpenRTBNativeTitle{Text: "Spring sale"}},
+			{ID: 2, Img: &OpenRTBNativeImage{Type: 3, URL: "https://cdn.example.com/main.jpg", W: 1200, H: 627}},
+			{ID: 5, Data: &OpenRTBNativeData{Type: 1, Value: "Example Shop"}},
+			{ID: 6, Data: &OpenRTBNativeData{Type: 12, Value: "Shop now"}},
+		},
+		Link:          OpenRTBNativeLink{URL: "/api/v1/track/click/imp-123"},
+		EventTrackers: []OpenRTBEventTracker{{Event: 1, Method: 1, URL: "/api/v1/track/impression?id=imp-123&banner_id=ban-1&campaign_id=&slot_id="}},
+	}
+	if !reflect.DeepEqual(native.Native, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, native.Native)
 	}
 }
 
-func TestService_weightedRandomSelect_SelectsHighestWeight(t *testing.T) {
+func TestService_demoToResponse(t *testing.T) {
 	service := &Service{}
 
-	banners := []*entities.Banner{
-		{ID: "ban-1", Name: "Light", Weight: 1},
-		{ID: "ban-2", Name: "Heavy", Weight: 10},
-		{ID: "ban-3", Name: "Medium", Weight: 5},
+	demo := &DemoCreative{
+		HTML:   "<div>Demo Ad</div>",
+		Width:  728,
+		Height: 90,
 	}
 
-	result := service.weightedRandomSelect(banners)
+	response := service.demoToResponse(demo)
 
-	if result.ID != "ban-2" {
-		t.Errorf("Expected highest weight banner (ban-2), got %s", result.ID)
+	if response.Creative == nil {
+		t.Fatal("Expected creative, got nil")
 	}
-}
 
-func TestService_weightedRandomSelect_ZeroWeightDefaultsToOne(t *testing.T) {
-	service := &Service{}
+	if response.Creative.HTML != "<div>Demo Ad</div>" {
+		t.Errorf("Expected HTML '<div>Demo Ad</div>', got %s", response.Creative.HTML)
+	}
 
-	banners := []*entities.Banner{
-		{ID: "ban-1", Name: "Zero Weight", Weight: 0},
-		{ID: "ban-2", Name: "Normal Weight", Weight: 1},
+	if response.Creative.Width != 728 {
+		t.Errorf("Expected width 728, got %d", response.Creative.Width)
 	}
 
-	result := service.weightedRandomSelect(banners)
+	if response.Creative.Height != 90 {
+		t.Errorf("Expected height 90, got %d", response.Creative.Height)
+	}
 
-	// Both should be treated as weight 1, so either could be selected
-	if result == nil {
-		t.Errorf("Expected a banner to be selected")
+	if response.Tracking.Impression != "" {
+		t.Errorf("Expected no impression URL for demo creative, got %s", response.Tracking.Impression)
 	}
 }
 
@@ -377,6 +432,30 @@ func TestService_matchesTime_WithinRange(t *testing.T) {
 	}
 }
 
+func TestService_matchesTargeting_Timezone(t *testing.T) {
+	service := &Service{}
+	evening := []entities.TimeRange{{
+		Start: time.Date(0, 1, 1, 18, 0, 0, 0, time.UTC),
+		End:   time.Date(0, 1, 1, 23, 0, 0, 0, time.UTC),
+	}}
+
+	// 2024-01-05 23:30 UTC is 18:30 in New York and 00:30 in Paris
+	at := time.Date(2024, 1, 5, 23, 30, 0, 0, time.UTC)
+
+	newYork := entities.Targeting{TimeOfDay: evening, Timezone: "America/New_York"}
+	if !service.matchesTargeting(newYork, &DeliveryRequest{Timestamp: at}) {
+		t.Errorf("Expected evening in New York to match")
+	}
+
+	viewer := entities.Targeting{TimeOfDay: evening, Timezone: entities.TimezoneViewer}
+	if service.matchesTargeting(viewer, &DeliveryRequest{Timestamp: at, Timezone: "Europe/Paris"}) {
+		t.Errorf("Expected past-midnight viewer in Paris not to match")
+	}
+	if !service.matchesTargeting(viewer, &DeliveryRequest{Timestamp: at, Timezone: "America/Sao_Paulo"}) {
+		t.Errorf("Expected evening viewer in Sao Paulo to match")
+	}
+}
+
 func TestService_DeliverBanner_RepositoryError_ReturnsFallback(t *testing.T) {
 	// Arrange
 	_ = context.Background()
@@ -386,7 +465,7 @@ func TestService_DeliverBanner_RepositoryError_ReturnsFallback(t *testing.T) {
 	var demoSlotRepo *mockDemoSlotRepo
 	cache := &mockCache{}
 
-	service := NewService(campaignRepo, bannerRepo, demoSlotRepo, cache)
+	service := NewService(campaignRepo, bannerRepo, nil, demoSlotRepo, cache)
 
 	// Mock a repository error by passing nil context
 	_, err := service.DeliverBanner(nil, "slot-1", &DeliveryRequest{
@@ -399,3 +478,24 @@ func TestService_DeliverBanner_RepositoryError_ReturnsFallback(t *testing.T) {
 		t.Errorf("Expected no error on repository failure, got %v", err)
 	}
 }
+
+type stubTrackingSigner struct{}
+
+func (s *stubTrackingSigner) Sign(claims entities.TrackingClaims) string {
+	return claims.ImpressionID + "." + claims.BannerID + "." + claims.CampaignID + "." + claims.SlotID
+}
+
+func TestService_SignedTrackingURLs(t *testing.T) {
+	service := (&Service{}).WithTrackingSigner(&stubTrackingSigner{})
+
+	banner := &entities.Banner{ID: "ban-1", CampaignID: "cmp-1"}
+	if result := service.impressionURL("imp-123", banner, "slot-1"); result != "/api/v1/track/impression?t=imp-123.ban-1.cmp-1.slot-1" {
+		t.Errorf("Expected signed impression URL, got %s", result)
+	}
+	if result := service.clickURL("imp-123", banner, "slot-1"); result != "/api/v1/track/click/imp-123?t=imp-123.ban-1.cmp-1.slot-1" {
+		t.Errorf("Expected signed click URL, got %s", result)
+	}
+	if result := service.videoEventURL("imp-123", banner, "slot-1", "start"); result != "/api/v1/track/video/imp-123?event=start&t=imp-123.ban-1.cmp-1.slot-1" {
+		t.Errorf("Expected signed video event URL, got %s", result)
+	}
+}
@@ -0,0 +1,118 @@
+package delivery
+
+import "github.com/fall-out-bug/demo-adserver/src/domain/entities"
+
+// OpenRTB Native 1.2 asset and tracker type codes
+const (
+	openRTBImageIcon       = 1
+	openRTBImageMain       = 3
+	openRTBDataSponsored   = 1
+	openRTBDataDesc        = 2
+	openRTBDataCTAText     = 12
+	openRTBEventImpression = 1
+	openRTBMethodImage     = 1
+)
+
+// Asset IDs of native responses; publishers map them to their request assets
+const (
+	nativeAssetTitle = iota + 1
+	nativeAssetImage
+	nativeAssetIcon
+	nativeAssetDescription
+	nativeAssetSponsor
+	nativeAssetCTA
+)
+
+// OpenRTBNativeResponse is a native ad in the OpenRTB Native 1.2 response format
+type OpenRTBNativeResponse struct {
+	Native OpenRTBNative `json:"native"`
+}
+
+// OpenRTBNative is the native object of an OpenRTB Native 1.2 response
+type OpenRTBNative struct {
+	Ver           string                `json:"ver"`
+	Assets        []OpenRTBNativeAsset  `json:"assets"`
+	Link          OpenRTBNativeLink     `json:"link"`
+	EventTrackers []OpenRTBEventTracker `json:"eventtrackers,omitempty"`
+}
+
+// OpenRTBNativeAsset is one title, image or data asset
+type OpenRTBNativeAsset struct {
+	ID    int                 `json:"id"`
+	Title *OpenRTBNativeTitle `json:"title,omitempty"`
+	Img   *OpenRTBNativeImage `json:"img,omitempty"`
+	Data  *OpenRTBNativeData  `json:"data,omitempty"`
+}
+
+// OpenRTBNativeTitle is a title asset
+type OpenRTBNativeTitle struct {
+	Text string `json:"text"`
+}
+
+// OpenRTBNativeImage is an image asset
+type OpenRTBNativeImage struct {
+	Type int    `json:"type"`
+	URL  string `json:"url"`
+	W    int    `json:"w,omitempty"`
+	H    int    `json:"h,omitempty"`
+}
+
+// OpenRTBNativeData is a data asset such as the sponsor or description
+type OpenRTBNativeData struct {
+	Type  int    `json:"type"`
+	Value string `json:"value"`
+}
+
+// OpenRTBNativeLink is the click destination of the ad
+type OpenRTBNativeLink struct {
+	URL string `json:"url"`
+}
+
+// OpenRTBEventTracker is a tracker fired on an ad event
+type OpenRTBEventTracker struct {
+	Event  int    `json:"event"`
+	Method int    `json:"method"`
+	URL    string `json:"url"`
+}
+
+// openRTBNative converts native assets to an OpenRTB Native 1.2 response
+func openRTBNative(assets *entities.NativeAssets, clickURL, impressionURL string) *OpenRTBNativeResponse {
+	native := OpenRTBNative{
+		Ver:  "1.2",
+		Link: OpenRTBNativeLink{URL: clickURL},
+		Assets: []OpenRTBNativeAsset{
+			{ID: nativeAssetTitle, Title: &OpenRTBNativeTitle{Text: assets.Title}},
+		},
+	}
+
+	if assets.Image != nil {
+		native.Assets = append(native.Assets, OpenRTBNativeAsset{ID: nativeAssetImage, Img: openRTBImage(openRTBImageMain, assets.Image)})
+	}
+	if assets.Icon != nil {
+		native.Assets = append(native.Assets, OpenRTBNativeAsset{ID: nativeAssetIcon, Img: openRTBImage(openRTBImageIcon, assets.Icon)})
+	}
+	for _, data := range []struct {
+		id, typ int
+		value   string
+	}{
+		{nativeAssetDescription, openRTBDataDesc, assets.Description},
+		{nativeAssetSponsor, openRTBDataSponsored, assets.Sponsor},
+		{nativeAssetCTA, openRTBDataCTAText, assets.CTA},
+	} {
+		if data.value != "" {
+			native.Assets = append(native.Assets, OpenRTBNativeAsset{ID: data.id, Data: &OpenRTBNativeData{Type: data.typ, Value: data.value}})
+		}
+	}
+
+	if impressionURL != "" {
+		native.EventTrackers = []OpenRTBEventTracker{
+			{Event: openRTBEventImpression, Method: openRTBMethodImage, URL: impressionURL},
+		}
+	}
+
+	return &OpenRTBNativeResponse{Native: native}
+}
+
+func openRTBImage(typ int, img *entities.NativeImage) *OpenRTBNativeImage {
+	return &OpenRTBNativeImage{Type: typ, URL: img.URL, W: img.Width, H: img.Height}
+}
@@ -0,0 +1,95 @@
+package delivery
+
+import (
+	"context"
+	"strings"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+)
+
+// MaxBatchSlots caps how many slots one batch request may fill
+const MaxBatchSlots = 20
+
+// PageExclusions tracks what is already shown on a page so that one campaign
+// fills at most one slot and competing advertisers never appear side by side
+type PageExclusions struct {
+	campaigns   map[string]bool
+	advertisers map[string]bool
+	categories  map[string]bool
+}
+
+// NewPageExclusions creates an empty set of page exclusions
+func NewPageExclusions() *PageExclusions {
+	return &PageExclusions{
+		campaigns:   make(map[string]bool),
+		advertisers: make(map[string]bool),
+		categories:  make(map[string]bool),
+	}
+}
+
+// Excludes reports whether the campaign competes with something already on the page
+func (p *PageExclusions) Excludes(c *entities.Campaign) bool {
+	if p == nil {
+		return false
+	}
+	if p.campaigns[c.ID] {
+		return true
+	}
+	if c.AdvertiserID != "" && p.advertisers[c.AdvertiserID] {
+		return true
+	}
+	return c.Category != "" && p.categories[strings.ToLower(c.Category)]
+}
+
+// ExcludeCategories keeps campaigns of the categories off the page, e.g. the
+// categories an exchange blocks
+func (p *PageExclusions) ExcludeCategories(categories ...string) {
+	for _, category := range categories {
+		if category != "" {
+			p.categories[strings.ToLower(category)] = true
+		}
+	}
+}
+
+// Add records a campaign as shown on the page
+func (p *PageExclusions) Add(c *entities.Campaign) {
+	if p == nil {
+		return
+	}
+	p.campaigns[c.ID] = true
+	if c.AdvertiserID != "" {
+		p.advertisers[c.AdvertiserID] = true
+	}
+	if c.Category != "" {
+		p.categories[strings.ToLower(c.Category)] = true
+	}
+}
+
+// DeliverBatch fills all slots of a page in one pass. Slots are filled in the
+// given order, so earlier slots get the first pick; a campaign that won one
+// slot, its advertiser and its competitors are excluded from the rest.
+// Duplicate slot IDs are filled once.
+func (s *Service) DeliverBatch(ctx context.Context, slotIDs []string, req *DeliveryRequest) (*BatchResponse, error) {
+	page := NewPageExclusions()
+	seen := make(map[string]bool, len(slotIDs))
+	resp := &BatchResponse{Slots: make([]BatchSlotResponse, 0, len(slotIDs))}
+
+	for _, slotID := range slotIDs {
+		if slotID == "" || seen[slotID] {
+			continue
+		}
+		seen[slotID] = true
+
+		slotReq := *req
+		slotReq.SlotID = slotID
+		slotReq.Page = page
+
+		banner, err := s.DeliverBanner(ctx, slotID, &slotReq)
+		if err != nil {
+			return nil, err
+		}
+		resp.Slots = append(resp.Slots, BatchSlotResponse{SlotID: slotID, GetBannerResponse: banner})
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,101 @@
+package delivery
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/shopspring/decimal"
+)
+
+func TestPageExclusions(t *testing.T) {
+	page := NewPageExclusions()
+	page.Add(&entities.Campaign{ID: "cmp-1", AdvertiserID: "adv-1", Category: "Automotive"})
+
+	tests := []struct {
+		name     string
+		campaign *entities.Campaign
+		expected bool
+	}{
+		{"same campaign", &entities.Campaign{ID: "cmp-1"}, true},
+		{"same advertiser", &entities.Campaign{ID: "cmp-2", AdvertiserID: "adv-1"}, true},
+		{"competitor", &entities.Campaign{ID: "cmp-3", AdvertiserID: "adv-2", Category: "automotive"}, true},
+		{"other category", &entities.Campaign{ID: "cmp-4", AdvertiserID: "adv-2", Category: "travel"}, false},
+		{"house campaign", &entities.Campaign{ID: "cmp-5"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := page.Excludes(tt.campaign); got != tt.expected {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, got)
+		}
+	}
+
+	// Single-slot requests have no page exclusions
+	var none *PageExclusions
+	if none.Excludes(&entities.Campaign{ID: "cmp-1"}) {
+		t.Errorf("Expected nil exclusions to exclude nothing")
+	}
+}
+
+func TestService_DeliverBatch_SeparatesCompetitors(t *testing.T) {
+	now := time.Now()
+	campaign := func(id, advertiserID, category string, priority entities.PriorityTier) *entities.Campaign {
+		return &entities.Campaign{
+			ID: id, AdvertiserID: advertiserID, Category: category, Priority: priority,
+			Status: entities.CampaignStatusActive, StartDate: now.Add(-time.Hour), Bid: decimal.NewFromInt(5),
+		}
+	}
+	campaigns := []*entities.Campaign{
+		campaign("cmp-1", "adv-1", "automotive", entities.PrioritySponsorship),
+		campaign("cmp-2", "adv-1", "finance", entities.PriorityStandard),    // Same advertiser
+		campaign("cmp-3", "adv-2", "automotive", entities.PriorityStandard), // Competitor
+		campaign("cmp-4", "adv-3", "travel", entities.PriorityHouse),
+	}
+	var banners []*entities.Banner
+	for _, c := range campaigns {
+		banners = append(banners, &entities.Banner{ID: "ban-" + c.ID, CampaignID: c.ID, Status: entities.BannerStatusActive, HTML: c.ID})
+	}
+
+	service := NewService(
+		&mockCampaignRepo{campaigns: campaigns},
+		&mockBannerRepo{banners: banners},
+		nil, nil, &mockCache{},
+	)
+
+	response, err := service.DeliverBatch(context.Background(), []string{"top", "sidebar", "top", "footer"}, &DeliveryRequest{UserID: "alice"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(response.Slots) != 3 {
+		t.Fatalf("Expected duplicate slots to be filled once, got %d slots", len(response.Slots))
+	}
+
+	expected := []struct {
+		slotID string
+		html   string
+	}{
+		{"top", "cmp-1"},
+		{"sidebar", "cmp-4"},
+		{"footer", ""}, // Every remaining campaign conflicts with the page
+	}
+	for i, want := range expected {
+		slot := response.Slots[i]
+		if slot.SlotID != want.slotID {
+			t.Errorf("Expected slot %d to be %s, got %s", i, want.slotID, slot.SlotID)
+		}
+		html := ""
+		if slot.Creative != nil {
+			html = slot.Creative.HTML
+		}
+		if html != want.html {
+			t.Errorf("Expected %s to show %q, got %q", want.slotID, want.html, html)
+		}
+	}
+
+	// Single-slot delivery is unaffected by earlier batches
+	single, _ := service.DeliverBanner(context.Background(), "footer", &DeliveryRequest{SlotID: "footer"})
+	if single.Creative == nil || single.Creative.HTML != "cmp-1" {
+		t.Errorf("Expected the sponsorship on a single slot, got %+v", single)
+	}
+}
@@ -0,0 +1,129 @@
+package delivery
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/shopspring/decimal"
+)
+
+func rankedCandidate(id string, tier entities.PriorityTier, pricing entities.PricingModel, bid float64, stats entities.CampaignStats) Candidate {
+	return Candidate{
+		Campaign: &entities.Campaign{
+			ID:        id,
+			Status:    entities.CampaignStatusActive,
+			StartDate: time.Now().Add(-time.Hour),
+			Priority:  tier,
+			Pricing:   pricing,
+			Bid:       decimal.NewFromFloat(bid),
+		},
+		Banners: []*entities.Banner{{ID: "ban-" + id, CampaignID: id, Status: entities.BannerStatusActive, HTML: id, Weight: 1}},
+		Stats:   stats,
+	}
+}
+
+func TestTopRanked_TierBeatsPrice(t *testing.T) {
+	candidates := []Candidate{
+		rankedCandidate("standard", entities.PriorityStandard, entities.PricingCPM, 50, entities.CampaignStats{}),
+		rankedCandidate("sponsor", entities.PrioritySponsorship, entities.PricingFlat, 1000, entities.CampaignStats{}),
+		rankedCandidate("house", entities.PriorityHouse, entities.PricingCPM, 100, entities.CampaignStats{}),
+	}
+
+	top := topRanked(candidates)
+	if len(top) != 1 || top[0].Campaign.ID != "sponsor" {
+		t.Errorf("Expected the sponsorship to win, got %v", top)
+	}
+}
+
+func TestTopRanked_ECPMWithinTier(t *testing.T) {
+	// CPC at $0.50 with a 2% CTR is worth $10 CPM and beats a $4 CPM bid
+	candidates := []Candidate{
+		rankedCandidate("cpm", entities.PriorityStandard, entities.PricingCPM, 4, entities.CampaignStats{}),
+		rankedCandidate("cpc", entities.PriorityStandard, entities.PricingCPC, 0.5, entities.CampaignStats{Impressions: 99000, Clicks: 1999}),
+	}
+
+	top := topRanked(candidates)
+	if len(top) != 1 || top[0].Campaign.ID != "cpc" {
+		t.Errorf("Expected the CPC campaign with higher eCPM to win, got %v", top)
+	}
+
+	// Without history the CPC campaign falls back to the prior CTR ($0.50 CPM)
+	candidates[1].Stats = entities.CampaignStats{}
+	top = topRanked(candidates)
+	if len(top) != 1 || top[0].Campaign.ID != "cpm" {
+		t.Errorf("Expected the CPM campaign to win on priors, got %v", top)
+	}
+}
+
+func TestTopRanked_KeepsTies(t *testing.T) {
+	candidates := []Candidate{
+		rankedCandidate("a", entities.PriorityStandard, entities.PricingCPM, 2, entities.CampaignStats{}),
+		rankedCandidate("b", entities.PriorityStandard, entities.PricingCPM, 2, entities.CampaignStats{}),
+		rankedCandidate("c", entities.PriorityStandard, entities.PricingCPM, 1, entities.CampaignStats{}),
+	}
+
+	if top := topRanked(candidates); len(top) != 2 {
+		t.Errorf("Expected both top campaigns to be kept for rotation, got %d", len(top))
+	}
+}
+
+type mockStatsRepo struct {
+	stats map[string]entities.CampaignStats
+}
+
+func (m *mockStatsRepo) FindByCampaignIDs(ctx context.Context, campaignIDs []string, since time.Time) (map[string]entities.CampaignStats, error) {
+	return m.stats, nil
+}
+
+func TestService_DeliverBanner_RanksWithHistory(t *testing.T) {
+	ctx := context.Background()
+	cpm := rankedCandidate("cpm", entities.PriorityStandard, entities.PricingCPM, 4, entities.CampaignStats{})
+	cpc := rankedCandidate("cpc", entities.PriorityStandard, entities.PricingCPC, 0.5, entities.CampaignStats{})
+
+	cache := &mockCache{}
+	service := NewService(
+		&mockCampaignRepo{campaigns: []*entities.Campaign{cpm.Campaign, cpc.Campaign}},
+		&mockBannerRepo{banners: append(cpm.Banners, cpc.Banners...)},
+		nil, nil, cache,
+	).WithStatsRepository(&mockStatsRepo{stats: map[string]entities.CampaignStats{
+		"cpc": {Impressions: 99000, Clicks: 1999},
+	}})
+
+	response, _ := service.DeliverBanner(ctx, "slot-1", &DeliveryRequest{SlotID: "slot-1"})
+	if response.Creative == nil || response.Creative.HTML != "cpc" {
+		t.Fatalf("Expected the CPC campaign to win on observed CTR, got %+v", response)
+	}
+
+	for _, c := range cache.sets["slot-1"].Candidates {
+		if c.Campaign.ID == "cpc" && c.Stats.Clicks != 1999 {
+			t.Errorf("Expected stats to be cached with the candidates, got %+v", c.Stats)
+		}
+	}
+}
+
+func TestService_DeliverBanner_RanksWithHistoryUnderFrequencyCaps(t *testing.T) {
+	ctx := context.Background()
+	cpm := rankedCandidate("cpm", entities.PriorityStandard, entities.PricingCPM, 4, entities.CampaignStats{})
+	cpc := rankedCandidate("cpc", entities.PriorityStandard, entities.PricingCPC, 0.5, entities.CampaignStats{})
+	cpc.Campaign.FrequencyCaps = []entities.FrequencyCap{{Limit: 10, Period: entities.FrequencyPerDay}}
+	stats := entities.CampaignStats{Impressions: 99000, Clicks: 1999}
+
+	ledger := &mockServedAdRepo{}
+	service := NewService(
+		&mockCampaignRepo{campaigns: []*entities.Campaign{cpm.Campaign, cpc.Campaign}},
+		&mockBannerRepo{banners: append(cpm.Banners, cpc.Banners...)},
+		nil, nil, &mockCache{},
+	).WithStatsRepository(&mockStatsRepo{stats: map[string]entities.CampaignStats{"cpc": stats}}).
+		WithLedger(ledger)
+
+	// The cap applies to the viewer but isn't reached: stats still rank the campaigns
+	response, _ := service.DeliverBanner(ctx, "slot-1", &DeliveryRequest{SlotID: "slot-1", UserID: "alice"})
+	if response.Creative == nil || response.Creative.HTML != "cpc" {
+		t.Fatalf("Expected the CPC campaign to win on observed CTR, got %+v", response)
+	}
+	if len(ledger.ads) != 1 || !ledger.ads[0].Price.Equal(cpc.Campaign.ECPM(stats)) {
+		t.Errorf("Expected the served ad priced at the observed eCPM %s, got %+v", cpc.Campaign.ECPM(stats), ledger.ads)
+	}
+}
@@ -2,38 +2,69 @@ package delivery
 
 import (
 	"fmt"
+	"net/url"
 
 	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
 )
 
-// cachedToResponse converts cached banner to response
-func (s *Service) cachedToResponse(cached *CachedBanner) *GetBannerResponse {
+// demoToResponse converts a demo creative to response
+func (s *Service) demoToResponse(demo *DemoCreative) *GetBannerResponse {
 	return &GetBannerResponse{
 		Creative: &Creative{
-			HTML:   cached.HTML,
-			Width:  cached.Width,
-			Height: cached.Height,
+			Type:   demo.Type,
+			HTML:   demo.HTML,
+			Width:  demo.Width,
+			Height: demo.Height,
 		},
 		Tracking: &TrackingInfo{
-			Impression: cached.Impression,
-			Click:      cached.ClickURL,
+			Impression: "",
+			Click:      "",
 		},
 	}
 }
 
 // bannerToResponse converts banner to response
-func (s *Service) bannerToResponse(banner *entities.Banner, impressionID string) *GetBannerResponse {
-	return &GetBannerResponse{
+func (s *Service) bannerToResponse(banner *entities.Banner, impressionID string, req *DeliveryRequest) *GetBannerResponse {
+	size := s.creativeSize(banner, req.Sizes)
+	response := &GetBannerResponse{
 		Creative: &Creative{
-			HTML:   banner.HTML,
-			Width:  s.extractWidth(banner.Size),
-			Height: s.extractHeight(banner.Size),
+			Type:   banner.CreativeType(),
+			HTML:   banner.Markup(),
+			Width:  size.Width,
+			Height: size.Height,
 		},
 		Tracking: &TrackingInfo{
-			Impression: s.impressionURL(impressionID),
-			Click:      banner.ClickURL,
+			ImpressionID: impressionID,
+			Impression:   s.impressionURL(impressionID, banner, req.SlotID),
+			Click:        s.clickURL(impressionID, banner, req.SlotID),
 		},
 	}
+	// Won bids are counted by the exchange's billing notice at the clearing
+	// price; an impression pixel firing first would bill them at the bid
+	if req.Programmatic {
+		response.Tracking.Impression = ""
+	}
+	switch banner.CreativeType() {
+	case entities.CreativeImage:
+		response.Creative.ImageURL = banner.ImageURL
+		response.Creative.AltText = banner.AltText
+	case entities.CreativeNative:
+		response.Creative.Native = banner.Native
+		if req.Format == FormatOpenRTBNative && banner.Native != nil {
+			response.Creative.OpenRTB = openRTBNative(banner.Native, response.Tracking.Click, response.Tracking.Impression)
+		}
+	case entities.CreativeVideo:
+		// Players fetch trackers with GET, so every event goes through the video tracking endpoint
+		response.Creative.Video = banner.Video
+		if !req.Programmatic {
+			response.Tracking.Impression = s.videoEventURL(impressionID, banner, req.SlotID, "impression")
+		}
+		response.Tracking.Video = make(map[string]string, len(entities.VideoEventTypes))
+		for _, event := range entities.VideoEventTypes {
+			response.Tracking.Video[string(event)] = s.videoEventURL(impressionID, banner, req.SlotID, string(event))
+		}
+	}
+	return response
 }
 
 // fallbackResponse returns a fallback banner
@@ -46,35 +77,54 @@ func (s *Service) fallbackResponse() *GetBannerResponse {
 	}
 }
 
-// extractWidth extracts width from banner size
-func (s *Service) extractWidth(size entities.BannerSize) int {
-	switch size {
-	case entities.BannerSize300x250:
-		return 300
-	case entities.BannerSize728x90:
-		return 728
-	case entities.BannerSize160x600:
-		return 160
-	default:
-		return 300
+// creativeSize is the size a banner renders at. Responsive banners take the
+// size of the slot; their dimensions stay 0 where the slot is fluid too.
+func (s *Service) creativeSize(banner *entities.Banner, slots []entities.Size) entities.Size {
+	size := banner.Size()
+	if size.IsResponsive() && len(slots) > 0 {
+		return slots[0]
 	}
+	return size
+}
+
+// videoEventURL generates a playback event tracking URL. It carries the banner,
+// campaign and slot so the event can be stored without looking anything up.
+func (s *Service) videoEventURL(impressionID string, banner *entities.Banner, slotID, event string) string {
+	query := s.trackingQuery(impressionID, banner, slotID)
+	query.Set("event", event)
+	return fmt.Sprintf("/api/v1/track/video/%s?%s", impressionID, query.Encode())
 }
 
-// extractHeight extracts height from banner size
-func (s *Service) extractHeight(size entities.BannerSize) int {
-	switch size {
-	case entities.BannerSize300x250:
-		return 250
-	case entities.BannerSize728x90:
-		return 90
-	case entities.BannerSize160x600:
-		return 600
-	default:
-		return 250
+// clickURL generates the click tracking URL that counts the click and
+// redirects to the banner's landing page with its macros expanded
+func (s *Service) clickURL(impressionID string, banner *entities.Banner, slotID string) string {
+	if s.signer == nil {
+		return fmt.Sprintf("/api/v1/track/click/%s", impressionID)
 	}
+	return fmt.Sprintf("/api/v1/track/click/%s?%s", impressionID, s.trackingQuery(impressionID, banner, slotID).Encode())
 }
 
-// impressionURL generates impression tracking URL
-func (s *Service) impressionURL(impressionID string) string {
-	return fmt.Sprintf("/api/v1/track/impression?id=%s", impressionID)
+// impressionURL generates the impression tracking URL. Like the video event
+// URLs it carries the banner, campaign and slot, so ad tags can report the
+// impression with a plain image pixel.
+func (s *Service) impressionURL(impressionID string, banner *entities.Banner, slotID string) string {
+	query := s.trackingQuery(impressionID, banner, slotID)
+	if s.signer != nil {
+		return "/api/v1/track/impression?" + query.Encode()
+	}
+	return fmt.Sprintf("/api/v1/track/impression?id=%s&%s", impressionID, query.Encode())
+}
+
+// trackingQuery identifies the served ad in tracking URLs. Signed URLs carry
+// a token (t) with the banner, campaign and slot instead of plain parameters.
+func (s *Service) trackingQuery(impressionID string, banner *entities.Banner, slotID string) url.Values {
+	query := url.Values{}
+	if s.signer != nil {
+		query.Set("t", s.signer.Sign(entities.NewTrackingClaims(impressionID, banner, slotID)))
+		return query
+	}
+	query.Set("slot_id", slotID)
+	query.Set("banner_id", banner.ID)
+	query.Set("campaign_id", banner.CampaignID)
+	return query
 }
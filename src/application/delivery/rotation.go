@@ -0,0 +1,182 @@
+package delivery
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+)
+
+// SelectionStrategy picks one banner out of a campaign's eligible banners
+type SelectionStrategy interface {
+	Select(ctx context.Context, campaign *entities.Campaign, banners []*entities.Banner, req *DeliveryRequest) *entities.Banner
+}
+
+// RotationCounter hands out monotonically increasing positions per key.
+// It backs the even and sequential rotation modes.
+type RotationCounter interface {
+	Next(ctx context.Context, key string) (int64, error)
+}
+
+// Random is a goroutine-safe random source that can be seeded for tests
+type Random struct {
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// NewRandom creates a random source with the given seed
+func NewRandom(seed int64) *Random {
+	return &Random{rng: rand.New(rand.NewSource(seed))}
+}
+
+// Intn returns a random int in [0, n)
+func (r *Random) Intn(n int) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rng.Intn(n)
+}
+
+// Float64 returns a random float in [0.0, 1.0)
+func (r *Random) Float64() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rng.Float64()
+}
+
+// WeightedRandomStrategy picks banners at random in proportion to Banner.Weight
+type WeightedRandomStrategy struct {
+	rng *Random
+}
+
+// NewWeightedRandomStrategy creates a weighted-random strategy
+func NewWeightedRandomStrategy(rng *Random) *WeightedRandomStrategy {
+	return &WeightedRandomStrategy{rng: rng}
+}
+
+// Select implements SelectionStrategy
+func (s *WeightedRandomStrategy) Select(ctx context.Context, campaign *entities.Campaign, banners []*entities.Banner, req *DeliveryRequest) *entities.Banner {
+	if len(banners) == 0 {
+		return nil
+	}
+	if len(banners) == 1 {
+		return banners[0]
+	}
+
+	totalWeight := 0
+	for _, b := range banners {
+		totalWeight += bannerWeight(b)
+	}
+
+	pick := s.rng.Intn(totalWeight)
+	for _, b := range banners {
+		pick -= bannerWeight(b)
+		if pick < 0 {
+			return b
+		}
+	}
+	return banners[len(banners)-1]
+}
+
+// EvenRotationStrategy serves a campaign's banners round-robin, ignoring weight
+type EvenRotationStrategy struct {
+	counter RotationCounter
+}
+
+// NewEvenRotationStrategy creates an even rotation strategy
+func NewEvenRotationStrategy(counter RotationCounter) *EvenRotationStrategy {
+	return &EvenRotationStrategy{counter: counter}
+}
+
+// Select implements SelectionStrategy
+func (s *EvenRotationStrategy) Select(ctx context.Context, campaign *entities.Campaign, banners []*entities.Banner, req *DeliveryRequest) *entities.Banner {
+	key := fmt.Sprintf("even:%s", campaign.ID)
+	return pickInOrder(ctx, s.counter, key, banners)
+}
+
+// SequentialStrategy walks each viewer through a campaign's banners in
+// creation order (storyboard), starting over after the last one
+type SequentialStrategy struct {
+	counter RotationCounter
+}
+
+// NewSequentialStrategy creates a sequential (storyboard) strategy
+func NewSequentialStrategy(counter RotationCounter) *SequentialStrategy {
+	return &SequentialStrategy{counter: counter}
+}
+
+// Select implements SelectionStrategy
+func (s *SequentialStrategy) Select(ctx context.Context, campaign *entities.Campaign, banners []*entities.Banner, req *DeliveryRequest) *entities.Banner {
+	key := fmt.Sprintf("seq:%s:%s", campaign.ID, viewerKey(req))
+	return pickInOrder(ctx, s.counter, key, banners)
+}
+
+// pickInOrder returns the banner at the counter's next position for key
+func pickInOrder(ctx context.Context, counter RotationCounter, key string, banners []*entities.Banner) *entities.Banner {
+	if len(banners) == 0 {
+		return nil
+	}
+
+	ordered := make([]*entities.Banner, len(banners))
+	copy(ordered, banners)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].CreatedAt.Equal(ordered[j].CreatedAt) {
+			return ordered[i].ID < ordered[j].ID
+		}
+		return ordered[i].CreatedAt.Before(ordered[j].CreatedAt)
+	})
+
+	pos, err := counter.Next(ctx, key)
+	if err != nil || pos < 1 {
+		// Counter unavailable - degrade to the first banner in sequence
+		return ordered[0]
+	}
+	return ordered[(pos-1)%int64(len(ordered))]
+}
+
+// viewerKey identifies the viewer for per-user rotation state
+func viewerKey(req *DeliveryRequest) string {
+	if req == nil {
+		return ""
+	}
+	if req.UserID != "" {
+		return req.UserID
+	}
+	return req.IP
+}
+
+// bannerWeight returns the rotation weight, treating non-positive weights as 1
+func bannerWeight(b *entities.Banner) int {
+	if b.Weight <= 0 {
+		return 1
+	}
+	return b.Weight
+}
+
+// memoryCounter is an in-process RotationCounter used when no shared store is configured
+type memoryCounter struct {
+	mu     sync.Mutex
+	counts map[string]int64
+}
+
+// NewMemoryRotationCounter creates an in-process rotation counter.
+// State is per instance, so rotation is only even within a single server.
+func NewMemoryRotationCounter() RotationCounter {
+	return &memoryCounter{counts: make(map[string]int64)}
+}
+
+// Next implements RotationCounter
+func (c *memoryCounter) Next(ctx context.Context, key string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts[key]++
+	return c.counts[key], nil
+}
+
+// defaultRandom returns a time-seeded random source for production use
+func defaultRandom() *Random {
+	return NewRandom(time.Now().UnixNano())
+}
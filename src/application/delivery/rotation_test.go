@@ -0,0 +1,138 @@
+package delivery
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+)
+
+func TestWeightedRandomStrategy_EmptyBanners(t *testing.T) {
+	strategy := NewWeightedRandomStrategy(NewRandom(1))
+
+	result := strategy.Select(context.Background(), &entities.Campaign{ID: "cmp-1"}, nil, &DeliveryRequest{})
+
+	if result != nil {
+		t.Errorf("Expected nil for empty banners, got %v", result)
+	}
+}
+
+func TestWeightedRandomStrategy_SingleBanner(t *testing.T) {
+	strategy := NewWeightedRandomStrategy(NewRandom(1))
+	banner := &entities.Banner{ID: "ban-1", Name: "Single Banner"}
+
+	result := strategy.Select(context.Background(), &entities.Campaign{ID: "cmp-1"}, []*entities.Banner{banner}, &DeliveryRequest{})
+
+	if result != banner {
+		t.Errorf("Expected single banner to be returned")
+	}
+}
+
+func TestWeightedRandomStrategy_SplitsTrafficByWeight(t *testing.T) {
+	strategy := NewWeightedRandomStrategy(NewRandom(42))
+	campaign := &entities.Campaign{ID: "cmp-1"}
+
+	banners := []*entities.Banner{
+		{ID: "ban-1", Name: "Light", Weight: 1},
+		{ID: "ban-2", Name: "Heavy", Weight: 3},
+		{ID: "ban-3", Name: "Zero Weight", Weight: 0}, // treated as 1
+	}
+
+	const draws = 10000
+	counts := make(map[string]int)
+	for i := 0; i < draws; i++ {
+		counts[strategy.Select(context.Background(), campaign, banners, &DeliveryRequest{}).ID]++
+	}
+
+	// Expected shares: 1/5, 3/5, 1/5
+	expected := map[string]float64{"ban-1": 0.2, "ban-2": 0.6, "ban-3": 0.2}
+	for id, share := range expected {
+		got := float64(counts[id]) / draws
+		if got < share-0.03 || got > share+0.03 {
+			t.Errorf("Expected %s share around %.2f, got %.3f", id, share, got)
+		}
+	}
+}
+
+func TestWeightedRandomStrategy_SameSeedSameSequence(t *testing.T) {
+	campaign := &entities.Campaign{ID: "cmp-1"}
+	banners := []*entities.Banner{
+		{ID: "ban-1", Weight: 1},
+		{ID: "ban-2", Weight: 1},
+		{ID: "ban-3", Weight: 1},
+	}
+
+	a := NewWeightedRandomStrategy(NewRandom(7))
+	b := NewWeightedRandomStrategy(NewRandom(7))
+	for i := 0; i < 50; i++ {
+		ra := a.Select(context.Background(), campaign, banners, &DeliveryRequest{})
+		rb := b.Select(context.Background(), campaign, banners, &DeliveryRequest{})
+		if ra != rb {
+			t.Fatalf("Expected identical picks for identical seeds at draw %d", i)
+		}
+	}
+}
+
+func TestEvenRotationStrategy_RoundRobin(t *testing.T) {
+	strategy := NewEvenRotationStrategy(NewMemoryRotationCounter())
+	campaign := &entities.Campaign{ID: "cmp-1"}
+	now := time.Now()
+
+	banners := []*entities.Banner{
+		{ID: "ban-2", Weight: 10, CreatedAt: now.Add(time.Minute)},
+		{ID: "ban-1", Weight: 1, CreatedAt: now},
+	}
+
+	var got []string
+	for i := 0; i < 4; i++ {
+		got = append(got, strategy.Select(context.Background(), campaign, banners, &DeliveryRequest{}).ID)
+	}
+
+	want := []string{"ban-1", "ban-2", "ban-1", "ban-2"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected rotation %v, got %v", want, got)
+			break
+		}
+	}
+}
+
+func TestSequentialStrategy_TracksEachViewer(t *testing.T) {
+	strategy := NewSequentialStrategy(NewMemoryRotationCounter())
+	campaign := &entities.Campaign{ID: "cmp-1", Rotation: entities.RotationSequential}
+	now := time.Now()
+
+	banners := []*entities.Banner{
+		{ID: "step-1", CreatedAt: now},
+		{ID: "step-2", CreatedAt: now.Add(time.Minute)},
+		{ID: "step-3", CreatedAt: now.Add(2 * time.Minute)},
+	}
+
+	alice := &DeliveryRequest{UserID: "alice"}
+	bob := &DeliveryRequest{UserID: "bob"}
+
+	if id := strategy.Select(context.Background(), campaign, banners, alice).ID; id != "step-1" {
+		t.Errorf("Expected alice to start at step-1, got %s", id)
+	}
+	if id := strategy.Select(context.Background(), campaign, banners, alice).ID; id != "step-2" {
+		t.Errorf("Expected alice to continue with step-2, got %s", id)
+	}
+	if id := strategy.Select(context.Background(), campaign, banners, bob).ID; id != "step-1" {
+		t.Errorf("Expected bob to start at step-1, got %s", id)
+	}
+}
+
+func TestService_strategyFor_DefaultsToWeighted(t *testing.T) {
+	service := NewService(nil, nil, nil, nil, nil)
+
+	if _, ok := service.strategyFor(&entities.Campaign{}).(*WeightedRandomStrategy); !ok {
+		t.Errorf("Expected weighted strategy for campaign without rotation mode")
+	}
+	if _, ok := service.strategyFor(&entities.Campaign{Rotation: entities.RotationEven}).(*EvenRotationStrategy); !ok {
+		t.Errorf("Expected even strategy for even rotation mode")
+	}
+	if _, ok := service.strategyFor(&entities.Campaign{Rotation: entities.RotationSequential}).(*SequentialStrategy); !ok {
+		t.Errorf("Expected sequential strategy for sequential rotation mode")
+	}
+}
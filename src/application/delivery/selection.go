@@ -5,84 +5,229 @@ import (
 	"fmt"
 
 	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/shopspring/decimal"
 )
 
+// selection is the winner of a slot
+type selection struct {
+	campaign *entities.Campaign
+	banner   *entities.Banner
+	ad       *entities.ServedAd // Ledger entry; its ID is the impression ID
+}
+
 // selectBanner selects a banner based on targeting and rotation
-func (s *Service) selectBanner(ctx context.Context, campaigns []*entities.Campaign, req *DeliveryRequest) (*entities.Banner, string, error) {
+func (s *Service) selectBanner(ctx context.Context, candidates []Candidate, req *DeliveryRequest) (*selection, error) {
+	req.Segments = s.viewerSegments(ctx, candidates, req)
+
 	// Filter active campaigns by targeting
-	var activeCampaigns []*entities.Campaign
-	for _, c := range campaigns {
-		if c.IsActive() && s.matchesTargeting(c.Targeting, req) {
-			activeCampaigns = append(activeCampaigns, c)
+	var eligible []Candidate
+	for _, c := range candidates {
+		if c.Campaign.IsActive() && !req.Page.Excludes(c.Campaign) && s.matchesTargeting(c.Campaign.Targeting, req) {
+			eligible = append(eligible, c)
+		}
+	}
+
+	if len(eligible) == 0 {
+		return nil, fmt.Errorf("no active campaigns match targeting")
+	}
+
+	// Exchanges only take bids at or above the floor
+	if req.Programmatic {
+		eligible = applyFloor(eligible, req.BidFloor)
+		if len(eligible) == 0 {
+			return nil, fmt.Errorf("no campaigns bid at or above the floor")
 		}
 	}
 
-	if len(activeCampaigns) == 0 {
-		return nil, "", fmt.Errorf("no active campaigns match targeting")
+	// Keep only banners of the slot's format that fit it
+	eligible = applyCreativeFit(eligible, req)
+	if len(eligible) == 0 {
+		return nil, fmt.Errorf("no banners fit the slot")
 	}
 
-	// Get banners from active campaigns
-	banners, err := s.getBannersForCampaigns(ctx, activeCampaigns)
-	if err != nil || len(banners) == 0 {
-		return nil, "", fmt.Errorf("no banners found")
+	// Drop campaigns that exhausted their budget or are ahead of their pacing
+	eligible = s.applyBudget(ctx, eligible)
+	if len(eligible) == 0 {
+		return nil, fmt.Errorf("no campaigns with remaining budget")
 	}
 
-	// Weighted random selection
-	banner := s.weightedRandomSelect(banners)
-	impressionID := entities.NewImpression(banner.ID, req.SlotID, banner.CampaignID).ID
+	// Drop campaigns and banners the viewer has seen too often
+	eligible = s.applyFrequencyCaps(ctx, eligible, req)
+	if len(eligible) == 0 {
+		return nil, fmt.Errorf("frequency caps reached for all campaigns")
+	}
 
-	return banner, impressionID, nil
+	// Highest priority tier wins, then highest eCPM within it; ties are
+	// broken at random. Then rotate within the campaign using its mode.
+	chosen := s.pickCandidate(topRanked(eligible))
+	banner := s.strategyFor(chosen.Campaign).Select(ctx, chosen.Campaign, chosen.Banners, req)
+	if banner == nil {
+		return nil, fmt.Errorf("no banner selected")
+	}
+	ad, err := s.recordServed(ctx, chosen, banner, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record served ad: %w", err)
+	}
+
+	// Bids may lose: they count against frequency caps once won (RecordExposure)
+	// and don't take the campaign off the rest of the bid request
+	if !req.Programmatic {
+		s.recordExposure(ctx, chosen.Campaign, banner, req)
+		req.Page.Add(chosen.Campaign)
+	}
+
+	return &selection{campaign: chosen.Campaign, banner: banner, ad: ad}, nil
 }
 
-// getBannersForCampaigns gets all active banners for given campaigns
-func (s *Service) getBannersForCampaigns(ctx context.Context, campaigns []*entities.Campaign) ([]*entities.Banner, error) {
-	var banners []*entities.Banner
-	for _, c := range campaigns {
-		campaignBanners, err := s.bannerRepo.FindActiveForCampaign(ctx, c.ID)
-		if err != nil {
-			continue
+// viewerSegments loads the viewer's audience segments when any candidate targets
+// segments. On lookup errors the viewer is treated as belonging to no segment.
+func (s *Service) viewerSegments(ctx context.Context, candidates []Candidate, req *DeliveryRequest) []string {
+	if s.audience == nil || req.UserID == "" {
+		return nil
+	}
+
+	for _, c := range candidates {
+		if c.Campaign.Targeting.HasSegmentTargeting() {
+			segments, err := s.audience.Segments(ctx, req.UserID)
+			if err != nil {
+				return nil
+			}
+			return segments
 		}
-		banners = append(banners, campaignBanners...)
 	}
-	return banners, nil
+	return nil
 }
 
-// weightedRandomSelect selects a banner based on weight
-func (s *Service) weightedRandomSelect(banners []*entities.Banner) *entities.Banner {
-	if len(banners) == 0 {
-		return nil
+// applyBudget keeps the candidates whose campaigns may still spend
+func (s *Service) applyBudget(ctx context.Context, candidates []Candidate) []Candidate {
+	if s.budget == nil {
+		return candidates
 	}
-	if len(banners) == 1 {
-		return banners[0]
+
+	campaigns := make([]*entities.Campaign, len(candidates))
+	for i, c := range candidates {
+		campaigns[i] = c.Campaign
 	}
+	allowed := s.budget.CanServe(ctx, campaigns)
 
-	// Calculate total weight
-	totalWeight := 0
-	for _, b := range banners {
-		if b.Weight <= 0 {
-			totalWeight++
-		} else {
-			totalWeight += b.Weight
+	var funded []Candidate
+	for _, c := range candidates {
+		if allowed[c.Campaign.ID] {
+			funded = append(funded, c)
 		}
 	}
+	return funded
+}
 
-	// Simple selection - in production use proper random
-	// For now, return the first banner with highest weight
-	var selected *entities.Banner
-	maxWeight := 0
-	for _, b := range banners {
-		weight := b.Weight
-		if weight <= 0 {
-			weight = 1
+// applyFloor keeps the campaigns worth a positive eCPM of at least the floor.
+// Flat-rate sponsorships have no price to bid with.
+func applyFloor(candidates []Candidate, floor decimal.Decimal) []Candidate {
+	var bidding []Candidate
+	for _, c := range candidates {
+		ecpm := c.Campaign.ECPM(c.Stats)
+		if ecpm.IsPositive() && ecpm.GreaterThanOrEqual(floor) {
+			bidding = append(bidding, c)
 		}
-		if weight > maxWeight {
-			maxWeight = weight
-			selected = b
+	}
+	return bidding
+}
+
+// applyCreativeFit narrows each candidate to the banners the slot can show,
+// dropping campaigns left without any. Display slots take HTML and image
+// banners that fit one of their sizes; native and video slots take creatives
+// of their type, which the publisher or player lays out.
+func applyCreativeFit(candidates []Candidate, req *DeliveryRequest) []Candidate {
+	display := req.Format == FormatDisplay
+
+	var fitting []Candidate
+	for _, c := range candidates {
+		var banners []*entities.Banner
+		for _, b := range c.Banners {
+			if !acceptsCreative(req.Format, b.CreativeType()) {
+				continue
+			}
+			if !display || b.Size().FitsAny(req.Sizes) {
+				banners = append(banners, b)
+			}
+		}
+		if len(banners) > 0 {
+			c.Banners = banners
+			fitting = append(fitting, c)
+		}
+	}
+	return fitting
+}
+
+// getCandidates loads active banners for the given campaigns, skipping campaigns without any
+func (s *Service) getCandidates(ctx context.Context, campaigns []*entities.Campaign) []Candidate {
+	var candidates []Candidate
+	for _, c := range campaigns {
+		banners, err := s.bannerRepo.FindActiveForCampaign(ctx, c.ID)
+		if err != nil || len(banners) == 0 {
+			continue
+		}
+		candidates = append(candidates, Candidate{Campaign: c, Banners: banners})
+	}
+	return candidates
+}
+
+// topRanked returns the candidates of the highest priority tier that share its highest eCPM
+func topRanked(candidates []Candidate) []Candidate {
+	var best []Candidate
+	var bestRank int
+	var bestECPM decimal.Decimal
+
+	for _, c := range candidates {
+		rank := c.Campaign.PriorityTier().Rank()
+		ecpm := c.Campaign.ECPM(c.Stats)
+
+		switch {
+		case len(best) == 0 || rank > bestRank || (rank == bestRank && ecpm.GreaterThan(bestECPM)):
+			best = []Candidate{c}
+			bestRank, bestECPM = rank, ecpm
+		case rank == bestRank && ecpm.Equal(bestECPM):
+			best = append(best, c)
 		}
 	}
 
-	if selected == nil {
-		return banners[0]
+	return best
+}
+
+// pickCandidate chooses among equally ranked campaigns at random, weighted by the sum
+// of their banner weights, so tied traffic splits as if all banners were rotated together
+func (s *Service) pickCandidate(candidates []Candidate) Candidate {
+	if len(candidates) == 1 {
+		return candidates[0]
+	}
+
+	total := 0
+	for _, c := range candidates {
+		total += candidateWeight(c)
+	}
+
+	pick := s.rng.Intn(total)
+	for _, c := range candidates {
+		pick -= candidateWeight(c)
+		if pick < 0 {
+			return c
+		}
+	}
+	return candidates[len(candidates)-1]
+}
+
+// strategyFor returns the selection strategy for the campaign's rotation mode
+func (s *Service) strategyFor(campaign *entities.Campaign) SelectionStrategy {
+	if strategy, ok := s.strategies[campaign.Rotation]; ok {
+		return strategy
+	}
+	return s.strategies[entities.RotationWeighted]
+}
+
+// candidateWeight sums the rotation weights of a candidate's banners
+func candidateWeight(c Candidate) int {
+	total := 0
+	for _, b := range c.Banners {
+		total += bannerWeight(b)
 	}
-	return selected
+	return total
 }
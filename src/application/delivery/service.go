@@ -2,17 +2,33 @@ package delivery
 
 import (
 	"context"
+	"sync/atomic"
+	"time"
 
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
 	"github.com/fall-out-bug/demo-adserver/src/domain/repositories"
 )
 
+// statsWindow is how far back delivery history is looked at for eCPM ranking
+const statsWindow = 30 * 24 * time.Hour
+
 // Service handles banner delivery with cache-first strategy
 type Service struct {
 	campaignRepo   repositories.CampaignRepository
 	bannerRepo     repositories.BannerRepository
 	demoBannerRepo repositories.DemoBannerRepository
 	demoSlotRepo   repositories.DemoSlotRepository
+	statsRepo      repositories.CampaignStatsRepository
 	cache          Cache
+	rng            *Random
+	counter        RotationCounter
+	frequency      FrequencyCounter
+	budget         BudgetChecker
+	audience       AudienceProvider
+	ledger         repositories.ServedAdRepository
+	signer         TrackingSigner
+	strategies     map[entities.RotationMode]SelectionStrategy
+	ledgerFailures atomic.Uint64
 }
 
 // NewService creates a new delivery service
@@ -23,98 +39,186 @@ func NewService(
 	demoSlotRepo repositories.DemoSlotRepository,
 	cache Cache,
 ) *Service {
-	return &Service{
+	s := &Service{
 		campaignRepo:   campaignRepo,
 		bannerRepo:     bannerRepo,
 		demoBannerRepo: demoBannerRepo,
 		demoSlotRepo:   demoSlotRepo,
 		cache:          cache,
+		rng:            defaultRandom(),
+		counter:        NewMemoryRotationCounter(),
+		frequency:      NewMemoryFrequencyCounter(),
+	}
+	s.configureRotation()
+	return s
+}
+
+// WithRandom replaces the random source used for rotation (seed it in tests)
+func (s *Service) WithRandom(rng *Random) *Service {
+	s.rng = rng
+	s.configureRotation()
+	return s
+}
+
+// WithRotationCounter replaces the in-process counter behind even and
+// sequential rotation, e.g. with a Redis-backed one shared across instances
+func (s *Service) WithRotationCounter(counter RotationCounter) *Service {
+	s.counter = counter
+	s.configureRotation()
+	return s
+}
+
+// WithFrequencyCounter replaces the in-process counter behind frequency caps,
+// e.g. with a Redis-backed one shared across instances
+func (s *Service) WithFrequencyCounter(counter FrequencyCounter) *Service {
+	s.frequency = counter
+	return s
+}
+
+// WithBudgetChecker excludes campaigns that exhausted or are ahead of their budget.
+// Without one, delivery doesn't look at spend.
+func (s *Service) WithBudgetChecker(budget BudgetChecker) *Service {
+	s.budget = budget
+	return s
+}
+
+// WithAudience sets where viewer segments are looked up for audience targeting
+func (s *Service) WithAudience(audience AudienceProvider) *Service {
+	s.audience = audience
+	return s
+}
+
+// WithStatsRepository loads campaign history so CPC and CPA campaigns are
+// ranked by their observed click and conversion rates instead of priors
+func (s *Service) WithStatsRepository(statsRepo repositories.CampaignStatsRepository) *Service {
+	s.statsRepo = statsRepo
+	return s
+}
+
+// WithLedger records every delivered ad, so tracking can resolve impression
+// IDs to what was actually served
+func (s *Service) WithLedger(ledger repositories.ServedAdRepository) *Service {
+	s.ledger = ledger
+	return s
+}
+
+// WithTrackingSigner signs the tracking URLs of delivered ads, so tracking
+// can trust the banner, campaign and slot they carry
+func (s *Service) WithTrackingSigner(signer TrackingSigner) *Service {
+	s.signer = signer
+	return s
+}
+
+// configureRotation builds the per-mode selection strategies
+func (s *Service) configureRotation() {
+	s.strategies = map[entities.RotationMode]SelectionStrategy{
+		entities.RotationWeighted:   NewWeightedRandomStrategy(s.rng),
+		entities.RotationEven:       NewEvenRotationStrategy(s.counter),
+		entities.RotationSequential: NewSequentialStrategy(s.counter),
 	}
 }
 
 // DeliverBanner delivers a banner for the given slot
 func (s *Service) DeliverBanner(ctx context.Context, slotID string, req *DeliveryRequest) (*GetBannerResponse, error) {
-	// 1. Check cache first
-	cached, err := s.cache.GetBanner(ctx, slotID)
+	// 1. Load the slot's candidates (cache first)
+	set := s.loadCandidates(ctx, slotID)
+
+	// 2. Targeting, rotation and impression ID are decided per request
+	if len(set.Candidates) > 0 {
+		chosen, err := s.selectBanner(ctx, set.Candidates, req)
+		if err == nil {
+			return s.bannerToResponse(chosen.banner, chosen.ad.ID, req), nil
+		}
+	}
+
+	// 3. Fallback to demo banner if no campaign won the slot
+	if set.Demo != nil {
+		return s.demoToResponse(set.Demo), nil
+	}
+
+	// 4. Return fallback if demo banners also not available
+	return s.fallbackResponse(), nil
+}
+
+// loadCandidates returns the cached candidate set for a slot, rebuilding it on a miss.
+// Empty sets are cached too, so slots without campaigns don't hit the database.
+func (s *Service) loadCandidates(ctx context.Context, slotID string) *CandidateSet {
+	cached, err := s.cache.GetCandidates(ctx, slotID)
 	if err == nil && cached != nil {
-		return s.cachedToResponse(cached), nil
+		return cached
 	}
 
-	// 2. Try to find active campaigns for this slot
+	set := &CandidateSet{}
 	campaigns, err := s.campaignRepo.FindBySlotID(ctx, slotID)
-	if err == nil && len(campaigns) > 0 {
-		// 3. Select banner from campaigns
-		banner, impressionID, err := s.selectBanner(ctx, campaigns, req)
-		if err == nil {
-			// 4. Cache the banner
-			s.cache.SetBanner(ctx, slotID, &CachedBanner{
-				HTML:       banner.HTML,
-				Width:      s.extractWidth(banner.Size),
-				Height:     s.extractHeight(banner.Size),
-				ClickURL:   banner.ClickURL,
-				Impression: s.impressionURL(impressionID),
-				CampaignID: banner.CampaignID,
-			})
-
-			return s.bannerToResponse(banner, impressionID), nil
-		}
+	if err != nil {
+		// Don't cache a partial set built from a failed query
+		return set
 	}
+	set.Candidates = s.getCandidates(ctx, campaigns)
+	s.attachStats(ctx, set.Candidates)
 
-	// 5. Fallback to demo banners if no campaigns found or selection failed
 	if s.demoSlotRepo != nil {
-		return s.deliverDemoBanner(ctx, slotID)
+		set.Demo = s.loadDemoCreative(ctx, slotID)
 	}
 
-	// 6. Return fallback if demo banners also not available
-	return s.fallbackResponse(), nil
+	s.cache.SetCandidates(ctx, slotID, set)
+	return set
+}
+
+// attachStats fills in the delivery history of each candidate's campaign
+func (s *Service) attachStats(ctx context.Context, candidates []Candidate) {
+	if s.statsRepo == nil || len(candidates) == 0 {
+		return
+	}
+
+	ids := make([]string, len(candidates))
+	for i, c := range candidates {
+		ids[i] = c.Campaign.ID
+	}
+
+	stats, err := s.statsRepo.FindByCampaignIDs(ctx, ids, time.Now().Add(-statsWindow))
+	if err != nil {
+		return // Rank on priors alone
+	}
+	for i := range candidates {
+		candidates[i].Stats = stats[candidates[i].Campaign.ID]
+	}
 }
 
-// deliverDemoBanner delivers a demo banner for the given slot
-func (s *Service) deliverDemoBanner(ctx context.Context, slotID string) (*GetBannerResponse, error) {
+// loadDemoCreative loads the demo banner assigned to the given slot, if any
+func (s *Service) loadDemoCreative(ctx context.Context, slotID string) *DemoCreative {
 	slot, err := s.demoSlotRepo.GetBySlotID(ctx, slotID)
 	if err != nil {
-		return s.fallbackResponse(), nil
+		return nil
 	}
 
 	if slot == nil || slot.DemoBannerID == nil {
-		return s.fallbackResponse(), nil
+		return nil
 	}
 
 	// Explicitly load the banner since GetBySlotID doesn't preload it
 	banner, err := s.demoBannerRepo.GetByID(ctx, *slot.DemoBannerID)
 	if err != nil || banner == nil {
-		return s.fallbackResponse(), nil
+		return nil
 	}
 
 	if !banner.Active {
-		return s.fallbackResponse(), nil
-	}
-
-	// Extract HTML from pointer
-	html := ""
-	if banner.HTML != nil {
-		html = *banner.HTML
-	}
-
-	// Cache the demo banner
-	s.cache.SetBanner(ctx, slotID, &CachedBanner{
-		HTML:       html,
-		Width:      slot.Width,
-		Height:     slot.Height,
-		ClickURL:   "",
-		Impression: "",
-		CampaignID: "",
-	})
-
-	return &GetBannerResponse{
-		Creative: &Creative{
-			HTML:   html,
-			Width:  slot.Width,
-			Height: slot.Height,
-		},
-		Tracking: &TrackingInfo{
-			Impression: "",
-			Click:      "",
-		},
-	}, nil
+		return nil
+	}
+
+	// Image-only demo banners get generated markup like image creatives
+	creative := &DemoCreative{Type: entities.CreativeHTML, Width: slot.Width, Height: slot.Height}
+	switch {
+	case banner.HTML != nil && *banner.HTML != "":
+		creative.HTML = *banner.HTML
+	case banner.ImageURL != nil:
+		clickURL := ""
+		if banner.ClickURL != nil {
+			clickURL = *banner.ClickURL
+		}
+		creative.Type = entities.CreativeImage
+		creative.HTML = entities.ImageMarkup(*banner.ImageURL, banner.Name, clickURL, entities.Size{Width: slot.Width, Height: slot.Height})
+	}
+
+	return creative
 }
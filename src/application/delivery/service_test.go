@@ -2,6 +2,8 @@ package delivery
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -88,23 +90,31 @@ func (m *mockBannerRepo) Update(ctx context.Context, banner *entities.Banner) er
 }
 
 type mockCache struct {
-	banners map[string]*CachedBanner
+	sets          map[string]*CandidateSet
+	invalidations int
 }
 
-func (m *mockCache) GetBanner(ctx context.Context, slotID string) (*CachedBanner, error) {
-	return m.banners[slotID], nil
+func (m *mockCache) GetCandidates(ctx context.Context, slotID string) (*CandidateSet, error) {
+	return m.sets[slotID], nil
 }
 
-func (m *mockCache) SetBanner(ctx context.Context, slotID string, banner *CachedBanner) error {
-	if m.banners == nil {
-		m.banners = make(map[string]*CachedBanner)
+func (m *mockCache) SetCandidates(ctx context.Context, slotID string, set *CandidateSet) error {
+	if m.sets == nil {
+		m.sets = make(map[string]*CandidateSet)
 	}
-	m.banners[slotID] = banner
+	m.sets[slotID] = set
 	return nil
 }
 
-func (m *mockCache) InvalidateBanner(ctx context.Context, slotID string) error {
-	delete(m.banners, slotID)
+func (m *mockCache) InvalidateSlot(ctx context.Context, slotID string) error {
+	delete(m.sets, slotID)
+	m.invalidations++
+	return nil
+}
+
+func (m *mockCache) InvalidateAll(ctx context.Context) error {
+	m.sets = nil
+	m.invalidations++
 	return nil
 }
 
@@ -145,17 +155,18 @@ func TestService_DeliverBanner_CacheHit_ReturnsCached(t *testing.T) {
 	bannerRepo := &mockBannerRepo{}
 	var demoSlotRepo *mockDemoSlotRepo
 	cache := &mockCache{
-		banners: map[string]*CachedBanner{
+		sets: map[string]*CandidateSet{
 			"slot-1": {
-				HTML:     "<div>Cached Ad</div>",
-				Width:    300,
-				Height:   250,
-				ClickURL: "https://example.com",
+				Demo: &DemoCreative{
+					HTML:   "<div>Cached Ad</div>",
+					Width:  300,
+					Height: 250,
+				},
 			},
 		},
 	}
 
-	service := NewService(campaignRepo, bannerRepo, demoSlotRepo, cache)
+	service := NewService(campaignRepo, bannerRepo, nil, demoSlotRepo, cache)
 
 	// Act
 	response, err := service.DeliverBanner(ctx, "slot-1", &DeliveryRequest{
@@ -183,7 +194,7 @@ func TestService_DeliverBanner_NoCampaigns_ReturnsFallback(t *testing.T) {
 	var demoSlotRepo *mockDemoSlotRepo
 	cache := &mockCache{}
 
-	service := NewService(campaignRepo, bannerRepo, demoSlotRepo, cache)
+	service := NewService(campaignRepo, bannerRepo, nil, demoSlotRepo, cache)
 
 	// Act
 	response, err := service.DeliverBanner(ctx, "slot-1", &DeliveryRequest{
@@ -226,7 +237,8 @@ func TestService_DeliverBanner_ActiveCampaign_ReturnsBanner(t *testing.T) {
 		CampaignID: "cmp-1",
 		Name:       "Test Banner",
 		Status:     entities.BannerStatusActive,
-		Size:       entities.BannerSize300x250,
+		Width:      300,
+		Height:     250,
 		HTML:       "<div>Test Ad</div>",
 		ClickURL:   "https://example.com",
 		Weight:     1,
@@ -237,7 +249,7 @@ func TestService_DeliverBanner_ActiveCampaign_ReturnsBanner(t *testing.T) {
 	var demoSlotRepo *mockDemoSlotRepo
 	cache := &mockCache{}
 
-	service := NewService(campaignRepo, bannerRepo, demoSlotRepo, cache)
+	service := NewService(campaignRepo, bannerRepo, nil, demoSlotRepo, cache)
 
 	// Act
 	response, err := service.DeliverBanner(ctx, "slot-1", &DeliveryRequest{
@@ -284,7 +296,7 @@ func TestService_DeliverBanner_TargetingMismatch_ReturnsFallback(t *testing.T) {
 	var demoSlotRepo *mockDemoSlotRepo
 	cache := &mockCache{}
 
-	service := NewService(campaignRepo, bannerRepo, demoSlotRepo, cache)
+	service := NewService(campaignRepo, bannerRepo, nil, demoSlotRepo, cache)
 
 	// Act - Request from CA (not US)
 	response, err := service.DeliverBanner(ctx, "slot-1", &DeliveryRequest{
@@ -306,6 +318,411 @@ func TestService_DeliverBanner_TargetingMismatch_ReturnsFallback(t *testing.T) {
 	}
 }
 
+func TestService_DeliverBanner_CacheHit_DecidesPerRequest(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	now := time.Now()
+
+	campaign := &entities.Campaign{
+		ID:        "cmp-1",
+		Status:    entities.CampaignStatusActive,
+		StartDate: now.Add(-1 * time.Hour),
+		Targeting: entities.Targeting{Geo: []string{"US"}},
+	}
+	banner := &entities.Banner{
+		ID:         "ban-1",
+		CampaignID: "cmp-1",
+		Status:     entities.BannerStatusActive,
+		Width:      300,
+		Height:     250,
+		HTML:       "<div>Cached Campaign Ad</div>",
+		Weight:     1,
+	}
+
+	// The repositories are empty: everything must come from the cached candidate set
+	cache := &mockCache{
+		sets: map[string]*CandidateSet{
+			"slot-1": {Candidates: []Candidate{{Campaign: campaign, Banners: []*entities.Banner{banner}}}},
+		},
+	}
+	service := NewService(&mockCampaignRepo{}, &mockBannerRepo{}, nil, nil, cache)
+
+	// Act
+	first, _ := service.DeliverBanner(ctx, "slot-1", &DeliveryRequest{SlotID: "slot-1", Country: "US"})
+	second, _ := service.DeliverBanner(ctx, "slot-1", &DeliveryRequest{SlotID: "slot-1", Country: "US"})
+	other, _ := service.DeliverBanner(ctx, "slot-1", &DeliveryRequest{SlotID: "slot-1", Country: "CA"})
+
+	// Assert
+	if first.Creative == nil || second.Creative == nil {
+		t.Fatal("Expected cached campaign to be served")
+	}
+	if first.Tracking.Impression == second.Tracking.Impression {
+		t.Errorf("Expected a fresh impression ID per request, got %s twice", first.Tracking.Impression)
+	}
+	if other.Fallback == nil {
+		t.Errorf("Expected targeting to be evaluated per request on cached candidates")
+	}
+}
+
+func TestService_DeliverBanner_CacheMiss_StoresCandidates(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	now := time.Now()
+
+	campaign := &entities.Campaign{ID: "cmp-1", Status: entities.CampaignStatusActive, StartDate: now.Add(-1 * time.Hour)}
+	banner := &entities.Banner{ID: "ban-1", CampaignID: "cmp-1", Status: entities.BannerStatusActive, Weight: 1}
+
+	cache := &mockCache{}
+	service := NewService(
+		&mockCampaignRepo{campaigns: []*entities.Campaign{campaign}},
+		&mockBannerRepo{banners: []*entities.Banner{banner}},
+		nil, nil, cache,
+	)
+
+	// Act
+	_, err := service.DeliverBanner(ctx, "slot-1", &DeliveryRequest{SlotID: "slot-1"})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	set := cache.sets["slot-1"]
+	if set == nil || len(set.Candidates) != 1 || set.Candidates[0].Campaign.ID != "cmp-1" {
+		t.Errorf("Expected slot candidates to be cached, got %+v", set)
+	}
+}
+
 func ptrTime(t time.Time) *time.Time {
 	return &t
 }
+
+type stubBudgetChecker struct {
+	exhausted map[string]bool
+}
+
+func (s *stubBudgetChecker) CanServe(ctx context.Context, campaigns []*entities.Campaign) map[string]bool {
+	allowed := make(map[string]bool)
+	for _, c := range campaigns {
+		allowed[c.ID] = !s.exhausted[c.ID]
+	}
+	return allowed
+}
+
+func TestService_DeliverBanner_ExcludesExhaustedCampaigns(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	exhausted := &entities.Campaign{ID: "cmp-1", Status: entities.CampaignStatusActive, StartDate: now.Add(-time.Hour)}
+	funded := &entities.Campaign{ID: "cmp-2", Status: entities.CampaignStatusActive, StartDate: now.Add(-time.Hour)}
+	banners := []*entities.Banner{
+		{ID: "ban-1", CampaignID: "cmp-1", Status: entities.BannerStatusActive, HTML: "exhausted", Weight: 100},
+		{ID: "ban-2", CampaignID: "cmp-2", Status: entities.BannerStatusActive, HTML: "funded", Weight: 1},
+	}
+
+	service := NewService(
+		&mockCampaignRepo{campaigns: []*entities.Campaign{exhausted, funded}},
+		&mockBannerRepo{banners: banners},
+		nil, nil, &mockCache{},
+	).WithBudgetChecker(&stubBudgetChecker{exhausted: map[string]bool{"cmp-1": true}})
+
+	for i := 0; i < 20; i++ {
+		response, _ := service.DeliverBanner(ctx, "slot-1", &DeliveryRequest{SlotID: "slot-1"})
+		if response.Creative == nil || response.Creative.HTML != "funded" {
+			t.Fatalf("Expected only the funded campaign to serve, got %+v", response)
+		}
+	}
+
+	service.WithBudgetChecker(&stubBudgetChecker{exhausted: map[string]bool{"cmp-1": true, "cmp-2": true}})
+	response, _ := service.DeliverBanner(ctx, "slot-1", &DeliveryRequest{SlotID: "slot-1"})
+	if response.Fallback == nil {
+		t.Errorf("Expected fallback when every campaign is out of budget")
+	}
+}
+
+type stubAudience struct {
+	segments map[string][]string
+	lookups  int
+}
+
+func (s *stubAudience) Segments(ctx context.Context, userID string) ([]string, error) {
+	s.lookups++
+	return s.segments[userID], nil
+}
+
+func TestService_DeliverBanner_RetargetsSegmentMembers(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	retargeting := &entities.Campaign{
+		ID: "cmp-1", Status: entities.CampaignStatusActive, StartDate: now.Add(-time.Hour),
+		Priority:  entities.PrioritySponsorship,
+		Targeting: entities.Targeting{Segments: []string{"visitors"}},
+	}
+	prospecting := &entities.Campaign{ID: "cmp-2", Status: entities.CampaignStatusActive, StartDate: now.Add(-time.Hour)}
+	banners := []*entities.Banner{
+		{ID: "ban-1", CampaignID: "cmp-1", Status: entities.BannerStatusActive, HTML: "retargeting"},
+		{ID: "ban-2", CampaignID: "cmp-2", Status: entities.BannerStatusActive, HTML: "prospecting"},
+	}
+
+	audience := &stubAudience{segments: map[string][]string{"alice": {"visitors"}}}
+	service := NewService(
+		&mockCampaignRepo{campaigns: []*entities.Campaign{retargeting, prospecting}},
+		&mockBannerRepo{banners: banners},
+		nil, nil, &mockCache{},
+	).WithAudience(audience)
+
+	response, _ := service.DeliverBanner(ctx, "slot-1", &DeliveryRequest{SlotID: "slot-1", UserID: "alice"})
+	if response.Creative == nil || response.Creative.HTML != "retargeting" {
+		t.Errorf("Expected segment member to be retargeted, got %+v", response)
+	}
+
+	response, _ = service.DeliverBanner(ctx, "slot-1", &DeliveryRequest{SlotID: "slot-1", UserID: "bob"})
+	if response.Creative == nil || response.Creative.HTML != "prospecting" {
+		t.Errorf("Expected other viewers to get the prospecting campaign, got %+v", response)
+	}
+
+	// Segments are only looked up when a candidate targets them
+	retargeting.Targeting = entities.Targeting{}
+	lookups := audience.lookups
+	service.DeliverBanner(ctx, "slot-2", &DeliveryRequest{SlotID: "slot-2", UserID: "alice"})
+	if audience.lookups != lookups {
+		t.Errorf("Expected no segment lookup without segment targeting")
+	}
+}
+
+func TestService_DeliverBanner_MatchesSlotSize(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	campaigns := []*entities.Campaign{
+		{ID: "cmp-1", Status: entities.CampaignStatusActive, StartDate: now.Add(-time.Hour), Priority: entities.PrioritySponsorship},
+		{ID: "cmp-2", Status: entities.CampaignStatusActive, StartDate: now.Add(-time.Hour)},
+	}
+	banners := []*entities.Banner{
+		{ID: "ban-1", CampaignID: "cmp-1", Status: entities.BannerStatusActive, HTML: "leaderboard", Width: 728, Height: 90},
+		{ID: "ban-2", CampaignID: "cmp-2", Status: entities.BannerStatusActive, HTML: "rectangle", Width: 300, Height: 250},
+	}
+
+	service := NewService(
+		&mockCampaignRepo{campaigns: campaigns},
+		&mockBannerRepo{banners: banners},
+		nil, nil, &mockCache{},
+	)
+
+	// The sponsorship has no banner for a rectangle slot
+	response, _ := service.DeliverBanner(ctx, "slot-1", &DeliveryRequest{
+		SlotID: "slot-1",
+		Sizes:  []entities.Size{{Width: 300, Height: 250}},
+	})
+	if response.Creative == nil || response.Creative.HTML != "rectangle" {
+		t.Fatalf("Expected the rectangle banner, got %+v", response)
+	}
+	if response.Creative.Width != 300 || response.Creative.Height != 250 {
+		t.Errorf("Expected 300x250 creative, got %dx%d", response.Creative.Width, response.Creative.Height)
+	}
+
+	// Nothing fits a skyscraper slot
+	response, _ = service.DeliverBanner(ctx, "slot-1", &DeliveryRequest{
+		SlotID: "slot-1",
+		Sizes:  []entities.Size{{Width: 160, Height: 600}},
+	})
+	if response.Fallback == nil {
+		t.Errorf("Expected fallback when no banner fits, got %+v", response)
+	}
+}
+
+func TestService_DeliverBanner_SeparatesNativeAndDisplay(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	campaigns := []*entities.Campaign{{ID: "cmp-1", Status: entities.CampaignStatusActive, StartDate: now.Add(-time.Hour)}}
+	banners := []*entities.Banner{
+		{ID: "ban-1", CampaignID: "cmp-1", Status: entities.BannerStatusActive, HTML: "display", Width: 300, Height: 250},
+		{
+			ID: "ban-2", CampaignID: "cmp-1", Status: entities.BannerStatusActive, Type: entities.CreativeNative,
+			Native: &entities.NativeAssets{Title: "Native", Sponsor: "Example"},
+		},
+	}
+
+	service := NewService(
+		&mockCampaignRepo{campaigns: campaigns},
+		&mockBannerRepo{banners: banners},
+		nil, nil, &mockCache{},
+	)
+
+	for i := 0; i < 5; i++ {
+		response, _ := service.DeliverBanner(ctx, "slot-1", &DeliveryRequest{SlotID: "slot-1"})
+		if response.Creative == nil || response.Creative.HTML != "display" {
+			t.Fatalf("Expected display slot to get the display banner, got %+v", response)
+		}
+
+		response, _ = service.DeliverBanner(ctx, "slot-1", &DeliveryRequest{SlotID: "slot-1", Format: FormatNative})
+		if response.Creative == nil || response.Creative.Native == nil || response.Creative.Native.Title != "Native" {
+			t.Fatalf("Expected native slot to get the native banner, got %+v", response)
+		}
+	}
+}
+
+func TestService_DeliverBanner_VideoTracking(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	campaigns := []*entities.Campaign{{ID: "cmp-1", Status: entities.CampaignStatusActive, StartDate: now.Add(-time.Hour)}}
+	banners := []*entities.Banner{
+		{ID: "ban-1", CampaignID: "cmp-1", Status: entities.BannerStatusActive, HTML: "display", Width: 300, Height: 250},
+		{
+			ID: "ban-2", CampaignID: "cmp-1", Status: entities.BannerStatusActive, Type: entities.CreativeVideo,
+			Video: &entities.VideoAssets{Duration: 15, MediaFiles: []entities.MediaFile{{URL: "https://cdn.example.com/ad.mp4", Type: "video/mp4", Width: 640, Height: 360}}},
+		},
+	}
+
+	service := NewService(
+		&mockCampaignRepo{campaigns: campaigns},
+		&mockBannerRepo{banners: banners},
+		nil, nil, &mockCache{},
+	)
+
+	response, _ := service.DeliverBanner(ctx, "preroll", &DeliveryRequest{SlotID: "preroll", Format: FormatVideo})
+	if response.Creative == nil || response.Creative.Video == nil {
+		t.Fatalf("Expected the video creative, got %+v", response)
+	}
+
+	impressionID := response.Tracking.ImpressionID
+	prefix := "/api/v1/track/video/" + impressionID + "?"
+	if !strings.HasPrefix(response.Tracking.Impression, prefix) || !strings.Contains(response.Tracking.Impression, "event=impression") {
+		t.Errorf("Expected GET impression tracker, got %q", response.Tracking.Impression)
+	}
+	for _, event := range entities.VideoEventTypes {
+		url := response.Tracking.Video[string(event)]
+		if !strings.HasPrefix(url, prefix) || !strings.Contains(url, "event="+string(event)) || !strings.Contains(url, "campaign_id=cmp-1") {
+			t.Errorf("Unexpected %s tracker %q", event, url)
+		}
+	}
+	if response.Tracking.Click != "/api/v1/track/click/"+impressionID {
+		t.Errorf("Expected click tracker, got %q", response.Tracking.Click)
+	}
+}
+
+type mockServedAdRepo struct {
+	ads []*entities.ServedAd
+	err error
+}
+
+func (m *mockServedAdRepo) Create(ctx context.Context, ad *entities.ServedAd) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.ads = append(m.ads, ad)
+	return nil
+}
+
+func (m *mockServedAdRepo) FindByID(ctx context.Context, id string) (*entities.ServedAd, error) {
+	return nil, nil
+}
+
+func (m *mockServedAdRepo) MarkRendered(ctx context.Context, id string, at time.Time) (bool, error) {
+	return false, nil
+}
+
+func (m *mockServedAdRepo) UnmarkRendered(ctx context.Context, id string) error {
+	return nil
+}
+
+func (m *mockServedAdRepo) MarkWon(ctx context.Context, id string, price decimal.Decimal, at time.Time) (bool, error) {
+	return false, nil
+}
+
+func TestService_DeliverBanner_RecordsServedAd(t *testing.T) {
+	now := time.Now()
+	campaigns := []*entities.Campaign{{ID: "cmp-1", Status: entities.CampaignStatusActive, StartDate: now.Add(-time.Hour), Bid: decimal.NewFromInt(2)}}
+	banners := []*entities.Banner{{ID: "ban-1", CampaignID: "cmp-1", Status: entities.BannerStatusActive, HTML: "display", Width: 300, Height: 250}}
+
+	ledger := &mockServedAdRepo{}
+	service := NewService(
+		&mockCampaignRepo{campaigns: campaigns},
+		&mockBannerRepo{banners: banners},
+		nil, nil, &mockCache{},
+	).WithLedger(ledger)
+
+	response, _ := service.DeliverBanner(context.Background(), "slot-1", &DeliveryRequest{SlotID: "slot-1", IP: "10.0.0.1", Country: "US"})
+	if response.Tracking == nil || len(ledger.ads) != 1 {
+		t.Fatalf("Expected one served ad for the delivered banner, got %d", len(ledger.ads))
+	}
+
+	ad := ledger.ads[0]
+	if ad.ID != response.Tracking.ImpressionID {
+		t.Errorf("Expected served ad %s to carry the impression ID %s", ad.ID, response.Tracking.ImpressionID)
+	}
+	if ad.BannerID != "ban-1" || ad.CampaignID != "cmp-1" || ad.SlotID != "slot-1" || ad.IP != "10.0.0.1" || ad.Country != "US" {
+		t.Errorf("Unexpected served ad %+v", ad)
+	}
+}
+
+func TestService_DeliverBanner_LedgerFailure(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	campaigns := []*entities.Campaign{{ID: "cmp-1", Status: entities.CampaignStatusActive, StartDate: now.Add(-time.Hour), Bid: decimal.NewFromInt(2)}}
+	banners := []*entities.Banner{{ID: "ban-1", CampaignID: "cmp-1", Status: entities.BannerStatusActive, HTML: "display", Width: 300, Height: 250}}
+
+	service := NewService(
+		&mockCampaignRepo{campaigns: campaigns},
+		&mockBannerRepo{banners: banners},
+		nil, nil, &mockCache{},
+	).WithLedger(&mockServedAdRepo{err: errors.New("database unavailable")})
+
+	// Tracking could never resolve the impression, so the banner is not served
+	response, _ := service.DeliverBanner(ctx, "slot-1", &DeliveryRequest{SlotID: "slot-1"})
+	if response.Creative != nil || response.Fallback == nil {
+		t.Errorf("Expected the fallback when the served ad can't be recorded, got %+v", response)
+	}
+	if bid, _ := service.DeliverBid(ctx, "slot-1", &DeliveryRequest{SlotID: "slot-1"}); bid != nil {
+		t.Errorf("Expected no bid when the served ad can't be recorded, got %+v", bid)
+	}
+	if failures := service.LedgerFailures(); failures != 2 {
+		t.Errorf("Expected 2 ledger failures, got %d", failures)
+	}
+}
+
+func TestService_DeliverBid_Floor(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	campaigns := []*entities.Campaign{
+		{ID: "cmp-1", Status: entities.CampaignStatusActive, StartDate: now.Add(-time.Hour), Bid: decimal.NewFromInt(2)},
+		{ID: "cmp-2", Status: entities.CampaignStatusActive, StartDate: now.Add(-time.Hour), Bid: decimal.NewFromInt(4)},
+	}
+	banners := []*entities.Banner{
+		{ID: "ban-1", CampaignID: "cmp-1", Status: entities.BannerStatusActive, HTML: "cheap", Width: 300, Height: 250},
+		{ID: "ban-2", CampaignID: "cmp-2", Status: entities.BannerStatusActive, HTML: "dear", Width: 300, Height: 250},
+	}
+
+	ledger := &mockServedAdRepo{}
+	service := NewService(
+		&mockCampaignRepo{campaigns: campaigns},
+		&mockBannerRepo{banners: banners},
+		nil, nil, &mockCache{},
+	).WithLedger(ledger)
+
+	for i := 0; i < 5; i++ {
+		bid, err := service.DeliverBid(ctx, "slot-1", &DeliveryRequest{SlotID: "slot-1", BidFloor: decimal.NewFromInt(3)})
+		if err != nil || bid == nil {
+			t.Fatalf("Expected a bid above the floor, got %v", err)
+		}
+		if bid.Campaign.ID != "cmp-2" || !bid.Price.Equal(decimal.NewFromInt(4)) {
+			t.Errorf("Expected cmp-2 to bid 4, got %s at %s", bid.Campaign.ID, bid.Price)
+		}
+		if bid.Response.Tracking == nil || bid.Response.Tracking.ImpressionID != bid.ImpressionID || bid.Response.Tracking.Impression != "" {
+			t.Errorf("Expected the bid's markup to leave impression tracking to the billing notice, got %+v", bid.Response.Tracking)
+		}
+		if !strings.HasPrefix(bid.WinURL, "/api/v1/track/win/"+bid.ImpressionID+"?") || !strings.HasPrefix(bid.BillURL, "/api/v1/track/bill/"+bid.ImpressionID+"?") {
+			t.Errorf("Expected notice URLs of the bid's impression, got %q and %q", bid.WinURL, bid.BillURL)
+		}
+	}
+	if len(ledger.ads) != 5 || !ledger.ads[0].Auction || !ledger.ads[0].Price.Equal(decimal.NewFromInt(4)) {
+		t.Errorf("Expected the bids recorded as auction ads at their price, got %d", len(ledger.ads))
+	}
+
+	bid, err := service.DeliverBid(ctx, "slot-1", &DeliveryRequest{SlotID: "slot-1", BidFloor: decimal.NewFromInt(5)})
+	if err != nil || bid != nil {
+		t.Errorf("Expected no bid under the floor, got %+v", bid)
+	}
+}
@@ -1,6 +1,7 @@
 package delivery
 
 import (
+	"sync"
 	"time"
 
 	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
@@ -9,7 +10,7 @@ import (
 // matchesTargeting checks if request matches campaign targeting
 func (s *Service) matchesTargeting(t entities.Targeting, req *DeliveryRequest) bool {
 	// Geo targeting
-	if len(t.Geo) > 0 && !s.contains(t.Geo, req.Country) {
+	if len(t.Geo) > 0 && !s.matchesGeo(t.Geo, req) {
 		return false
 	}
 
@@ -23,14 +24,42 @@ func (s *Service) matchesTargeting(t entities.Targeting, req *DeliveryRequest) b
 		return false
 	}
 
-	// Time targeting (simplified - check if current time is within any range)
-	if len(t.TimeOfDay) > 0 && !s.matchesTime(t.TimeOfDay, req.Timestamp) {
+	// Browser targeting
+	if len(t.Browsers) > 0 && !s.contains(t.Browsers, req.Browser) {
+		return false
+	}
+
+	// Site, domain and page URL targeting
+	if !t.MatchesPage(req.Referer) {
+		return false
+	}
+
+	// Contextual keyword targeting
+	if !t.MatchesKeywords(req.Keywords) {
+		return false
+	}
+
+	// Audience segment targeting
+	if !t.MatchesSegments(req.Segments) {
+		return false
+	}
+
+	// Dayparting in the campaign's (or viewer's) time zone
+	if len(t.TimeOfDay) > 0 && !s.matchesTime(t.TimeOfDay, req.Timestamp.In(location(t.TimezoneName(req.Timezone)))) {
 		return false
 	}
 
 	return true
 }
 
+// matchesGeo checks if the request's country or country-region is targeted
+func (s *Service) matchesGeo(geo []string, req *DeliveryRequest) bool {
+	if s.contains(geo, req.Country) {
+		return true
+	}
+	return req.Country != "" && req.Region != "" && s.contains(geo, req.Country+"-"+req.Region)
+}
+
 // contains checks if slice contains string
 func (s *Service) contains(slice []string, item string) bool {
 	for _, str := range slice {
@@ -41,18 +70,28 @@ func (s *Service) contains(slice []string, item string) bool {
 	return false
 }
 
-// matchesTime checks if current time matches any time range
-func (s *Service) matchesTime(ranges []entities.TimeRange, timestamp time.Time) bool {
-	hour := timestamp.Hour()
-	minute := timestamp.Minute()
-	current := hour*60 + minute
-
+// matchesTime checks if a local time matches any time range
+func (s *Service) matchesTime(ranges []entities.TimeRange, local time.Time) bool {
 	for _, r := range ranges {
-		start := r.Start.Hour()*60 + r.Start.Minute()
-		end := r.End.Hour()*60 + r.End.Minute()
-		if current >= start && current <= end {
+		if r.Contains(local) {
 			return true
 		}
 	}
 	return false
 }
+
+// locations caches loaded time zones by IANA name
+var locations sync.Map
+
+// location loads a time zone, falling back to UTC for unknown names
+func location(name string) *time.Location {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		loc = time.UTC
+	}
+	locations.Store(name, loc)
+	return loc
+}
@@ -3,34 +3,81 @@ package delivery
 import (
 	"context"
 	"time"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/shopspring/decimal"
 )
 
-// Cache defines the interface for banner caching
+// Cache defines the interface for slot candidate caching.
+// Only request-independent data is cached; targeting, rotation and
+// impression IDs are evaluated per request.
 type Cache interface {
-	GetBanner(ctx context.Context, slotID string) (*CachedBanner, error)
-	SetBanner(ctx context.Context, slotID string, banner *CachedBanner) error
+	GetCandidates(ctx context.Context, slotID string) (*CandidateSet, error)
+	SetCandidates(ctx context.Context, slotID string, set *CandidateSet) error
+	InvalidateSlot(ctx context.Context, slotID string) error
+	InvalidateAll(ctx context.Context) error
+}
+
+// AudienceProvider returns the audience segments a viewer belongs to
+type AudienceProvider interface {
+	Segments(ctx context.Context, userID string) ([]string, error)
+}
+
+// TrackingSigner signs the claims carried by tracking URLs
+type TrackingSigner interface {
+	Sign(claims entities.TrackingClaims) string
+}
+
+// BudgetChecker decides which campaigns are still within budget and on pace
+type BudgetChecker interface {
+	CanServe(ctx context.Context, campaigns []*entities.Campaign) map[string]bool
+}
+
+// CandidateSet is everything that can serve on a slot
+type CandidateSet struct {
+	Candidates []Candidate   `json:"candidates"`
+	Demo       *DemoCreative `json:"demo,omitempty"`
 }
 
-// CachedBanner represents a cached banner response
-type CachedBanner struct {
-	HTML       string `json:"html"`
-	Width      int    `json:"width"`
-	Height     int    `json:"height"`
-	ClickURL   string `json:"click_url"`
-	Impression string `json:"impression_url"`
-	CampaignID string `json:"campaign_id"`
+// Candidate is a campaign booked on a slot together with its active banners
+// and its recent delivery history (for eCPM ranking)
+type Candidate struct {
+	Campaign *entities.Campaign     `json:"campaign"`
+	Banners  []*entities.Banner     `json:"banners"`
+	Stats    entities.CampaignStats `json:"stats"`
+}
+
+// DemoCreative is the demo banner served when no campaign wins the slot
+type DemoCreative struct {
+	Type   entities.CreativeType `json:"type,omitempty"`
+	HTML   string                `json:"html"`
+	Width  int                   `json:"width"`
+	Height int                   `json:"height"`
 }
 
 // DeliveryRequest represents a delivery request
 type DeliveryRequest struct {
 	SlotID    string
+	UserID    string // Stable viewer identifier; falls back to IP when empty
 	IP        string
 	UserAgent string
 	Country   string
+	Region    string // ISO 3166-2 subdivision code without country, e.g. "CA"
+	City      string
+	Timezone  string // Viewer's IANA time zone, e.g. "Europe/London"
 	Device    string
 	OS        string
-	Referer   string
+	Browser   string
+	Sizes     []entities.Size // Sizes the slot accepts; empty accepts any banner
+	Format    string          // FormatDisplay, FormatNative, FormatOpenRTBNative or FormatVideo
+	Referer   string          // URL of the page the ad is shown on
+	Keywords  []string        // Normalized page keywords for contextual targeting
+	Segments  []string        // Audience segments of the viewer, loaded during selection
+	Page      *PageExclusions // Campaigns already on the page in batch requests; nil for single slots
 	Timestamp time.Time
+
+	Programmatic bool            // Offered by an exchange: only campaigns with an eCPM of at least BidFloor compete
+	BidFloor     decimal.Decimal // Minimum CPM of programmatic requests
 }
 
 // GetBannerResponse represents the API response
@@ -40,17 +87,38 @@ type GetBannerResponse struct {
 	Fallback *FallbackInfo `json:"fallback,omitempty"`
 }
 
+// BatchResponse holds the creatives of every slot of a page, in request order
+type BatchResponse struct {
+	Slots []BatchSlotResponse `json:"slots"`
+}
+
+// BatchSlotResponse is the creative delivered to one slot of a batch
+type BatchSlotResponse struct {
+	SlotID string `json:"slot_id"`
+	*GetBannerResponse
+}
+
 // Creative represents banner creative
 type Creative struct {
-	HTML   string `json:"html"`
-	Width  int    `json:"width"`
-	Height int    `json:"height"`
+	Type     entities.CreativeType `json:"type"`
+	HTML     string                `json:"html"` // Markup to render, generated for image creatives
+	Width    int                   `json:"width"`
+	Height   int                   `json:"height"`
+	ImageURL string                `json:"image_url,omitempty"`
+	AltText  string                `json:"alt_text,omitempty"`
+
+	Native  *entities.NativeAssets `json:"native,omitempty"`  // Native assets for the publisher's template
+	OpenRTB *OpenRTBNativeResponse `json:"openrtb,omitempty"` // Native assets in OpenRTB Native 1.2 shape, on request
+
+	Video *entities.VideoAssets `json:"video,omitempty"` // Media of video creatives
 }
 
 // TrackingInfo contains tracking URLs
 type TrackingInfo struct {
-	Impression string `json:"impression"`
-	Click      string `json:"click"`
+	ImpressionID string            `json:"impression_id,omitempty"`
+	Impression   string            `json:"impression"`
+	Click        string            `json:"click"`
+	Video        map[string]string `json:"video,omitempty"` // Playback event tracking URLs by VAST event name
 }
 
 // FallbackInfo represents fallback banner
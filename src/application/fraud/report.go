@@ -0,0 +1,35 @@
+package fraud
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/fall-out-bug/demo-adserver/src/domain/repositories"
+)
+
+// MaxReportPeriod is the longest period one invalid traffic report covers
+const MaxReportPeriod = 92 * 24 * time.Hour
+
+// ErrInvalidPeriod is returned for report periods that are empty or too long
+var ErrInvalidPeriod = errors.New("invalid report period")
+
+// ReportService reports invalid traffic
+type ReportService struct {
+	repo repositories.InvalidTrafficRepository
+}
+
+// NewReportService creates a new invalid traffic report service
+func NewReportService(repo repositories.InvalidTrafficRepository) *ReportService {
+	return &ReportService{repo: repo}
+}
+
+// Report returns the invalid traffic of each campaign, or of one campaign,
+// from since until until
+func (s *ReportService) Report(ctx context.Context, since, until time.Time, campaignID string) ([]*entities.InvalidTrafficReport, error) {
+	if !until.After(since) || until.Sub(since) > MaxReportPeriod {
+		return nil, ErrInvalidPeriod
+	}
+	return s.repo.Report(ctx, since, until, campaignID)
+}
@@ -0,0 +1,150 @@
+package fraud
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+)
+
+// BotMatcher recognizes the user agents of bots, crawlers and other non-browser clients
+type BotMatcher interface {
+	IsBot(userAgent string) bool
+}
+
+// NetworkMatcher recognizes addresses in a set of IP ranges
+type NetworkMatcher interface {
+	Contains(ip string) bool
+}
+
+// VelocityCounter counts events per key in fixed time windows
+type VelocityCounter interface {
+	// Increment adds an event and returns the events of the key in the current window
+	Increment(ctx context.Context, key string, window time.Duration) (int64, error)
+}
+
+// ruleWeights is how strongly each rule indicates invalid traffic. The
+// weights of matched rules combine as independent probabilities.
+var ruleWeights = map[string]float64{
+	entities.FraudBotUserAgent:   1.0,
+	entities.FraudDatacenterIP:   0.8,
+	entities.FraudFastClick:      0.7,
+	entities.FraudIPVelocity:     0.6,
+	entities.FraudMissingReferer: 0.2, // Referrer policies strip it from real traffic too
+}
+
+// Config configures the scorer; zero values take the defaults
+type Config struct {
+	Threshold      float64       // Score from which events are invalid
+	VelocityLimit  int64         // Events per IP and window before the velocity rule matches
+	VelocityWindow time.Duration // Window of the velocity rule
+	MinClickDelay  time.Duration // Clicks sooner after the ad was served match the fast click rule
+}
+
+// DefaultConfig is the configuration used for unset fields
+var DefaultConfig = Config{
+	Threshold:      0.7,
+	VelocityLimit:  60,
+	VelocityWindow: time.Minute,
+	MinClickDelay:  time.Second,
+}
+
+// Scorer scores impressions and clicks for invalid traffic. Rules without
+// their data source (bot list, datacenter ranges, velocity counter) are
+// skipped.
+type Scorer struct {
+	config      Config
+	bots        BotMatcher
+	datacenters NetworkMatcher
+	velocity    VelocityCounter
+}
+
+// NewScorer creates a new scorer
+func NewScorer(config Config) *Scorer {
+	if config.Threshold <= 0 {
+		config.Threshold = DefaultConfig.Threshold
+	}
+	if config.VelocityLimit <= 0 {
+		config.VelocityLimit = DefaultConfig.VelocityLimit
+	}
+	if config.VelocityWindow <= 0 {
+		config.VelocityWindow = DefaultConfig.VelocityWindow
+	}
+	if config.MinClickDelay <= 0 {
+		config.MinClickDelay = DefaultConfig.MinClickDelay
+	}
+	return &Scorer{config: config}
+}
+
+// WithBots matches user agents against a bot list
+func (s *Scorer) WithBots(bots BotMatcher) *Scorer {
+	s.bots = bots
+	return s
+}
+
+// WithDatacenters matches IPs against datacenter ranges
+func (s *Scorer) WithDatacenters(datacenters NetworkMatcher) *Scorer {
+	s.datacenters = datacenters
+	return s
+}
+
+// WithVelocity limits the events per IP
+func (s *Scorer) WithVelocity(velocity VelocityCounter) *Scorer {
+	s.velocity = velocity
+	return s
+}
+
+// ScoreImpression scores an impression
+func (s *Scorer) ScoreImpression(ctx context.Context, impression *entities.Impression) entities.FraudVerdict {
+	reasons := s.clientReasons(ctx, "impression", impression.IP, impression.UserAgent, impression.Referer)
+	return s.verdict(reasons)
+}
+
+// ScoreClick scores a click on an impression; the impression's timestamp
+// is when the ad was served
+func (s *Scorer) ScoreClick(ctx context.Context, click *entities.Click, impression *entities.Impression) entities.FraudVerdict {
+	reasons := s.clientReasons(ctx, "click", click.IP, click.UserAgent, click.Referer)
+	if !impression.Timestamp.IsZero() && click.Timestamp.Sub(impression.Timestamp) < s.config.MinClickDelay {
+		reasons = append(reasons, entities.FraudFastClick)
+	}
+	return s.verdict(reasons)
+}
+
+// clientReasons runs the rules on the client of an event
+func (s *Scorer) clientReasons(ctx context.Context, kind, ip, userAgent, referer string) []string {
+	var reasons []string
+	if s.bots != nil && s.bots.IsBot(userAgent) {
+		reasons = append(reasons, entities.FraudBotUserAgent)
+	}
+	if s.datacenters != nil && s.datacenters.Contains(ip) {
+		reasons = append(reasons, entities.FraudDatacenterIP)
+	}
+	if s.velocity != nil && ip != "" {
+		// Fail open: an unavailable counter doesn't flag traffic
+		count, err := s.velocity.Increment(ctx, kind+":"+ip, s.config.VelocityWindow)
+		if err == nil && count > s.config.VelocityLimit {
+			reasons = append(reasons, entities.FraudIPVelocity)
+		}
+	}
+	if referer == "" {
+		reasons = append(reasons, entities.FraudMissingReferer)
+	}
+	return reasons
+}
+
+// verdict combines the weights of the matched rules into a score
+func (s *Scorer) verdict(reasons []string) entities.FraudVerdict {
+	clean := 1.0
+	for _, reason := range reasons {
+		clean *= 1 - ruleWeights[reason]
+	}
+	// Rounded to the precision scores are stored at
+	score := math.Round((1-clean)*100) / 100
+
+	return entities.FraudVerdict{
+		Score:   score,
+		Reasons: reasons,
+		Invalid: score >= s.config.Threshold,
+	}
+}
@@ -0,0 +1,132 @@
+package fraud
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+)
+
+type stubBots struct{}
+
+func (s *stubBots) IsBot(userAgent string) bool {
+	return strings.Contains(userAgent, "bot")
+}
+
+type stubNetworks struct{}
+
+func (s *stubNetworks) Contains(ip string) bool {
+	return strings.HasPrefix(ip, "3.")
+}
+
+type mockVelocity struct {
+	counts map[string]int64
+	err    error
+}
+
+func (m *mockVelocity) Increment(ctx context.Context, key string, window time.Duration) (int64, error) {
+	if m.err != nil {
+		return 0, m.err
+	}
+	m.counts[key]++
+	return m.counts[key], nil
+}
+
+func TestScorer_ScoreImpression(t *testing.T) {
+	scorer := NewScorer(Config{VelocityLimit: 2}).
+		WithBots(&stubBots{}).
+		WithDatacenters(&stubNetworks{}).
+		WithVelocity(&mockVelocity{counts: map[string]int64{}})
+
+	tests := []struct {
+		name       string
+		impression *entities.Impression
+		score      float64
+		reasons    []string
+		invalid    bool
+	}{
+		{
+			name:       "clean",
+			impression: &entities.Impression{IP: "10.0.0.1", UserAgent: "Mozilla/5.0", Referer: "https://news.example.com/"},
+		},
+		{
+			name:       "no referer",
+			impression: &entities.Impression{IP: "10.0.0.2", UserAgent: "Mozilla/5.0"},
+			score:      0.2,
+			reasons:    []string{entities.FraudMissingReferer},
+		},
+		{
+			name:       "bot",
+			impression: &entities.Impression{IP: "10.0.0.3", UserAgent: "Googlebot/2.1", Referer: "https://news.example.com/"},
+			score:      1,
+			reasons:    []string{entities.FraudBotUserAgent},
+			invalid:    true,
+		},
+		{
+			name:       "datacenter",
+			impression: &entities.Impression{IP: "3.5.140.2", UserAgent: "Mozilla/5.0", Referer: "https://news.example.com/"},
+			score:      0.8,
+			reasons:    []string{entities.FraudDatacenterIP},
+			invalid:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verdict := scorer.ScoreImpression(context.Background(), tt.impression)
+			if verdict.Score != tt.score || verdict.Invalid != tt.invalid || !reflect.DeepEqual(verdict.Reasons, tt.reasons) {
+				t.Errorf("Expected %v %v invalid=%v, got %+v", tt.score, tt.reasons, tt.invalid, verdict)
+			}
+		})
+	}
+}
+
+func TestScorer_Velocity(t *testing.T) {
+	velocity := &mockVelocity{counts: map[string]int64{}}
+	scorer := NewScorer(Config{VelocityLimit: 2}).WithVelocity(velocity)
+	impression := &entities.Impression{IP: "10.0.0.1", Referer: "https://news.example.com/"}
+
+	for i := 0; i < 2; i++ {
+		if verdict := scorer.ScoreImpression(context.Background(), impression); verdict.Score != 0 {
+			t.Fatalf("Expected events within the limit to be clean, got %+v", verdict)
+		}
+	}
+	verdict := scorer.ScoreImpression(context.Background(), impression)
+	if !reflect.DeepEqual(verdict.Reasons, []string{entities.FraudIPVelocity}) || verdict.Score != 0.6 {
+		t.Errorf("Expected the velocity rule to match, got %+v", verdict)
+	}
+
+	// Velocity plus no referer: 1 - 0.4*0.8
+	impression.Referer = ""
+	if verdict := scorer.ScoreImpression(context.Background(), impression); verdict.Score != 0.68 || verdict.Invalid {
+		t.Errorf("Expected score 0.68 below the threshold, got %+v", verdict)
+	}
+
+	// An unavailable counter doesn't flag traffic
+	velocity.err = errors.New("connection refused")
+	impression.Referer = "https://news.example.com/"
+	if verdict := scorer.ScoreImpression(context.Background(), impression); verdict.Score != 0 {
+		t.Errorf("Expected a clean verdict without the counter, got %+v", verdict)
+	}
+}
+
+func TestScorer_ScoreClick(t *testing.T) {
+	scorer := NewScorer(Config{})
+	served := time.Now()
+	impression := &entities.Impression{Timestamp: served}
+
+	click := &entities.Click{Timestamp: served.Add(200 * time.Millisecond), Referer: "https://news.example.com/"}
+	verdict := scorer.ScoreClick(context.Background(), click, impression)
+	if !reflect.DeepEqual(verdict.Reasons, []string{entities.FraudFastClick}) || !verdict.Invalid {
+		t.Errorf("Expected a fast click to be invalid, got %+v", verdict)
+	}
+
+	click.Timestamp = served.Add(5 * time.Second)
+	if verdict := scorer.ScoreClick(context.Background(), click, impression); verdict.Score != 0 {
+		t.Errorf("Expected a clean click, got %+v", verdict)
+	}
+}
@@ -0,0 +1,116 @@
+package placement
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/fall-out-bug/demo-adserver/src/domain/repositories"
+)
+
+// ErrCampaignNotFound is returned when assigning slots to an unknown campaign
+// or to a campaign of another advertiser
+var ErrCampaignNotFound = errors.New("campaign not found")
+
+// CacheInvalidator drops cached delivery candidates after booking changes
+type CacheInvalidator interface {
+	InvalidateSlot(ctx context.Context, slotID string) error
+	InvalidateAll(ctx context.Context) error
+}
+
+// Service manages which slots campaigns are booked on. Calls are scoped by
+// advertiser ID to the advertiser's own campaigns; admins pass an empty ID
+// and manage every campaign.
+type Service struct {
+	campaignRepo     repositories.CampaignRepository
+	campaignSlotRepo repositories.CampaignSlotRepository
+	invalidator      CacheInvalidator
+}
+
+// NewService creates a new placement service
+func NewService(
+	campaignRepo repositories.CampaignRepository,
+	campaignSlotRepo repositories.CampaignSlotRepository,
+	invalidator CacheInvalidator,
+) *Service {
+	return &Service{
+		campaignRepo:     campaignRepo,
+		campaignSlotRepo: campaignSlotRepo,
+		invalidator:      invalidator,
+	}
+}
+
+// ListAssignments returns all slot rules of a campaign
+func (s *Service) ListAssignments(ctx context.Context, advertiserID, campaignID string) ([]*entities.CampaignSlot, error) {
+	if err := s.ensureCampaign(ctx, advertiserID, campaignID); err != nil {
+		return nil, err
+	}
+
+	assignments, err := s.campaignSlotRepo.FindByCampaignID(ctx, campaignID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load assignments: %w", err)
+	}
+
+	return assignments, nil
+}
+
+// Assign books (include) or blocks (exclude) a campaign on a slot.
+// Assigning an existing pair replaces its rule.
+func (s *Service) Assign(ctx context.Context, advertiserID, campaignID, slotID string, rule entities.SlotRule) (*entities.CampaignSlot, error) {
+	assignment, err := entities.NewCampaignSlot(campaignID, slotID, rule)
+	if err != nil {
+		return nil, fmt.Errorf("invalid assignment: %w", err)
+	}
+
+	if err := s.ensureCampaign(ctx, advertiserID, campaignID); err != nil {
+		return nil, err
+	}
+
+	if err := s.campaignSlotRepo.Assign(ctx, assignment); err != nil {
+		return nil, fmt.Errorf("failed to assign slot: %w", err)
+	}
+	s.invalidate(ctx, slotID)
+
+	return assignment, nil
+}
+
+// Unassign removes a campaign's rule for a slot
+func (s *Service) Unassign(ctx context.Context, advertiserID, campaignID, slotID string) error {
+	if err := s.ensureCampaign(ctx, advertiserID, campaignID); err != nil {
+		return err
+	}
+
+	if err := s.campaignSlotRepo.Unassign(ctx, campaignID, slotID); err != nil {
+		return fmt.Errorf("failed to unassign slot: %w", err)
+	}
+	s.invalidate(ctx, slotID)
+
+	return nil
+}
+
+// ensureCampaign checks that the campaign exists and, unless advertiserID is
+// empty, belongs to the advertiser
+func (s *Service) ensureCampaign(ctx context.Context, advertiserID, campaignID string) error {
+	campaign, err := s.campaignRepo.FindByID(ctx, campaignID)
+	if err != nil {
+		return fmt.Errorf("failed to load campaign: %w", err)
+	}
+	if campaign == nil || (advertiserID != "" && campaign.AdvertiserID != advertiserID) {
+		return ErrCampaignNotFound
+	}
+	return nil
+}
+
+// invalidate drops cached candidates affected by a booking change.
+// Failures are not fatal: entries expire on their own TTL.
+func (s *Service) invalidate(ctx context.Context, slotID string) {
+	if s.invalidator == nil {
+		return
+	}
+	if slotID == entities.AllSlots {
+		s.invalidator.InvalidateAll(ctx)
+		return
+	}
+	s.invalidator.InvalidateSlot(ctx, slotID)
+}
@@ -0,0 +1,104 @@
+package tracking
+
+import (
+	"context"
+	"time"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/fall-out-bug/demo-adserver/src/domain/repositories"
+	"github.com/shopspring/decimal"
+)
+
+// AuctionService handles the win (nurl) and billing (burl) notices exchanges
+// send for bids placed through the OpenRTB endpoint
+type AuctionService struct {
+	ledger      repositories.ServedAdRepository
+	impressions *ImpressionService
+	exposures   ExposureRecorder
+}
+
+// ExposureRecorder counts a won bid against the frequency caps of its banner and campaign
+type ExposureRecorder interface {
+	RecordExposure(ctx context.Context, ad *entities.ServedAd)
+}
+
+// NewAuctionService creates a new auction notice service. Billing notices
+// are tracked as impressions by the given service, which should use the same ledger.
+func NewAuctionService(ledger repositories.ServedAdRepository, impressions *ImpressionService) *AuctionService {
+	return &AuctionService{
+		ledger:      ledger,
+		impressions: impressions,
+	}
+}
+
+// WithExposures counts won bids against frequency caps
+func (s *AuctionService) WithExposures(exposures ExposureRecorder) *AuctionService {
+	s.exposures = exposures
+	return s
+}
+
+// Win records the clearing price (CPM) of a won bid. Repeated notices keep the
+// first price; prices above the bid are capped at the bid.
+func (s *AuctionService) Win(ctx context.Context, impressionID string, price decimal.Decimal) *TrackResponse {
+	if _, resp := s.win(ctx, impressionID, price); resp != nil {
+		return resp
+	}
+
+	return &TrackResponse{
+		Success: true,
+		Message: "win recorded",
+	}
+}
+
+// Bill confirms a won bid as a rendered, billable impression. Exchanges send
+// billing notices from their servers, so the impression is attributed to the
+// viewer the ad was served to. A missing win notice is recorded here.
+func (s *AuctionService) Bill(ctx context.Context, impressionID string, price decimal.Decimal) *TrackResponse {
+	ad, resp := s.win(ctx, impressionID, price)
+	if resp != nil {
+		return resp
+	}
+
+	return s.impressions.Track(ctx, &TrackRequest{
+		ImpressionID: ad.ID,
+		SlotID:       ad.SlotID,
+		BannerID:     ad.BannerID,
+		CampaignID:   ad.CampaignID,
+		IP:           ad.IP,
+		UserAgent:    ad.UserAgent,
+		Referer:      ad.Referer,
+		Country:      ad.Country,
+		Device:       ad.Device,
+	})
+}
+
+// win records the clearing price of a served ad, returning a response on failure
+func (s *AuctionService) win(ctx context.Context, impressionID string, price decimal.Decimal) (*entities.ServedAd, *TrackResponse) {
+	// Ads served outside an auction have no clearing price to record
+	ad, err := s.ledger.FindByID(ctx, impressionID)
+	if err != nil || ad == nil || !ad.Auction {
+		return nil, &TrackResponse{
+			Success: false,
+			Message: "impression not found",
+		}
+	}
+
+	// A second-price auction never clears above the bid
+	if price.GreaterThan(ad.Price) {
+		price = ad.Price
+	}
+
+	won, err := s.ledger.MarkWon(ctx, impressionID, price, time.Now())
+	if err != nil {
+		return nil, &TrackResponse{
+			Success: false,
+			Message: "failed to record win",
+		}
+	}
+
+	// Only the first notice counts the exposure
+	if won && s.exposures != nil {
+		s.exposures.RecordExposure(ctx, ad)
+	}
+	return ad, nil
+}
@@ -13,6 +13,11 @@ type ClickService struct {
 	impressionRepo repositories.ImpressionRepository
 	clickRepo      repositories.ClickRepository
 	bannerRepo     repositories.BannerRepository
+	biller         Biller
+	ledger         repositories.ServedAdRepository
+	utmSource      string
+	publisher      EventPublisher
+	scorer         FraudScorer
 }
 
 // NewClickService creates a new click service
@@ -28,10 +33,46 @@ func NewClickService(
 	}
 }
 
+// WithBiller records a billable event for every tracked click
+func (s *ClickService) WithBiller(biller Biller) *ClickService {
+	s.biller = biller
+	return s
+}
+
+// WithLedger resolves clicks through the ledger of served ads, so clicks on
+// ads whose impression was not confirmed yet still count and redirect
+func (s *ClickService) WithLedger(ledger repositories.ServedAdRepository) *ClickService {
+	s.ledger = ledger
+	return s
+}
+
+// WithUTMSource tags landing URLs with UTM parameters naming source as utm_source
+func (s *ClickService) WithUTMSource(source string) *ClickService {
+	s.utmSource = source
+	return s
+}
+
+// WithPublisher publishes clicks to the event stream, where the event
+// consumer stores and bills them. Clicks are written directly when
+// publishing fails.
+func (s *ClickService) WithPublisher(publisher EventPublisher) *ClickService {
+	s.publisher = publisher
+	return s
+}
+
+// WithFraudScorer scores clicks for invalid traffic. Invalid clicks are
+// stored with their score and redirected but not billed.
+func (s *ClickService) WithFraudScorer(scorer FraudScorer) *ClickService {
+	s.scorer = scorer
+	return s
+}
+
 // TrackClick logs a click and returns target URL
-func (s *ClickService) TrackClick(ctx context.Context, impressionID string) *ClickResponse {
+func (s *ClickService) TrackClick(ctx context.Context, req *ClickRequest) *ClickResponse {
+	impressionID := req.ImpressionID
+
 	// Get impression to find banner
-	impression, err := s.impressionRepo.FindByImpressionID(ctx, impressionID)
+	impression, err := s.findImpression(ctx, impressionID)
 	if err != nil || impression == nil {
 		return &ClickResponse{
 			Success: false,
@@ -48,29 +89,87 @@ func (s *ClickService) TrackClick(ctx context.Context, impressionID string) *Cli
 		}
 	}
 
-	// Log click
 	click := &entities.Click{
 		ID:           entities.NewImpression("", "", "").ID, // Reuse UUID generator
 		ImpressionID: impressionID,
 		BannerID:     impression.BannerID,
 		Timestamp:    time.Now(),
-		IP:           impression.IP,
-		Referer:      impression.Referer,
+		IP:           req.IP,
+		UserAgent:    req.UserAgent,
+		Referer:      req.Referer,
 		Country:      impression.Country,
 	}
+	// Clicks without client details keep the impression's
+	if click.IP == "" {
+		click.IP = impression.IP
+	}
+	if click.UserAgent == "" {
+		click.UserAgent = impression.UserAgent
+	}
+	if click.Referer == "" {
+		click.Referer = impression.Referer
+	}
+
+	// Expand the landing URL first; a click that can't be redirected safely isn't counted
+	landing, err := entities.LandingURL(banner.ClickURL, entities.ClickContext{
+		ClickID:      click.ID,
+		ImpressionID: impressionID,
+		CampaignID:   impression.CampaignID,
+		BannerID:     impression.BannerID,
+		SlotID:       impression.SlotID,
+		Timestamp:    click.Timestamp,
+		UTMSource:    s.utmSource,
+	})
+	if err != nil {
+		return &ClickResponse{
+			Success: false,
+			Message: "unsafe landing URL",
+		}
+	}
 
+	if s.scorer != nil {
+		s.scorer.ScoreClick(ctx, click, impression).ApplyClick(click)
+	}
+
+	if s.publisher != nil {
+		if err := s.publisher.Publish(ctx, []*entities.TrackingEvent{entities.NewClickEvent(click, impression)}); err == nil {
+			return &ClickResponse{
+				RedirectURL: landing,
+				Success:     true,
+				Message:     "click tracked successfully",
+			}
+		}
+	}
+
+	// Log click
 	if err := s.clickRepo.Create(ctx, click); err != nil {
 		// Log error but don't block redirect - still return success
 		return &ClickResponse{
-			RedirectURL: banner.ClickURL,
+			RedirectURL: landing,
 			Success:     true,
 			Message:     "redirecting (click logging failed)",
 		}
 	}
 
+	if s.biller != nil && !click.Invalid {
+		s.biller.Bill(ctx, entities.NewBillableEvent(entities.EventClick, impression))
+	}
+
 	return &ClickResponse{
-		RedirectURL: banner.ClickURL,
+		RedirectURL: landing,
 		Success:     true,
 		Message:     "click tracked successfully",
 	}
 }
+
+// findImpression resolves an impression ID through the ledger, falling back
+// to tracked impressions for ads served before the ledger existed
+func (s *ClickService) findImpression(ctx context.Context, impressionID string) (*entities.Impression, error) {
+	if s.ledger != nil {
+		ad, err := s.ledger.FindByID(ctx, impressionID)
+		if err == nil && ad != nil {
+			return ad.Impression(), nil
+		}
+	}
+	return s.impressionRepo.FindByImpressionID(ctx, impressionID)
+}
@@ -0,0 +1,213 @@
+package tracking
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/fall-out-bug/demo-adserver/src/domain/repositories"
+)
+
+// EventPublisher hands tracked impressions and clicks to durable storage
+// instead of writing them to the database during tracking
+type EventPublisher interface {
+	Publish(ctx context.Context, events []*entities.TrackingEvent) error
+}
+
+// EventStream is the durable queue the consumer reads events from. Events
+// read but not acknowledged are delivered again later.
+type EventStream interface {
+	Read(ctx context.Context, consumer string, count int) ([]*entities.TrackingEvent, error)
+	Ack(ctx context.Context, events []*entities.TrackingEvent) error
+	DeadLetter(ctx context.Context, event *entities.TrackingEvent, reason string) error
+}
+
+// ConsumerConfig configures an event consumer; zero values take the defaults
+type ConsumerConfig struct {
+	Name         string        // Consumer name within the group, unique per running consumer
+	BatchSize    int           // Events read and stored at once
+	MaxAttempts  int           // Deliveries of an event before it is dead-lettered
+	ErrorBackoff time.Duration // Wait after a failed read
+}
+
+// DefaultConsumerConfig is the configuration used for unset fields
+var DefaultConsumerConfig = ConsumerConfig{
+	Name:         "consumer",
+	BatchSize:    100,
+	MaxAttempts:  5,
+	ErrorBackoff: time.Second,
+}
+
+// ConsumerStats counts the events an event consumer handled
+type ConsumerStats struct {
+	Stored       uint64 // Events stored and billed
+	Failed       uint64 // Failed attempts, retried later
+	DeadLettered uint64 // Events given up on
+}
+
+// EventConsumer stores events from the stream in Postgres and bills them.
+// Inserts are keyed by event ID, so redelivered events are stored once, and
+// billing charges an impression once per event type. An event is
+// acknowledged only after it was stored and billed; failed events are
+// retried until MaxAttempts, then moved to the dead letters.
+type EventConsumer struct {
+	stream         EventStream
+	impressionRepo repositories.ImpressionRepository
+	clickRepo      repositories.ClickRepository
+	biller         Biller
+	config         ConsumerConfig
+
+	stored       atomic.Uint64
+	failed       atomic.Uint64
+	deadLettered atomic.Uint64
+}
+
+// NewEventConsumer creates a new event consumer
+func NewEventConsumer(
+	stream EventStream,
+	impressionRepo repositories.ImpressionRepository,
+	clickRepo repositories.ClickRepository,
+	config ConsumerConfig,
+) *EventConsumer {
+	if config.Name == "" {
+		config.Name = DefaultConsumerConfig.Name
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultConsumerConfig.BatchSize
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = DefaultConsumerConfig.MaxAttempts
+	}
+	if config.ErrorBackoff <= 0 {
+		config.ErrorBackoff = DefaultConsumerConfig.ErrorBackoff
+	}
+
+	return &EventConsumer{
+		stream:         stream,
+		impressionRepo: impressionRepo,
+		clickRepo:      clickRepo,
+		config:         config,
+	}
+}
+
+// WithBiller bills the campaigns of stored events
+func (c *EventConsumer) WithBiller(biller Biller) *EventConsumer {
+	c.biller = biller
+	return c
+}
+
+// Run consumes events until ctx is cancelled. The batch being stored when
+// ctx is cancelled is finished first.
+func (c *EventConsumer) Run(ctx context.Context) {
+	for ctx.Err() == nil {
+		events, err := c.stream.Read(ctx, c.config.Name, c.config.BatchSize)
+		if err != nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(c.config.ErrorBackoff):
+			}
+			continue
+		}
+
+		c.Process(context.Background(), events)
+	}
+}
+
+// Process stores and bills a batch of events, acknowledging the ones that succeeded
+func (c *EventConsumer) Process(ctx context.Context, events []*entities.TrackingEvent) {
+	var impressions, clicks []*entities.TrackingEvent
+	for _, event := range events {
+		if event.Deliveries > int64(c.config.MaxAttempts) {
+			c.deadLetter(ctx, event, fmt.Sprintf("not stored after %d attempts", event.Deliveries-1))
+			continue
+		}
+
+		switch event.Type {
+		case entities.EventImpression:
+			impressions = append(impressions, event)
+		case entities.EventClick:
+			clicks = append(clicks, event)
+		default:
+			c.deadLetter(ctx, event, fmt.Sprintf("unknown event type %q", event.Type))
+		}
+	}
+
+	stored := append(c.storeImpressions(ctx, impressions), c.storeClicks(ctx, clicks)...)
+
+	done := stored[:0]
+	for _, event := range stored {
+		if c.biller != nil && event.Billable() {
+			if err := c.biller.Bill(ctx, event.BillableEvent()); err != nil {
+				c.failed.Add(1)
+				continue
+			}
+		}
+		done = append(done, event)
+	}
+
+	if err := c.stream.Ack(ctx, done); err != nil {
+		// Redelivered and stored again as no-ops
+		c.failed.Add(uint64(len(done)))
+		return
+	}
+	c.stored.Add(uint64(len(done)))
+}
+
+// Stats returns the consumer's counters
+func (c *EventConsumer) Stats() ConsumerStats {
+	return ConsumerStats{
+		Stored:       c.stored.Load(),
+		Failed:       c.failed.Load(),
+		DeadLettered: c.deadLettered.Load(),
+	}
+}
+
+// storeImpressions inserts impression events in one batch. When the batch
+// fails they are inserted one by one, so one bad event doesn't hold back the
+// others; it returns the events stored.
+func (c *EventConsumer) storeImpressions(ctx context.Context, events []*entities.TrackingEvent) []*entities.TrackingEvent {
+	if len(events) == 0 {
+		return nil
+	}
+
+	impressions := make([]*entities.Impression, len(events))
+	for i, event := range events {
+		impressions[i] = event.Impression
+	}
+	if err := c.impressionRepo.CreateBatch(ctx, impressions); err == nil {
+		return events
+	}
+
+	stored := make([]*entities.TrackingEvent, 0, len(events))
+	for _, event := range events {
+		if err := c.impressionRepo.CreateBatch(ctx, []*entities.Impression{event.Impression}); err != nil {
+			c.failed.Add(1)
+			continue
+		}
+		stored = append(stored, event)
+	}
+	return stored
+}
+
+// storeClicks inserts click events and returns the ones stored
+func (c *EventConsumer) storeClicks(ctx context.Context, events []*entities.TrackingEvent) []*entities.TrackingEvent {
+	stored := make([]*entities.TrackingEvent, 0, len(events))
+	for _, event := range events {
+		if err := c.clickRepo.Create(ctx, event.Click); err != nil {
+			c.failed.Add(1)
+			continue
+		}
+		stored = append(stored, event)
+	}
+	return stored
+}
+
+// deadLetter gives up on an event; if that fails it stays pending and is
+// dead-lettered on its next delivery
+func (c *EventConsumer) deadLetter(ctx context.Context, event *entities.TrackingEvent, reason string) {
+	if err := c.stream.DeadLetter(ctx, event, reason); err == nil {
+		c.deadLettered.Add(1)
+	}
+}
@@ -0,0 +1,117 @@
+package tracking
+
+import (
+	"context"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/fall-out-bug/demo-adserver/src/domain/repositories"
+)
+
+// ConversionService handles conversion (action) postbacks for CPA campaigns.
+// Postbacks are server-to-server calls of the advertiser, who can only
+// report conversions of their own campaigns.
+type ConversionService struct {
+	impressionRepo repositories.ImpressionRepository
+	clickRepo      repositories.ClickRepository
+	campaignRepo   repositories.CampaignRepository
+	biller         Biller
+	ledger         repositories.ServedAdRepository
+}
+
+// NewConversionService creates a new conversion service
+func NewConversionService(
+	impressionRepo repositories.ImpressionRepository,
+	clickRepo repositories.ClickRepository,
+	campaignRepo repositories.CampaignRepository,
+	biller Biller,
+) *ConversionService {
+	return &ConversionService{
+		impressionRepo: impressionRepo,
+		clickRepo:      clickRepo,
+		campaignRepo:   campaignRepo,
+		biller:         biller,
+	}
+}
+
+// WithLedger resolves conversions through the ledger of served ads, so
+// conversions of impressions that are still queued for storage count
+func (s *ConversionService) WithLedger(ledger repositories.ServedAdRepository) *ConversionService {
+	s.ledger = ledger
+	return s
+}
+
+// TrackConversion attributes a conversion reported by an advertiser to the
+// impression that led to it
+func (s *ConversionService) TrackConversion(ctx context.Context, advertiserID, impressionID string) *TrackResponse {
+	impression, err := s.findImpression(ctx, impressionID)
+	if err != nil || impression == nil {
+		return &TrackResponse{
+			Success: false,
+			Message: "impression not found",
+		}
+	}
+
+	// Impressions of other advertisers' campaigns look unknown
+	campaign, err := s.campaignRepo.FindByID(ctx, impression.CampaignID)
+	if err != nil || campaign == nil || campaign.AdvertiserID == "" || campaign.AdvertiserID != advertiserID {
+		return &TrackResponse{
+			Success: false,
+			Message: "impression not found",
+		}
+	}
+
+	// Conversions attributed to invalid traffic aren't charged
+	invalid, err := s.invalidTraffic(ctx, impression)
+	if err != nil {
+		return &TrackResponse{
+			Success: false,
+			Message: "failed to record conversion",
+		}
+	}
+	if invalid {
+		return &TrackResponse{
+			Success: true,
+			Message: "conversion of invalid traffic not billed",
+		}
+	}
+
+	if err := s.biller.Bill(ctx, entities.NewBillableEvent(entities.EventConversion, impression)); err != nil {
+		return &TrackResponse{
+			Success: false,
+			Message: "failed to record conversion",
+		}
+	}
+
+	return &TrackResponse{
+		Success: true,
+		Message: "conversion tracked successfully",
+	}
+}
+
+// findImpression resolves the impression through the ledger when there is
+// one, falling back to stored impressions
+func (s *ConversionService) findImpression(ctx context.Context, impressionID string) (*entities.Impression, error) {
+	if s.ledger != nil {
+		ad, err := s.ledger.FindByID(ctx, impressionID)
+		if err == nil && ad != nil {
+			return ad.Impression(), nil
+		}
+	}
+	return s.impressionRepo.FindByImpressionID(ctx, impressionID)
+}
+
+// invalidTraffic reports whether the impression or a click on it was scored
+// as invalid. Impressions resolved through the ledger carry no score, so the
+// stored impression is looked at as well.
+func (s *ConversionService) invalidTraffic(ctx context.Context, impression *entities.Impression) (bool, error) {
+	if !impression.Invalid {
+		stored, err := s.impressionRepo.FindByImpressionID(ctx, impression.ID)
+		if err != nil {
+			return false, err
+		}
+		if stored == nil || !stored.Invalid {
+			return s.clickRepo.HasInvalidClick(ctx, impression.ID)
+		}
+	}
+	return true, nil
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
 	"github.com/fall-out-bug/demo-adserver/src/domain/repositories"
+	"github.com/shopspring/decimal"
 )
 
 // Deduper defines the interface for impression deduplication
@@ -15,10 +16,25 @@ type Deduper interface {
 	MarkImpression(ctx context.Context, slotID, userID string) error
 }
 
+// Biller prices billable events and debits them from campaign budgets
+type Biller interface {
+	Bill(ctx context.Context, event *entities.BillableEvent) error
+}
+
+// FraudScorer scores tracked events for invalid traffic
+type FraudScorer interface {
+	ScoreImpression(ctx context.Context, impression *entities.Impression) entities.FraudVerdict
+	ScoreClick(ctx context.Context, click *entities.Click, impression *entities.Impression) entities.FraudVerdict
+}
+
 // ImpressionService handles impression tracking
 type ImpressionService struct {
 	impressionRepo repositories.ImpressionRepository
 	deduper         Deduper
+	biller         Biller
+	ledger         repositories.ServedAdRepository
+	publisher      EventPublisher
+	scorer         FraudScorer
 }
 
 // NewImpressionService creates a new impression service
@@ -32,56 +48,69 @@ func NewImpressionService(
 	}
 }
 
-// Track logs an impression (fire-and-forget)
-func (s *ImpressionService) Track(ctx context.Context, req *TrackRequest) *TrackResponse {
-	// Check for deduplication
-	userID := s.deduper.GenerateUserID(req.IP, req.UserAgent)
-	exists, err := s.deduper.CheckImpression(ctx, req.SlotID, userID, 5*time.Minute)
-	if err != nil {
-		// Log error but don't block - return success with warning
-		return &TrackResponse{
-			Success: true,
-			Message: "tracked with warning: deduplication check failed",
-		}
-	}
+// WithBiller records a billable event for every tracked impression
+func (s *ImpressionService) WithBiller(biller Biller) *ImpressionService {
+	s.biller = biller
+	return s
+}
 
-	if exists {
-		// Duplicate impression, skip
-		return &TrackResponse{
-			Success: true,
-			Message: "duplicate impression skipped",
-		}
+// WithPublisher publishes impressions to the event stream, where the event
+// consumer stores and bills them. Impressions are written directly when
+// publishing fails.
+func (s *ImpressionService) WithPublisher(publisher EventPublisher) *ImpressionService {
+	s.publisher = publisher
+	return s
+}
+
+// WithFraudScorer scores impressions for invalid traffic. Invalid
+// impressions are stored with their score but not billed.
+func (s *ImpressionService) WithFraudScorer(scorer FraudScorer) *ImpressionService {
+	s.scorer = scorer
+	return s
+}
+
+// WithLedger resolves impressions through the ledger of served ads: unknown
+// impression IDs are rejected, banner, campaign and slot are taken from what
+// was served, and every served ad is counted at most once
+func (s *ImpressionService) WithLedger(ledger repositories.ServedAdRepository) *ImpressionService {
+	s.ledger = ledger
+	return s
+}
+
+// pendingImpression is an impression that passed deduplication and waits to be stored
+type pendingImpression struct {
+	impression *entities.Impression
+	ad         *entities.ServedAd // Ledger entry; nil without a ledger
+	warning    string
+}
+
+// clearingPrice is the CPM an exchange cleared the impression at
+func (p *pendingImpression) clearingPrice() decimal.Decimal {
+	if p.ad == nil {
+		return decimal.Zero
 	}
+	return p.ad.ClearingPrice
+}
 
-	// Create impression entity
-	impression := &entities.Impression{
-		ID:         req.ImpressionID,
-		BannerID:   req.BannerID,
-		SlotID:     req.SlotID,
-		CampaignID: req.CampaignID,
-		Timestamp:  time.Now(),
-		IP:         req.IP,
-		UserAgent:  req.UserAgent,
-		Referer:    req.Referer,
-		Country:    req.Country,
-		Device:     req.Device,
-		FraudScore: 0.0,
+// Track logs an impression
+func (s *ImpressionService) Track(ctx context.Context, req *TrackRequest) *TrackResponse {
+	pending, resp := s.prepare(ctx, req)
+	if resp != nil {
+		return resp
 	}
 
 	// Log to database
-	if err := s.impressionRepo.Create(ctx, impression); err != nil {
+	if err := s.store(ctx, []*pendingImpression{pending}); err != nil {
 		return &TrackResponse{
 			Success: false,
 			Message: "failed to log impression",
 		}
 	}
 
-	// Mark as tracked in dedupe cache
-	if err := s.deduper.MarkImpression(ctx, req.SlotID, userID); err != nil {
-		// Non-fatal error - impression was logged
+	if pending.warning != "" {
 		return &TrackResponse{
 			Success: true,
-			Message: "tracked with warning: dedupe marking failed",
+			Message: pending.warning,
 		}
 	}
 
@@ -90,3 +119,165 @@ func (s *ImpressionService) Track(ctx context.Context, req *TrackRequest) *Track
 		Message: "impression tracked successfully",
 	}
 }
+
+// prepare runs the checks of an impression and builds it. It returns a
+// response instead when the impression must not be stored.
+func (s *ImpressionService) prepare(ctx context.Context, req *TrackRequest) (*pendingImpression, *TrackResponse) {
+	var ad *entities.ServedAd
+	if s.ledger != nil {
+		var resp *TrackResponse
+		if ad, resp = s.resolveServed(ctx, req); resp != nil {
+			return nil, resp
+		}
+	}
+
+	// Check for deduplication. Served ads are counted once by the ledger
+	// instead: a viewer may well be shown the same slot twice.
+	var userID string
+	if ad == nil {
+		userID = s.deduper.GenerateUserID(req.IP, req.UserAgent)
+		exists, err := s.deduper.CheckImpression(ctx, req.SlotID, userID, 5*time.Minute)
+		if err != nil {
+			// Log error but don't block - return success with warning
+			return nil, &TrackResponse{
+				Success: true,
+				Message: "tracked with warning: deduplication check failed",
+			}
+		}
+
+		if exists {
+			// Duplicate impression, skip
+			return nil, &TrackResponse{
+				Success: true,
+				Message: "duplicate impression skipped",
+			}
+		}
+	}
+
+	// Confirm the served ad as rendered; losing a race means another request counted it
+	if ad != nil {
+		if rendered, err := s.ledger.MarkRendered(ctx, ad.ID, time.Now()); err == nil && !rendered {
+			return nil, &TrackResponse{
+				Success: true,
+				Message: "impression already tracked",
+			}
+		}
+	}
+
+	// Create impression entity
+	pending := &pendingImpression{
+		impression: &entities.Impression{
+			ID:         req.ImpressionID,
+			BannerID:   req.BannerID,
+			SlotID:     req.SlotID,
+			CampaignID: req.CampaignID,
+			Timestamp:  time.Now(),
+			IP:         req.IP,
+			UserAgent:  req.UserAgent,
+			Referer:    req.Referer,
+			Country:    req.Country,
+			Device:     req.Device,
+		},
+		ad: ad,
+	}
+	if ad != nil {
+		pending.impression.Pricing = ad.Pricing
+		pending.impression.Bid = ad.Bid
+	}
+
+	if s.scorer != nil {
+		s.scorer.ScoreImpression(ctx, pending.impression).Apply(pending.impression)
+	}
+
+	// Mark as tracked in dedupe cache before storing, so impressions waiting
+	// for a batch insert count as well
+	if ad == nil {
+		if err := s.deduper.MarkImpression(ctx, req.SlotID, userID); err != nil {
+			// Non-fatal error - the impression is still stored
+			pending.warning = "tracked with warning: dedupe marking failed"
+		}
+	}
+
+	return pending, nil
+}
+
+// store logs prepared impressions, in bulk when there are several, and bills
+// their campaigns
+func (s *ImpressionService) store(ctx context.Context, pending []*pendingImpression) error {
+	if s.publisher != nil {
+		events := make([]*entities.TrackingEvent, len(pending))
+		for i, p := range pending {
+			events[i] = entities.NewImpressionEvent(p.impression, p.clearingPrice())
+		}
+		if err := s.publisher.Publish(ctx, events); err == nil {
+			return nil
+		}
+	}
+
+	impressions := make([]*entities.Impression, len(pending))
+	for i, p := range pending {
+		impressions[i] = p.impression
+	}
+
+	var err error
+	if len(impressions) == 1 {
+		err = s.impressionRepo.Create(ctx, impressions[0])
+	} else {
+		err = s.impressionRepo.CreateBatch(ctx, impressions)
+	}
+	if err != nil {
+		s.release(ctx, pending)
+		return err
+	}
+
+	// Bill the campaigns (non-fatal: the impressions are already logged)
+	if s.biller != nil {
+		for _, p := range pending {
+			if p.impression.Invalid {
+				continue
+			}
+			event := entities.NewBillableEvent(entities.EventImpression, p.impression)
+			event.ClearingPrice = p.clearingPrice()
+			s.biller.Bill(ctx, event)
+		}
+	}
+
+	return nil
+}
+
+// release returns the served ads of impressions that could not be stored to
+// pending, so that a retried pixel or billing notice tracks them again
+func (s *ImpressionService) release(ctx context.Context, pending []*pendingImpression) {
+	for _, p := range pending {
+		if p.ad != nil {
+			s.ledger.UnmarkRendered(ctx, p.ad.ID)
+		}
+	}
+}
+
+// resolveServed looks the impression up in the ledger and takes its banner,
+// campaign and slot from what was served. A failing ledger falls back to the
+// request; a response is returned when the impression must not be tracked.
+func (s *ImpressionService) resolveServed(ctx context.Context, req *TrackRequest) (*entities.ServedAd, *TrackResponse) {
+	ad, err := s.ledger.FindByID(ctx, req.ImpressionID)
+	if err != nil {
+		return nil, nil
+	}
+	if ad == nil {
+		return nil, &TrackResponse{
+			Success: false,
+			Message: "impression not found",
+		}
+	}
+	if ad.IsRendered() {
+		return nil, &TrackResponse{
+			Success: true,
+			Message: "impression already tracked",
+		}
+	}
+
+	req.BannerID = ad.BannerID
+	req.CampaignID = ad.CampaignID
+	req.SlotID = ad.SlotID
+	return ad, nil
+}
@@ -0,0 +1,196 @@
+package tracking
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrQueueFull is returned for impressions dropped because the pipeline is saturated
+var ErrQueueFull = errors.New("impression queue full")
+
+// PipelineConfig sizes the impression pipeline; zero values take the defaults
+type PipelineConfig struct {
+	QueueSize      int           // Impressions waiting for a worker
+	Workers        int           // Goroutines checking and storing impressions
+	BatchSize      int           // Impressions per INSERT
+	FlushInterval  time.Duration // Longest an impression waits for its batch to fill
+	EnqueueTimeout time.Duration // How long Enqueue waits for room in a full queue before dropping
+}
+
+// DefaultPipelineConfig is the configuration used for unset fields
+var DefaultPipelineConfig = PipelineConfig{
+	QueueSize:      10000,
+	Workers:        4,
+	BatchSize:      100,
+	FlushInterval:  time.Second,
+	EnqueueTimeout: 50 * time.Millisecond,
+}
+
+// PipelineStats is a snapshot of the pipeline's queue and counters
+type PipelineStats struct {
+	Depth    int    // Impressions waiting in the queue
+	Capacity int    // Size of the queue
+	Enqueued uint64 // Impressions accepted
+	Dropped  uint64 // Impressions rejected with a full queue
+	Skipped  uint64 // Impressions not stored: duplicates, unknown or already tracked
+	Stored   uint64 // Impressions written to the database or the event stream
+	Failed   uint64 // Impressions lost to failed inserts
+	Batches  uint64 // Inserts run
+}
+
+// ImpressionPipeline tracks impressions asynchronously: requests queue
+// impressions in a bounded queue, and a pool of workers checks them and
+// stores them in batches. A full queue makes Enqueue wait briefly, then drop.
+type ImpressionPipeline struct {
+	service *ImpressionService
+	config  PipelineConfig
+	queue   chan *TrackRequest
+
+	enqueued atomic.Uint64
+	dropped  atomic.Uint64
+	skipped  atomic.Uint64
+	stored   atomic.Uint64
+	failed   atomic.Uint64
+	batches  atomic.Uint64
+}
+
+// NewImpressionPipeline creates a new impression pipeline tracking through service
+func NewImpressionPipeline(service *ImpressionService, config PipelineConfig) *ImpressionPipeline {
+	if config.QueueSize <= 0 {
+		config.QueueSize = DefaultPipelineConfig.QueueSize
+	}
+	if config.Workers <= 0 {
+		config.Workers = DefaultPipelineConfig.Workers
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultPipelineConfig.BatchSize
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = DefaultPipelineConfig.FlushInterval
+	}
+	if config.EnqueueTimeout < 0 {
+		config.EnqueueTimeout = 0
+	}
+
+	return &ImpressionPipeline{
+		service: service,
+		config:  config,
+		queue:   make(chan *TrackRequest, config.QueueSize),
+	}
+}
+
+// Enqueue queues an impression. With the queue full it waits up to the
+// enqueue timeout (or until ctx is done) and returns ErrQueueFull.
+func (p *ImpressionPipeline) Enqueue(ctx context.Context, req *TrackRequest) error {
+	select {
+	case p.queue <- req:
+		p.enqueued.Add(1)
+		return nil
+	default:
+	}
+
+	timer := time.NewTimer(p.config.EnqueueTimeout)
+	defer timer.Stop()
+
+	select {
+	case p.queue <- req:
+		p.enqueued.Add(1)
+		return nil
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	p.dropped.Add(1)
+	return ErrQueueFull
+}
+
+// Run processes queued impressions until ctx is cancelled, then drains the
+// queue and stores the last batches before returning
+func (p *ImpressionPipeline) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < p.config.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.work(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+// Stats returns the current queue depth and counters
+func (p *ImpressionPipeline) Stats() PipelineStats {
+	return PipelineStats{
+		Depth:    len(p.queue),
+		Capacity: cap(p.queue),
+		Enqueued: p.enqueued.Load(),
+		Dropped:  p.dropped.Load(),
+		Skipped:  p.skipped.Load(),
+		Stored:   p.stored.Load(),
+		Failed:   p.failed.Load(),
+		Batches:  p.batches.Load(),
+	}
+}
+
+// work is one worker. Impressions are processed with a context of their own,
+// so the ones queued before shutdown are still stored.
+func (p *ImpressionPipeline) work(ctx context.Context) {
+	processCtx := context.Background()
+	batch := make([]*pendingImpression, 0, p.config.BatchSize)
+
+	ticker := time.NewTicker(p.config.FlushInterval)
+	defer ticker.Stop()
+
+	add := func(req *TrackRequest) {
+		pending, resp := p.service.prepare(processCtx, req)
+		if resp != nil {
+			p.skipped.Add(1)
+			return
+		}
+		batch = append(batch, pending)
+		if len(batch) >= p.config.BatchSize {
+			batch = p.flush(processCtx, batch)
+		}
+	}
+
+	for {
+		select {
+		case req := <-p.queue:
+			add(req)
+		case <-ticker.C:
+			batch = p.flush(processCtx, batch)
+		case <-ctx.Done():
+			for {
+				select {
+				case req := <-p.queue:
+					add(req)
+				default:
+					p.flush(processCtx, batch)
+					return
+				}
+			}
+		}
+	}
+}
+
+// flush stores a batch and returns it emptied for reuse
+func (p *ImpressionPipeline) flush(ctx context.Context, batch []*pendingImpression) []*pendingImpression {
+	if len(batch) == 0 {
+		return batch
+	}
+
+	p.batches.Add(1)
+	if err := p.service.store(ctx, batch); err != nil {
+		p.failed.Add(uint64(len(batch)))
+	} else {
+		p.stored.Add(uint64(len(batch)))
+	}
+
+	for i := range batch {
+		batch[i] = nil
+	}
+	return batch[:0]
+}
@@ -2,17 +2,25 @@ package tracking
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/shopspring/decimal"
 )
 
 type mockImpressionRepo struct {
 	impressions map[string]*entities.Impression
+	batches     int
+	err         error
 }
 
 func (m *mockImpressionRepo) Create(ctx context.Context, impression *entities.Impression) error {
+	if m.err != nil {
+		return m.err
+	}
 	if m.impressions == nil {
 		m.impressions = make(map[string]*entities.Impression)
 	}
@@ -20,6 +28,19 @@ func (m *mockImpressionRepo) Create(ctx context.Context, impression *entities.Im
 	return nil
 }
 
+func (m *mockImpressionRepo) CreateBatch(ctx context.Context, impressions []*entities.Impression) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.batches++
+	for _, impression := range impressions {
+		if _, exists := m.impressions[impression.ID]; !exists {
+			m.Create(ctx, impression)
+		}
+	}
+	return nil
+}
+
 func (m *mockImpressionRepo) CountBySlotID(ctx context.Context, slotID string, since time.Time) (int64, error) {
 	return 0, nil
 }
@@ -52,6 +73,44 @@ func (m *mockClickRepo) FindByImpressionID(ctx context.Context, impressionID str
 	return nil, nil
 }
 
+func (m *mockClickRepo) HasInvalidClick(ctx context.Context, impressionID string) (bool, error) {
+	for _, click := range m.clicks {
+		if click.ImpressionID == impressionID && click.Invalid {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type mockCampaignRepo struct {
+	campaigns map[string]*entities.Campaign
+}
+
+func (m *mockCampaignRepo) FindByID(ctx context.Context, id string) (*entities.Campaign, error) {
+	return m.campaigns[id], nil
+}
+
+func (m *mockCampaignRepo) FindActive(ctx context.Context) ([]*entities.Campaign, error) {
+	return nil, nil
+}
+
+func (m *mockCampaignRepo) FindBySlotID(ctx context.Context, slotID string) ([]*entities.Campaign, error) {
+	return nil, nil
+}
+
+func (m *mockCampaignRepo) Create(ctx context.Context, campaign *entities.Campaign) error {
+	return nil
+}
+
+func (m *mockCampaignRepo) Update(ctx context.Context, campaign *entities.Campaign) error {
+	return nil
+}
+
+// advertiserCampaigns has cmp-1 of advertiser adv-1
+var advertiserCampaigns = &mockCampaignRepo{campaigns: map[string]*entities.Campaign{
+	"cmp-1": {ID: "cmp-1", AdvertiserID: "adv-1"},
+}}
+
 type mockBannerRepo struct {
 	banners map[string]*entities.Banner
 }
@@ -202,7 +261,7 @@ func TestClickService_TrackClick_Success(t *testing.T) {
 
 	service := NewClickService(impressionRepo, clickRepo, bannerRepo)
 
-	response := service.TrackClick(ctx, "imp-1")
+	response := service.TrackClick(ctx, &ClickRequest{ImpressionID: "imp-1"})
 
 	if !response.Success {
 		t.Errorf("Expected success, got failure: %s", response.Message)
@@ -217,6 +276,42 @@ func TestClickService_TrackClick_Success(t *testing.T) {
 	}
 }
 
+func TestClickService_TrackClick_ExpandsLandingURL(t *testing.T) {
+	ctx := context.Background()
+	impressionRepo := &mockImpressionRepo{impressions: map[string]*entities.Impression{
+		"imp-1": {ID: "imp-1", BannerID: "ban-1", SlotID: "sidebar", CampaignID: "cmp-1"},
+		"imp-2": {ID: "imp-2", BannerID: "ban-2", SlotID: "sidebar", CampaignID: "cmp-1"},
+	}}
+	bannerRepo := &mockBannerRepo{banners: map[string]*entities.Banner{
+		"ban-1": {ID: "ban-1", CampaignID: "cmp-1", ClickURL: "https://target.com/?slot={slot_id}&click={click_id}"},
+		"ban-2": {ID: "ban-2", CampaignID: "cmp-1", ClickURL: "javascript:alert(1)"},
+	}}
+	clickRepo := &mockClickRepo{}
+
+	service := NewClickService(impressionRepo, clickRepo, bannerRepo).WithUTMSource("adserver")
+
+	response := service.TrackClick(ctx, &ClickRequest{ImpressionID: "imp-1"})
+	if !response.Success || len(clickRepo.clicks) != 1 {
+		t.Fatalf("Expected click to be tracked, got %+v", response)
+	}
+	var clickID string
+	for id := range clickRepo.clicks {
+		clickID = id
+	}
+	expected := "https://target.com/?slot=sidebar&click=" + clickID + "&utm_campaign=cmp-1&utm_content=ban-1&utm_medium=display&utm_source=adserver"
+	if response.RedirectURL != expected {
+		t.Errorf("Expected redirect to %s, got %s", expected, response.RedirectURL)
+	}
+
+	// Unsafe landing URLs are neither redirected to nor counted
+	if response := service.TrackClick(ctx, &ClickRequest{ImpressionID: "imp-2"}); response.Success || response.RedirectURL != "" {
+		t.Errorf("Expected unsafe landing URL to fail, got %+v", response)
+	}
+	if len(clickRepo.clicks) != 1 {
+		t.Errorf("Expected unsafe click not to be logged, got %d clicks", len(clickRepo.clicks))
+	}
+}
+
 func TestClickService_TrackClick_ImpressionNotFound(t *testing.T) {
 	ctx := context.Background()
 
@@ -226,7 +321,7 @@ func TestClickService_TrackClick_ImpressionNotFound(t *testing.T) {
 
 	service := NewClickService(impressionRepo, clickRepo, bannerRepo)
 
-	response := service.TrackClick(ctx, "nonexistent")
+	response := service.TrackClick(ctx, &ClickRequest{ImpressionID: "nonexistent"})
 
 	if response.Success {
 		t.Errorf("Expected failure for nonexistent impression")
@@ -236,3 +331,605 @@ func TestClickService_TrackClick_ImpressionNotFound(t *testing.T) {
 		t.Errorf("Expected 'impression not found' message, got: %s", response.Message)
 	}
 }
+
+type mockBiller struct {
+	events []*entities.BillableEvent
+}
+
+func (m *mockBiller) Bill(ctx context.Context, event *entities.BillableEvent) error {
+	m.events = append(m.events, event)
+	return nil
+}
+
+func TestTracking_RecordsBillableEvents(t *testing.T) {
+	ctx := context.Background()
+	biller := &mockBiller{}
+	impressionRepo := &mockImpressionRepo{}
+	bannerRepo := &mockBannerRepo{banners: map[string]*entities.Banner{"ban-1": {ID: "ban-1", ClickURL: "https://target.com"}}}
+
+	clickRepo := &mockClickRepo{}
+
+	impressions := NewImpressionService(impressionRepo, &mockDeduper{}).WithBiller(biller)
+	clicks := NewClickService(impressionRepo, clickRepo, bannerRepo).WithBiller(biller)
+	conversions := NewConversionService(impressionRepo, clickRepo, advertiserCampaigns, biller)
+
+	req := &TrackRequest{ImpressionID: "imp-1", SlotID: "slot-1", BannerID: "ban-1", CampaignID: "cmp-1", IP: "10.0.0.1"}
+	impressions.Track(ctx, req)
+	impressions.Track(ctx, req) // duplicate, not billed again
+	clicks.TrackClick(ctx, &ClickRequest{ImpressionID: "imp-1"})
+	if response := conversions.TrackConversion(ctx, "adv-1", "imp-1"); !response.Success {
+		t.Fatalf("Expected conversion to be tracked, got %s", response.Message)
+	}
+	// Advertisers only report conversions of their own campaigns
+	if response := conversions.TrackConversion(ctx, "adv-2", "imp-1"); response.Success {
+		t.Errorf("Expected another advertiser's conversion to be rejected")
+	}
+
+	expected := []entities.EventType{entities.EventImpression, entities.EventClick, entities.EventConversion}
+	if len(biller.events) != len(expected) {
+		t.Fatalf("Expected %d billable events, got %d", len(expected), len(biller.events))
+	}
+	for i, event := range biller.events {
+		if event.Type != expected[i] || event.CampaignID != "cmp-1" || event.ImpressionID != "imp-1" || event.BannerID != "ban-1" {
+			t.Errorf("Unexpected billable event %+v", event)
+		}
+	}
+}
+
+// stubScorer flags traffic from bots as invalid
+type stubScorer struct{}
+
+func (s *stubScorer) verdict(userAgent string) entities.FraudVerdict {
+	if userAgent != "bot" {
+		return entities.FraudVerdict{}
+	}
+	return entities.FraudVerdict{Score: 1, Reasons: []string{entities.FraudBotUserAgent}, Invalid: true}
+}
+
+func (s *stubScorer) ScoreImpression(ctx context.Context, impression *entities.Impression) entities.FraudVerdict {
+	return s.verdict(impression.UserAgent)
+}
+
+func (s *stubScorer) ScoreClick(ctx context.Context, click *entities.Click, impression *entities.Impression) entities.FraudVerdict {
+	return s.verdict(click.UserAgent)
+}
+
+func TestTracking_InvalidTrafficNotBilled(t *testing.T) {
+	ctx := context.Background()
+	biller := &mockBiller{}
+	impressionRepo := &mockImpressionRepo{}
+	clickRepo := &mockClickRepo{}
+	bannerRepo := &mockBannerRepo{banners: map[string]*entities.Banner{"ban-1": {ID: "ban-1", ClickURL: "https://target.com"}}}
+
+	impressions := NewImpressionService(impressionRepo, &mockDeduper{}).WithBiller(biller).WithFraudScorer(&stubScorer{})
+	clicks := NewClickService(impressionRepo, clickRepo, bannerRepo).WithBiller(biller).WithFraudScorer(&stubScorer{})
+	conversions := NewConversionService(impressionRepo, clickRepo, advertiserCampaigns, biller)
+
+	impressions.Track(ctx, &TrackRequest{ImpressionID: "imp-bot", SlotID: "slot-1", BannerID: "ban-1", CampaignID: "cmp-1", UserAgent: "bot"})
+	impressions.Track(ctx, &TrackRequest{ImpressionID: "imp-1", SlotID: "slot-2", BannerID: "ban-1", CampaignID: "cmp-1", UserAgent: "Mozilla/5.0"})
+
+	// Invalid impressions are stored with their score
+	stored := impressionRepo.impressions["imp-bot"]
+	if stored == nil || !stored.Invalid || stored.FraudScore != 1 || len(stored.FraudReasons) != 1 {
+		t.Fatalf("Expected the bot impression stored as invalid, got %+v", stored)
+	}
+
+	// A bot clicking a valid impression: the click is redirected but not billed
+	if response := clicks.TrackClick(ctx, &ClickRequest{ImpressionID: "imp-1", UserAgent: "bot"}); !response.Success {
+		t.Fatalf("Expected the invalid click redirected, got %+v", response)
+	}
+	for _, click := range clickRepo.clicks {
+		if !click.Invalid || click.UserAgent != "bot" {
+			t.Errorf("Expected the click stored as invalid, got %+v", click)
+		}
+	}
+
+	// Conversions of invalid impressions or after invalid clicks aren't billed either
+	for _, impressionID := range []string{"imp-bot", "imp-1"} {
+		if response := conversions.TrackConversion(ctx, "adv-1", impressionID); !response.Success {
+			t.Fatalf("Expected the conversion of %s accepted, got %s", impressionID, response.Message)
+		}
+	}
+
+	if len(biller.events) != 1 || biller.events[0].ImpressionID != "imp-1" || biller.events[0].Type != entities.EventImpression {
+		t.Errorf("Expected only the valid impression billed, got %+v", biller.events)
+	}
+}
+
+func TestConversionService_ImpressionNotFound(t *testing.T) {
+	service := NewConversionService(&mockImpressionRepo{}, &mockClickRepo{}, advertiserCampaigns, &mockBiller{})
+
+	response := service.TrackConversion(context.Background(), "adv-1", "missing")
+	if response.Success {
+		t.Errorf("Expected failure for unknown impression")
+	}
+}
+
+type mockVideoEventRepo struct {
+	events []*entities.VideoEvent
+}
+
+func (m *mockVideoEventRepo) Create(ctx context.Context, event *entities.VideoEvent) error {
+	m.events = append(m.events, event)
+	return nil
+}
+
+func TestVideoEventService_TrackVideoEvent(t *testing.T) {
+	repo := &mockVideoEventRepo{}
+	service := NewVideoEventService(repo)
+
+	response := service.TrackVideoEvent(context.Background(), &VideoEventRequest{
+		ImpressionID: "imp-1",
+		BannerID:     "ban-1",
+		CampaignID:   "cmp-1",
+		Event:        entities.VideoFirstQuartile,
+		IP:           "192.168.1.1",
+	})
+	if !response.Success {
+		t.Fatalf("Expected success, got %s", response.Message)
+	}
+	if len(repo.events) != 1 || repo.events[0].Event != entities.VideoFirstQuartile || repo.events[0].CampaignID != "cmp-1" {
+		t.Errorf("Expected first quartile event to be logged, got %+v", repo.events)
+	}
+
+	for _, req := range []*VideoEventRequest{
+		{ImpressionID: "imp-1", Event: "rewind"},
+		{Event: entities.VideoStart},
+	} {
+		if response := service.TrackVideoEvent(context.Background(), req); response.Success {
+			t.Errorf("Expected %+v to be rejected", req)
+		}
+	}
+	if len(repo.events) != 1 {
+		t.Errorf("Expected invalid events not to be logged")
+	}
+}
+
+type mockServedAdRepo struct {
+	ads map[string]*entities.ServedAd
+}
+
+func newMockServedAdRepo(ads ...*entities.ServedAd) *mockServedAdRepo {
+	m := &mockServedAdRepo{ads: make(map[string]*entities.ServedAd)}
+	for _, ad := range ads {
+		m.ads[ad.ID] = ad
+	}
+	return m
+}
+
+func (m *mockServedAdRepo) Create(ctx context.Context, ad *entities.ServedAd) error {
+	m.ads[ad.ID] = ad
+	return nil
+}
+
+func (m *mockServedAdRepo) FindByID(ctx context.Context, id string) (*entities.ServedAd, error) {
+	ad, ok := m.ads[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *ad
+	return &copied, nil
+}
+
+func (m *mockServedAdRepo) MarkRendered(ctx context.Context, id string, at time.Time) (bool, error) {
+	ad, ok := m.ads[id]
+	if !ok || ad.IsRendered() {
+		return false, nil
+	}
+	ad.Status = entities.ServedAdRendered
+	ad.RenderedAt = &at
+	return true, nil
+}
+
+func (m *mockServedAdRepo) UnmarkRendered(ctx context.Context, id string) error {
+	if ad, ok := m.ads[id]; ok {
+		ad.Status = entities.ServedAdPending
+		ad.RenderedAt = nil
+	}
+	return nil
+}
+
+func (m *mockServedAdRepo) MarkWon(ctx context.Context, id string, price decimal.Decimal, at time.Time) (bool, error) {
+	ad, ok := m.ads[id]
+	if !ok || ad.WonAt != nil {
+		return false, nil
+	}
+	ad.ClearingPrice = price
+	ad.WonAt = &at
+	return true, nil
+}
+
+func servedAd(id string) *entities.ServedAd {
+	ad := entities.NewServedAd("ban-1", "cmp-1", "slot-1")
+	ad.ID = id
+	ad.IP = "10.0.0.1"
+	ad.UserAgent = "Mozilla/5.0"
+	ad.Country = "US"
+	return ad
+}
+
+func TestImpressionService_Track_Ledger(t *testing.T) {
+	ctx := context.Background()
+	impressionRepo := &mockImpressionRepo{}
+	ledger := newMockServedAdRepo(servedAd("imp-1"))
+	service := NewImpressionService(impressionRepo, &mockDeduper{}).WithLedger(ledger)
+
+	if response := service.Track(ctx, &TrackRequest{ImpressionID: "forged", SlotID: "slot-1", BannerID: "ban-1", CampaignID: "cmp-1"}); response.Success {
+		t.Errorf("Expected an impression that was never served to be rejected")
+	}
+
+	// Tracking parameters are taken from what was served, not from the request
+	response := service.Track(ctx, &TrackRequest{ImpressionID: "imp-1", SlotID: "slot-x", BannerID: "ban-x", CampaignID: "cmp-x", IP: "10.0.0.2"})
+	if !response.Success {
+		t.Fatalf("Expected success, got %s", response.Message)
+	}
+	impression := impressionRepo.impressions["imp-1"]
+	if impression == nil || impression.BannerID != "ban-1" || impression.CampaignID != "cmp-1" || impression.SlotID != "slot-1" {
+		t.Errorf("Expected the served banner, campaign and slot, got %+v", impression)
+	}
+	if !ledger.ads["imp-1"].IsRendered() {
+		t.Errorf("Expected served ad to be marked rendered")
+	}
+
+	// A replay from another viewer is not counted twice
+	response = service.Track(ctx, &TrackRequest{ImpressionID: "imp-1", IP: "10.0.0.3"})
+	if response.Message != "impression already tracked" {
+		t.Errorf("Expected replay to be skipped, got %s", response.Message)
+	}
+}
+
+func TestClickService_TrackClick_Ledger(t *testing.T) {
+	bannerRepo := &mockBannerRepo{banners: map[string]*entities.Banner{"ban-1": {ID: "ban-1", ClickURL: "https://target.com"}}}
+	clickRepo := &mockClickRepo{}
+	service := NewClickService(&mockImpressionRepo{}, clickRepo, bannerRepo).WithLedger(newMockServedAdRepo(servedAd("imp-1")))
+
+	// The impression pixel has not fired yet
+	response := service.TrackClick(context.Background(), &ClickRequest{ImpressionID: "imp-1"})
+	if !response.Success || response.RedirectURL != "https://target.com" {
+		t.Errorf("Expected click on a served ad to redirect, got %+v", response)
+	}
+}
+
+func TestConversionService_Ledger(t *testing.T) {
+	ctx := context.Background()
+	biller := &mockBiller{}
+	// imp-1 is still queued for storage; imp-2 is stored and was scored invalid
+	impressionRepo := &mockImpressionRepo{impressions: map[string]*entities.Impression{
+		"imp-2": {ID: "imp-2", BannerID: "ban-1", SlotID: "slot-1", CampaignID: "cmp-1", Invalid: true},
+	}}
+	service := NewConversionService(impressionRepo, &mockClickRepo{}, advertiserCampaigns, biller).
+		WithLedger(newMockServedAdRepo(servedAd("imp-1"), servedAd("imp-2")))
+
+	if response := service.TrackConversion(ctx, "adv-1", "imp-1"); response.Message != "conversion tracked successfully" {
+		t.Errorf("Expected conversion of a served ad to be tracked before its impression is stored, got %s", response.Message)
+	}
+	if response := service.TrackConversion(ctx, "adv-1", "imp-2"); response.Message != "conversion of invalid traffic not billed" {
+		t.Errorf("Expected the stored invalid impression to count, got %s", response.Message)
+	}
+	if len(biller.events) != 1 || biller.events[0].ImpressionID != "imp-1" {
+		t.Errorf("Expected only the conversion of imp-1 billed, got %+v", biller.events)
+	}
+}
+
+// bidAd is a served ad that was bid to an exchange at price
+func bidAd(id string, price decimal.Decimal) *entities.ServedAd {
+	ad := servedAd(id)
+	ad.Auction = true
+	ad.Price = price
+	return ad
+}
+
+func TestAuctionService_BillsClearingPrice(t *testing.T) {
+	ctx := context.Background()
+	biller := &mockBiller{}
+	impressionRepo := &mockImpressionRepo{}
+	ledger := newMockServedAdRepo(bidAd("imp-1", decimal.NewFromInt(2)), servedAd("imp-direct"))
+	impressions := NewImpressionService(impressionRepo, &mockDeduper{}).WithBiller(biller).WithLedger(ledger)
+	service := NewAuctionService(ledger, impressions)
+
+	if response := service.Win(ctx, "missing", decimal.NewFromFloat(1.5)); response.Success {
+		t.Errorf("Expected win notice for an unknown impression to fail")
+	}
+	if response := service.Bill(ctx, "imp-direct", decimal.NewFromFloat(1.5)); response.Success {
+		t.Errorf("Expected billing notice for an ad served outside an auction to fail")
+	}
+	if response := service.Win(ctx, "imp-1", decimal.NewFromFloat(1.5)); !response.Success {
+		t.Fatalf("Expected win to be recorded, got %s", response.Message)
+	}
+	// Repeated notices keep the first clearing price
+	service.Win(ctx, "imp-1", decimal.NewFromFloat(9))
+
+	if response := service.Bill(ctx, "imp-1", decimal.NewFromFloat(9)); !response.Success {
+		t.Fatalf("Expected billing notice to track the impression, got %s", response.Message)
+	}
+	if impression := impressionRepo.impressions["imp-1"]; impression == nil || impression.IP != "10.0.0.1" {
+		t.Errorf("Expected impression attributed to the served viewer, got %+v", impression)
+	}
+	if len(biller.events) != 1 || !biller.events[0].ClearingPrice.Equal(decimal.NewFromFloat(1.5)) {
+		t.Fatalf("Expected one billable event at the clearing price, got %+v", biller.events)
+	}
+
+	// Billing notices are not counted twice
+	service.Bill(ctx, "imp-1", decimal.NewFromFloat(1.5))
+	if len(biller.events) != 1 {
+		t.Errorf("Expected repeated billing notice not to bill again, got %d events", len(biller.events))
+	}
+	if len(impressionRepo.impressions) != 1 {
+		t.Errorf("Expected only the bid to be tracked, got %d impressions", len(impressionRepo.impressions))
+	}
+}
+
+type recordingExposures struct {
+	ads []string
+}
+
+func (r *recordingExposures) RecordExposure(ctx context.Context, ad *entities.ServedAd) {
+	r.ads = append(r.ads, ad.ID)
+}
+
+func TestAuctionService_RecordsExposureOnFirstWin(t *testing.T) {
+	ctx := context.Background()
+	ledger := newMockServedAdRepo(bidAd("imp-1", decimal.NewFromInt(2)), bidAd("imp-2", decimal.NewFromInt(2)))
+	exposures := &recordingExposures{}
+	service := NewAuctionService(ledger, NewImpressionService(&mockImpressionRepo{}, &mockDeduper{}).WithLedger(ledger)).
+		WithExposures(exposures)
+
+	service.Win(ctx, "imp-1", decimal.NewFromFloat(1.5))
+	service.Bill(ctx, "imp-1", decimal.NewFromFloat(1.5))
+	// A billing notice without a win notice counts too
+	service.Bill(ctx, "imp-2", decimal.NewFromFloat(1.5))
+
+	if strings.Join(exposures.ads, ",") != "imp-1,imp-2" {
+		t.Errorf("Expected one exposure per won bid, got %v", exposures.ads)
+	}
+}
+
+func TestAuctionService_BillsEveryBidOfTheSameViewer(t *testing.T) {
+	ctx := context.Background()
+	biller := &mockBiller{}
+	impressionRepo := &mockImpressionRepo{}
+	// Two bids on the same slot for a viewer the exchange sent no IP or User-Agent for
+	first, second := bidAd("imp-1", decimal.NewFromInt(2)), bidAd("imp-2", decimal.NewFromInt(2))
+	first.IP, first.UserAgent, second.IP, second.UserAgent = "", "", "", ""
+	ledger := newMockServedAdRepo(first, second)
+	impressions := NewImpressionService(impressionRepo, &mockDeduper{}).WithBiller(biller).WithLedger(ledger)
+	service := NewAuctionService(ledger, impressions)
+
+	for _, id := range []string{"imp-1", "imp-2"} {
+		if response := service.Bill(ctx, id, decimal.NewFromFloat(1.5)); response.Message != "impression tracked successfully" {
+			t.Errorf("Expected billing notice of %s to track the impression, got %s", id, response.Message)
+		}
+	}
+	if len(impressionRepo.impressions) != 2 || len(biller.events) != 2 {
+		t.Errorf("Expected both won bids stored and billed, got %d impressions and %d events",
+			len(impressionRepo.impressions), len(biller.events))
+	}
+}
+
+func TestAuctionService_CapsClearingPriceAtBid(t *testing.T) {
+	ledger := newMockServedAdRepo(bidAd("imp-1", decimal.NewFromInt(2)))
+	service := NewAuctionService(ledger, NewImpressionService(&mockImpressionRepo{}, &mockDeduper{}).WithLedger(ledger))
+
+	if response := service.Win(context.Background(), "imp-1", decimal.NewFromInt(1000)); !response.Success {
+		t.Fatalf("Expected win to be recorded, got %s", response.Message)
+	}
+	if price := ledger.ads["imp-1"].ClearingPrice; !price.Equal(decimal.NewFromInt(2)) {
+		t.Errorf("Expected clearing price capped at the bid of 2, got %s", price)
+	}
+}
+
+func TestImpressionPipeline_BatchesAndDrains(t *testing.T) {
+	impressionRepo := &mockImpressionRepo{}
+	pipeline := NewImpressionPipeline(NewImpressionService(impressionRepo, &mockDeduper{}), PipelineConfig{
+		QueueSize:     10,
+		Workers:       1,
+		BatchSize:     2,
+		FlushInterval: time.Hour,
+	})
+
+	// Queued before the pipeline runs; the same viewer twice in slot-2
+	for _, req := range []*TrackRequest{
+		{ImpressionID: "imp-1", SlotID: "slot-1", BannerID: "ban-1", CampaignID: "cmp-1", IP: "10.0.0.1"},
+		{ImpressionID: "imp-2", SlotID: "slot-2", BannerID: "ban-1", CampaignID: "cmp-1", IP: "10.0.0.1"},
+		{ImpressionID: "imp-3", SlotID: "slot-2", BannerID: "ban-1", CampaignID: "cmp-1", IP: "10.0.0.1"},
+		{ImpressionID: "imp-4", SlotID: "slot-3", BannerID: "ban-1", CampaignID: "cmp-1", IP: "10.0.0.1"},
+		{ImpressionID: "imp-5", SlotID: "slot-4", BannerID: "ban-1", CampaignID: "cmp-1", IP: "10.0.0.1"},
+	} {
+		if err := pipeline.Enqueue(context.Background(), req); err != nil {
+			t.Fatalf("Failed to enqueue %s: %v", req.ImpressionID, err)
+		}
+	}
+
+	// Cancelled right away: Run still drains the queue before returning
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	pipeline.Run(ctx)
+
+	stats := pipeline.Stats()
+	if stats.Depth != 0 || stats.Enqueued != 5 || stats.Stored != 4 || stats.Skipped != 1 {
+		t.Errorf("Expected 5 enqueued, 4 stored and 1 skipped, got %+v", stats)
+	}
+	if len(impressionRepo.impressions) != 4 || impressionRepo.impressions["imp-3"] != nil {
+		t.Errorf("Expected all but the duplicate stored, got %v", impressionRepo.impressions)
+	}
+	// Two full batches, the last one flushed on shutdown
+	if stats.Batches != 2 || impressionRepo.batches != 2 {
+		t.Errorf("Expected 2 batch inserts, got %d (%d in the repo)", stats.Batches, impressionRepo.batches)
+	}
+}
+
+func TestImpressionPipeline_FailedBatchCanBeRetried(t *testing.T) {
+	impressionRepo := &mockImpressionRepo{err: errors.New("database unavailable")}
+	ledger := newMockServedAdRepo(servedAd("imp-1"))
+	service := NewImpressionService(impressionRepo, &mockDeduper{}).WithLedger(ledger)
+	pipeline := NewImpressionPipeline(service, PipelineConfig{QueueSize: 10, Workers: 1, BatchSize: 10, FlushInterval: time.Hour})
+
+	if err := pipeline.Enqueue(context.Background(), &TrackRequest{ImpressionID: "imp-1"}); err != nil {
+		t.Fatalf("Failed to enqueue: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	pipeline.Run(ctx)
+
+	if stats := pipeline.Stats(); stats.Failed != 1 {
+		t.Fatalf("Expected the batch to fail, got %+v", stats)
+	}
+	if ledger.ads["imp-1"].IsRendered() {
+		t.Errorf("Expected the served ad back to pending after the failed insert")
+	}
+
+	// The pixel fires again once the database is back
+	impressionRepo.err = nil
+	if response := service.Track(context.Background(), &TrackRequest{ImpressionID: "imp-1"}); response.Message != "impression tracked successfully" {
+		t.Errorf("Expected the retry to track the impression, got %s", response.Message)
+	}
+}
+
+func TestImpressionPipeline_DropsWhenFull(t *testing.T) {
+	pipeline := NewImpressionPipeline(NewImpressionService(&mockImpressionRepo{}, &mockDeduper{}), PipelineConfig{
+		QueueSize:      1,
+		EnqueueTimeout: time.Millisecond,
+	})
+
+	if err := pipeline.Enqueue(context.Background(), &TrackRequest{ImpressionID: "imp-1"}); err != nil {
+		t.Fatalf("Failed to enqueue: %v", err)
+	}
+	if err := pipeline.Enqueue(context.Background(), &TrackRequest{ImpressionID: "imp-2"}); err != ErrQueueFull {
+		t.Fatalf("Expected ErrQueueFull, got %v", err)
+	}
+
+	stats := pipeline.Stats()
+	if stats.Depth != 1 || stats.Capacity != 1 || stats.Enqueued != 1 || stats.Dropped != 1 {
+		t.Errorf("Expected 1 queued and 1 dropped, got %+v", stats)
+	}
+}
+
+type mockPublisher struct {
+	events []*entities.TrackingEvent
+	err    error
+}
+
+func (m *mockPublisher) Publish(ctx context.Context, events []*entities.TrackingEvent) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.events = append(m.events, events...)
+	return nil
+}
+
+func TestTracking_PublishesEvents(t *testing.T) {
+	ctx := context.Background()
+	biller := &mockBiller{}
+	publisher := &mockPublisher{}
+	impressionRepo := &mockImpressionRepo{}
+	clickRepo := &mockClickRepo{}
+	bannerRepo := &mockBannerRepo{banners: map[string]*entities.Banner{"ban-1": {ID: "ban-1", ClickURL: "https://target.com"}}}
+
+	impressions := NewImpressionService(impressionRepo, &mockDeduper{}).WithBiller(biller).WithPublisher(publisher)
+	clicks := NewClickService(impressionRepo, clickRepo, bannerRepo).WithBiller(biller).WithPublisher(publisher).
+		WithLedger(newMockServedAdRepo(servedAd("imp-1")))
+
+	impressions.Track(ctx, &TrackRequest{ImpressionID: "imp-1", SlotID: "slot-1", BannerID: "ban-1", CampaignID: "cmp-1", IP: "10.0.0.1"})
+	if response := clicks.TrackClick(ctx, &ClickRequest{ImpressionID: "imp-1"}); !response.Success || response.RedirectURL != "https://target.com" {
+		t.Fatalf("Expected the click redirected, got %+v", response)
+	}
+
+	// Stored and billed by the consumer instead
+	if len(publisher.events) != 2 || publisher.events[0].Type != entities.EventImpression || publisher.events[1].Type != entities.EventClick {
+		t.Fatalf("Expected an impression and a click event, got %+v", publisher.events)
+	}
+	if publisher.events[1].Click.ImpressionID != "imp-1" || publisher.events[1].ID != publisher.events[1].Click.ID {
+		t.Errorf("Expected the click event keyed by the click ID, got %+v", publisher.events[1])
+	}
+	if len(impressionRepo.impressions) != 0 || len(clickRepo.clicks) != 0 || len(biller.events) != 0 {
+		t.Error("Expected nothing stored or billed while tracking")
+	}
+
+	// Redis down: written directly
+	publisher.err = errors.New("connection refused")
+	impressions.Track(ctx, &TrackRequest{ImpressionID: "imp-2", SlotID: "slot-2", BannerID: "ban-1", CampaignID: "cmp-1", IP: "10.0.0.1"})
+	if impressionRepo.impressions["imp-2"] == nil || len(biller.events) != 1 {
+		t.Error("Expected the impression stored and billed without the stream")
+	}
+}
+
+type mockEventStream struct {
+	acked []string
+	dead  map[string]string
+}
+
+func (m *mockEventStream) Read(ctx context.Context, consumer string, count int) ([]*entities.TrackingEvent, error) {
+	return nil, nil
+}
+
+func (m *mockEventStream) Ack(ctx context.Context, events []*entities.TrackingEvent) error {
+	for _, event := range events {
+		m.acked = append(m.acked, event.ID)
+	}
+	return nil
+}
+
+func (m *mockEventStream) DeadLetter(ctx context.Context, event *entities.TrackingEvent, reason string) error {
+	if m.dead == nil {
+		m.dead = make(map[string]string)
+	}
+	m.dead[event.ID] = reason
+	return nil
+}
+
+// flakyImpressionRepo fails inserts containing one impression
+type flakyImpressionRepo struct {
+	mockImpressionRepo
+	failID string
+}
+
+func (m *flakyImpressionRepo) CreateBatch(ctx context.Context, impressions []*entities.Impression) error {
+	for _, impression := range impressions {
+		if impression.ID == m.failID {
+			return errors.New("insert failed")
+		}
+	}
+	return m.mockImpressionRepo.CreateBatch(ctx, impressions)
+}
+
+func TestEventConsumer_Process(t *testing.T) {
+	ctx := context.Background()
+	stream := &mockEventStream{}
+	impressionRepo := &flakyImpressionRepo{failID: "imp-bad"}
+	clickRepo := &mockClickRepo{}
+	biller := &mockBiller{}
+	consumer := NewEventConsumer(stream, impressionRepo, clickRepo, ConsumerConfig{MaxAttempts: 3}).WithBiller(biller)
+
+	impression := &entities.Impression{ID: "imp-1", BannerID: "ban-1", CampaignID: "cmp-1"}
+	event := func(e *entities.TrackingEvent, deliveries int64) *entities.TrackingEvent {
+		e.Deliveries = deliveries
+		return e
+	}
+	consumer.Process(ctx, []*entities.TrackingEvent{
+		event(entities.NewImpressionEvent(impression, decimal.NewFromFloat(2)), 1),
+		event(entities.NewImpressionEvent(&entities.Impression{ID: "imp-bad", CampaignID: "cmp-1"}, decimal.Zero), 1),
+		event(entities.NewClickEvent(&entities.Click{ID: "clk-1", ImpressionID: "imp-1"}, impression), 2),
+		event(entities.NewImpressionEvent(&entities.Impression{ID: "imp-old", CampaignID: "cmp-1"}, decimal.Zero), 4),
+		event(&entities.TrackingEvent{ID: "evt-x", Type: entities.EventConversion, Impression: impression}, 1),
+		event(entities.NewClickEvent(&entities.Click{ID: "clk-bot", ImpressionID: "imp-1", Invalid: true}, impression), 1),
+	})
+
+	// The failing impression doesn't hold back the rest of its batch
+	if impressionRepo.impressions["imp-1"] == nil || clickRepo.clicks["clk-1"] == nil {
+		t.Error("Expected the impression and the click stored")
+	}
+	if len(stream.acked) != 3 || stream.acked[0] != "imp-1" || stream.acked[1] != "clk-1" || stream.acked[2] != "clk-bot" {
+		t.Errorf("Expected the stored events acknowledged, got %v", stream.acked)
+	}
+	if len(biller.events) != 2 || !biller.events[0].ClearingPrice.Equal(decimal.NewFromFloat(2)) || biller.events[1].Type != entities.EventClick {
+		t.Errorf("Expected the impression and the valid click billed, got %+v", biller.events)
+	}
+
+	// Out of attempts or not storable: dead letters; the failed insert is retried
+	if len(stream.dead) != 2 || stream.dead["imp-old"] == "" || stream.dead["evt-x"] == "" {
+		t.Errorf("Expected imp-old and evt-x dead-lettered, got %v", stream.dead)
+	}
+	if stats := consumer.Stats(); stats.Stored != 3 || stats.Failed != 1 || stats.DeadLettered != 2 {
+		t.Errorf("Expected 3 stored, 1 failed and 2 dead-lettered, got %+v", stats)
+	}
+}
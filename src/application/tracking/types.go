@@ -19,6 +19,14 @@ type TrackResponse struct {
 	Message string `json:"message,omitempty"`
 }
 
+// ClickRequest represents click tracking request
+type ClickRequest struct {
+	ImpressionID string
+	IP           string
+	UserAgent    string
+	Referer      string
+}
+
 // ClickResponse represents click tracking response
 type ClickResponse struct {
 	RedirectURL string `json:"redirect_url"`
@@ -0,0 +1,57 @@
+package tracking
+
+import (
+	"context"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/fall-out-bug/demo-adserver/src/domain/repositories"
+)
+
+// VideoEventRequest is a playback event reported by a VAST tracking URL
+type VideoEventRequest struct {
+	ImpressionID string
+	SlotID       string
+	BannerID     string
+	CampaignID   string
+	Event        entities.VideoEventType
+	IP           string
+	UserAgent    string
+}
+
+// VideoEventService records video playback events (start, quartiles, complete, skip)
+type VideoEventService struct {
+	eventRepo repositories.VideoEventRepository
+}
+
+// NewVideoEventService creates a new video event service
+func NewVideoEventService(eventRepo repositories.VideoEventRepository) *VideoEventService {
+	return &VideoEventService{eventRepo: eventRepo}
+}
+
+// TrackVideoEvent logs a playback event of a served video ad
+func (s *VideoEventService) TrackVideoEvent(ctx context.Context, req *VideoEventRequest) *TrackResponse {
+	event, err := entities.NewVideoEvent(req.ImpressionID, req.Event)
+	if err != nil {
+		return &TrackResponse{
+			Success: false,
+			Message: err.Error(),
+		}
+	}
+	event.SlotID = req.SlotID
+	event.BannerID = req.BannerID
+	event.CampaignID = req.CampaignID
+	event.IP = req.IP
+	event.UserAgent = req.UserAgent
+
+	if err := s.eventRepo.Create(ctx, event); err != nil {
+		return &TrackResponse{
+			Success: false,
+			Message: "failed to log video event",
+		}
+	}
+
+	return &TrackResponse{
+		Success: true,
+		Message: "video event tracked successfully",
+	}
+}
@@ -8,26 +8,36 @@ import (
 	"os/signal"
 	"syscall"
 
+	"github.com/fall-out-bug/demo-adserver/src/application/audience"
 	"github.com/fall-out-bug/demo-adserver/src/application/auth"
+	"github.com/fall-out-bug/demo-adserver/src/application/budget"
 	"github.com/fall-out-bug/demo-adserver/src/application/demo"
 	"github.com/fall-out-bug/demo-adserver/src/application/delivery"
+	"github.com/fall-out-bug/demo-adserver/src/application/fraud"
+	"github.com/fall-out-bug/demo-adserver/src/application/placement"
 	"github.com/fall-out-bug/demo-adserver/src/application/tracking"
 	"github.com/fall-out-bug/demo-adserver/src/config"
 	httpHandlers "github.com/fall-out-bug/demo-adserver/src/presentation/http"
 	"github.com/fall-out-bug/demo-adserver/src/presentation/http/middleware"
 	"github.com/fall-out-bug/demo-adserver/src/infrastructure/postgres"
 	"github.com/fall-out-bug/demo-adserver/src/infrastructure/redis"
+	"github.com/fall-out-bug/demo-adserver/src/infrastructure/geoip"
+	"github.com/fall-out-bug/demo-adserver/src/infrastructure/ivt"
 	securityinfra "github.com/fall-out-bug/demo-adserver/src/infrastructure/security"
+	"github.com/fall-out-bug/demo-adserver/src/infrastructure/useragent"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
 // App represents the application
 type App struct {
-	config     *config.Config
-	server     *http.Server
-	logger     *zap.Logger
-	shutdownCh chan struct{}
+	config       *config.Config
+	server       *http.Server
+	logger       *zap.Logger
+	spendTracker *budget.Tracker
+	geoResolver  *geoip.Resolver
+	pipeline     *tracking.ImpressionPipeline
+	shutdownCh   chan struct{}
 }
 
 // New creates and initializes the application
@@ -52,34 +62,98 @@ func New(cfg *config.Config) (*App, error) {
 		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
-	// Initialize repositories
-	campaignRepo := postgres.NewCampaignRepository(db)
-	bannerRepo := postgres.NewBannerRepository(db)
+	// Create cache adapter
+	cacheAdapter := &cacheAdapter{cache: redis.NewCache(redisClient.Client)}
+
+	// Initialize repositories (campaign and banner writes invalidate the delivery cache)
+	campaignRepo := delivery.NewInvalidatingCampaignRepository(postgres.NewCampaignRepository(db), cacheAdapter)
+	campaignSlotRepo := postgres.NewCampaignSlotRepository(db)
+	bannerRepo := delivery.NewInvalidatingBannerRepository(postgres.NewBannerRepository(db), cacheAdapter)
 	impressionRepo := postgres.NewImpressionRepository(db)
 	clickRepo := postgres.NewClickRepository(db)
 	publisherRepo := postgres.NewPublisherRepository(db)
 	advertiserRepo := postgres.NewAdvertiserRepository(db)
 	demoBannerRepo := postgres.NewDemoBannerRepository(db)
 	demoSlotRepo := postgres.NewDemoSlotRepository(db)
+	spendRepo := postgres.NewSpendRepository(db)
+	billableEventRepo := postgres.NewBillableEventRepository(db)
+	campaignStatsRepo := postgres.NewCampaignStatsRepository(db)
+	segmentRepo := postgres.NewSegmentRepository(db)
+	videoEventRepo := postgres.NewVideoEventRepository(db)
+	servedAdRepo := postgres.NewServedAdRepository(db)
+	invalidTrafficRepo := postgres.NewInvalidTrafficRepository(db)
 
 	// Initialize infrastructure
 	rateLimiter := redis.NewRateLimiter(redisClient.Client)
 	deduper := redis.NewDeduper(redisClient.Client)
 
-	// Create cache adapter
-	cacheAdapter := &cacheAdapter{cache: redis.NewCache(redisClient.Client)}
+	// Initialize geolocation (optional)
+	var geoResolver *geoip.Resolver
+	var geoLocator httpHandlers.GeoResolver
+	if cfg.Geo.DatabasePath != "" {
+		geoResolver, err = geoip.NewResolver(cfg.Geo.DatabasePath)
+		if err != nil {
+			logger.Error("Failed to open geo database", zap.Error(err))
+			return nil, fmt.Errorf("failed to open geo database: %w", err)
+		}
+		geoLocator = geoResolver
+	}
 
 	// Initialize security
 	passwordHasher := securityinfra.NewBcryptPasswordHasher(12)
 	jwtService := securityinfra.NewJWTService(cfg.JWT.Secret, cfg.JWT.Expiration)
+	trackingSigner := securityinfra.NewTrackingSigner(cfg.Tracking.Secret, cfg.Tracking.URLTTL)
 
 	// Initialize services
-	deliveryService := delivery.NewService(campaignRepo, bannerRepo, demoBannerRepo, demoSlotRepo, cacheAdapter)
-	impressionService := tracking.NewImpressionService(impressionRepo, deduper)
-	clickService := tracking.NewClickService(impressionRepo, clickRepo, bannerRepo)
+	audienceService := audience.NewService(segmentRepo, redis.NewSegmentStore(redisClient.Client))
+	spendTracker := budget.NewTracker(redis.NewSpendStore(redisClient.Client), spendRepo)
+	biller := budget.NewBiller(campaignRepo, billableEventRepo, spendTracker)
+	deliveryService := delivery.NewService(campaignRepo, bannerRepo, demoBannerRepo, demoSlotRepo, cacheAdapter).
+		WithRotationCounter(redis.NewRotationCounter(redisClient.Client)).
+		WithFrequencyCounter(redis.NewFrequencyCounter(redisClient.Client)).
+		WithBudgetChecker(spendTracker).
+		WithStatsRepository(campaignStatsRepo).
+		WithAudience(audienceService).
+		WithLedger(servedAdRepo).
+		WithTrackingSigner(trackingSigner)
+	impressionService := tracking.NewImpressionService(impressionRepo, deduper).WithBiller(biller).WithLedger(servedAdRepo)
+	clickService := tracking.NewClickService(impressionRepo, clickRepo, bannerRepo).
+		WithBiller(biller).
+		WithLedger(servedAdRepo).
+		WithUTMSource(cfg.Tracking.UTMSource)
+	impressionPipeline := tracking.NewImpressionPipeline(impressionService, tracking.PipelineConfig{
+		QueueSize:      cfg.Events.QueueSize,
+		Workers:        cfg.Events.Workers,
+		BatchSize:      cfg.Events.BatchSize,
+		FlushInterval:  cfg.Events.FlushInterval,
+		EnqueueTimeout: cfg.Events.EnqueueTimeout,
+	})
+	auctionService := tracking.NewAuctionService(servedAdRepo, impressionService).WithExposures(deliveryService)
+
+	// Score impressions and clicks for invalid traffic
+	if cfg.Fraud.Enabled {
+		scorer, err := newFraudScorer(cfg.Fraud, redisClient)
+		if err != nil {
+			logger.Error("Failed to load invalid traffic lists", zap.Error(err))
+			return nil, err
+		}
+		impressionService.WithFraudScorer(scorer)
+		clickService.WithFraudScorer(scorer)
+	}
+
+	// Hand impressions and clicks to the event consumer through Redis
+	if cfg.Events.StreamEnabled {
+		eventStream := redis.NewEventStream(redisClient.Client, cfg.Events.StreamMaxLen, cfg.Events.RetryDelay)
+		impressionService.WithPublisher(eventStream)
+		clickService.WithPublisher(eventStream)
+	}
+	conversionService := tracking.NewConversionService(impressionRepo, clickRepo, campaignRepo, biller).WithLedger(servedAdRepo)
+	videoEventService := tracking.NewVideoEventService(videoEventRepo)
 	publisherService := auth.NewPublisherService(publisherRepo, passwordHasher, jwtService)
 	advertiserService := auth.NewAdvertiserService(advertiserRepo, passwordHasher, jwtService)
 	demoService := demo.NewService(demoBannerRepo, demoSlotRepo)
+	placementService := placement.NewService(campaignRepo, campaignSlotRepo, deliveryService)
+	invalidTrafficService := fraud.NewReportService(invalidTrafficRepo)
 
 	// Create JWT authenticator adapter
 	jwtAuthenticator := securityinfra.NewJWTAuthenticatorAdapter(jwtService)
@@ -101,8 +175,30 @@ func New(cfg *config.Config) (*App, error) {
 	router.Use(middleware.NewRateLimitMiddleware(rateLimitAdapter).Handle())
 
 	// Setup routes with auth services
-	httpHandlers.SetupRoutes(router, deliveryService, impressionService, clickService,
-		publisherService, advertiserService, demoService, jwtAuthenticator)
+	httpHandlers.SetupRoutes(router, httpHandlers.Handlers{
+		DeliveryService:       deliveryService,
+		ImpressionService:     impressionService,
+		ClickService:          clickService,
+		ConversionService:     conversionService,
+		PublisherService:      publisherService,
+		AdvertiserService:     advertiserService,
+		DemoService:           demoService,
+		PlacementService:      placementService,
+		AudienceService:       audienceService,
+		UserIdentifier:        deduper,
+		ClientDetector:        useragent.NewParser(),
+		GeoResolver:           geoLocator,
+		TrustedCountryHeader:  cfg.Geo.TrustedCountryHeader,
+		KeywordsFromURL:       cfg.Context.KeywordsFromURL,
+		VideoEventService:     videoEventService,
+		AuctionService:        auctionService,
+		TrackingVerifier:      trackingSigner,
+		ImpressionPipeline:    impressionPipeline,
+		LedgerStats:           deliveryService,
+		InvalidTrafficService: invalidTrafficService,
+		PublicURL:             cfg.Server.PublicURL,
+		JWTAuthenticator:      jwtAuthenticator,
+	})
 
 	server := &http.Server{
 		Addr:         fmt.Sprintf(":%d", cfg.Server.Port),
@@ -112,13 +208,44 @@ func New(cfg *config.Config) (*App, error) {
 	}
 
 	return &App{
-		config:     cfg,
-		server:     server,
-		logger:     logger,
-		shutdownCh: make(chan struct{}),
+		config:       cfg,
+		server:       server,
+		logger:       logger,
+		spendTracker: spendTracker,
+		geoResolver:  geoResolver,
+		pipeline:     impressionPipeline,
+		shutdownCh:   make(chan struct{}),
 	}, nil
 }
 
+// newFraudScorer creates the invalid traffic scorer with its bot list,
+// datacenter ranges (when configured) and per-IP velocity counter
+func newFraudScorer(cfg config.FraudConfig, redisClient *redis.Client) (*fraud.Scorer, error) {
+	bots, err := ivt.LoadBotList(cfg.BotListPath)
+	if err != nil {
+		return nil, err
+	}
+
+	scorer := fraud.NewScorer(fraud.Config{
+		Threshold:      cfg.Threshold,
+		VelocityLimit:  cfg.VelocityLimit,
+		VelocityWindow: cfg.VelocityWindow,
+		MinClickDelay:  cfg.MinClickDelay,
+	}).
+		WithBots(bots).
+		WithVelocity(redis.NewVelocityCounter(redisClient.Client))
+
+	if cfg.DatacenterListPath != "" {
+		datacenters, err := ivt.LoadNetworkList(cfg.DatacenterListPath)
+		if err != nil {
+			return nil, err
+		}
+		scorer.WithDatacenters(datacenters)
+	}
+
+	return scorer, nil
+}
+
 // rateLimitAdapter adapts redis.RateLimiter to middleware.RateLimiter interface
 type rateLimitAdapter struct {
 	limiter interface {
@@ -138,35 +265,64 @@ func (a *rateLimitAdapter) GetRetryAfter(ctx interface{}, ip string) (int, error
 // cacheAdapter adapts redis.Cache to delivery.Cache interface
 type cacheAdapter struct {
 	cache interface {
-		GetBanner(ctx context.Context, slotID string) (*redis.CachedBanner, error)
-		SetBanner(ctx context.Context, slotID string, banner *redis.CachedBanner) error
+		GetCandidates(ctx context.Context, slotID string) (*redis.CachedCandidates, error)
+		SetCandidates(ctx context.Context, slotID string, candidates *redis.CachedCandidates) error
+		InvalidateSlot(ctx context.Context, slotID string) error
+		InvalidateAll(ctx context.Context) error
 	}
 }
 
-func (a *cacheAdapter) GetBanner(ctx context.Context, slotID string) (*delivery.CachedBanner, error) {
-	b, err := a.cache.GetBanner(ctx, slotID)
-	if err != nil || b == nil {
+func (a *cacheAdapter) GetCandidates(ctx context.Context, slotID string) (*delivery.CandidateSet, error) {
+	c, err := a.cache.GetCandidates(ctx, slotID)
+	if err != nil || c == nil {
 		return nil, err
 	}
-	return &delivery.CachedBanner{
-		HTML:       b.HTML,
-		Width:      b.Width,
-		Height:     b.Height,
-		ClickURL:   b.ClickURL,
-		Impression: b.Impression,
-		CampaignID: b.CampaignID,
-	}, nil
+
+	set := &delivery.CandidateSet{}
+	for _, candidate := range c.Candidates {
+		set.Candidates = append(set.Candidates, delivery.Candidate{
+			Campaign: candidate.Campaign,
+			Banners:  candidate.Banners,
+			Stats:    candidate.Stats,
+		})
+	}
+	if c.Demo != nil {
+		set.Demo = &delivery.DemoCreative{
+			Type:   c.Demo.Type,
+			HTML:   c.Demo.HTML,
+			Width:  c.Demo.Width,
+			Height: c.Demo.Height,
+		}
+	}
+	return set, nil
 }
 
-func (a *cacheAdapter) SetBanner(ctx context.Context, slotID string, banner *delivery.CachedBanner) error {
-	return a.cache.SetBanner(ctx, slotID, &redis.CachedBanner{
-		HTML:       banner.HTML,
-		Width:      banner.Width,
-		Height:     banner.Height,
-		ClickURL:   banner.ClickURL,
-		Impression: banner.Impression,
-		CampaignID: banner.CampaignID,
-	})
+func (a *cacheAdapter) SetCandidates(ctx context.Context, slotID string, set *delivery.CandidateSet) error {
+	c := &redis.CachedCandidates{}
+	for _, candidate := range set.Candidates {
+		c.Candidates = append(c.Candidates, redis.CachedCandidate{
+			Campaign: candidate.Campaign,
+			Banners:  candidate.Banners,
+			Stats:    candidate.Stats,
+		})
+	}
+	if set.Demo != nil {
+		c.Demo = &redis.CachedDemo{
+			Type:   set.Demo.Type,
+			HTML:   set.Demo.HTML,
+			Width:  set.Demo.Width,
+			Height: set.Demo.Height,
+		}
+	}
+	return a.cache.SetCandidates(ctx, slotID, c)
+}
+
+func (a *cacheAdapter) InvalidateSlot(ctx context.Context, slotID string) error {
+	return a.cache.InvalidateSlot(ctx, slotID)
+}
+
+func (a *cacheAdapter) InvalidateAll(ctx context.Context) error {
+	return a.cache.InvalidateAll(ctx)
 }
 
 // Run starts the HTTP server
@@ -183,6 +339,28 @@ func (a *App) Run() error {
 		}
 	}()
 
+	// Reconcile campaign spend to Postgres in the background
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	reconcileDone := make(chan struct{})
+	go func() {
+		defer close(reconcileDone)
+		a.spendTracker.Run(backgroundCtx, a.config.Budget.ReconcileInterval)
+	}()
+
+	// Store queued impressions in batches; stopped after the server so the
+	// impressions of the last requests are drained
+	pipelineCtx, stopPipeline := context.WithCancel(context.Background())
+	pipelineDone := make(chan struct{})
+	go func() {
+		defer close(pipelineDone)
+		a.pipeline.Run(pipelineCtx)
+	}()
+
+	// Pick up geo database updates without a restart
+	if a.geoResolver != nil {
+		go a.geoResolver.Run(backgroundCtx, a.config.Geo.ReloadInterval)
+	}
+
 	// Wait for shutdown signal
 	<-a.shutdownCh
 
@@ -191,8 +369,30 @@ func (a *App) Run() error {
 	ctx, cancel := context.WithTimeout(context.Background(), a.config.Server.ShutdownTimeout)
 	defer cancel()
 
-	if err := a.server.Shutdown(ctx); err != nil {
-		return fmt.Errorf("server shutdown failed: %w", err)
+	shutdownErr := a.server.Shutdown(ctx)
+
+	// Drain the impression queue before the spend flush below, as draining bills
+	stopPipeline()
+	select {
+	case <-pipelineDone:
+		stats := a.pipeline.Stats()
+		a.logger.Info("Impression pipeline drained",
+			zap.Uint64("stored", stats.Stored),
+			zap.Uint64("dropped", stats.Dropped),
+			zap.Uint64("failed", stats.Failed),
+		)
+	case <-ctx.Done():
+		a.logger.Warn("Impression pipeline not drained before the shutdown timeout",
+			zap.Int("queued", a.pipeline.Stats().Depth),
+		)
+	}
+
+	// Stop background jobs; the tracker flushes spend debited by the last requests
+	stopBackground()
+	<-reconcileDone
+
+	if shutdownErr != nil {
+		return fmt.Errorf("server shutdown failed: %w", shutdownErr)
 	}
 
 	a.logger.Info("Server stopped")
@@ -206,6 +406,11 @@ func (a *App) Shutdown() {
 
 // WaitForShutdown waits for interrupt signal
 func (a *App) WaitForShutdown() {
+	waitForSignal()
+}
+
+// waitForSignal blocks until SIGINT or SIGTERM
+func waitForSignal() {
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 	<-sigCh
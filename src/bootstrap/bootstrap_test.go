@@ -1,11 +1,16 @@
 package bootstrap
 
 import (
+	"context"
 	"os"
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
+	"github.com/fall-out-bug/demo-adserver/src/application/delivery"
 	"github.com/fall-out-bug/demo-adserver/src/config"
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/fall-out-bug/demo-adserver/src/infrastructure/redis"
 )
 
 func TestNew_MissingDBPassword(t *testing.T) {
@@ -56,3 +61,28 @@ func TestApp_Shutdown(t *testing.T) {
 		t.Errorf("Shutdown timed out")
 	}
 }
+
+func TestCacheAdapter_DemoRoundTrip(t *testing.T) {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	client := redis.NewClient(s.Addr())
+	defer client.Close()
+
+	ctx := context.Background()
+	adapter := &cacheAdapter{cache: redis.NewCache(client.Client)}
+	demo := &delivery.DemoCreative{Type: entities.CreativeImage, HTML: "<img>", Width: 300, Height: 250}
+
+	if err := adapter.SetCandidates(ctx, "slot-1", &delivery.CandidateSet{Demo: demo}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	set, err := adapter.GetCandidates(ctx, "slot-1")
+	if err != nil || set == nil || set.Demo == nil {
+		t.Fatalf("Expected the cached demo, got %+v, %v", set, err)
+	}
+	if *set.Demo != *demo {
+		t.Errorf("Expected %+v from the cache, got %+v", *demo, *set.Demo)
+	}
+}
@@ -0,0 +1,135 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/fall-out-bug/demo-adserver/src/application/budget"
+	"github.com/fall-out-bug/demo-adserver/src/application/tracking"
+	"github.com/fall-out-bug/demo-adserver/src/config"
+	"github.com/fall-out-bug/demo-adserver/src/infrastructure/postgres"
+	"github.com/fall-out-bug/demo-adserver/src/infrastructure/redis"
+	"go.uber.org/zap"
+)
+
+// Consumer is the event consumer process: it stores the impressions and
+// clicks the servers publish to the event stream in Postgres and bills them
+type Consumer struct {
+	config       *config.Config
+	logger       *zap.Logger
+	consumer     *tracking.EventConsumer
+	spendTracker *budget.Tracker
+	shutdownCh   chan struct{}
+}
+
+// NewConsumer creates and initializes the event consumer
+func NewConsumer(cfg *config.Config) (*Consumer, error) {
+	// Initialize logger
+	logger, err := zap.NewProduction()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create logger: %w", err)
+	}
+
+	// Initialize database
+	db, err := postgres.NewConnection(cfg.Database.DSN())
+	if err != nil {
+		logger.Error("Failed to connect to database", zap.Error(err))
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	// Initialize Redis
+	redisClient := redis.NewClient(cfg.Redis.Addr)
+	if err := redisClient.Ping(context.Background()); err != nil {
+		logger.Error("Failed to connect to Redis", zap.Error(err))
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+
+	eventStream := redis.NewEventStream(redisClient.Client, cfg.Events.StreamMaxLen, cfg.Events.RetryDelay)
+	if err := eventStream.EnsureGroup(context.Background()); err != nil {
+		logger.Error("Failed to create consumer group", zap.Error(err))
+		return nil, fmt.Errorf("failed to create consumer group: %w", err)
+	}
+
+	name := cfg.Events.ConsumerName
+	if name == "" {
+		if name, err = os.Hostname(); err != nil {
+			return nil, fmt.Errorf("failed to name consumer: %w", err)
+		}
+	}
+
+	// Billing debits the same spend counters as the servers
+	spendTracker := budget.NewTracker(redis.NewSpendStore(redisClient.Client), postgres.NewSpendRepository(db))
+	biller := budget.NewBiller(postgres.NewCampaignRepository(db), postgres.NewBillableEventRepository(db), spendTracker)
+
+	consumer := tracking.NewEventConsumer(eventStream,
+		postgres.NewImpressionRepository(db), postgres.NewClickRepository(db),
+		tracking.ConsumerConfig{
+			Name:        name,
+			BatchSize:   cfg.Events.BatchSize,
+			MaxAttempts: cfg.Events.MaxAttempts,
+		}).WithBiller(biller)
+
+	return &Consumer{
+		config:       cfg,
+		logger:       logger.With(zap.String("consumer", name)),
+		consumer:     consumer,
+		spendTracker: spendTracker,
+		shutdownCh:   make(chan struct{}),
+	}, nil
+}
+
+// Run consumes events until Shutdown is called
+func (c *Consumer) Run() error {
+	c.logger.Info("Starting event consumer")
+
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	reconcileDone := make(chan struct{})
+	go func() {
+		defer close(reconcileDone)
+		c.spendTracker.Run(backgroundCtx, c.config.Budget.ReconcileInterval)
+	}()
+
+	consumerCtx, stopConsumer := context.WithCancel(context.Background())
+	consumerDone := make(chan struct{})
+	go func() {
+		defer close(consumerDone)
+		c.consumer.Run(consumerCtx)
+	}()
+
+	// Wait for shutdown signal
+	<-c.shutdownCh
+
+	c.logger.Info("Shutting down event consumer...")
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.Server.ShutdownTimeout)
+	defer cancel()
+
+	// Finish the batch in flight before the spend flush below
+	stopConsumer()
+	select {
+	case <-consumerDone:
+	case <-ctx.Done():
+		c.logger.Warn("Event batch not finished before the shutdown timeout")
+	}
+
+	stopBackground()
+	<-reconcileDone
+
+	stats := c.consumer.Stats()
+	c.logger.Info("Event consumer stopped",
+		zap.Uint64("stored", stats.Stored),
+		zap.Uint64("failed", stats.Failed),
+		zap.Uint64("dead_lettered", stats.DeadLettered),
+	)
+	return nil
+}
+
+// Shutdown stops the consumer
+func (c *Consumer) Shutdown() {
+	close(c.shutdownCh)
+}
+
+// WaitForShutdown waits for interrupt signal
+func (c *Consumer) WaitForShutdown() {
+	waitForSignal()
+}
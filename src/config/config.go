@@ -1,6 +1,9 @@
 package config
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"time"
 
@@ -14,6 +17,12 @@ type Config struct {
 	Redis    RedisConfig
 	JWT      JWTConfig
 	CORS     CORSConfig
+	Budget   BudgetConfig
+	Geo      GeoConfig
+	Context  ContextConfig
+	Tracking TrackingConfig
+	Events   EventsConfig
+	Fraud    FraudConfig
 }
 
 // ServerConfig holds HTTP server configuration
@@ -22,6 +31,9 @@ type ServerConfig struct {
 	ReadTimeout     time.Duration `envconfig:"SERVER_READ_TIMEOUT" default:"10s"`
 	WriteTimeout    time.Duration `envconfig:"SERVER_WRITE_TIMEOUT" default:"10s"`
 	ShutdownTimeout time.Duration `envconfig:"SERVER_SHUTDOWN_TIMEOUT" default:"10s"`
+	// PublicURL is the external base URL (e.g. https://ads.example.com) used in
+	// absolute tracking URLs; empty derives it from each request
+	PublicURL string `envconfig:"SERVER_PUBLIC_URL" default:""`
 }
 
 // DatabaseConfig holds PostgreSQL configuration
@@ -52,6 +64,80 @@ type CORSConfig struct {
 	AllowedOrigins []string `envconfig:"CORS_ALLOWED_ORIGINS" default:"http://localhost:3000,http://localhost:3001,http://127.0.0.1:3000,http://127.0.0.1:3001"`
 }
 
+// BudgetConfig holds campaign spend tracking configuration
+type BudgetConfig struct {
+	ReconcileInterval time.Duration `envconfig:"BUDGET_RECONCILE_INTERVAL" default:"1m"`
+}
+
+// GeoConfig holds IP geolocation configuration
+type GeoConfig struct {
+	// DatabasePath points to a MaxMind-format (.mmdb) database; empty disables lookups
+	DatabasePath   string        `envconfig:"GEOIP_DATABASE_PATH" default:""`
+	ReloadInterval time.Duration `envconfig:"GEOIP_RELOAD_INTERVAL" default:"1m"`
+	// TrustedCountryHeader names a header set by a trusted proxy (e.g. CF-IPCountry)
+	// that is used when the database has no answer; empty ignores client headers
+	TrustedCountryHeader string `envconfig:"GEOIP_TRUSTED_COUNTRY_HEADER" default:""`
+}
+
+// ContextConfig holds contextual targeting configuration
+type ContextConfig struct {
+	// KeywordsFromURL derives page keywords from the page URL path when a request passes none
+	KeywordsFromURL bool `envconfig:"CONTEXT_KEYWORDS_FROM_URL" default:"true"`
+}
+
+// TrackingConfig holds tracking URL signing configuration
+type TrackingConfig struct {
+	// Secret signs impression, click and video event URLs; empty derives one from the JWT secret
+	Secret string `envconfig:"TRACKING_SECRET" default:""`
+	// URLTTL is how long tracking URLs stay valid after the ad was served
+	URLTTL time.Duration `envconfig:"TRACKING_URL_TTL" default:"24h"`
+	// UTMSource tags landing URLs with UTM parameters (utm_source set to it)
+	// they don't set themselves; empty leaves landing URLs alone
+	UTMSource string `envconfig:"TRACKING_UTM_SOURCE" default:""`
+}
+
+// EventsConfig holds the impression pipeline configuration. Impressions are
+// queued in memory and stored in batches by a pool of workers.
+type EventsConfig struct {
+	QueueSize     int           `envconfig:"EVENTS_QUEUE_SIZE" default:"10000"`
+	Workers       int           `envconfig:"EVENTS_WORKERS" default:"4"`
+	BatchSize     int           `envconfig:"EVENTS_BATCH_SIZE" default:"100"`
+	FlushInterval time.Duration `envconfig:"EVENTS_FLUSH_INTERVAL" default:"1s"`
+	// EnqueueTimeout is how long a request waits for room in a full queue
+	// before the impression is dropped with 503
+	EnqueueTimeout time.Duration `envconfig:"EVENTS_ENQUEUE_TIMEOUT" default:"50ms"`
+
+	// StreamEnabled publishes impressions and clicks to a Redis Stream; the
+	// consumer (cmd/consumer) stores them in Postgres
+	StreamEnabled bool  `envconfig:"EVENTS_STREAM_ENABLED" default:"false"`
+	StreamMaxLen  int64 `envconfig:"EVENTS_STREAM_MAXLEN" default:"1000000"`
+	// RetryDelay is how long an event that failed to store waits for a retry
+	RetryDelay time.Duration `envconfig:"EVENTS_RETRY_DELAY" default:"30s"`
+	// MaxAttempts is how often an event is tried before it is dead-lettered
+	MaxAttempts int `envconfig:"EVENTS_MAX_ATTEMPTS" default:"5"`
+	// ConsumerName must be unique per running consumer; empty uses the host name
+	ConsumerName string `envconfig:"EVENTS_CONSUMER_NAME" default:""`
+}
+
+// FraudConfig holds invalid traffic scoring configuration. Impressions and
+// clicks scoring at least Threshold are stored as invalid and not billed.
+type FraudConfig struct {
+	Enabled   bool    `envconfig:"FRAUD_ENABLED" default:"true"`
+	Threshold float64 `envconfig:"FRAUD_THRESHOLD" default:"0.7"`
+	// BotListPath points to an IAB-style bot list (pattern|active|start per
+	// line); empty uses the built-in list
+	BotListPath string `envconfig:"FRAUD_BOT_LIST_PATH" default:""`
+	// DatacenterListPath points to a file of datacenter CIDRs, one per line;
+	// empty disables the datacenter rule
+	DatacenterListPath string `envconfig:"FRAUD_DATACENTER_LIST_PATH" default:""`
+	// VelocityLimit is how many impressions or clicks one IP may make per
+	// VelocityWindow before they count as suspicious
+	VelocityLimit  int64         `envconfig:"FRAUD_VELOCITY_LIMIT" default:"60"`
+	VelocityWindow time.Duration `envconfig:"FRAUD_VELOCITY_WINDOW" default:"1m"`
+	// MinClickDelay is the shortest time from serving an ad to a human click
+	MinClickDelay time.Duration `envconfig:"FRAUD_MIN_CLICK_DELAY" default:"1s"`
+}
+
 // Load loads configuration from environment variables
 func Load() (*Config, error) {
 	cfg := &Config{}
@@ -67,19 +153,53 @@ func Load() (*Config, error) {
 		cfg.Server.Port = 8080
 	}
 
+	// Set default spend reconciliation interval if not set
+	if cfg.Budget.ReconcileInterval == 0 {
+		cfg.Budget.ReconcileInterval = time.Minute
+	}
+
+	// Set default geo database reload interval if not set
+	if cfg.Geo.ReloadInterval == 0 {
+		cfg.Geo.ReloadInterval = time.Minute
+	}
+
 	// Set default JWT expiration if not set
 	if cfg.JWT.Expiration == 0 {
 		cfg.JWT.Expiration = 24 * time.Hour
 	}
 
+	// Set default event retry delay if not set
+	if cfg.Events.RetryDelay == 0 {
+		cfg.Events.RetryDelay = 30 * time.Second
+	}
+
+	// Set default tracking URL lifetime if not set
+	if cfg.Tracking.URLTTL == 0 {
+		cfg.Tracking.URLTTL = 24 * time.Hour
+	}
+
 	// Validate JWT secret is not obviously insecure
 	if err := validateJWTSecret(cfg.JWT.Secret); err != nil {
 		return nil, fmt.Errorf("JWT secret validation failed: %w", err)
 	}
 
+	// Tracking tokens are handed to every browser and exchange: unless they
+	// have their own secret, sign them with a key derived from the JWT secret
+	// rather than the key of auth tokens
+	if cfg.Tracking.Secret == "" {
+		cfg.Tracking.Secret = deriveKey(cfg.JWT.Secret, "tracking")
+	}
+
 	return cfg, nil
 }
 
+// deriveKey derives the key of one purpose from a secret (HMAC-SHA256 of the purpose)
+func deriveKey(secret, purpose string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(purpose))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
 // validateJWTSecret ensures the JWT secret is not obviously insecure
 func validateJWTSecret(secret string) error {
 	if len(secret) < 32 {
@@ -25,6 +25,13 @@ func TestConfig_Load_Defaults(t *testing.T) {
 	if cfg.Database.Password != "testpass" {
 		t.Errorf("Expected password testpass, got %s", cfg.Database.Password)
 	}
+
+	if cfg.Tracking.Secret == "" || cfg.Tracking.Secret == cfg.JWT.Secret {
+		t.Errorf("Expected tracking URLs to be signed with a key derived from the JWT secret, got %q", cfg.Tracking.Secret)
+	}
+	if cfg.Tracking.Secret != deriveKey(cfg.JWT.Secret, "tracking") {
+		t.Errorf("Expected the derived tracking key to be stable across instances")
+	}
 }
 
 func TestConfig_Load_FromEnv(t *testing.T) {
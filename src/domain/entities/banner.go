@@ -1,15 +1,8 @@
 package entities
 
-import "time"
-
-// BannerSize represents banner dimensions
-type BannerSize string
-
-const (
-	BannerSize300x250    BannerSize = "300x250"
-	BannerSize728x90     BannerSize = "728x90"
-	BannerSize160x600    BannerSize = "160x600"
-	BannerSizeResponsive BannerSize = "responsive"
+import (
+	"strings"
+	"time"
 )
 
 // BannerStatus represents banner status
@@ -24,19 +17,92 @@ const (
 
 // Banner represents an advertising banner
 type Banner struct {
-	ID         string
-	CampaignID string
-	Name       string
-	Status     BannerStatus
-	Size       BannerSize
-	HTML       string // Banner HTML code
-	ClickURL   string // Target URL
-	Weight     int    // Rotation weight (default: 1)
-	CreatedAt  time.Time
-	UpdatedAt  time.Time
+	ID            string
+	CampaignID    string
+	Name          string
+	Status        BannerStatus
+	Type          CreativeType // html when empty
+	Width         int          // Pixels; 0x0 for responsive banners that fill any slot
+	Height        int
+	HTML          string         // Banner HTML code (html creatives)
+	ImageURL      string         // Image location (image creatives)
+	AltText       string         // Image alternative text (image creatives)
+	Native        *NativeAssets  // Assets of native creatives
+	Video         *VideoAssets   // Media of video creatives
+	ClickURL      string         // Target URL
+	Weight        int            // Rotation weight (default: 1)
+	FrequencyCaps []FrequencyCap // Per-viewer exposure limits for this banner
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
 }
 
 // IsActive checks if banner is active
 func (b *Banner) IsActive() bool {
 	return b.Status == BannerStatusActive
 }
+
+// CreativeType returns the kind of creative, defaulting to HTML
+func (b *Banner) CreativeType() CreativeType {
+	if b.Type == "" {
+		return CreativeHTML
+	}
+	return b.Type
+}
+
+// Markup returns the HTML served for the banner. Image creatives get markup
+// generated by the server so advertisers never supply HTML for them; native
+// and video creatives have none because publishers and players render them.
+func (b *Banner) Markup() string {
+	switch b.CreativeType() {
+	case CreativeImage:
+		return ImageMarkup(b.ImageURL, b.AltText, b.ClickURL, b.Size())
+	case CreativeNative, CreativeVideo:
+		return ""
+	default:
+		return b.HTML
+	}
+}
+
+// Validate checks that the banner has content for its creative type
+func (b *Banner) Validate() error {
+	if b.Width < 0 || b.Height < 0 || (b.Width == 0) != (b.Height == 0) {
+		return ErrInvalidDimensions
+	}
+
+	switch b.CreativeType() {
+	case CreativeHTML:
+		if strings.TrimSpace(b.HTML) == "" {
+			return ErrInvalidContent
+		}
+	case CreativeImage:
+		if !IsSafeURL(b.ImageURL) {
+			return ErrInvalidContent
+		}
+	case CreativeNative:
+		if b.Native == nil {
+			return ErrInvalidContent
+		}
+		if err := b.Native.Validate(); err != nil {
+			return err
+		}
+	case CreativeVideo:
+		if b.Video == nil {
+			return ErrInvalidContent
+		}
+		if err := b.Video.Validate(); err != nil {
+			return err
+		}
+	default:
+		return ErrInvalidFormat
+	}
+
+	if b.ClickURL != "" && !IsSafeURL(b.ClickURL) {
+		return ErrInvalidURL
+	}
+	return nil
+}
+
+// Size returns the banner dimensions
+func (b *Banner) Size() Size {
+	return Size{Width: b.Width, Height: b.Height}
+}
@@ -0,0 +1,64 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// BillableEvent is a tracked impression, click or conversion priced at the
+// campaign's pricing model. Events the model doesn't charge for cost zero.
+type BillableEvent struct {
+	ID           string
+	Type         EventType
+	CampaignID   string
+	BannerID     string
+	ImpressionID string
+	Pricing      PricingModel
+	Bid          decimal.Decimal // Campaign's bid the event is priced at
+	Cost         decimal.Decimal
+	CreatedAt    time.Time
+
+	ClearingPrice decimal.Decimal // CPM an exchange cleared the impression at; zero for direct delivery
+}
+
+// NewBillableEvent creates an unpriced billable event for an impression,
+// with the pricing the impression was served at when it is known
+func NewBillableEvent(eventType EventType, impression *Impression) *BillableEvent {
+	return &BillableEvent{
+		ID:           generateUUID(),
+		Type:         eventType,
+		CampaignID:   impression.CampaignID,
+		BannerID:     impression.BannerID,
+		ImpressionID: impression.ID,
+		Pricing:      impression.Pricing,
+		Bid:          impression.Bid,
+		CreatedAt:    time.Now(),
+	}
+}
+
+// Price sets the event's pricing model, bid and cost from the campaign
+func (e *BillableEvent) Price(campaign *Campaign) {
+	e.Pricing = campaign.PricingModel()
+	e.Bid = campaign.Bid
+	e.setCost()
+}
+
+// PriceAsServed sets the event's cost from the pricing it was served at,
+// reporting false when that pricing is unknown
+func (e *BillableEvent) PriceAsServed() bool {
+	if !e.Pricing.IsValid() {
+		return false
+	}
+	e.setCost()
+	return true
+}
+
+// setCost prices the event at its bid. Impressions won in an auction cost
+// CPM campaigns the clearing price rather than their bid.
+func (e *BillableEvent) setCost() {
+	e.Cost = e.Pricing.CostOf(e.Type, e.Bid)
+	if e.Pricing == PricingCPM && e.Type == EventImpression && e.ClearingPrice.IsPositive() {
+		e.Cost = e.ClearingPrice.Div(decimal.NewFromInt(1000))
+	}
+}
@@ -0,0 +1,58 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// PacingMode controls how a campaign's daily budget is spread over the day
+type PacingMode string
+
+const (
+	PacingASAP PacingMode = "asap" // Spend the daily budget as fast as traffic allows
+	PacingEven PacingMode = "even" // Spread the daily budget evenly across the day
+)
+
+// pacingSlack lets even-paced campaigns run slightly ahead of schedule,
+// so a quiet hour can be caught up instead of under-delivering
+const pacingSlack = time.Hour
+
+// Spend is what a campaign has spent so far
+type Spend struct {
+	Total decimal.Decimal
+	Today decimal.Decimal
+}
+
+// SpendDay returns the budget day (UTC) a point in time belongs to
+func SpendDay(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// CanSpend checks the total budget, the daily budget and the pacing schedule.
+// Non-positive budgets are treated as unlimited.
+func (c *Campaign) CanSpend(spend Spend, now time.Time) bool {
+	if c.BudgetTotal.IsPositive() && !c.IsWithinBudget(spend.Total) {
+		return false
+	}
+
+	if !c.BudgetDaily.IsPositive() {
+		return true
+	}
+	return spend.Today.LessThan(c.DailyAllowance(now))
+}
+
+// DailyAllowance returns how much of today's budget may be spent by now
+func (c *Campaign) DailyAllowance(now time.Time) decimal.Decimal {
+	if c.Pacing != PacingEven {
+		return c.BudgetDaily
+	}
+
+	elapsed := now.Sub(SpendDay(now)) + pacingSlack
+	day := 24 * time.Hour
+	if elapsed >= day {
+		return c.BudgetDaily
+	}
+	return c.BudgetDaily.Mul(decimal.NewFromInt(int64(elapsed))).Div(decimal.NewFromInt(int64(day)))
+}
@@ -0,0 +1,64 @@
+package entities_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/shopspring/decimal"
+)
+
+func TestCampaign_CanSpend_TotalBudget(t *testing.T) {
+	campaign := &entities.Campaign{BudgetTotal: decimal.NewFromInt(100)}
+	now := time.Now()
+
+	if !campaign.CanSpend(entities.Spend{Total: decimal.NewFromInt(99)}, now) {
+		t.Errorf("Expected campaign with remaining budget to spend")
+	}
+	if campaign.CanSpend(entities.Spend{Total: decimal.NewFromInt(100)}, now) {
+		t.Errorf("Expected exhausted campaign not to spend")
+	}
+}
+
+func TestCampaign_CanSpend_ZeroBudgetsAreUnlimited(t *testing.T) {
+	campaign := &entities.Campaign{}
+
+	if !campaign.CanSpend(entities.Spend{Total: decimal.NewFromInt(1000), Today: decimal.NewFromInt(1000)}, time.Now()) {
+		t.Errorf("Expected campaign without budgets to spend")
+	}
+}
+
+func TestCampaign_CanSpend_DailyASAP(t *testing.T) {
+	campaign := &entities.Campaign{BudgetDaily: decimal.NewFromInt(24), Pacing: entities.PacingASAP}
+	earlyMorning := time.Date(2024, 5, 1, 1, 0, 0, 0, time.UTC)
+
+	if !campaign.CanSpend(entities.Spend{Today: decimal.NewFromInt(20)}, earlyMorning) {
+		t.Errorf("Expected ASAP campaign to spend ahead of schedule")
+	}
+	if campaign.CanSpend(entities.Spend{Today: decimal.NewFromInt(24)}, earlyMorning) {
+		t.Errorf("Expected campaign to stop at its daily budget")
+	}
+}
+
+func TestCampaign_DailyAllowance_Even(t *testing.T) {
+	campaign := &entities.Campaign{BudgetDaily: decimal.NewFromInt(24), Pacing: entities.PacingEven}
+
+	tests := []struct {
+		at       time.Time
+		expected int64
+	}{
+		{time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), 1},   // one hour of slack
+		{time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC), 12}, // half the day plus slack
+		{time.Date(2024, 5, 1, 23, 30, 0, 0, time.UTC), 24},
+	}
+
+	for _, tt := range tests {
+		if got := campaign.DailyAllowance(tt.at); !got.Equal(decimal.NewFromInt(tt.expected)) {
+			t.Errorf("Expected allowance %d at %s, got %s", tt.expected, tt.at.Format("15:04"), got)
+		}
+	}
+
+	if campaign.CanSpend(entities.Spend{Today: decimal.NewFromInt(13)}, time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected even-paced campaign ahead of schedule to be held back")
+	}
+}
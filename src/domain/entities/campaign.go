@@ -16,33 +16,67 @@ const (
 	CampaignStatusCompleted CampaignStatus = "completed"
 )
 
+// RotationMode controls how traffic is split between a campaign's banners
+type RotationMode string
+
+const (
+	RotationWeighted   RotationMode = "weighted"   // Random, proportional to Banner.Weight
+	RotationEven       RotationMode = "even"       // Round-robin, equal share per banner
+	RotationSequential RotationMode = "sequential" // Storyboard: banners in order per viewer
+)
+
 // Campaign represents an advertising campaign
 type Campaign struct {
-	ID          string
-	Name        string
-	Status      CampaignStatus
-	BudgetTotal decimal.Decimal
-	BudgetDaily decimal.Decimal
-	StartDate   time.Time
-	EndDate     *time.Time
-	Targeting   Targeting
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	ID            string
+	AdvertiserID  string // Empty for house campaigns
+	Name          string
+	Category      string // Advertiser category, e.g. "automotive"; competitors share one
+	Status        CampaignStatus
+	BudgetTotal   decimal.Decimal
+	BudgetDaily   decimal.Decimal
+	Priority      PriorityTier
+	Pricing       PricingModel
+	Bid           decimal.Decimal // Price per pricing unit (per mille for CPM, flat fee for sponsorships)
+	Pacing        PacingMode
+	StartDate     time.Time
+	EndDate       *time.Time
+	Targeting     Targeting
+	Rotation      RotationMode
+	FrequencyCaps []FrequencyCap // Per-viewer exposure limits for the whole campaign
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
 }
 
 // Targeting represents campaign targeting criteria
 type Targeting struct {
-	Geo       []string    // Country codes
+	Geo       []string    // Country codes, or country-region codes such as US-CA
 	Devices   []string    // mobile, desktop, tablet
 	OS        []string    // ios, android, windows, macos
 	Browsers  []string    // chrome, firefox, safari, edge
 	TimeOfDay []TimeRange // Active hours
+	Timezone  string      // IANA zone for TimeOfDay, or "viewer" for viewer-local time; UTC when empty
+
+	// Page targeting by the referring page URL
+	Domains         []string // Site domains; "example.com" includes subdomains, "*.example.com" only subdomains
+	ExcludedDomains []string
+	URLs            []string // Page URL patterns with * wildcards, e.g. "/sports/*" or "example.com/news/*"
+	ExcludedURLs    []string
+
+	// Contextual targeting by page keywords or content categories
+	Keywords         []string
+	ExcludedKeywords []string
+
+	// Audience targeting by segment IDs the viewer was added to
+	Segments         []string
+	ExcludedSegments []string
 }
 
-// TimeRange represents a time range
+// TimeRange represents a time range. A range whose end is before its start
+// runs overnight, e.g. 22:00-02:00.
 type TimeRange struct {
-	Start time.Time // HH:MM format
-	End   time.Time // HH:MM format
+	Start time.Time      // HH:MM format
+	End   time.Time      // HH:MM format, inclusive
+	Days  []time.Weekday // Days the range starts on; every day when empty
 }
 
 // IsActive checks if campaign is currently active
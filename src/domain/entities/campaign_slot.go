@@ -0,0 +1,71 @@
+package entities
+
+import "time"
+
+// SlotRule decides whether an assignment books or blocks a campaign on a slot
+type SlotRule string
+
+const (
+	SlotRuleInclude SlotRule = "include"
+	SlotRuleExclude SlotRule = "exclude"
+)
+
+// AllSlots is the slot ID that books a campaign run-of-network
+const AllSlots = "*"
+
+// CampaignSlot assigns a campaign to a placement (slot).
+// A campaign is eligible for a slot when it has an include rule for that
+// slot (or for AllSlots) and no exclude rule for that slot.
+type CampaignSlot struct {
+	CampaignID string
+	SlotID     string
+	Rule       SlotRule
+	CreatedAt  time.Time
+}
+
+// NewCampaignSlot creates a new campaign slot assignment with validation
+func NewCampaignSlot(campaignID, slotID string, rule SlotRule) (*CampaignSlot, error) {
+	assignment := &CampaignSlot{
+		CampaignID: campaignID,
+		SlotID:     slotID,
+		Rule:       rule,
+		CreatedAt:  time.Now(),
+	}
+
+	if err := assignment.Validate(); err != nil {
+		return nil, err
+	}
+
+	return assignment, nil
+}
+
+// Validate checks if the assignment is valid
+func (a *CampaignSlot) Validate() error {
+	if a.CampaignID == "" {
+		return ErrInvalidCampaignID
+	}
+	if a.SlotID == "" {
+		return ErrInvalidSlotID
+	}
+	if a.Rule != SlotRuleInclude && a.Rule != SlotRuleExclude {
+		return ErrInvalidSlotRule
+	}
+	if a.Rule == SlotRuleExclude && a.SlotID == AllSlots {
+		return ErrInvalidSlotRule
+	}
+	return nil
+}
+
+// IsBookedOn reports whether the assignments book a campaign on slotID
+func IsBookedOn(assignments []*CampaignSlot, slotID string) bool {
+	booked := false
+	for _, a := range assignments {
+		switch {
+		case a.Rule == SlotRuleExclude && a.SlotID == slotID:
+			return false
+		case a.Rule == SlotRuleInclude && (a.SlotID == slotID || a.SlotID == AllSlots):
+			booked = true
+		}
+	}
+	return booked
+}
@@ -0,0 +1,61 @@
+package entities
+
+import "testing"
+
+func TestNewCampaignSlot(t *testing.T) {
+	tests := []struct {
+		name       string
+		campaignID string
+		slotID     string
+		rule       SlotRule
+		wantErr    error
+	}{
+		{"valid include", "cmp-1", "demo-leaderboard", SlotRuleInclude, nil},
+		{"valid exclude", "cmp-1", "demo-leaderboard", SlotRuleExclude, nil},
+		{"run of network", "cmp-1", AllSlots, SlotRuleInclude, nil},
+		{"exclude everything", "cmp-1", AllSlots, SlotRuleExclude, ErrInvalidSlotRule},
+		{"empty campaign", "", "demo-leaderboard", SlotRuleInclude, ErrInvalidCampaignID},
+		{"empty slot", "cmp-1", "", SlotRuleInclude, ErrInvalidSlotID},
+		{"unknown rule", "cmp-1", "demo-leaderboard", SlotRule("maybe"), ErrInvalidSlotRule},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewCampaignSlot(tt.campaignID, tt.slotID, tt.rule)
+			if err != tt.wantErr {
+				t.Errorf("NewCampaignSlot() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIsBookedOn(t *testing.T) {
+	include := func(slotID string) *CampaignSlot {
+		return &CampaignSlot{CampaignID: "cmp-1", SlotID: slotID, Rule: SlotRuleInclude}
+	}
+	exclude := func(slotID string) *CampaignSlot {
+		return &CampaignSlot{CampaignID: "cmp-1", SlotID: slotID, Rule: SlotRuleExclude}
+	}
+
+	tests := []struct {
+		name        string
+		assignments []*CampaignSlot
+		slotID      string
+		want        bool
+	}{
+		{"no assignments", nil, "slot-a", false},
+		{"included slot", []*CampaignSlot{include("slot-a")}, "slot-a", true},
+		{"other slot", []*CampaignSlot{include("slot-a")}, "slot-b", false},
+		{"run of network", []*CampaignSlot{include(AllSlots)}, "slot-b", true},
+		{"run of network with exclusion", []*CampaignSlot{include(AllSlots), exclude("slot-b")}, "slot-b", false},
+		{"exclusion wins over include", []*CampaignSlot{exclude("slot-a"), include("slot-a")}, "slot-a", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsBookedOn(tt.assignments, tt.slotID); got != tt.want {
+				t.Errorf("IsBookedOn() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
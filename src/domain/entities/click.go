@@ -9,6 +9,12 @@ type Click struct {
 	BannerID     string
 	Timestamp    time.Time
 	IP           string
+	UserAgent    string
 	Referer      string
 	Country      string
+	FraudScore   float64
+
+	// Invalid traffic is stored but not billed; FraudReasons are the rules it matched
+	Invalid      bool
+	FraudReasons []string
 }
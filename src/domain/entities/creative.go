@@ -0,0 +1,55 @@
+package entities
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+)
+
+// CreativeType is the kind of content a banner serves
+type CreativeType string
+
+const (
+	CreativeHTML   CreativeType = "html"   // Advertiser-supplied HTML
+	CreativeImage  CreativeType = "image"  // Image with server-generated markup
+	CreativeNative CreativeType = "native" // Structured assets rendered by the publisher
+	CreativeVideo  CreativeType = "video"  // Linear video served as VAST
+)
+
+// IsSafeURL reports whether raw is an absolute http(s) URL that can be put
+// into generated markup without running script
+func IsSafeURL(raw string) bool {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return false
+	}
+	return u.Scheme == "http" || u.Scheme == "https"
+}
+
+// ImageMarkup builds the markup of an image creative: the image wrapped in a
+// link to the click URL. All values are escaped, and unsafe URLs are left out.
+// Zero dimensions make the image scale to its container.
+func ImageMarkup(imageURL, altText, clickURL string, size Size) string {
+	if !IsSafeURL(imageURL) {
+		return ""
+	}
+
+	link := IsSafeURL(clickURL)
+
+	var b strings.Builder
+	if link {
+		fmt.Fprintf(&b, `<a href="%s" target="_blank" rel="noopener noreferrer">`, html.EscapeString(clickURL))
+	}
+	fmt.Fprintf(&b, `<img src="%s" alt="%s"`, html.EscapeString(imageURL), html.EscapeString(altText))
+	if size.Width > 0 && size.Height > 0 {
+		fmt.Fprintf(&b, ` width="%d" height="%d" style="display:block;border:0"`, size.Width, size.Height)
+	} else {
+		b.WriteString(` style="display:block;border:0;width:100%;height:auto"`)
+	}
+	b.WriteString(`>`)
+	if link {
+		b.WriteString(`</a>`)
+	}
+	return b.String()
+}
@@ -0,0 +1,103 @@
+package entities_test
+
+import (
+	"testing"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+)
+
+func TestImageMarkup(t *testing.T) {
+	tests := []struct {
+		name     string
+		imageURL string
+		altText  string
+		clickURL string
+		size     entities.Size
+		expected string
+	}{
+		{
+			"linked image",
+			"https://cdn.example.com/ad.png", "Summer sale", "https://shop.example.com/?a=1&b=2",
+			entities.Size{Width: 300, Height: 250},
+			`<a href="https://shop.example.com/?a=1&amp;b=2" target="_blank" rel="noopener noreferrer">` +
+				`<img src="https://cdn.example.com/ad.png" alt="Summer sale" width="300" height="250" style="display:block;border:0"></a>`,
+		},
+		{
+			"escaped alt text and responsive size",
+			"https://cdn.example.com/ad.png", `"><script>alert(1)</script>`, "",
+			entities.Size{},
+			`<img src="https://cdn.example.com/ad.png" alt="&#34;&gt;&lt;script&gt;alert(1)&lt;/script&gt;" style="display:block;border:0;width:100%;height:auto">`,
+		},
+		{
+			"script click URL is dropped",
+			"https://cdn.example.com/ad.png", "", "javascript:alert(1)",
+			entities.Size{Width: 728, Height: 90},
+			`<img src="https://cdn.example.com/ad.png" alt="" width="728" height="90" style="display:block;border:0">`,
+		},
+		{
+			"unsafe image URL",
+			"data:text/html,<script>", "", "https://shop.example.com",
+			entities.Size{Width: 728, Height: 90},
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := entities.ImageMarkup(tt.imageURL, tt.altText, tt.clickURL, tt.size); got != tt.expected {
+			t.Errorf("%s:\nexpected %s\ngot      %s", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestBanner_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		banner   entities.Banner
+		expected error
+	}{
+		{"html banner", entities.Banner{HTML: "<div>Ad</div>", Width: 300, Height: 250}, nil},
+		{"empty html", entities.Banner{Width: 300, Height: 250}, entities.ErrInvalidContent},
+		{"image banner", entities.Banner{Type: entities.CreativeImage, ImageURL: "https://cdn.example.com/ad.png"}, nil},
+		{"image without URL", entities.Banner{Type: entities.CreativeImage}, entities.ErrInvalidContent},
+		{"video banner", entities.Banner{Type: entities.CreativeVideo, Video: &entities.VideoAssets{Duration: 15, MediaFiles: []entities.MediaFile{{URL: "https://cdn.example.com/ad.mp4", Type: "video/mp4", Width: 640, Height: 360}}}}, nil},
+		{"video without assets", entities.Banner{Type: entities.CreativeVideo}, entities.ErrInvalidContent},
+		{"unknown type", entities.Banner{Type: "audio", HTML: "x"}, entities.ErrInvalidFormat},
+		{"half responsive size", entities.Banner{HTML: "x", Width: 300}, entities.ErrInvalidDimensions},
+		{"script click URL", entities.Banner{HTML: "x", ClickURL: "javascript:alert(1)"}, entities.ErrInvalidURL},
+	}
+
+	for _, tt := range tests {
+		if err := tt.banner.Validate(); err != tt.expected {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, err)
+		}
+	}
+}
+
+func TestBanner_Validate_Native(t *testing.T) {
+	valid := entities.NativeAssets{
+		Title:   "Spring sale",
+		Sponsor: "Example Shop",
+		Icon:    &entities.NativeImage{URL: "https://cdn.example.com/icon.png", Width: 80, Height: 80},
+	}
+
+	tests := []struct {
+		name     string
+		native   *entities.NativeAssets
+		expected error
+	}{
+		{"valid", &valid, nil},
+		{"missing assets", nil, entities.ErrInvalidContent},
+		{"missing sponsor", &entities.NativeAssets{Title: "Spring sale"}, entities.ErrInvalidContent},
+		{"unsafe image", &entities.NativeAssets{Title: "t", Sponsor: "s", Image: &entities.NativeImage{URL: "javascript:x"}}, entities.ErrInvalidContent},
+	}
+
+	for _, tt := range tests {
+		banner := entities.Banner{Type: entities.CreativeNative, Native: tt.native}
+		if err := banner.Validate(); err != tt.expected {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, err)
+		}
+		if banner.Markup() != "" {
+			t.Errorf("%s: expected no markup for native creatives", tt.name)
+		}
+	}
+}
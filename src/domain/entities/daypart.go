@@ -0,0 +1,68 @@
+package entities
+
+import "time"
+
+// TimezoneViewer evaluates dayparting in the viewer's local time zone
+const TimezoneViewer = "viewer"
+
+// Contains reports whether a local time falls within the range. For overnight
+// ranges the hours after midnight belong to the day the range started on.
+func (r TimeRange) Contains(local time.Time) bool {
+	current := local.Hour()*60 + local.Minute()
+	start := r.Start.Hour()*60 + r.Start.Minute()
+	end := r.End.Hour()*60 + r.End.Minute()
+	day := local.Weekday()
+
+	if start <= end {
+		return current >= start && current <= end && r.onDay(day)
+	}
+
+	// Overnight: the evening part starts today, the morning part started yesterday
+	if current >= start {
+		return r.onDay(day)
+	}
+	if current <= end {
+		return r.onDay((day + 6) % 7)
+	}
+	return false
+}
+
+// onDay reports whether the range starts on the given weekday
+func (r TimeRange) onDay(day time.Weekday) bool {
+	if len(r.Days) == 0 {
+		return true
+	}
+	for _, d := range r.Days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// TimezoneName returns the IANA zone TimeOfDay is evaluated in, given the
+// viewer's zone (which may be unknown). Falls back to UTC.
+func (t Targeting) TimezoneName(viewerTimezone string) string {
+	switch t.Timezone {
+	case "":
+		return "UTC"
+	case TimezoneViewer:
+		if viewerTimezone == "" {
+			return "UTC"
+		}
+		return viewerTimezone
+	default:
+		return t.Timezone
+	}
+}
+
+// ValidateTimezone checks that the targeting time zone is known
+func (t Targeting) ValidateTimezone() error {
+	if t.Timezone == "" || t.Timezone == TimezoneViewer {
+		return nil
+	}
+	if _, err := time.LoadLocation(t.Timezone); err != nil {
+		return ErrInvalidTimezone
+	}
+	return nil
+}
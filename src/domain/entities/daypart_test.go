@@ -0,0 +1,77 @@
+package entities_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+)
+
+func clock(hour, minute int) time.Time {
+	return time.Date(0, 1, 1, hour, minute, 0, 0, time.UTC)
+}
+
+// 2024-01-05 is a Friday
+func friday(hour, minute int) time.Time {
+	return time.Date(2024, 1, 5, hour, minute, 0, 0, time.UTC)
+}
+
+func TestTimeRange_Contains(t *testing.T) {
+	office := entities.TimeRange{Start: clock(9, 0), End: clock(17, 0)}
+	overnight := entities.TimeRange{Start: clock(22, 0), End: clock(2, 0)}
+	fridayNight := entities.TimeRange{Start: clock(22, 0), End: clock(2, 0), Days: []time.Weekday{time.Friday}}
+	weekdays := entities.TimeRange{
+		Start: clock(9, 0), End: clock(17, 0),
+		Days: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+	}
+
+	tests := []struct {
+		name     string
+		r        entities.TimeRange
+		at       time.Time
+		expected bool
+	}{
+		{"inside daytime range", office, friday(12, 0), true},
+		{"end is inclusive", office, friday(17, 0), true},
+		{"outside daytime range", office, friday(18, 0), false},
+		{"overnight before midnight", overnight, friday(23, 30), true},
+		{"overnight after midnight", overnight, friday(1, 0), true},
+		{"overnight gap", overnight, friday(12, 0), false},
+		{"day of week matches", weekdays, friday(10, 0), true},
+		{"day of week excluded", weekdays, friday(10, 0).AddDate(0, 0, 1), false},
+		{"overnight morning belongs to the start day", fridayNight, friday(1, 0).AddDate(0, 0, 1), true},
+		{"overnight morning of another start day", fridayNight, friday(1, 0), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.r.Contains(tt.at); got != tt.expected {
+				t.Errorf("Expected %v at %s, got %v", tt.expected, tt.at.Format("Mon 15:04"), got)
+			}
+		})
+	}
+}
+
+func TestTargeting_TimezoneName(t *testing.T) {
+	if got := (entities.Targeting{}).TimezoneName("Europe/Paris"); got != "UTC" {
+		t.Errorf("Expected UTC without a zone, got %s", got)
+	}
+	if got := (entities.Targeting{Timezone: "America/New_York"}).TimezoneName("Europe/Paris"); got != "America/New_York" {
+		t.Errorf("Expected campaign zone, got %s", got)
+	}
+	if got := (entities.Targeting{Timezone: entities.TimezoneViewer}).TimezoneName("Europe/Paris"); got != "Europe/Paris" {
+		t.Errorf("Expected viewer zone, got %s", got)
+	}
+	if got := (entities.Targeting{Timezone: entities.TimezoneViewer}).TimezoneName(""); got != "UTC" {
+		t.Errorf("Expected UTC for unknown viewer zone, got %s", got)
+	}
+}
+
+func TestTargeting_ValidateTimezone(t *testing.T) {
+	if err := (entities.Targeting{Timezone: "Asia/Tokyo"}).ValidateTimezone(); err != nil {
+		t.Errorf("Expected valid zone, got %v", err)
+	}
+	if err := (entities.Targeting{Timezone: "Mars/Olympus"}).ValidateTimezone(); err != entities.ErrInvalidTimezone {
+		t.Errorf("Expected ErrInvalidTimezone, got %v", err)
+	}
+}
@@ -46,7 +46,7 @@ func TestDemoBanner_HasContent(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			b := &DemoBanner{HTML: tt.html, ImageURL: tt.imageURL}
+			b := &DemoBanner{HTML: optionalString(tt.html), ImageURL: optionalString(tt.imageURL)}
 			if got := b.HasContent(); got != tt.want {
 				t.Errorf("DemoBanner.HasContent() = %v, want %v", got, tt.want)
 			}
@@ -143,7 +143,7 @@ func TestNewDemoBanner(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := NewDemoBanner(tt.bannerName, tt.format, tt.width, tt.height, tt.html, tt.imageURL, tt.clickURL)
+			got, err := NewDemoBanner(tt.bannerName, tt.format, tt.width, tt.height, optionalString(tt.html), optionalString(tt.imageURL), optionalString(tt.clickURL))
 			if err != tt.wantErr {
 				t.Errorf("%s: NewDemoBanner() error = %v, wantErr %v", tt.description, err, tt.wantErr)
 				return
@@ -278,3 +278,11 @@ func TestDemoSlot_HasBanner(t *testing.T) {
 		})
 	}
 }
+
+// optionalString maps empty test strings to nil, as the demo service does
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
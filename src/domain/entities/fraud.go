@@ -0,0 +1,52 @@
+package entities
+
+// Rules of invalid traffic scoring, as stored in the fraud reasons of
+// impressions and clicks
+const (
+	FraudBotUserAgent   = "bot_user_agent"  // Known bot, crawler or non-browser client
+	FraudDatacenterIP   = "datacenter_ip"   // Address of a hosting or cloud provider
+	FraudIPVelocity     = "ip_velocity"     // Too many events from one address
+	FraudFastClick      = "fast_click"      // Click too soon after the ad was served
+	FraudMissingReferer = "missing_referer" // No Referer header
+)
+
+// FraudVerdict is the invalid traffic score of an impression or click
+type FraudVerdict struct {
+	Score   float64  // From 0 for clean traffic to 1
+	Reasons []string // Rules the event matched
+	Invalid bool     // Score reached the threshold of invalid traffic
+}
+
+// Apply records the verdict on an impression
+func (v FraudVerdict) Apply(impression *Impression) {
+	impression.FraudScore = v.Score
+	impression.FraudReasons = v.Reasons
+	impression.Invalid = v.Invalid
+}
+
+// ApplyClick records the verdict on a click
+func (v FraudVerdict) ApplyClick(click *Click) {
+	click.FraudScore = v.Score
+	click.FraudReasons = v.Reasons
+	click.Invalid = v.Invalid
+}
+
+// InvalidTrafficReport counts a campaign's impressions and clicks over a
+// period, with the invalid ones by the rules they matched
+type InvalidTrafficReport struct {
+	CampaignID         string           `json:"campaign_id"`
+	Impressions        int64            `json:"impressions"`
+	InvalidImpressions int64            `json:"invalid_impressions"`
+	Clicks             int64            `json:"clicks"`
+	InvalidClicks      int64            `json:"invalid_clicks"`
+	Reasons            map[string]int64 `json:"reasons"` // Invalid impressions and clicks per rule
+}
+
+// InvalidRate is the share of invalid events among all events
+func (r *InvalidTrafficReport) InvalidRate() float64 {
+	total := r.Impressions + r.Clicks
+	if total == 0 {
+		return 0
+	}
+	return float64(r.InvalidImpressions+r.InvalidClicks) / float64(total)
+}
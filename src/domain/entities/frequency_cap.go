@@ -0,0 +1,52 @@
+package entities
+
+import "time"
+
+// FrequencyPeriod is the window a frequency cap is counted over
+type FrequencyPeriod string
+
+const (
+	FrequencyPerHour FrequencyPeriod = "hour"
+	FrequencyPerDay  FrequencyPeriod = "day"
+	FrequencyPerWeek FrequencyPeriod = "week"
+)
+
+// FrequencyCap limits how many times one viewer is shown a campaign or banner
+// within a period, e.g. {Limit: 3, Period: "day"}.
+// The window starts with the viewer's first exposure.
+type FrequencyCap struct {
+	Limit  int             `json:"limit"`
+	Period FrequencyPeriod `json:"period"`
+}
+
+// Duration returns the length of the period (zero for unknown periods)
+func (p FrequencyPeriod) Duration() time.Duration {
+	switch p {
+	case FrequencyPerHour:
+		return time.Hour
+	case FrequencyPerDay:
+		return 24 * time.Hour
+	case FrequencyPerWeek:
+		return 7 * 24 * time.Hour
+	default:
+		return 0
+	}
+}
+
+// Validate validates the frequency cap
+func (f FrequencyCap) Validate() error {
+	if f.Limit <= 0 || f.Period.Duration() == 0 {
+		return ErrInvalidFrequencyCap
+	}
+	return nil
+}
+
+// ValidateFrequencyCaps validates a list of frequency caps
+func ValidateFrequencyCaps(caps []FrequencyCap) error {
+	for _, c := range caps {
+		if err := c.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
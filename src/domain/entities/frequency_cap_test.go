@@ -0,0 +1,59 @@
+package entities
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFrequencyPeriod_Duration(t *testing.T) {
+	tests := []struct {
+		period   FrequencyPeriod
+		expected time.Duration
+	}{
+		{FrequencyPerHour, time.Hour},
+		{FrequencyPerDay, 24 * time.Hour},
+		{FrequencyPerWeek, 7 * 24 * time.Hour},
+		{FrequencyPeriod("month"), 0},
+	}
+
+	for _, tt := range tests {
+		if got := tt.period.Duration(); got != tt.expected {
+			t.Errorf("Expected %s to last %v, got %v", tt.period, tt.expected, got)
+		}
+	}
+}
+
+func TestFrequencyCap_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cap     FrequencyCap
+		wantErr bool
+	}{
+		{"valid daily cap", FrequencyCap{Limit: 3, Period: FrequencyPerDay}, false},
+		{"zero limit", FrequencyCap{Limit: 0, Period: FrequencyPerDay}, true},
+		{"unknown period", FrequencyCap{Limit: 1, Period: "fortnight"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cap.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error: %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateFrequencyCaps(t *testing.T) {
+	caps := []FrequencyCap{
+		{Limit: 1, Period: FrequencyPerHour},
+		{Limit: -1, Period: FrequencyPerDay},
+	}
+
+	if err := ValidateFrequencyCaps(caps); err != ErrInvalidFrequencyCap {
+		t.Errorf("Expected ErrInvalidFrequencyCap, got %v", err)
+	}
+	if err := ValidateFrequencyCaps(nil); err != nil {
+		t.Errorf("Expected no error for no caps, got %v", err)
+	}
+}
@@ -1,6 +1,10 @@
 package entities
 
-import "time"
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
 
 // Impression represents a banner impression
 type Impression struct {
@@ -15,6 +19,16 @@ type Impression struct {
 	Country    string
 	Device     string
 	FraudScore float64
+
+	// Invalid traffic is stored but not billed; FraudReasons are the rules it matched
+	Invalid      bool
+	FraudReasons []string
+
+	// Pricing and Bid of the campaign when the ad was served, if known. They
+	// are not stored: they let billing price the impression's events without
+	// loading the campaign.
+	Pricing PricingModel
+	Bid     decimal.Decimal
 }
 
 // NewImpression creates a new impression
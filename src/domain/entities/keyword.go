@@ -0,0 +1,87 @@
+package entities
+
+import (
+	"net/url"
+	"strings"
+	"unicode"
+)
+
+// minPathKeywordLength drops short URL path tokens such as "a", "id" or "en"
+const minPathKeywordLength = 3
+
+// pathStopwords are URL path tokens that say nothing about the page content
+var pathStopwords = map[string]bool{
+	"html": true, "htm": true, "php": true, "asp": true, "aspx": true, "jsp": true,
+	"index": true, "amp": true, "www": true,
+}
+
+// MatchesKeywords checks page keywords against the include and exclude sets.
+// A page without keywords only passes the exclude set.
+func (t Targeting) MatchesKeywords(keywords []string) bool {
+	if hasAnyKeyword(t.ExcludedKeywords, keywords) {
+		return false
+	}
+	return len(t.Keywords) == 0 || hasAnyKeyword(t.Keywords, keywords)
+}
+
+// NormalizeKeywords lowercases and trims keywords, splitting comma-separated
+// values and dropping empty entries and duplicates
+func NormalizeKeywords(raw []string) []string {
+	var keywords []string
+	seen := make(map[string]bool)
+
+	for _, value := range raw {
+		for _, k := range strings.Split(value, ",") {
+			k = strings.ToLower(strings.TrimSpace(k))
+			if k == "" || seen[k] {
+				continue
+			}
+			seen[k] = true
+			keywords = append(keywords, k)
+		}
+	}
+	return keywords
+}
+
+// KeywordsFromURL derives keywords from the words of a page URL path,
+// e.g. "/sports/football-news/2024" yields sports, football and news
+func KeywordsFromURL(pageURL string) []string {
+	page, err := url.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
+
+	words := strings.FieldsFunc(page.Path, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var keywords []string
+	for _, w := range words {
+		if len(w) < minPathKeywordLength || isNumeric(w) || pathStopwords[strings.ToLower(w)] {
+			continue
+		}
+		keywords = append(keywords, w)
+	}
+	return NormalizeKeywords(keywords)
+}
+
+// hasAnyKeyword reports whether any page keyword is in the campaign set
+func hasAnyKeyword(set, keywords []string) bool {
+	for _, k := range keywords {
+		for _, s := range set {
+			if strings.EqualFold(strings.TrimSpace(s), k) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isNumeric(s string) bool {
+	for _, r := range s {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,49 @@
+package entities_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+)
+
+func TestNormalizeKeywords(t *testing.T) {
+	got := entities.NormalizeKeywords([]string{"Sports, Football", "football", " ", "travel"})
+	expected := []string{"sports", "football", "travel"}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestKeywordsFromURL(t *testing.T) {
+	got := entities.KeywordsFromURL("https://example.com/en/Sports/football-news/2024/index.html?id=7")
+	expected := []string{"sports", "football", "news"}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+	if got := entities.KeywordsFromURL(""); len(got) != 0 {
+		t.Errorf("Expected no keywords for empty URL, got %v", got)
+	}
+}
+
+func TestTargeting_MatchesKeywords(t *testing.T) {
+	targeting := entities.Targeting{Keywords: []string{"Football", "tennis"}, ExcludedKeywords: []string{"tragedy"}}
+
+	if !targeting.MatchesKeywords([]string{"sports", "football"}) {
+		t.Errorf("Expected overlap with the include set to match")
+	}
+	if targeting.MatchesKeywords([]string{"football", "tragedy"}) {
+		t.Errorf("Expected excluded keyword to block the page")
+	}
+	if targeting.MatchesKeywords([]string{"cooking"}) {
+		t.Errorf("Expected page without included keywords not to match")
+	}
+	if targeting.MatchesKeywords(nil) {
+		t.Errorf("Expected page without keywords not to match an include set")
+	}
+	if !(entities.Targeting{ExcludedKeywords: []string{"tragedy"}}).MatchesKeywords(nil) {
+		t.Errorf("Expected page without keywords to pass an exclude set")
+	}
+}
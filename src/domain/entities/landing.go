@@ -0,0 +1,94 @@
+package entities
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrUnsafeRedirect is returned for landing URLs that would redirect somewhere
+// else than the advertiser's site
+var ErrUnsafeRedirect = errors.New("unsafe redirect URL")
+
+// Macros expanded in banner click URLs when a click is redirected
+const (
+	MacroClickID      = "{click_id}"
+	MacroImpressionID = "{impression_id}"
+	MacroCampaignID   = "{campaign_id}"
+	MacroBannerID     = "{banner_id}"
+	MacroSlotID       = "{slot_id}"
+	MacroTimestamp    = "{timestamp}"   // Unix seconds of the click
+	MacroCacheBuster  = "{cachebuster}" // Unique per click
+)
+
+// ClickContext is what the macros of a landing URL expand to
+type ClickContext struct {
+	ClickID      string
+	ImpressionID string
+	CampaignID   string
+	BannerID     string
+	SlotID       string
+	Timestamp    time.Time
+	// UTMSource adds utm_source, utm_medium, utm_campaign and utm_content to
+	// landing URLs that don't set them; empty adds none
+	UTMSource string
+}
+
+// LandingURL expands the macros of a banner click URL. Expanded values are
+// query-escaped, and the result must stay an http(s) URL on the click URL's
+// host, so a slot ID or other request data can't turn the click tracker into
+// an open redirect.
+func LandingURL(clickURL string, ctx ClickContext) (string, error) {
+	clickURL = strings.TrimSpace(clickURL)
+	if !IsSafeURL(clickURL) {
+		return "", ErrUnsafeRedirect
+	}
+	template, _ := url.Parse(clickURL)
+
+	expanded := strings.NewReplacer(
+		MacroClickID, url.QueryEscape(ctx.ClickID),
+		MacroImpressionID, url.QueryEscape(ctx.ImpressionID),
+		MacroCampaignID, url.QueryEscape(ctx.CampaignID),
+		MacroBannerID, url.QueryEscape(ctx.BannerID),
+		MacroSlotID, url.QueryEscape(ctx.SlotID),
+		MacroTimestamp, strconv.FormatInt(ctx.Timestamp.Unix(), 10),
+		MacroCacheBuster, strconv.FormatInt(ctx.Timestamp.UnixNano(), 10),
+	).Replace(clickURL)
+
+	landing, err := url.Parse(expanded)
+	if err != nil || landing.Scheme != template.Scheme || landing.Host != template.Host || landing.User != nil {
+		return "", ErrUnsafeRedirect
+	}
+
+	if ctx.UTMSource != "" {
+		addUTM(landing, ctx)
+	}
+	return landing.String(), nil
+}
+
+// addUTM appends the UTM parameters the landing URL doesn't set. Existing
+// parameters are kept as they are.
+func addUTM(landing *url.URL, ctx ClickContext) {
+	existing := landing.Query()
+	utm := url.Values{}
+	for key, value := range map[string]string{
+		"utm_source":   ctx.UTMSource,
+		"utm_medium":   "display",
+		"utm_campaign": ctx.CampaignID,
+		"utm_content":  ctx.BannerID,
+	} {
+		if value != "" && !existing.Has(key) {
+			utm.Set(key, value)
+		}
+	}
+	if len(utm) == 0 {
+		return
+	}
+
+	if landing.RawQuery != "" {
+		landing.RawQuery += "&"
+	}
+	landing.RawQuery += utm.Encode()
+}
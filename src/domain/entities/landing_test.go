@@ -0,0 +1,78 @@
+package entities_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+)
+
+func TestLandingURL(t *testing.T) {
+	ctx := entities.ClickContext{
+		ClickID:      "clk-1",
+		ImpressionID: "imp-1",
+		CampaignID:   "cmp-1",
+		BannerID:     "ban-1",
+		SlotID:       "sidebar",
+		Timestamp:    time.Unix(1700000000, 5),
+	}
+
+	tests := []struct {
+		name      string
+		clickURL  string
+		utmSource string
+		expected  string
+		err       error
+	}{
+		{
+			name:     "macros",
+			clickURL: "https://shop.example.com/p/{campaign_id}?b={banner_id}&s={slot_id}&c={click_id}&t={timestamp}&cb={cachebuster}",
+			expected: "https://shop.example.com/p/cmp-1?b=ban-1&s=sidebar&c=clk-1&t=1700000000&cb=1700000000000000005",
+		},
+		{
+			name:      "utm added",
+			clickURL:  "https://shop.example.com/?ref=ad",
+			utmSource: "adserver",
+			expected:  "https://shop.example.com/?ref=ad&utm_campaign=cmp-1&utm_content=ban-1&utm_medium=display&utm_source=adserver",
+		},
+		{
+			name:      "utm kept",
+			clickURL:  "https://shop.example.com/?utm_source=newsletter&utm_medium=email&utm_campaign=spring&utm_content=hero",
+			utmSource: "adserver",
+			expected:  "https://shop.example.com/?utm_source=newsletter&utm_medium=email&utm_campaign=spring&utm_content=hero",
+		},
+		{name: "javascript", clickURL: "javascript:alert(1)", err: entities.ErrUnsafeRedirect},
+		{name: "relative", clickURL: "//evil.example.com", err: entities.ErrUnsafeRedirect},
+		{name: "empty", clickURL: "", err: entities.ErrUnsafeRedirect},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := ctx
+			c.UTMSource = tt.utmSource
+			result, err := entities.LandingURL(tt.clickURL, c)
+			if err != tt.err {
+				t.Fatalf("Expected error %v, got %v", tt.err, err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestLandingURL_EscapesMacroValues(t *testing.T) {
+	// A slot ID can't move the redirect to another host
+	landing, err := entities.LandingURL("https://shop.example.com/?s={slot_id}", entities.ClickContext{SlotID: "x@evil.example.com/#"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if landing != "https://shop.example.com/?s=x%40evil.example.com%2F%23" {
+		t.Errorf("Expected the slot ID escaped in the query, got %s", landing)
+	}
+
+	// Expanding into the host is rejected
+	if _, err := entities.LandingURL("https://{slot_id}example.com/", entities.ClickContext{SlotID: "evil."}); err != entities.ErrUnsafeRedirect {
+		t.Errorf("Expected macro in the host to be unsafe, got %v", err)
+	}
+}
@@ -0,0 +1,35 @@
+package entities
+
+import "strings"
+
+// NativeAssets are the parts of a native creative that publishers lay out in
+// their own templates
+type NativeAssets struct {
+	Title       string       `json:"title"`
+	Description string       `json:"description,omitempty"`
+	Image       *NativeImage `json:"image,omitempty"` // Main image
+	Icon        *NativeImage `json:"icon,omitempty"`  // Logo or brand icon
+	Sponsor     string       `json:"sponsor"`         // "Sponsored by" disclosure
+	CTA         string       `json:"cta,omitempty"`   // Call to action, e.g. "Shop now"
+}
+
+// NativeImage is an image asset of a native creative
+type NativeImage struct {
+	URL    string `json:"url"`
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
+}
+
+// Validate checks that the native creative has a title, a sponsor disclosure
+// and safe image URLs
+func (n *NativeAssets) Validate() error {
+	if strings.TrimSpace(n.Title) == "" || strings.TrimSpace(n.Sponsor) == "" {
+		return ErrInvalidContent
+	}
+	for _, img := range []*NativeImage{n.Image, n.Icon} {
+		if img != nil && (!IsSafeURL(img.URL) || img.Width < 0 || img.Height < 0) {
+			return ErrInvalidContent
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package entities
+
+import "github.com/shopspring/decimal"
+
+// PricingModel decides which events a campaign pays for
+type PricingModel string
+
+const (
+	PricingCPM  PricingModel = "cpm"  // Cost per mille: Bid per 1000 impressions
+	PricingCPC  PricingModel = "cpc"  // Cost per click: Bid per click
+	PricingCPA  PricingModel = "cpa"  // Cost per action: Bid per conversion
+	PricingFlat PricingModel = "flat" // Sponsorship: Bid is a flat fee, events are free
+)
+
+// EventType is a tracked event that may cost the advertiser money
+type EventType string
+
+const (
+	EventImpression EventType = "impression"
+	EventClick      EventType = "click"
+	EventConversion EventType = "conversion"
+)
+
+// IsValid checks if the pricing model is known
+func (p PricingModel) IsValid() bool {
+	switch p {
+	case PricingCPM, PricingCPC, PricingCPA, PricingFlat:
+		return true
+	default:
+		return false
+	}
+}
+
+// BilledEvent returns the event type the pricing model charges for
+// (empty for flat-rate sponsorships)
+func (p PricingModel) BilledEvent() EventType {
+	switch p {
+	case PricingCPM:
+		return EventImpression
+	case PricingCPC:
+		return EventClick
+	case PricingCPA:
+		return EventConversion
+	default:
+		return ""
+	}
+}
+
+// PricingModel returns the campaign's pricing model, defaulting to CPM
+func (c *Campaign) PricingModel() PricingModel {
+	if c.Pricing == "" {
+		return PricingCPM
+	}
+	return c.Pricing
+}
+
+// CostOf returns what a single event costs the campaign under its pricing model
+func (c *Campaign) CostOf(event EventType) decimal.Decimal {
+	return c.PricingModel().CostOf(event, c.Bid)
+}
+
+// CostOf returns what a single event costs at a bid under the pricing model
+func (p PricingModel) CostOf(event EventType, bid decimal.Decimal) decimal.Decimal {
+	if p.BilledEvent() != event {
+		return decimal.Zero
+	}
+	if p == PricingCPM {
+		return bid.Div(decimal.NewFromInt(1000))
+	}
+	return bid
+}
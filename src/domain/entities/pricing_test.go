@@ -0,0 +1,75 @@
+package entities_test
+
+import (
+	"testing"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/shopspring/decimal"
+)
+
+func TestCampaign_CostOf(t *testing.T) {
+	bid := decimal.NewFromInt(5)
+
+	tests := []struct {
+		pricing  entities.PricingModel
+		event    entities.EventType
+		expected decimal.Decimal
+	}{
+		{entities.PricingCPM, entities.EventImpression, decimal.NewFromFloat(0.005)},
+		{entities.PricingCPM, entities.EventClick, decimal.Zero},
+		{"", entities.EventImpression, decimal.NewFromFloat(0.005)}, // defaults to CPM
+		{entities.PricingCPC, entities.EventClick, bid},
+		{entities.PricingCPC, entities.EventImpression, decimal.Zero},
+		{entities.PricingCPA, entities.EventConversion, bid},
+		{entities.PricingCPA, entities.EventClick, decimal.Zero},
+		{entities.PricingFlat, entities.EventImpression, decimal.Zero},
+		{entities.PricingFlat, entities.EventClick, decimal.Zero},
+	}
+
+	for _, tt := range tests {
+		campaign := &entities.Campaign{Pricing: tt.pricing, Bid: bid}
+		if got := campaign.CostOf(tt.event); !got.Equal(tt.expected) {
+			t.Errorf("Expected %q %s to cost %s, got %s", tt.pricing, tt.event, tt.expected, got)
+		}
+	}
+}
+
+func TestPricingModel_IsValid(t *testing.T) {
+	for _, model := range []entities.PricingModel{entities.PricingCPM, entities.PricingCPC, entities.PricingCPA, entities.PricingFlat} {
+		if !model.IsValid() {
+			t.Errorf("Expected %s to be valid", model)
+		}
+	}
+	if entities.PricingModel("cpv").IsValid() {
+		t.Errorf("Expected unknown pricing model to be invalid")
+	}
+}
+
+func TestBillableEvent_Price(t *testing.T) {
+	campaign := &entities.Campaign{ID: "cmp-1", Pricing: entities.PricingCPC, Bid: decimal.NewFromFloat(0.4)}
+	impression := &entities.Impression{ID: "imp-1", BannerID: "ban-1", CampaignID: "cmp-1"}
+
+	event := entities.NewBillableEvent(entities.EventClick, impression)
+	event.Price(campaign)
+
+	if event.ImpressionID != "imp-1" || event.BannerID != "ban-1" || event.CampaignID != "cmp-1" {
+		t.Errorf("Expected event to reference the impression, got %+v", event)
+	}
+	if event.Pricing != entities.PricingCPC || !event.Cost.Equal(decimal.NewFromFloat(0.4)) {
+		t.Errorf("Expected CPC cost 0.4, got %s %s", event.Pricing, event.Cost)
+	}
+}
+
+func TestBillableEvent_Price_ClearingPrice(t *testing.T) {
+	campaign := &entities.Campaign{ID: "cmp-1", Pricing: entities.PricingCPM, Bid: decimal.NewFromInt(5)}
+	impression := &entities.Impression{ID: "imp-1", BannerID: "ban-1", CampaignID: "cmp-1"}
+
+	event := entities.NewBillableEvent(entities.EventImpression, impression)
+	event.ClearingPrice = decimal.NewFromFloat(2.5)
+	event.Price(campaign)
+
+	// Auctions are billed at the clearing CPM rather than the bid
+	if !event.Cost.Equal(decimal.NewFromFloat(0.0025)) {
+		t.Errorf("Expected clearing price cost 0.0025, got %s", event.Cost)
+	}
+}
@@ -0,0 +1,82 @@
+package entities
+
+import "github.com/shopspring/decimal"
+
+// PriorityTier orders campaigns before any price comparison
+type PriorityTier string
+
+const (
+	PrioritySponsorship PriorityTier = "sponsorship" // Guaranteed deals, always served first
+	PriorityStandard    PriorityTier = "standard"    // Paid campaigns competing on eCPM
+	PriorityHouse       PriorityTier = "house"       // House ads and remnant backfill
+)
+
+// Priors for campaigns without enough history: rates are smoothed towards
+// these values as if the campaign had already served priorImpressions
+const (
+	priorCTR         = 0.001
+	priorCVR         = 0.0001
+	priorImpressions = 1000
+)
+
+// CampaignStats is a campaign's recent delivery history
+type CampaignStats struct {
+	Impressions int64 `json:"impressions"`
+	Clicks      int64 `json:"clicks"`
+	Conversions int64 `json:"conversions"`
+}
+
+// CTR returns the smoothed click-through rate
+func (s CampaignStats) CTR() float64 {
+	return smoothedRate(s.Clicks, s.Impressions, priorCTR)
+}
+
+// CVR returns the smoothed conversions per impression
+func (s CampaignStats) CVR() float64 {
+	return smoothedRate(s.Conversions, s.Impressions, priorCVR)
+}
+
+func smoothedRate(events, impressions int64, prior float64) float64 {
+	return (float64(events) + prior*priorImpressions) / float64(impressions+priorImpressions)
+}
+
+// Rank returns the tier's precedence (higher serves first)
+func (p PriorityTier) Rank() int {
+	switch p {
+	case PrioritySponsorship:
+		return 3
+	case PriorityHouse:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// IsValid checks if the priority tier is known
+func (p PriorityTier) IsValid() bool {
+	return p == PrioritySponsorship || p == PriorityStandard || p == PriorityHouse
+}
+
+// PriorityTier returns the campaign's tier, defaulting to standard
+func (c *Campaign) PriorityTier() PriorityTier {
+	if c.Priority == "" {
+		return PriorityStandard
+	}
+	return c.Priority
+}
+
+// ECPM returns the expected revenue per thousand impressions, estimating
+// click and conversion rates from the campaign's history.
+// Flat-rate campaigns have no per-impression value and rank on tier alone.
+func (c *Campaign) ECPM(stats CampaignStats) decimal.Decimal {
+	switch c.PricingModel() {
+	case PricingCPM:
+		return c.Bid
+	case PricingCPC:
+		return c.Bid.Mul(decimal.NewFromFloat(stats.CTR() * 1000))
+	case PricingCPA:
+		return c.Bid.Mul(decimal.NewFromFloat(stats.CVR() * 1000))
+	default:
+		return decimal.Zero
+	}
+}
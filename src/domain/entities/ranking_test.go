@@ -0,0 +1,55 @@
+package entities_test
+
+import (
+	"testing"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/shopspring/decimal"
+)
+
+func TestPriorityTier_Rank(t *testing.T) {
+	sponsorship := entities.PrioritySponsorship.Rank()
+	standard := entities.PriorityStandard.Rank()
+	house := entities.PriorityHouse.Rank()
+
+	if !(sponsorship > standard && standard > house) {
+		t.Errorf("Expected sponsorship > standard > house, got %d, %d, %d", sponsorship, standard, house)
+	}
+	if (&entities.Campaign{}).PriorityTier() != entities.PriorityStandard {
+		t.Errorf("Expected campaigns to default to the standard tier")
+	}
+}
+
+func TestCampaignStats_CTR_SmoothedTowardsPrior(t *testing.T) {
+	if ctr := (entities.CampaignStats{}).CTR(); ctr != 0.001 {
+		t.Errorf("Expected prior CTR 0.001 without history, got %f", ctr)
+	}
+
+	stats := entities.CampaignStats{Impressions: 99000, Clicks: 1999}
+	if ctr := stats.CTR(); ctr != 0.02 {
+		t.Errorf("Expected CTR 0.02 with enough history, got %f", ctr)
+	}
+}
+
+func TestCampaign_ECPM(t *testing.T) {
+	stats := entities.CampaignStats{Impressions: 99000, Clicks: 1999, Conversions: 99}
+
+	tests := []struct {
+		name     string
+		campaign entities.Campaign
+		expected decimal.Decimal
+	}{
+		{"cpm uses the bid", entities.Campaign{Pricing: entities.PricingCPM, Bid: decimal.NewFromInt(3)}, decimal.NewFromInt(3)},
+		{"cpc uses bid times ctr", entities.Campaign{Pricing: entities.PricingCPC, Bid: decimal.NewFromFloat(0.5)}, decimal.NewFromInt(10)},
+		{"cpa uses bid times cvr", entities.Campaign{Pricing: entities.PricingCPA, Bid: decimal.NewFromInt(20)}, decimal.NewFromFloat(19.82)},
+		{"flat has no eCPM", entities.Campaign{Pricing: entities.PricingFlat, Bid: decimal.NewFromInt(5000)}, decimal.Zero},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.campaign.ECPM(stats); !got.Round(6).Equal(tt.expected) {
+				t.Errorf("Expected eCPM %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
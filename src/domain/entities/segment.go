@@ -0,0 +1,81 @@
+package entities
+
+import "time"
+
+const (
+	// DefaultSegmentTTL is how long a viewer stays in a segment unless configured otherwise
+	DefaultSegmentTTL = 30 * 24 * time.Hour
+	// MaxSegmentTTL caps segment memberships
+	MaxSegmentTTL = 180 * 24 * time.Hour
+)
+
+// Segment is an advertiser's audience list, e.g. visitors of a product page.
+// Viewers join it through the segment pixel and leave after MembershipTTL.
+type Segment struct {
+	ID            string
+	AdvertiserID  string
+	Name          string
+	MembershipTTL time.Duration
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// NewSegment creates a new audience segment with validation.
+// A zero ttl uses DefaultSegmentTTL.
+func NewSegment(advertiserID, name string, ttl time.Duration) (*Segment, error) {
+	if ttl == 0 {
+		ttl = DefaultSegmentTTL
+	}
+
+	now := time.Now()
+	segment := &Segment{
+		ID:            generateUUID(),
+		AdvertiserID:  advertiserID,
+		Name:          name,
+		MembershipTTL: ttl,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	if err := segment.Validate(); err != nil {
+		return nil, err
+	}
+
+	return segment, nil
+}
+
+// Validate checks if the segment is valid
+func (s *Segment) Validate() error {
+	if s.Name == "" || len(s.Name) > 255 {
+		return ErrInvalidName
+	}
+	if s.MembershipTTL < time.Second || s.MembershipTTL > MaxSegmentTTL {
+		return ErrInvalidSegmentTTL
+	}
+	return nil
+}
+
+// MatchesSegments checks the viewer's segments against the include and exclude sets
+func (t Targeting) MatchesSegments(segments []string) bool {
+	if containsAny(t.ExcludedSegments, segments) {
+		return false
+	}
+	return len(t.Segments) == 0 || containsAny(t.Segments, segments)
+}
+
+// HasSegmentTargeting reports whether the targeting depends on the viewer's segments
+func (t Targeting) HasSegmentTargeting() bool {
+	return len(t.Segments) > 0 || len(t.ExcludedSegments) > 0
+}
+
+// containsAny reports whether any value is in the set
+func containsAny(set, values []string) bool {
+	for _, v := range values {
+		for _, s := range set {
+			if s == v {
+				return true
+			}
+		}
+	}
+	return false
+}
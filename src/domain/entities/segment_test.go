@@ -0,0 +1,42 @@
+package entities_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+)
+
+func TestNewSegment(t *testing.T) {
+	segment, err := entities.NewSegment("adv-1", "Cart abandoners", 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if segment.ID == "" || segment.MembershipTTL != entities.DefaultSegmentTTL {
+		t.Errorf("Expected ID and default TTL, got %+v", segment)
+	}
+
+	if _, err := entities.NewSegment("adv-1", "", time.Hour); err != entities.ErrInvalidName {
+		t.Errorf("Expected ErrInvalidName, got %v", err)
+	}
+	if _, err := entities.NewSegment("adv-1", "Forever", 365*24*time.Hour); err != entities.ErrInvalidSegmentTTL {
+		t.Errorf("Expected ErrInvalidSegmentTTL, got %v", err)
+	}
+}
+
+func TestTargeting_MatchesSegments(t *testing.T) {
+	targeting := entities.Targeting{Segments: []string{"visitors"}, ExcludedSegments: []string{"buyers"}}
+
+	if !targeting.MatchesSegments([]string{"visitors"}) {
+		t.Errorf("Expected segment member to match")
+	}
+	if targeting.MatchesSegments([]string{"visitors", "buyers"}) {
+		t.Errorf("Expected excluded segment member not to match")
+	}
+	if targeting.MatchesSegments(nil) {
+		t.Errorf("Expected viewer outside the segments not to match")
+	}
+	if !(entities.Targeting{ExcludedSegments: []string{"buyers"}}).MatchesSegments(nil) {
+		t.Errorf("Expected viewer outside excluded segments to match")
+	}
+}
@@ -0,0 +1,76 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ServedAdStatus is how far a served ad got after delivery
+type ServedAdStatus string
+
+const (
+	ServedAdPending  ServedAdStatus = "served"   // Delivered, rendering not confirmed yet
+	ServedAdRendered ServedAdStatus = "rendered" // Confirmed by a tracking pixel or billing notice
+)
+
+// ServedAd is the ledger entry written for every ad delivered. Its ID is the
+// impression ID handed out in tracking URLs, so impressions, clicks and
+// auction notices resolve to the banner, campaign and slot recorded at
+// delivery instead of trusting the client.
+type ServedAd struct {
+	ID            string
+	BannerID      string
+	CampaignID    string
+	SlotID        string
+	Status        ServedAdStatus
+	Price         decimal.Decimal // eCPM the campaign was selected at; the bid in auctions
+	Pricing       PricingModel    // Campaign's pricing model and bid at delivery, billed at
+	Bid           decimal.Decimal
+	ClearingPrice decimal.Decimal // CPM an exchange cleared a won bid at; zero otherwise
+	Auction       bool            // Bid to an exchange; only bids accept win and billing notices
+	UserID        string
+	IP            string
+	UserAgent     string
+	Referer       string
+	Country       string
+	Device        string
+	ServedAt      time.Time
+	WonAt         *time.Time
+	RenderedAt    *time.Time
+}
+
+// NewServedAd creates a pending ledger entry with a new impression ID
+func NewServedAd(bannerID, campaignID, slotID string) *ServedAd {
+	return &ServedAd{
+		ID:         generateImpressionID(),
+		BannerID:   bannerID,
+		CampaignID: campaignID,
+		SlotID:     slotID,
+		Status:     ServedAdPending,
+		ServedAt:   time.Now(),
+	}
+}
+
+// IsRendered reports whether the ad was confirmed as rendered
+func (a *ServedAd) IsRendered() bool {
+	return a.Status == ServedAdRendered
+}
+
+// Impression describes the served ad as an impression
+func (a *ServedAd) Impression() *Impression {
+	return &Impression{
+		ID:         a.ID,
+		BannerID:   a.BannerID,
+		SlotID:     a.SlotID,
+		CampaignID: a.CampaignID,
+		Timestamp:  a.ServedAt,
+		IP:         a.IP,
+		UserAgent:  a.UserAgent,
+		Referer:    a.Referer,
+		Country:    a.Country,
+		Device:     a.Device,
+		Pricing:    a.Pricing,
+		Bid:        a.Bid,
+	}
+}
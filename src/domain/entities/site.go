@@ -0,0 +1,120 @@
+package entities
+
+import (
+	"net/url"
+	"strings"
+)
+
+// HasPageTargeting reports whether the targeting restricts the pages an ad may run on
+func (t Targeting) HasPageTargeting() bool {
+	return len(t.Domains) > 0 || len(t.ExcludedDomains) > 0 || len(t.URLs) > 0 || len(t.ExcludedURLs) > 0
+}
+
+// MatchesPage checks the domain and URL lists against the page an ad would run on.
+// A page that is unknown or not an absolute URL only passes exclusion lists.
+func (t Targeting) MatchesPage(pageURL string) bool {
+	if !t.HasPageTargeting() {
+		return true
+	}
+
+	page, err := url.Parse(pageURL)
+	if err != nil || page.Host == "" {
+		return len(t.Domains) == 0 && len(t.URLs) == 0
+	}
+	host := strings.ToLower(page.Hostname())
+
+	if matchesAnyDomain(t.ExcludedDomains, host) || matchesAnyURL(t.ExcludedURLs, host, page) {
+		return false
+	}
+	if len(t.Domains) > 0 && !matchesAnyDomain(t.Domains, host) {
+		return false
+	}
+	if len(t.URLs) > 0 && !matchesAnyURL(t.URLs, host, page) {
+		return false
+	}
+	return true
+}
+
+// MatchDomain matches a host against a domain pattern. A plain domain matches
+// itself and its subdomains; a "*." prefix matches subdomains only.
+func MatchDomain(pattern, host string) bool {
+	pattern = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(pattern), "."))
+	host = strings.TrimSuffix(host, ".")
+	if pattern == "" || host == "" {
+		return false
+	}
+
+	if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+		return strings.HasSuffix(host, "."+suffix)
+	}
+	return host == pattern || strings.HasSuffix(host, "."+pattern)
+}
+
+// MatchURL matches a page against a URL pattern with "*" wildcards. Patterns
+// starting with "/" are matched against the path only; others name a host and
+// an optional path, e.g. "*.example.com/news/*". Hosts compare case-insensitively.
+func MatchURL(pattern, host string, page *url.URL) bool {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return false
+	}
+
+	path := page.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if strings.HasPrefix(pattern, "/") {
+		return matchWildcard(pattern, path)
+	}
+
+	// Scheme is irrelevant for page targeting
+	if i := strings.Index(pattern, "://"); i >= 0 {
+		pattern = pattern[i+3:]
+	}
+	hostPattern, pathPattern, hasPath := strings.Cut(pattern, "/")
+	if !matchWildcard(strings.ToLower(hostPattern), host) {
+		return false
+	}
+	return !hasPath || matchWildcard("/"+pathPattern, path)
+}
+
+func matchesAnyDomain(patterns []string, host string) bool {
+	for _, p := range patterns {
+		if MatchDomain(p, host) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAnyURL(patterns []string, host string, page *url.URL) bool {
+	for _, p := range patterns {
+		if MatchURL(p, host, page) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchWildcard matches s against a pattern where "*" matches any run of characters
+func matchWildcard(pattern, s string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == s
+	}
+
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(s, part)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(part):]
+	}
+	return strings.HasSuffix(s, last)
+}
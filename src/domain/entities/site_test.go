@@ -0,0 +1,59 @@
+package entities_test
+
+import (
+	"testing"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+)
+
+func TestMatchDomain(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		host     string
+		expected bool
+	}{
+		{"example.com", "example.com", true},
+		{"example.com", "news.example.com", true},
+		{"Example.COM", "example.com", true},
+		{"example.com", "badexample.com", false},
+		{"*.example.com", "news.example.com", true},
+		{"*.example.com", "example.com", false},
+		{"news.example.com", "example.com", false},
+	}
+
+	for _, tt := range tests {
+		if got := entities.MatchDomain(tt.pattern, tt.host); got != tt.expected {
+			t.Errorf("MatchDomain(%q, %q): expected %v, got %v", tt.pattern, tt.host, tt.expected, got)
+		}
+	}
+}
+
+func TestTargeting_MatchesPage(t *testing.T) {
+	tests := []struct {
+		name      string
+		targeting entities.Targeting
+		page      string
+		expected  bool
+	}{
+		{"no page targeting", entities.Targeting{}, "", true},
+		{"included domain", entities.Targeting{Domains: []string{"example.com"}}, "https://www.example.com/a", true},
+		{"other domain", entities.Targeting{Domains: []string{"example.com"}}, "https://other.org/", false},
+		{"unknown page fails include list", entities.Targeting{Domains: []string{"example.com"}}, "", false},
+		{"excluded subdomain", entities.Targeting{Domains: []string{"example.com"}, ExcludedDomains: []string{"forum.example.com"}}, "https://forum.example.com/t/1", false},
+		{"unknown page passes exclude list", entities.Targeting{ExcludedDomains: []string{"example.com"}}, "", true},
+		{"path pattern", entities.Targeting{URLs: []string{"/sports/*"}}, "https://example.com/sports/football/today", true},
+		{"path pattern miss", entities.Targeting{URLs: []string{"/sports/*"}}, "https://example.com/news/", false},
+		{"host and path pattern", entities.Targeting{URLs: []string{"*.example.com/news/*"}}, "https://EU.example.com/news/1?ref=x", true},
+		{"host and path pattern on other host", entities.Targeting{URLs: []string{"*.example.com/news/*"}}, "https://example.org/news/1", false},
+		{"excluded url", entities.Targeting{ExcludedURLs: []string{"/*/comments"}}, "https://example.com/post/comments", false},
+		{"middle wildcard", entities.Targeting{URLs: []string{"/shop/*/sale/*"}}, "https://example.com/shop/shoes/sale/42", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.targeting.MatchesPage(tt.page); got != tt.expected {
+				t.Errorf("Expected %v for %q, got %v", tt.expected, tt.page, got)
+			}
+		})
+	}
+}
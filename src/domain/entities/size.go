@@ -0,0 +1,74 @@
+package entities
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Size is a creative or slot size in CSS pixels
+type Size struct {
+	Width  int
+	Height int
+}
+
+// ParseSize parses a size written as "300x250"
+func ParseSize(s string) (Size, error) {
+	w, h, ok := strings.Cut(strings.ToLower(strings.TrimSpace(s)), "x")
+	if !ok {
+		return Size{}, ErrInvalidDimensions
+	}
+	width, err := strconv.Atoi(w)
+	if err != nil {
+		return Size{}, ErrInvalidDimensions
+	}
+	height, err := strconv.Atoi(h)
+	if err != nil {
+		return Size{}, ErrInvalidDimensions
+	}
+
+	size := Size{Width: width, Height: height}
+	if size.Width <= 0 || size.Height <= 0 {
+		return Size{}, ErrInvalidDimensions
+	}
+	return size, nil
+}
+
+// String formats the size as "300x250"
+func (s Size) String() string {
+	return fmt.Sprintf("%dx%d", s.Width, s.Height)
+}
+
+// IsResponsive reports whether a creative scales to any slot (0x0)
+func (s Size) IsResponsive() bool {
+	return s.Width == 0 && s.Height == 0
+}
+
+// Fits reports whether a creative of this size can fill the slot. Responsive
+// creatives fit everywhere. A slot with both dimensions needs an exact match;
+// a slot with only one dimension is responsive along the other and takes
+// creatives up to the given one.
+func (s Size) Fits(slot Size) bool {
+	switch {
+	case s.IsResponsive():
+		return true
+	case slot.Width > 0 && slot.Height > 0:
+		return s == slot
+	default:
+		return (slot.Width == 0 || s.Width <= slot.Width) && (slot.Height == 0 || s.Height <= slot.Height)
+	}
+}
+
+// FitsAny reports whether the creative fits one of the sizes of a multi-size
+// slot. A slot without sizes accepts any creative.
+func (s Size) FitsAny(slots []Size) bool {
+	if len(slots) == 0 {
+		return true
+	}
+	for _, slot := range slots {
+		if s.Fits(slot) {
+			return true
+		}
+	}
+	return false
+}
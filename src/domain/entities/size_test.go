@@ -0,0 +1,57 @@
+package entities_test
+
+import (
+	"testing"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected entities.Size
+		valid    bool
+	}{
+		{"300x250", entities.Size{Width: 300, Height: 250}, true},
+		{" 728X90 ", entities.Size{Width: 728, Height: 90}, true},
+		{"300", entities.Size{}, false},
+		{"0x250", entities.Size{}, false},
+		{"axb", entities.Size{}, false},
+	}
+
+	for _, tt := range tests {
+		size, err := entities.ParseSize(tt.input)
+		if (err == nil) != tt.valid {
+			t.Errorf("ParseSize(%q): expected valid=%v, got error %v", tt.input, tt.valid, err)
+		}
+		if size != tt.expected {
+			t.Errorf("ParseSize(%q): expected %v, got %v", tt.input, tt.expected, size)
+		}
+	}
+}
+
+func TestSize_FitsAny(t *testing.T) {
+	rectangle := entities.Size{Width: 300, Height: 250}
+	leaderboard := entities.Size{Width: 728, Height: 90}
+
+	tests := []struct {
+		name     string
+		creative entities.Size
+		slots    []entities.Size
+		expected bool
+	}{
+		{"any size slot", leaderboard, nil, true},
+		{"exact match", rectangle, []entities.Size{rectangle}, true},
+		{"exact mismatch", leaderboard, []entities.Size{rectangle}, false},
+		{"multi-size slot", leaderboard, []entities.Size{rectangle, leaderboard}, true},
+		{"responsive creative", entities.Size{}, []entities.Size{leaderboard}, true},
+		{"fits fluid slot width", rectangle, []entities.Size{{Width: 320}}, true},
+		{"too wide for fluid slot", leaderboard, []entities.Size{{Width: 320}}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.creative.FitsAny(tt.slots); got != tt.expected {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, got)
+		}
+	}
+}
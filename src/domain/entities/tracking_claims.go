@@ -0,0 +1,24 @@
+package entities
+
+import "time"
+
+// TrackingClaims is the signed payload of impression, click and video event
+// URLs. Tracking trusts these instead of parameters supplied by the client.
+type TrackingClaims struct {
+	ImpressionID string
+	BannerID     string
+	CampaignID   string
+	SlotID       string
+	IssuedAt     time.Time
+}
+
+// NewTrackingClaims creates the claims of an ad served now
+func NewTrackingClaims(impressionID string, banner *Banner, slotID string) TrackingClaims {
+	return TrackingClaims{
+		ImpressionID: impressionID,
+		BannerID:     banner.ID,
+		CampaignID:   banner.CampaignID,
+		SlotID:       slotID,
+		IssuedAt:     time.Now(),
+	}
+}
@@ -0,0 +1,53 @@
+package entities
+
+import "github.com/shopspring/decimal"
+
+// TrackingEvent is a tracked impression or click on its way to storage
+// through the event stream. Its ID is the ID of the impression or click,
+// so storing an event twice stores it once.
+type TrackingEvent struct {
+	ID            string
+	Type          EventType       // EventImpression or EventClick
+	Impression    *Impression     // The impression, or the clicked one for clicks
+	Click         *Click          // Set for clicks
+	ClearingPrice decimal.Decimal // CPM an exchange cleared the impression at
+
+	// Set when the event is read back from the stream
+	StreamID   string // ID of the stream entry
+	Deliveries int64  // Times the entry was delivered to consumers, this one included
+}
+
+// NewImpressionEvent creates the event of a tracked impression
+func NewImpressionEvent(impression *Impression, clearingPrice decimal.Decimal) *TrackingEvent {
+	return &TrackingEvent{
+		ID:            impression.ID,
+		Type:          EventImpression,
+		Impression:    impression,
+		ClearingPrice: clearingPrice,
+	}
+}
+
+// NewClickEvent creates the event of a click on an impression
+func NewClickEvent(click *Click, impression *Impression) *TrackingEvent {
+	return &TrackingEvent{
+		ID:         click.ID,
+		Type:       EventClick,
+		Impression: impression,
+		Click:      click,
+	}
+}
+
+// BillableEvent returns the unpriced billable event of the tracked event
+func (e *TrackingEvent) BillableEvent() *BillableEvent {
+	event := NewBillableEvent(e.Type, e.Impression)
+	event.ClearingPrice = e.ClearingPrice
+	return event
+}
+
+// Billable reports whether the event is charged; invalid traffic isn't
+func (e *TrackingEvent) Billable() bool {
+	if e.Type == EventClick {
+		return !e.Click.Invalid
+	}
+	return !e.Impression.Invalid
+}
@@ -41,17 +41,25 @@ func (r *RegistrationRequest) Validate() error {
 
 // Domain errors
 var (
-	ErrInvalidEmail       = &DomainError{Message: "invalid email"}
-	ErrInvalidPassword    = &DomainError{Message: "invalid password"}
-	ErrPasswordTooShort   = &DomainError{Message: "password must be at least 8 characters"}
-	ErrInvalidCompanyName = &DomainError{Message: "invalid company name"}
-	ErrUserNotFound       = &DomainError{Message: "user not found"}
-	ErrInvalidCredentials = &DomainError{Message: "invalid credentials"}
-	ErrInvalidName        = &DomainError{Message: "invalid name"}
-	ErrInvalidFormat      = &DomainError{Message: "invalid format"}
-	ErrInvalidContent     = &DomainError{Message: "invalid content"}
-	ErrInvalidDimensions  = &DomainError{Message: "invalid dimensions"}
-	ErrInvalidSlotID      = &DomainError{Message: "invalid slot id"}
+	ErrInvalidEmail        = &DomainError{Message: "invalid email"}
+	ErrInvalidPassword     = &DomainError{Message: "invalid password"}
+	ErrPasswordTooShort    = &DomainError{Message: "password must be at least 8 characters"}
+	ErrInvalidCompanyName  = &DomainError{Message: "invalid company name"}
+	ErrUserNotFound        = &DomainError{Message: "user not found"}
+	ErrInvalidCredentials  = &DomainError{Message: "invalid credentials"}
+	ErrInvalidName         = &DomainError{Message: "invalid name"}
+	ErrInvalidFormat       = &DomainError{Message: "invalid format"}
+	ErrInvalidContent      = &DomainError{Message: "invalid content"}
+	ErrInvalidDimensions   = &DomainError{Message: "invalid dimensions"}
+	ErrInvalidSlotID       = &DomainError{Message: "invalid slot id"}
+	ErrInvalidCampaignID   = &DomainError{Message: "invalid campaign id"}
+	ErrInvalidSlotRule     = &DomainError{Message: "invalid slot rule"}
+	ErrInvalidFrequencyCap = &DomainError{Message: "invalid frequency cap"}
+	ErrInvalidTimezone     = &DomainError{Message: "invalid timezone"}
+	ErrInvalidSegmentTTL   = &DomainError{Message: "invalid segment membership ttl"}
+	ErrInvalidURL          = &DomainError{Message: "invalid url"}
+	ErrInvalidImpressionID = &DomainError{Message: "invalid impression id"}
+	ErrInvalidVideoEvent   = &DomainError{Message: "invalid video event"}
 )
 
 // DomainError represents a domain error
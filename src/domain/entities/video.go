@@ -0,0 +1,91 @@
+package entities
+
+import (
+	"strings"
+	"time"
+)
+
+// VideoAssets describe a linear video creative served through VAST
+type VideoAssets struct {
+	Duration   int         `json:"duration"`              // Seconds
+	SkipOffset int         `json:"skip_offset,omitempty"` // Seconds before the ad can be skipped; 0 if not skippable
+	MediaFiles []MediaFile `json:"media_files"`           // Renditions of the same video
+}
+
+// MediaFile is one rendition of a video creative
+type MediaFile struct {
+	URL     string `json:"url"`
+	Type    string `json:"type"` // MIME type, e.g. video/mp4
+	Width   int    `json:"width"`
+	Height  int    `json:"height"`
+	Bitrate int    `json:"bitrate,omitempty"` // Kbps
+}
+
+// Validate checks that the video has a duration and playable media files
+func (v *VideoAssets) Validate() error {
+	if v.Duration <= 0 || v.SkipOffset < 0 || v.SkipOffset >= v.Duration || len(v.MediaFiles) == 0 {
+		return ErrInvalidContent
+	}
+	for _, m := range v.MediaFiles {
+		if !IsSafeURL(m.URL) || !strings.HasPrefix(m.Type, "video/") || m.Width <= 0 || m.Height <= 0 || m.Bitrate < 0 {
+			return ErrInvalidContent
+		}
+	}
+	return nil
+}
+
+// VideoEventType is a playback event reported by a video player
+type VideoEventType string
+
+const (
+	VideoStart         VideoEventType = "start"
+	VideoFirstQuartile VideoEventType = "firstQuartile"
+	VideoMidpoint      VideoEventType = "midpoint"
+	VideoThirdQuartile VideoEventType = "thirdQuartile"
+	VideoComplete      VideoEventType = "complete"
+	VideoSkip          VideoEventType = "skip"
+)
+
+// VideoEventTypes lists the tracked playback events in playback order
+var VideoEventTypes = []VideoEventType{
+	VideoStart, VideoFirstQuartile, VideoMidpoint, VideoThirdQuartile, VideoComplete, VideoSkip,
+}
+
+// IsValid reports whether the event is a tracked playback event
+func (e VideoEventType) IsValid() bool {
+	for _, t := range VideoEventTypes {
+		if e == t {
+			return true
+		}
+	}
+	return false
+}
+
+// VideoEvent is a playback event of a served video ad
+type VideoEvent struct {
+	ID           string
+	ImpressionID string
+	BannerID     string
+	CampaignID   string
+	SlotID       string
+	Event        VideoEventType
+	Timestamp    time.Time
+	IP           string
+	UserAgent    string
+}
+
+// NewVideoEvent creates a playback event for an impression
+func NewVideoEvent(impressionID string, event VideoEventType) (*VideoEvent, error) {
+	if impressionID == "" {
+		return nil, ErrInvalidImpressionID
+	}
+	if !event.IsValid() {
+		return nil, ErrInvalidVideoEvent
+	}
+	return &VideoEvent{
+		ID:           generateUUID(),
+		ImpressionID: impressionID,
+		Event:        event,
+		Timestamp:    time.Now(),
+	}, nil
+}
@@ -0,0 +1,47 @@
+package entities_test
+
+import (
+	"testing"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+)
+
+func TestVideoAssets_Validate(t *testing.T) {
+	mp4 := entities.MediaFile{URL: "https://cdn.example.com/ad.mp4", Type: "video/mp4", Width: 1280, Height: 720, Bitrate: 2000}
+
+	tests := []struct {
+		name     string
+		video    entities.VideoAssets
+		expected error
+	}{
+		{"valid", entities.VideoAssets{Duration: 30, SkipOffset: 5, MediaFiles: []entities.MediaFile{mp4}}, nil},
+		{"no duration", entities.VideoAssets{MediaFiles: []entities.MediaFile{mp4}}, entities.ErrInvalidContent},
+		{"skip after end", entities.VideoAssets{Duration: 15, SkipOffset: 15, MediaFiles: []entities.MediaFile{mp4}}, entities.ErrInvalidContent},
+		{"no media files", entities.VideoAssets{Duration: 15}, entities.ErrInvalidContent},
+		{"image media file", entities.VideoAssets{Duration: 15, MediaFiles: []entities.MediaFile{{URL: mp4.URL, Type: "image/png", Width: 1, Height: 1}}}, entities.ErrInvalidContent},
+		{"unsafe media URL", entities.VideoAssets{Duration: 15, MediaFiles: []entities.MediaFile{{URL: "javascript:x", Type: "video/mp4", Width: 1, Height: 1}}}, entities.ErrInvalidContent},
+	}
+
+	for _, tt := range tests {
+		if err := tt.video.Validate(); err != tt.expected {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, err)
+		}
+	}
+}
+
+func TestNewVideoEvent(t *testing.T) {
+	event, err := entities.NewVideoEvent("imp-1", entities.VideoMidpoint)
+	if err != nil {
+		t.Fatalf("Expected valid event, got %v", err)
+	}
+	if event.ID == "" || event.Timestamp.IsZero() {
+		t.Errorf("Expected ID and timestamp to be set, got %+v", event)
+	}
+
+	if _, err := entities.NewVideoEvent("", entities.VideoStart); err != entities.ErrInvalidImpressionID {
+		t.Errorf("Expected invalid impression ID, got %v", err)
+	}
+	if _, err := entities.NewVideoEvent("imp-1", "rewind"); err != entities.ErrInvalidVideoEvent {
+		t.Errorf("Expected invalid video event, got %v", err)
+	}
+}
@@ -0,0 +1,14 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+)
+
+// BillableEventRepository defines the interface for billable event data access
+type BillableEventRepository interface {
+	// Create stores the event; it returns false if the impression was already
+	// billed for this event type
+	Create(ctx context.Context, event *entities.BillableEvent) (bool, error)
+}
@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+)
+
+// CampaignSlotRepository defines the interface for campaign slot assignment data access
+type CampaignSlotRepository interface {
+	FindByCampaignID(ctx context.Context, campaignID string) ([]*entities.CampaignSlot, error)
+	FindBySlotID(ctx context.Context, slotID string) ([]*entities.CampaignSlot, error)
+	Assign(ctx context.Context, assignment *entities.CampaignSlot) error
+	Unassign(ctx context.Context, campaignID, slotID string) error
+}
@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+)
+
+// CampaignStatsRepository defines the interface for campaign delivery history
+type CampaignStatsRepository interface {
+	// FindByCampaignIDs returns impression, click and conversion counts since
+	// the given time; campaigns without impressions are omitted
+	FindByCampaignIDs(ctx context.Context, campaignIDs []string, since time.Time) (map[string]entities.CampaignStats, error)
+}
@@ -12,4 +12,6 @@ type ClickRepository interface {
 	Create(ctx context.Context, click *entities.Click) error
 	CountByBannerID(ctx context.Context, bannerID string, since time.Time) (int64, error)
 	FindByImpressionID(ctx context.Context, impressionID string) (*entities.Impression, error)
+	// HasInvalidClick reports whether a click on the impression was marked invalid
+	HasInvalidClick(ctx context.Context, impressionID string) (bool, error)
 }
@@ -9,7 +9,10 @@ import (
 
 // ImpressionRepository defines the interface for impression data access
 type ImpressionRepository interface {
+	// Create stores an impression, skipping an ID that is already stored
 	Create(ctx context.Context, impression *entities.Impression) error
+	// CreateBatch stores impressions in bulk, skipping IDs that are already stored
+	CreateBatch(ctx context.Context, impressions []*entities.Impression) error
 	CountBySlotID(ctx context.Context, slotID string, since time.Time) (int64, error)
 	Exists(ctx context.Context, slotID, userID string, within time.Duration) (bool, error)
 	FindByImpressionID(ctx context.Context, impressionID string) (*entities.Impression, error)
@@ -0,0 +1,16 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+)
+
+// InvalidTrafficRepository defines the interface for invalid traffic reporting
+type InvalidTrafficRepository interface {
+	// Report counts the impressions and clicks of each campaign from since
+	// until until, with the invalid ones by reason; a campaign ID limits the
+	// report to that campaign
+	Report(ctx context.Context, since, until time.Time, campaignID string) ([]*entities.InvalidTrafficReport, error)
+}
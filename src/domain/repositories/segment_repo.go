@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+)
+
+// SegmentRepository defines the interface for audience segment data access
+type SegmentRepository interface {
+	Create(ctx context.Context, segment *entities.Segment) error
+	FindByID(ctx context.Context, id string) (*entities.Segment, error)
+	FindByAdvertiserID(ctx context.Context, advertiserID string) ([]*entities.Segment, error)
+	Delete(ctx context.Context, id string) error
+}
@@ -0,0 +1,22 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/shopspring/decimal"
+)
+
+// ServedAdRepository is the ledger of delivered ads
+type ServedAdRepository interface {
+	Create(ctx context.Context, ad *entities.ServedAd) error
+	// FindByID returns the served ad with the impression ID, or nil if there is none
+	FindByID(ctx context.Context, id string) (*entities.ServedAd, error)
+	// MarkRendered confirms a pending ad as rendered; false if it was not pending
+	MarkRendered(ctx context.Context, id string, at time.Time) (bool, error)
+	// UnmarkRendered returns a rendered ad to pending, e.g. when its impression could not be stored
+	UnmarkRendered(ctx context.Context, id string) error
+	// MarkWon records the clearing price of a won bid; false if the win was already recorded
+	MarkWon(ctx context.Context, id string, price decimal.Decimal, at time.Time) (bool, error)
+}
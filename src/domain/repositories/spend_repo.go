@@ -0,0 +1,17 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/shopspring/decimal"
+)
+
+// SpendRepository defines the interface for durable campaign spend data access
+type SpendRepository interface {
+	// SaveDaily stores the campaign's spend for a day, replacing the previous amount
+	SaveDaily(ctx context.Context, campaignID string, day time.Time, amount decimal.Decimal) error
+	// FindSpend returns the campaign's total spend and its spend on the given day
+	FindSpend(ctx context.Context, campaignID string, day time.Time) (entities.Spend, error)
+}
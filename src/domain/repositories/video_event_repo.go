@@ -0,0 +1,12 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+)
+
+// VideoEventRepository defines the interface for video playback event data access
+type VideoEventRepository interface {
+	Create(ctx context.Context, event *entities.VideoEvent) error
+}
@@ -0,0 +1,319 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+)
+
+// metadataMarker precedes the metadata map at the end of a MaxMind DB file
+var metadataMarker = []byte("\xAB\xCD\xEFMaxMind.com")
+
+// dataSectionSeparator is the gap between the search tree and the data section
+const dataSectionSeparator = 16
+
+// MaxMind DB data types
+const (
+	typeExtended = iota
+	typePointer
+	typeString
+	typeDouble
+	typeBytes
+	typeUint16
+	typeUint32
+	typeMap
+	typeInt32
+	typeUint64
+	typeUint128
+	typeArray
+	typeContainer
+	typeEndMarker
+	typeBool
+	typeFloat
+)
+
+var errInvalidDatabase = errors.New("invalid MaxMind DB")
+
+// database is a parsed MaxMind DB (.mmdb) file held in memory
+type database struct {
+	buf          []byte
+	nodeCount    uint
+	recordSize   uint
+	ipVersion    uint
+	treeSize     uint
+	ipv4Start    uint
+	databaseType string
+}
+
+// parseDatabase reads the metadata of a MaxMind DB file and prepares lookups
+func parseDatabase(buf []byte) (*database, error) {
+	marker := bytes.LastIndex(buf, metadataMarker)
+	if marker < 0 {
+		return nil, fmt.Errorf("%w: metadata not found", errInvalidDatabase)
+	}
+
+	metaStart := marker + len(metadataMarker)
+	raw, _, err := (&decoder{buf: buf[metaStart:]}).decode(0)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidDatabase, err)
+	}
+	meta, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: metadata is not a map", errInvalidDatabase)
+	}
+
+	db := &database{
+		buf:        buf,
+		nodeCount:  uint(toUint(meta["node_count"])),
+		recordSize: uint(toUint(meta["record_size"])),
+		ipVersion:  uint(toUint(meta["ip_version"])),
+	}
+	db.databaseType, _ = meta["database_type"].(string)
+
+	switch db.recordSize {
+	case 24, 28, 32:
+	default:
+		return nil, fmt.Errorf("%w: unsupported record size %d", errInvalidDatabase, db.recordSize)
+	}
+
+	db.treeSize = db.nodeCount * db.recordSize / 4
+	if db.treeSize+dataSectionSeparator > uint(marker) {
+		return nil, fmt.Errorf("%w: search tree exceeds file", errInvalidDatabase)
+	}
+
+	// IPv4 addresses live under ::/96 in IPv6 trees
+	if db.ipVersion == 6 {
+		node := uint(0)
+		for i := 0; i < 96 && node < db.nodeCount; i++ {
+			node = db.readNode(node, 0)
+		}
+		db.ipv4Start = node
+	}
+
+	return db, nil
+}
+
+// lookup returns the decoded record of the network containing ip, or nil if there is none
+func (db *database) lookup(ip net.IP) (interface{}, error) {
+	node, bits := uint(0), 128
+	if ip4 := ip.To4(); ip4 != nil {
+		ip, bits = ip4, 32
+		if db.ipVersion == 6 {
+			node = db.ipv4Start
+		}
+	} else if db.ipVersion == 4 {
+		return nil, nil // IPv6 addresses are not covered by IPv4 databases
+	}
+
+	for i := 0; i < bits && node < db.nodeCount; i++ {
+		bit := uint(ip[i>>3]>>(7-uint(i&7))) & 1
+		node = db.readNode(node, bit)
+	}
+
+	if node == db.nodeCount {
+		return nil, nil // Network not in the database
+	}
+	if node < db.nodeCount {
+		return nil, fmt.Errorf("%w: lookup ended inside the search tree", errInvalidDatabase)
+	}
+
+	offset := node - db.nodeCount - dataSectionSeparator
+	dataStart := db.treeSize + dataSectionSeparator
+	d := &decoder{buf: db.buf[dataStart:]}
+	value, _, err := d.decode(offset)
+	return value, err
+}
+
+// readNode reads the left (0) or right (1) record of a search tree node
+func (db *database) readNode(node, bit uint) uint {
+	b := db.buf[node*db.recordSize/4:]
+
+	switch db.recordSize {
+	case 24:
+		o := bit * 3
+		return uint(b[o])<<16 | uint(b[o+1])<<8 | uint(b[o+2])
+	case 28:
+		if bit == 0 {
+			return uint(b[3]&0xF0)<<20 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+		}
+		return uint(b[3]&0x0F)<<24 | uint(b[4])<<16 | uint(b[5])<<8 | uint(b[6])
+	default:
+		return uint(binary.BigEndian.Uint32(b[bit*4:]))
+	}
+}
+
+// decoder decodes values of a MaxMind DB data section
+type decoder struct {
+	buf []byte
+}
+
+// decode decodes the value at offset and returns it with the offset following it
+func (d *decoder) decode(offset uint) (interface{}, uint, error) {
+	typ, size, offset, err := d.decodeControl(offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if typ == typePointer {
+		target, next, err := d.decodePointer(size, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		value, _, err := d.decode(target)
+		return value, next, err
+	}
+
+	return d.decodeValue(typ, size, offset)
+}
+
+// decodeControl reads a control byte with its extended type and size bytes
+func (d *decoder) decodeControl(offset uint) (typ int, size uint, next uint, err error) {
+	if offset >= uint(len(d.buf)) {
+		return 0, 0, 0, fmt.Errorf("unexpected end of data at offset %d", offset)
+	}
+	ctrl := d.buf[offset]
+	offset++
+
+	typ = int(ctrl >> 5)
+	if typ == typeExtended {
+		if offset >= uint(len(d.buf)) {
+			return 0, 0, 0, errors.New("unexpected end of data in extended type")
+		}
+		typ = 7 + int(d.buf[offset])
+		offset++
+	}
+
+	// Pointers pack their value into the size bits
+	if typ == typePointer {
+		return typ, uint(ctrl & 0x1F), offset, nil
+	}
+
+	size = uint(ctrl & 0x1F)
+	if size >= 29 {
+		n := size - 28
+		if offset+n > uint(len(d.buf)) {
+			return 0, 0, 0, errors.New("unexpected end of data in size")
+		}
+		extra := uintFromBytes(d.buf[offset : offset+n])
+		offset += n
+		switch n {
+		case 1:
+			size = 29 + extra
+		case 2:
+			size = 285 + extra
+		default:
+			size = 65821 + extra
+		}
+	}
+
+	return typ, size, offset, nil
+}
+
+// decodePointer resolves a pointer to an offset in the data section
+func (d *decoder) decodePointer(bits, offset uint) (uint, uint, error) {
+	n := (bits>>3)&0x3 + 1
+	if offset+n > uint(len(d.buf)) {
+		return 0, 0, errors.New("unexpected end of data in pointer")
+	}
+	value := uintFromBytes(d.buf[offset : offset+n])
+
+	switch n {
+	case 1:
+		value = (bits&0x7)<<8 | value
+	case 2:
+		value = ((bits&0x7)<<16 | value) + 2048
+	case 3:
+		value = ((bits&0x7)<<24 | value) + 526336
+	}
+
+	return value, offset + n, nil
+}
+
+// decodeValue decodes a non-pointer value of the given type and size
+func (d *decoder) decodeValue(typ int, size, offset uint) (interface{}, uint, error) {
+	switch typ {
+	case typeMap:
+		m := make(map[string]interface{}, size)
+		for i := uint(0); i < size; i++ {
+			key, next, err := d.decode(offset)
+			if err != nil {
+				return nil, 0, err
+			}
+			k, ok := key.(string)
+			if !ok {
+				return nil, 0, errors.New("map key is not a string")
+			}
+			value, next, err := d.decode(next)
+			if err != nil {
+				return nil, 0, err
+			}
+			m[k] = value
+			offset = next
+		}
+		return m, offset, nil
+
+	case typeArray:
+		a := make([]interface{}, 0, size)
+		for i := uint(0); i < size; i++ {
+			value, next, err := d.decode(offset)
+			if err != nil {
+				return nil, 0, err
+			}
+			a = append(a, value)
+			offset = next
+		}
+		return a, offset, nil
+
+	case typeBool:
+		return size != 0, offset, nil
+	}
+
+	if offset+size > uint(len(d.buf)) {
+		return nil, 0, fmt.Errorf("value of %d bytes exceeds data section", size)
+	}
+	b := d.buf[offset : offset+size]
+	next := offset + size
+
+	switch typ {
+	case typeString:
+		return string(b), next, nil
+	case typeBytes, typeUint128:
+		return append([]byte(nil), b...), next, nil
+	case typeDouble:
+		if size != 8 {
+			return nil, 0, errors.New("invalid double size")
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), next, nil
+	case typeFloat:
+		if size != 4 {
+			return nil, 0, errors.New("invalid float size")
+		}
+		return math.Float32frombits(binary.BigEndian.Uint32(b)), next, nil
+	case typeUint16, typeUint32, typeUint64:
+		return uint64(uintFromBytes(b)), next, nil
+	case typeInt32:
+		return int32(uint32(uintFromBytes(b))), next, nil
+	default:
+		return nil, 0, fmt.Errorf("unsupported data type %d", typ)
+	}
+}
+
+// uintFromBytes decodes a big-endian unsigned integer of up to 8 bytes
+func uintFromBytes(b []byte) uint {
+	var v uint
+	for _, c := range b {
+		v = v<<8 | uint(c)
+	}
+	return v
+}
+
+// toUint converts a decoded metadata number to uint64
+func toUint(v interface{}) uint64 {
+	if n, ok := v.(uint64); ok {
+		return n
+	}
+	return 0
+}
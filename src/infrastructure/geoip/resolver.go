@@ -0,0 +1,146 @@
+package geoip
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// Location is the geographic position of an IP address; unknown parts are empty
+type Location struct {
+	Country  string // ISO 3166-1 alpha-2 code, e.g. "US"
+	Region   string // ISO 3166-2 subdivision code without country, e.g. "CA"
+	City     string // English city name
+	Timezone string // IANA time zone, e.g. "Europe/London"
+}
+
+// Resolver resolves IP addresses against a MaxMind-format (.mmdb) database
+// such as GeoLite2-City or GeoIP2-Country. The file is reloaded when it changes.
+type Resolver struct {
+	path string
+
+	mu      sync.RWMutex
+	db      *database
+	modTime time.Time
+}
+
+// NewResolver opens the database at path
+func NewResolver(path string) (*Resolver, error) {
+	r := &Resolver{path: path}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Locate resolves an IP address to country, region, city and time zone.
+// Unparseable or unknown addresses resolve to empty strings.
+func (r *Resolver) Locate(ip string) (country, region, city, timezone string) {
+	loc := r.Lookup(net.ParseIP(ip))
+	return loc.Country, loc.Region, loc.City, loc.Timezone
+}
+
+// Lookup resolves an IP address to its location
+func (r *Resolver) Lookup(ip net.IP) Location {
+	if ip == nil {
+		return Location{}
+	}
+
+	r.mu.RLock()
+	db := r.db
+	r.mu.RUnlock()
+
+	record, err := db.lookup(ip)
+	if err != nil {
+		return Location{}
+	}
+	return locationFromRecord(record)
+}
+
+// Reload re-reads the database if the file changed since it was last loaded.
+// A failed reload keeps serving the previous database.
+func (r *Resolver) Reload() error {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return fmt.Errorf("failed to stat geo database: %w", err)
+	}
+
+	r.mu.RLock()
+	unchanged := info.ModTime().Equal(r.modTime)
+	r.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	return r.load()
+}
+
+// Run checks the database file for changes every interval until ctx is done
+func (r *Resolver) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = r.Reload() // Keep the previous database until the file is valid again
+		}
+	}
+}
+
+// load reads and parses the database file, replacing the current one
+func (r *Resolver) load() error {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return fmt.Errorf("failed to stat geo database: %w", err)
+	}
+	buf, err := os.ReadFile(r.path)
+	if err != nil {
+		return fmt.Errorf("failed to read geo database: %w", err)
+	}
+	db, err := parseDatabase(buf)
+	if err != nil {
+		return fmt.Errorf("failed to parse geo database %s: %w", r.path, err)
+	}
+
+	r.mu.Lock()
+	r.db = db
+	r.modTime = info.ModTime()
+	r.mu.Unlock()
+
+	return nil
+}
+
+// locationFromRecord extracts the location from a GeoIP2/GeoLite2 record
+func locationFromRecord(record interface{}) Location {
+	var loc Location
+
+	m, _ := record.(map[string]interface{})
+	if m == nil {
+		return loc
+	}
+
+	if country, ok := m["country"].(map[string]interface{}); ok {
+		loc.Country, _ = country["iso_code"].(string)
+	}
+	if subdivisions, ok := m["subdivisions"].([]interface{}); ok && len(subdivisions) > 0 {
+		if subdivision, ok := subdivisions[0].(map[string]interface{}); ok {
+			loc.Region, _ = subdivision["iso_code"].(string)
+		}
+	}
+	if city, ok := m["city"].(map[string]interface{}); ok {
+		if names, ok := city["names"].(map[string]interface{}); ok {
+			loc.City, _ = names["en"].(string)
+		}
+	}
+	if location, ok := m["location"].(map[string]interface{}); ok {
+		loc.Timezone, _ = location["time_zone"].(string)
+	}
+
+	return loc
+}
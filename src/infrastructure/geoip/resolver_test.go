@@ -0,0 +1,202 @@
+package geoip
+
+import (
+	"bytes"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+type testNetwork struct {
+	cidr   string
+	record map[string]interface{}
+}
+
+func cityRecord(country, region, city, timezone string) map[string]interface{} {
+	return map[string]interface{}{
+		"country":      map[string]interface{}{"iso_code": country},
+		"subdivisions": []interface{}{map[string]interface{}{"iso_code": region}},
+		"city":         map[string]interface{}{"names": map[string]interface{}{"en": city}},
+		"location":     map[string]interface{}{"time_zone": timezone},
+	}
+}
+
+// testRecord is a search tree record: 0 = empty, 1 = node index, 2 = data offset
+type testRecord struct {
+	kind  int
+	value int
+}
+
+// writeTestDatabase writes an IPv6 MaxMind DB with 24-bit records holding the networks
+func writeTestDatabase(t *testing.T, path string, networks []testNetwork) {
+	t.Helper()
+
+	nodes := [][2]testRecord{{}}
+	var data []byte
+
+	for _, n := range networks {
+		_, network, err := net.ParseCIDR(n.cidr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ip := network.IP.To16()
+		ones, _ := network.Mask.Size()
+		if ip4 := network.IP.To4(); ip4 != nil {
+			// IPv4 networks live under ::/96
+			ip = append(make(net.IP, 12), ip4...)
+			ones += 96
+		}
+
+		offset := len(data)
+		data = append(data, encodeTestValue(n.record)...)
+
+		node := 0
+		for i := 0; i < ones; i++ {
+			bit := int(ip[i/8]>>(7-uint(i%8))) & 1
+			if i == ones-1 {
+				nodes[node][bit] = testRecord{kind: 2, value: offset}
+				break
+			}
+			if nodes[node][bit].kind != 1 {
+				nodes = append(nodes, [2]testRecord{})
+				nodes[node][bit] = testRecord{kind: 1, value: len(nodes) - 1}
+			}
+			node = nodes[node][bit].value
+		}
+	}
+
+	var buf bytes.Buffer
+	nodeCount := len(nodes)
+	for _, node := range nodes {
+		for _, rec := range node {
+			v := nodeCount
+			switch rec.kind {
+			case 1:
+				v = rec.value
+			case 2:
+				v = nodeCount + dataSectionSeparator + rec.value
+			}
+			buf.Write([]byte{byte(v >> 16), byte(v >> 8), byte(v)})
+		}
+	}
+	buf.Write(make([]byte, dataSectionSeparator))
+	buf.Write(data)
+	buf.Write(metadataMarker)
+	buf.Write(encodeTestValue(map[string]interface{}{
+		"node_count":                  uint32(nodeCount),
+		"record_size":                 uint16(24),
+		"ip_version":                  uint16(6),
+		"database_type":               "GeoIP2-City",
+		"binary_format_major_version": uint16(2),
+	}))
+
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// encodeTestValue encodes the small values used by the test databases
+func encodeTestValue(v interface{}) []byte {
+	switch v := v.(type) {
+	case string:
+		return append([]byte{byte(typeString<<5 | len(v))}, v...)
+	case uint16:
+		return []byte{byte(typeUint16<<5 | 2), byte(v >> 8), byte(v)}
+	case uint32:
+		return []byte{byte(typeUint32<<5 | 4), byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)}
+	case []interface{}:
+		out := []byte{byte(len(v)), typeArray - 7}
+		for _, item := range v {
+			out = append(out, encodeTestValue(item)...)
+		}
+		return out
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		out := []byte{byte(typeMap<<5 | len(v))}
+		for _, k := range keys {
+			out = append(out, encodeTestValue(k)...)
+			out = append(out, encodeTestValue(v[k])...)
+		}
+		return out
+	default:
+		panic("unsupported test value")
+	}
+}
+
+func TestResolver_Locate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "city.mmdb")
+	writeTestDatabase(t, path, []testNetwork{
+		{"81.2.69.0/24", cityRecord("GB", "ENG", "London", "Europe/London")},
+		{"2001:db8::/32", cityRecord("US", "CA", "San Francisco", "America/Los_Angeles")},
+	})
+
+	resolver, err := NewResolver(path)
+	if err != nil {
+		t.Fatalf("Expected database to open, got %v", err)
+	}
+
+	tests := []struct {
+		ip       string
+		expected Location
+	}{
+		{"81.2.69.142", Location{Country: "GB", Region: "ENG", City: "London", Timezone: "Europe/London"}},
+		{"2001:db8::1", Location{Country: "US", Region: "CA", City: "San Francisco", Timezone: "America/Los_Angeles"}},
+		{"81.2.70.1", Location{}},
+		{"not-an-ip", Location{}},
+	}
+
+	for _, tt := range tests {
+		country, region, city, timezone := resolver.Locate(tt.ip)
+		if got := (Location{Country: country, Region: region, City: city, Timezone: timezone}); got != tt.expected {
+			t.Errorf("Expected %+v for %s, got %+v", tt.expected, tt.ip, got)
+		}
+	}
+}
+
+func TestResolver_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "city.mmdb")
+	writeTestDatabase(t, path, []testNetwork{{"81.2.69.0/24", cityRecord("GB", "ENG", "London", "Europe/London")}})
+
+	resolver, err := NewResolver(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The network moved to another country in the new release
+	writeTestDatabase(t, path, []testNetwork{{"81.2.69.0/24", cityRecord("FR", "IDF", "Paris", "Europe/Paris")}})
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(path, future, future)
+
+	if err := resolver.Reload(); err != nil {
+		t.Fatalf("Expected reload to succeed, got %v", err)
+	}
+	if country, _, _, _ := resolver.Locate("81.2.69.142"); country != "FR" {
+		t.Errorf("Expected reloaded country FR, got %q", country)
+	}
+
+	// A broken file keeps the previous database
+	os.WriteFile(path, []byte("truncated"), 0o644)
+	future = future.Add(time.Minute)
+	os.Chtimes(path, future, future)
+
+	if err := resolver.Reload(); err == nil {
+		t.Errorf("Expected reload of a broken file to fail")
+	}
+	if country, _, _, _ := resolver.Locate("81.2.69.142"); country != "FR" {
+		t.Errorf("Expected previous database to stay in use, got %q", country)
+	}
+}
+
+func TestNewResolver_MissingFile(t *testing.T) {
+	if _, err := NewResolver(filepath.Join(t.TempDir(), "missing.mmdb")); err == nil {
+		t.Errorf("Expected error for a missing database")
+	}
+}
@@ -0,0 +1,118 @@
+// Package ivt loads the data sources of invalid traffic detection: bot
+// user agent lists and datacenter IP ranges
+package ivt
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"fmt"
+	"os"
+	"strings"
+)
+
+//go:embed bots.txt
+var defaultBots []byte
+
+// botPattern is one entry of a bot list
+type botPattern struct {
+	text  string // Lower case
+	start bool   // Matches only at the start of the user agent
+}
+
+// matches reports whether a lower case user agent matches the pattern
+func (p botPattern) matches(userAgent string) bool {
+	if p.start {
+		return strings.HasPrefix(userAgent, p.text)
+	}
+	return strings.Contains(userAgent, p.text)
+}
+
+// BotList matches user agents against an IAB-style list of bots and
+// crawlers. Lines are "pattern|active|start": inactive entries are skipped,
+// start entries match only at the start of the user agent, and entries
+// prefixed with ! are exceptions that are never bots. See bots.txt.
+type BotList struct {
+	patterns   []botPattern
+	exceptions []botPattern
+}
+
+// LoadBotList reads a bot list file; an empty path loads the built-in list
+func LoadBotList(path string) (*BotList, error) {
+	if path == "" {
+		return ParseBotList(defaultBots)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bot list: %w", err)
+	}
+	return ParseBotList(data)
+}
+
+// ParseBotList parses a bot list
+func ParseBotList(data []byte) (*BotList, error) {
+	list := &BotList{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "|")
+		pattern := botPattern{text: strings.ToLower(strings.TrimSpace(fields[0]))}
+		active := true
+		for i, field := range fields[1:] {
+			flag := strings.TrimSpace(field)
+			if flag != "0" && flag != "1" {
+				return nil, fmt.Errorf("bot list line %d: invalid flag %q", n, flag)
+			}
+			switch i {
+			case 0:
+				active = flag == "1"
+			case 1:
+				pattern.start = flag == "1"
+			}
+		}
+
+		exception := strings.HasPrefix(pattern.text, "!")
+		pattern.text = strings.TrimPrefix(pattern.text, "!")
+		if !active || pattern.text == "" {
+			continue
+		}
+
+		if exception {
+			list.exceptions = append(list.exceptions, pattern)
+		} else {
+			list.patterns = append(list.patterns, pattern)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// IsBot reports whether a user agent is a bot. Clients without a user agent
+// aren't browsers and count as bots.
+func (l *BotList) IsBot(userAgent string) bool {
+	userAgent = strings.ToLower(strings.TrimSpace(userAgent))
+	if userAgent == "" {
+		return true
+	}
+
+	for _, exception := range l.exceptions {
+		if exception.matches(userAgent) {
+			return false
+		}
+	}
+	for _, pattern := range l.patterns {
+		if pattern.matches(userAgent) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package ivt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBotList_Default(t *testing.T) {
+	list, err := LoadBotList("")
+	if err != nil {
+		t.Fatalf("Failed to load the built-in list: %v", err)
+	}
+
+	tests := []struct {
+		userAgent string
+		bot       bool
+	}{
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36", false},
+		{"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", true},
+		{"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) HeadlessChrome/120.0 Safari/537.36", true},
+		{"curl/8.4.0", true},
+		{"python-requests/2.31.0", true},
+		{"Mozilla/5.0 (Linux; Android 10; CUBOT X30) AppleWebKit/537.36 Chrome/120.0 Mobile Safari/537.36", false}, // Exception
+		{"", true},
+	}
+
+	for _, tt := range tests {
+		if got := list.IsBot(tt.userAgent); got != tt.bot {
+			t.Errorf("%q: expected bot=%v, got %v", tt.userAgent, tt.bot, got)
+		}
+	}
+}
+
+func TestBotList_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bots.txt")
+	os.WriteFile(path, []byte("# test list\nscanner|1|0\noldbot|0|0\nfetch|1|1\n"), 0o644)
+
+	list, err := LoadBotList(path)
+	if err != nil {
+		t.Fatalf("Failed to load bot list: %v", err)
+	}
+	if !list.IsBot("Security Scanner 1.0") || list.IsBot("OldBot/1.0") {
+		t.Error("Expected active entries to match and inactive ones to be skipped")
+	}
+	if !list.IsBot("Fetch/2.0") || list.IsBot("Mozilla/5.0 fetch") {
+		t.Error("Expected start entries to match only at the start")
+	}
+
+	if _, err := ParseBotList([]byte("bot|yes\n")); err == nil {
+		t.Error("Expected an invalid flag to fail")
+	}
+}
+
+func TestNetworkList_Contains(t *testing.T) {
+	list, err := ParseNetworkList([]byte(`
+# Example ranges
+3.5.140.0/22
+3.5.142.0/23   # overlaps the range above
+192.0.2.10
+2600:1f00::/24
+`))
+	if err != nil {
+		t.Fatalf("Failed to parse network list: %v", err)
+	}
+	if list.Len() != 3 {
+		t.Errorf("Expected overlapping ranges merged into 3, got %d", list.Len())
+	}
+
+	tests := []struct {
+		ip       string
+		contains bool
+	}{
+		{"3.5.140.0", true},
+		{"3.5.143.255", true},
+		{"3.5.144.0", false},
+		{"3.5.139.255", false},
+		{"192.0.2.10", true},
+		{"192.0.2.11", false},
+		{"2600:1f00::1", true},
+		{"2600:2000::1", false},
+		{"not-an-ip", false},
+	}
+
+	for _, tt := range tests {
+		if got := list.Contains(tt.ip); got != tt.contains {
+			t.Errorf("%s: expected %v, got %v", tt.ip, tt.contains, got)
+		}
+	}
+
+	if _, err := ParseNetworkList([]byte("3.5.140.0/33\n")); err == nil {
+		t.Error("Expected an invalid CIDR to fail")
+	}
+}
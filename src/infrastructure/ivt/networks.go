@@ -0,0 +1,114 @@
+package ivt
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ipRange is an inclusive range of addresses in 16-byte form
+type ipRange struct {
+	first, last net.IP
+}
+
+// NetworkList matches IPs against a list of ranges, such as the published
+// ranges of hosting and cloud providers. The file lists one CIDR or single
+// address per line; # starts a comment.
+type NetworkList struct {
+	ranges []ipRange // Sorted and not overlapping
+}
+
+// LoadNetworkList reads a network list file
+func LoadNetworkList(path string) (*NetworkList, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read network list: %w", err)
+	}
+	return ParseNetworkList(data)
+}
+
+// ParseNetworkList parses a network list
+func ParseNetworkList(data []byte) (*NetworkList, error) {
+	var ranges []ipRange
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if !strings.Contains(line, "/") {
+			ip := net.ParseIP(line)
+			if ip == nil {
+				return nil, fmt.Errorf("network list line %d: invalid address %q", n, line)
+			}
+			ranges = append(ranges, ipRange{first: ip.To16(), last: ip.To16()})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(line)
+		if err != nil {
+			return nil, fmt.Errorf("network list line %d: %w", n, err)
+		}
+		ranges = append(ranges, networkRange(network))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(ranges, func(i, j int) bool { return bytes.Compare(ranges[i].first, ranges[j].first) < 0 })
+
+	// Merge overlapping ranges so a lookup only checks one candidate
+	merged := ranges[:0]
+	for _, r := range ranges {
+		if last := len(merged) - 1; last >= 0 && bytes.Compare(r.first, merged[last].last) <= 0 {
+			if bytes.Compare(r.last, merged[last].last) > 0 {
+				merged[last].last = r.last
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+
+	return &NetworkList{ranges: merged}, nil
+}
+
+// Contains reports whether the IP is in one of the ranges
+func (l *NetworkList) Contains(ip string) bool {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	addr = addr.To16()
+
+	// The last range starting at or before the address
+	i := sort.Search(len(l.ranges), func(i int) bool { return bytes.Compare(l.ranges[i].first, addr) > 0 }) - 1
+	return i >= 0 && bytes.Compare(addr, l.ranges[i].last) <= 0
+}
+
+// Len returns the number of ranges after merging
+func (l *NetworkList) Len() int {
+	return len(l.ranges)
+}
+
+// networkRange returns the first and last address of a network
+func networkRange(network *net.IPNet) ipRange {
+	first := network.IP.To16()
+	mask := network.Mask
+	if len(mask) == net.IPv4len {
+		// Keep the 12-byte IPv4-mapped prefix of the 16-byte form
+		mask = append(net.IPMask(bytes.Repeat([]byte{0xff}, net.IPv6len-net.IPv4len)), mask...)
+	}
+
+	last := make(net.IP, net.IPv6len)
+	for i := range first {
+		last[i] = first[i] | ^mask[i]
+	}
+	return ipRange{first: first, last: last}
+}
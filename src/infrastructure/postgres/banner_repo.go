@@ -3,11 +3,15 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 
 	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
 	"github.com/fall-out-bug/demo-adserver/src/domain/repositories"
 )
 
+// bannerColumns is the column list shared by all banner SELECTs
+const bannerColumns = `id, campaign_id, name, status, creative_type, width, height, html, image_url, alt_text, native_assets, video_assets, click_url, weight, frequency_caps, created_at, updated_at`
+
 type bannerRepository struct {
 	db *sql.DB
 }
@@ -17,98 +21,159 @@ func NewBannerRepository(db *sql.DB) repositories.BannerRepository {
 	return &bannerRepository{db: db}
 }
 
-func (r *bannerRepository) FindByID(ctx context.Context, id string) (*entities.Banner, error) {
+// scanBanner reads a banner row selected with bannerColumns
+func scanBanner(row rowScanner) (*entities.Banner, error) {
 	var b entities.Banner
+	var capsJSON, nativeJSON, videoJSON []byte
 
-	query := `SELECT id, campaign_id, name, status, size, html, click_url, weight, created_at, updated_at
-              FROM banners WHERE id = $1`
-
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&b.ID, &b.CampaignID, &b.Name, &b.Status, &b.Size, &b.HTML, &b.ClickURL,
-		&b.Weight, &b.CreatedAt, &b.UpdatedAt,
-	)
+	if err := row.Scan(
+		&b.ID, &b.CampaignID, &b.Name, &b.Status, &b.Type, &b.Width, &b.Height, &b.HTML, &b.ImageURL, &b.AltText,
+		&nativeJSON, &videoJSON, &b.ClickURL, &b.Weight, &capsJSON, &b.CreatedAt, &b.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
 
-	if err == sql.ErrNoRows {
-		return nil, nil
+	if nativeJSON != nil {
+		b.Native = &entities.NativeAssets{}
+		if err := json.Unmarshal(nativeJSON, b.Native); err != nil {
+			return nil, err
+		}
 	}
-	if err != nil {
+	if videoJSON != nil {
+		b.Video = &entities.VideoAssets{}
+		if err := json.Unmarshal(videoJSON, b.Video); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := json.Unmarshal(capsJSON, &b.FrequencyCaps); err != nil {
 		return nil, err
 	}
 
 	return &b, nil
 }
 
-func (r *bannerRepository) FindByCampaignID(ctx context.Context, campaignID string) ([]*entities.Banner, error) {
-	query := `SELECT id, campaign_id, name, status, size, html, click_url, weight, created_at, updated_at
-              FROM banners WHERE campaign_id = $1 ORDER BY created_at DESC`
+func (r *bannerRepository) FindByID(ctx context.Context, id string) (*entities.Banner, error) {
+	query := `SELECT ` + bannerColumns + `
+              FROM banners WHERE id = $1`
 
-	rows, err := r.db.QueryContext(ctx, query, campaignID)
+	b, err := scanBanner(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	var banners []*entities.Banner
-	for rows.Next() {
-		var b entities.Banner
-		if err := rows.Scan(
-			&b.ID, &b.CampaignID, &b.Name, &b.Status, &b.Size, &b.HTML, &b.ClickURL,
-			&b.Weight, &b.CreatedAt, &b.UpdatedAt,
-		); err != nil {
-			return nil, err
-		}
-		banners = append(banners, &b)
-	}
+	return b, nil
+}
 
-	return banners, rows.Err()
+func (r *bannerRepository) FindByCampaignID(ctx context.Context, campaignID string) ([]*entities.Banner, error) {
+	query := `SELECT ` + bannerColumns + `
+              FROM banners WHERE campaign_id = $1 ORDER BY created_at DESC`
+
+	return r.queryBanners(ctx, query, campaignID)
 }
 
 func (r *bannerRepository) FindActiveForCampaign(ctx context.Context, campaignID string) ([]*entities.Banner, error) {
-	query := `SELECT id, campaign_id, name, status, size, html, click_url, weight, created_at, updated_at
+	query := `SELECT ` + bannerColumns + `
               FROM banners WHERE campaign_id = $1 AND status = 'active' ORDER BY weight DESC`
 
-	rows, err := r.db.QueryContext(ctx, query, campaignID)
+	return r.queryBanners(ctx, query, campaignID)
+}
+
+func (r *bannerRepository) Create(ctx context.Context, banner *entities.Banner) error {
+	capsJSON, err := frequencyCapsJSON(banner.FrequencyCaps)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer rows.Close()
 
-	var banners []*entities.Banner
-	for rows.Next() {
-		var b entities.Banner
-		if err := rows.Scan(
-			&b.ID, &b.CampaignID, &b.Name, &b.Status, &b.Size, &b.HTML, &b.ClickURL,
-			&b.Weight, &b.CreatedAt, &b.UpdatedAt,
-		); err != nil {
-			return nil, err
-		}
-		banners = append(banners, &b)
+	nativeJSON, err := nativeAssetsJSON(banner.Native)
+	if err != nil {
+		return err
 	}
 
-	return banners, rows.Err()
-}
+	videoJSON, err := videoAssetsJSON(banner.Video)
+	if err != nil {
+		return err
+	}
 
-func (r *bannerRepository) Create(ctx context.Context, banner *entities.Banner) error {
-	query := `INSERT INTO banners (id, campaign_id, name, status, size, html, click_url, weight, created_at, updated_at)
-              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+	query := `INSERT INTO banners (id, campaign_id, name, status, creative_type, width, height, html, image_url, alt_text,
+                                   native_assets, video_assets, click_url, weight, frequency_caps, created_at, updated_at)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)`
 
-	_, err := r.db.ExecContext(ctx, query,
-		banner.ID, banner.CampaignID, banner.Name, banner.Status, banner.Size,
-		banner.HTML, banner.ClickURL, banner.Weight, banner.CreatedAt, banner.UpdatedAt,
+	_, err = r.db.ExecContext(ctx, query,
+		banner.ID, banner.CampaignID, banner.Name, banner.Status, banner.CreativeType(), banner.Width, banner.Height,
+		banner.HTML, banner.ImageURL, banner.AltText, nativeJSON, videoJSON, banner.ClickURL,
+		banner.Weight, capsJSON, banner.CreatedAt, banner.UpdatedAt,
 	)
 
 	return err
 }
 
 func (r *bannerRepository) Update(ctx context.Context, banner *entities.Banner) error {
+	capsJSON, err := frequencyCapsJSON(banner.FrequencyCaps)
+	if err != nil {
+		return err
+	}
+
+	nativeJSON, err := nativeAssetsJSON(banner.Native)
+	if err != nil {
+		return err
+	}
+
+	videoJSON, err := videoAssetsJSON(banner.Video)
+	if err != nil {
+		return err
+	}
+
 	query := `UPDATE banners SET
-              name = $2, status = $3, size = $4, html = $5, click_url = $6, weight = $7, updated_at = $8
+              name = $2, status = $3, creative_type = $4, width = $5, height = $6, html = $7,
+              image_url = $8, alt_text = $9, native_assets = $10, video_assets = $11, click_url = $12, weight = $13,
+              frequency_caps = $14, updated_at = $15
               WHERE id = $1`
 
-	_, err := r.db.ExecContext(ctx, query,
-		banner.ID, banner.Name, banner.Status, banner.Size,
-		banner.HTML, banner.ClickURL, banner.Weight, banner.UpdatedAt,
+	_, err = r.db.ExecContext(ctx, query,
+		banner.ID, banner.Name, banner.Status, banner.CreativeType(), banner.Width, banner.Height,
+		banner.HTML, banner.ImageURL, banner.AltText, nativeJSON, videoJSON, banner.ClickURL,
+		banner.Weight, capsJSON, banner.UpdatedAt,
 	)
 
 	return err
 }
+
+// queryBanners runs a banner SELECT and scans all rows
+func (r *bannerRepository) queryBanners(ctx context.Context, query string, args ...interface{}) ([]*entities.Banner, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var banners []*entities.Banner
+	for rows.Next() {
+		b, err := scanBanner(rows)
+		if err != nil {
+			return nil, err
+		}
+		banners = append(banners, b)
+	}
+
+	return banners, rows.Err()
+}
+
+// nativeAssetsJSON encodes native assets for the nullable native_assets column
+func nativeAssetsJSON(assets *entities.NativeAssets) ([]byte, error) {
+	if assets == nil {
+		return nil, nil
+	}
+	return json.Marshal(assets)
+}
+
+// videoAssetsJSON encodes video assets for the nullable video_assets column
+func videoAssetsJSON(assets *entities.VideoAssets) ([]byte, error) {
+	if assets == nil {
+		return nil, nil
+	}
+	return json.Marshal(assets)
+}
@@ -0,0 +1,40 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/fall-out-bug/demo-adserver/src/domain/repositories"
+)
+
+type billableEventRepository struct {
+	db *sql.DB
+}
+
+// NewBillableEventRepository creates a new billable event repository
+func NewBillableEventRepository(db *sql.DB) repositories.BillableEventRepository {
+	return &billableEventRepository{db: db}
+}
+
+func (r *billableEventRepository) Create(ctx context.Context, event *entities.BillableEvent) (bool, error) {
+	query := `INSERT INTO billable_events (id, event_type, campaign_id, banner_id, impression_id,
+                                           pricing_model, cost, created_at)
+              VALUES ($1, $2, $3, NULLIF($4, '')::uuid, $5, $6, $7, $8)
+              ON CONFLICT (impression_id, event_type) DO NOTHING`
+
+	result, err := r.db.ExecContext(ctx, query,
+		event.ID, event.Type, event.CampaignID, event.BannerID, event.ImpressionID,
+		event.Pricing, event.Cost, event.CreatedAt,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return inserted > 0, nil
+}
@@ -9,6 +9,15 @@ import (
 	"github.com/fall-out-bug/demo-adserver/src/domain/repositories"
 )
 
+// campaignColumns is the column list shared by all campaign SELECTs
+const campaignColumns = `id, COALESCE(advertiser_id::text, ''), name, category, status, budget_total, budget_daily, priority, pricing_model, bid, pacing,
+                     start_date, end_date, targeting, rotation_mode, frequency_caps, created_at, updated_at`
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
 type campaignRepository struct {
 	db *sql.DB
 }
@@ -18,19 +27,33 @@ func NewCampaignRepository(db *sql.DB) repositories.CampaignRepository {
 	return &campaignRepository{db: db}
 }
 
-func (r *campaignRepository) FindByID(ctx context.Context, id string) (*entities.Campaign, error) {
+// scanCampaign reads a campaign row selected with campaignColumns
+func scanCampaign(row rowScanner) (*entities.Campaign, error) {
 	var c entities.Campaign
-	var targetingJSON []byte
+	var targetingJSON, capsJSON []byte
 
-	query := `SELECT id, name, status, budget_total, budget_daily,
-                     start_date, end_date, targeting, created_at, updated_at
-              FROM campaigns WHERE id = $1`
+	if err := row.Scan(
+		&c.ID, &c.AdvertiserID, &c.Name, &c.Category, &c.Status, &c.BudgetTotal, &c.BudgetDaily, &c.Priority, &c.Pricing, &c.Bid, &c.Pacing,
+		&c.StartDate, &c.EndDate, &targetingJSON, &c.Rotation, &capsJSON, &c.CreatedAt, &c.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&c.ID, &c.Name, &c.Status, &c.BudgetTotal, &c.BudgetDaily,
-		&c.StartDate, &c.EndDate, &targetingJSON, &c.CreatedAt, &c.UpdatedAt,
-	)
+	if err := json.Unmarshal(targetingJSON, &c.Targeting); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(capsJSON, &c.FrequencyCaps); err != nil {
+		return nil, err
+	}
 
+	return &c, nil
+}
+
+func (r *campaignRepository) FindByID(ctx context.Context, id string) (*entities.Campaign, error) {
+	query := `SELECT ` + campaignColumns + `
+              FROM campaigns WHERE id = $1`
+
+	c, err := scanCampaign(r.db.QueryRowContext(ctx, query, id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -38,54 +61,39 @@ func (r *campaignRepository) FindByID(ctx context.Context, id string) (*entities
 		return nil, err
 	}
 
-	if err := json.Unmarshal(targetingJSON, &c.Targeting); err != nil {
-		return nil, err
-	}
-
-	return &c, nil
+	return c, nil
 }
 
 func (r *campaignRepository) FindActive(ctx context.Context) ([]*entities.Campaign, error) {
-	query := `SELECT id, name, status, budget_total, budget_daily,
-                     start_date, end_date, targeting, created_at, updated_at
+	query := `SELECT ` + campaignColumns + `
               FROM campaigns
               WHERE status = 'active'
                 AND start_date <= NOW()
                 AND (end_date IS NULL OR end_date > NOW())
               ORDER BY created_at DESC`
 
-	rows, err := r.db.QueryContext(ctx, query)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var campaigns []*entities.Campaign
-	for rows.Next() {
-		var c entities.Campaign
-		var targetingJSON []byte
-
-		if err := rows.Scan(
-			&c.ID, &c.Name, &c.Status, &c.BudgetTotal, &c.BudgetDaily,
-			&c.StartDate, &c.EndDate, &targetingJSON, &c.CreatedAt, &c.UpdatedAt,
-		); err != nil {
-			return nil, err
-		}
-
-		if err := json.Unmarshal(targetingJSON, &c.Targeting); err != nil {
-			return nil, err
-		}
-
-		campaigns = append(campaigns, &c)
-	}
-
-	return campaigns, rows.Err()
+	return r.queryCampaigns(ctx, query)
 }
 
 func (r *campaignRepository) FindBySlotID(ctx context.Context, slotID string) ([]*entities.Campaign, error) {
-	// For now, return all active campaigns - slot-specific filtering would be done at application layer
-	// In a real implementation, you'd have a slot_campaigns mapping table
-	return r.FindActive(ctx)
+	// Only campaigns booked on the slot (directly or run-of-network) and not excluded from it
+	query := `SELECT ` + campaignColumns + `
+              FROM campaigns c
+              WHERE c.status = 'active'
+                AND c.start_date <= NOW()
+                AND (c.end_date IS NULL OR c.end_date > NOW())
+                AND EXISTS (
+                    SELECT 1 FROM campaign_slots cs
+                    WHERE cs.campaign_id = c.id AND cs.rule = 'include'
+                      AND (cs.slot_id = $1 OR cs.slot_id = '*')
+                )
+                AND NOT EXISTS (
+                    SELECT 1 FROM campaign_slots cs
+                    WHERE cs.campaign_id = c.id AND cs.rule = 'exclude' AND cs.slot_id = $1
+                )
+              ORDER BY c.created_at DESC`
+
+	return r.queryCampaigns(ctx, query, slotID)
 }
 
 func (r *campaignRepository) Create(ctx context.Context, campaign *entities.Campaign) error {
@@ -93,14 +101,21 @@ func (r *campaignRepository) Create(ctx context.Context, campaign *entities.Camp
 	if err != nil {
 		return err
 	}
+	capsJSON, err := frequencyCapsJSON(campaign.FrequencyCaps)
+	if err != nil {
+		return err
+	}
 
-	query := `INSERT INTO campaigns (id, name, status, budget_total, budget_daily,
-                                     start_date, end_date, targeting, created_at, updated_at)
-              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+	query := `INSERT INTO campaigns (id, advertiser_id, name, category, status, budget_total, budget_daily, priority,
+                                     pricing_model, bid, pacing, start_date, end_date, targeting, rotation_mode,
+                                     frequency_caps, created_at, updated_at)
+              VALUES ($1, NULLIF($2, '')::uuid, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)`
 
 	_, err = r.db.ExecContext(ctx, query,
-		campaign.ID, campaign.Name, campaign.Status, campaign.BudgetTotal, campaign.BudgetDaily,
-		campaign.StartDate, campaign.EndDate, targetingJSON, campaign.CreatedAt, campaign.UpdatedAt,
+		campaign.ID, campaign.AdvertiserID, campaign.Name, campaign.Category, campaign.Status, campaign.BudgetTotal, campaign.BudgetDaily,
+		campaign.PriorityTier(), campaign.PricingModel(), campaign.Bid, pacingMode(campaign.Pacing),
+		campaign.StartDate, campaign.EndDate, targetingJSON, rotationMode(campaign.Rotation), capsJSON,
+		campaign.CreatedAt, campaign.UpdatedAt,
 	)
 
 	return err
@@ -111,16 +126,68 @@ func (r *campaignRepository) Update(ctx context.Context, campaign *entities.Camp
 	if err != nil {
 		return err
 	}
+	capsJSON, err := frequencyCapsJSON(campaign.FrequencyCaps)
+	if err != nil {
+		return err
+	}
 
 	query := `UPDATE campaigns SET
-              name = $2, status = $3, budget_total = $4, budget_daily = $5,
-              start_date = $6, end_date = $7, targeting = $8, updated_at = $9
+              advertiser_id = NULLIF($2, '')::uuid, name = $3, category = $4, status = $5,
+              budget_total = $6, budget_daily = $7, priority = $8, pricing_model = $9, bid = $10, pacing = $11,
+              start_date = $12, end_date = $13, targeting = $14, rotation_mode = $15,
+              frequency_caps = $16, updated_at = $17
               WHERE id = $1`
 
 	_, err = r.db.ExecContext(ctx, query,
-		campaign.ID, campaign.Name, campaign.Status, campaign.BudgetTotal, campaign.BudgetDaily,
-		campaign.StartDate, campaign.EndDate, targetingJSON, campaign.UpdatedAt,
+		campaign.ID, campaign.AdvertiserID, campaign.Name, campaign.Category, campaign.Status, campaign.BudgetTotal, campaign.BudgetDaily,
+		campaign.PriorityTier(), campaign.PricingModel(), campaign.Bid, pacingMode(campaign.Pacing),
+		campaign.StartDate, campaign.EndDate, targetingJSON, rotationMode(campaign.Rotation), capsJSON,
+		campaign.UpdatedAt,
 	)
 
 	return err
 }
+
+// queryCampaigns runs a campaign SELECT and scans all rows
+func (r *campaignRepository) queryCampaigns(ctx context.Context, query string, args ...interface{}) ([]*entities.Campaign, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var campaigns []*entities.Campaign
+	for rows.Next() {
+		c, err := scanCampaign(rows)
+		if err != nil {
+			return nil, err
+		}
+		campaigns = append(campaigns, c)
+	}
+
+	return campaigns, rows.Err()
+}
+
+// rotationMode applies the column default for campaigns created without a mode
+func rotationMode(mode entities.RotationMode) entities.RotationMode {
+	if mode == "" {
+		return entities.RotationWeighted
+	}
+	return mode
+}
+
+// pacingMode applies the column default for campaigns created without pacing
+func pacingMode(mode entities.PacingMode) entities.PacingMode {
+	if mode == "" {
+		return entities.PacingASAP
+	}
+	return mode
+}
+
+// frequencyCapsJSON encodes caps for the JSONB column, storing "no caps" as an empty array
+func frequencyCapsJSON(caps []entities.FrequencyCap) ([]byte, error) {
+	if caps == nil {
+		caps = []entities.FrequencyCap{}
+	}
+	return json.Marshal(caps)
+}
@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/fall-out-bug/demo-adserver/src/domain/repositories"
+)
+
+type campaignSlotRepository struct {
+	db *sql.DB
+}
+
+// NewCampaignSlotRepository creates a new campaign slot repository
+func NewCampaignSlotRepository(db *sql.DB) repositories.CampaignSlotRepository {
+	return &campaignSlotRepository{db: db}
+}
+
+func (r *campaignSlotRepository) FindByCampaignID(ctx context.Context, campaignID string) ([]*entities.CampaignSlot, error) {
+	query := `SELECT campaign_id, slot_id, rule, created_at
+              FROM campaign_slots WHERE campaign_id = $1 ORDER BY slot_id`
+
+	return r.query(ctx, query, campaignID)
+}
+
+func (r *campaignSlotRepository) FindBySlotID(ctx context.Context, slotID string) ([]*entities.CampaignSlot, error) {
+	query := `SELECT campaign_id, slot_id, rule, created_at
+              FROM campaign_slots WHERE slot_id = $1 OR slot_id = $2 ORDER BY campaign_id`
+
+	return r.query(ctx, query, slotID, entities.AllSlots)
+}
+
+func (r *campaignSlotRepository) Assign(ctx context.Context, assignment *entities.CampaignSlot) error {
+	query := `INSERT INTO campaign_slots (campaign_id, slot_id, rule, created_at)
+              VALUES ($1, $2, $3, $4)
+              ON CONFLICT (campaign_id, slot_id) DO UPDATE SET rule = EXCLUDED.rule`
+
+	_, err := r.db.ExecContext(ctx, query,
+		assignment.CampaignID, assignment.SlotID, assignment.Rule, assignment.CreatedAt,
+	)
+
+	return err
+}
+
+func (r *campaignSlotRepository) Unassign(ctx context.Context, campaignID, slotID string) error {
+	query := `DELETE FROM campaign_slots WHERE campaign_id = $1 AND slot_id = $2`
+
+	_, err := r.db.ExecContext(ctx, query, campaignID, slotID)
+
+	return err
+}
+
+func (r *campaignSlotRepository) query(ctx context.Context, query string, args ...interface{}) ([]*entities.CampaignSlot, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var assignments []*entities.CampaignSlot
+	for rows.Next() {
+		var a entities.CampaignSlot
+		if err := rows.Scan(&a.CampaignID, &a.SlotID, &a.Rule, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, &a)
+	}
+
+	return assignments, rows.Err()
+}
@@ -0,0 +1,54 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/fall-out-bug/demo-adserver/src/domain/repositories"
+	"github.com/lib/pq"
+)
+
+type campaignStatsRepository struct {
+	db *sql.DB
+}
+
+// NewCampaignStatsRepository creates a new campaign stats repository
+func NewCampaignStatsRepository(db *sql.DB) repositories.CampaignStatsRepository {
+	return &campaignStatsRepository{db: db}
+}
+
+func (r *campaignStatsRepository) FindByCampaignIDs(ctx context.Context, campaignIDs []string, since time.Time) (map[string]entities.CampaignStats, error) {
+	stats := make(map[string]entities.CampaignStats)
+	if len(campaignIDs) == 0 {
+		return stats, nil
+	}
+
+	query := `SELECT i.campaign_id,
+                     COUNT(DISTINCT i.id),
+                     COUNT(DISTINCT c.impression_id),
+                     COUNT(DISTINCT b.impression_id)
+              FROM impressions i
+              LEFT JOIN clicks c ON c.impression_id = i.id AND NOT c.invalid
+              LEFT JOIN billable_events b ON b.impression_id = i.id AND b.event_type = 'conversion'
+              WHERE i.campaign_id::text = ANY($1) AND i.timestamp >= $2 AND NOT i.invalid
+              GROUP BY i.campaign_id`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(campaignIDs), since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var campaignID string
+		var s entities.CampaignStats
+		if err := rows.Scan(&campaignID, &s.Impressions, &s.Clicks, &s.Conversions); err != nil {
+			return nil, err
+		}
+		stats[campaignID] = s
+	}
+
+	return stats, rows.Err()
+}
@@ -18,13 +18,18 @@ func NewClickRepository(db *sql.DB) repositories.ClickRepository {
 	return &clickRepository{db: db}
 }
 
+// Create stores a click; a click ID that is already stored is skipped, so
+// redelivered click events are stored once
 func (r *clickRepository) Create(ctx context.Context, click *entities.Click) error {
-	query := `INSERT INTO clicks (id, impression_id, banner_id, timestamp, ip, referer, country)
-              VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	query := `INSERT INTO clicks (id, impression_id, banner_id, timestamp, ip, user_agent, referer,
+                                country, fraud_score, invalid, fraud_reasons)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+              ON CONFLICT (id) DO NOTHING`
 
 	_, err := r.db.ExecContext(ctx, query,
 		click.ID, click.ImpressionID, click.BannerID,
-		click.Timestamp, click.IP, click.Referer, click.Country,
+		click.Timestamp, click.IP, click.UserAgent, click.Referer, click.Country,
+		click.FraudScore, click.Invalid, joinReasons(click.FraudReasons),
 	)
 
 	return err
@@ -40,6 +45,16 @@ func (r *clickRepository) CountByBannerID(ctx context.Context, bannerID string,
 	return count, err
 }
 
+func (r *clickRepository) HasInvalidClick(ctx context.Context, impressionID string) (bool, error) {
+	var invalid bool
+
+	query := `SELECT EXISTS (SELECT 1 FROM clicks WHERE impression_id = $1 AND invalid)`
+
+	err := r.db.QueryRowContext(ctx, query, impressionID).Scan(&invalid)
+
+	return invalid, err
+}
+
 func (r *clickRepository) FindByImpressionID(ctx context.Context, impressionID string) (*entities.Impression, error) {
 	var i entities.Impression
 
@@ -3,6 +3,8 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
@@ -18,20 +20,70 @@ func NewImpressionRepository(db *sql.DB) repositories.ImpressionRepository {
 	return &impressionRepository{db: db}
 }
 
+// Create stores an impression; like CreateBatch it skips an impression ID
+// that is already stored, so redelivered impression events are stored once
 func (r *impressionRepository) Create(ctx context.Context, impression *entities.Impression) error {
 	query := `INSERT INTO impressions (id, banner_id, slot_id, campaign_id, timestamp, ip,
-                                     user_agent, referer, country, device, fraud_score)
-              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+                                     user_agent, referer, country, device, fraud_score,
+                                     invalid, fraud_reasons)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+              ON CONFLICT (id) DO NOTHING`
 
 	_, err := r.db.ExecContext(ctx, query,
 		impression.ID, impression.BannerID, impression.SlotID, impression.CampaignID,
 		impression.Timestamp, impression.IP, impression.UserAgent, impression.Referer,
 		impression.Country, impression.Device, impression.FraudScore,
+		impression.Invalid, joinReasons(impression.FraudReasons),
 	)
 
 	return err
 }
 
+// impressionBatchRows caps the rows of one INSERT below the 65535 bind parameters of Postgres
+const impressionBatchRows = 1000
+
+func (r *impressionRepository) CreateBatch(ctx context.Context, impressions []*entities.Impression) error {
+	for start := 0; start < len(impressions); start += impressionBatchRows {
+		end := start + impressionBatchRows
+		if end > len(impressions) {
+			end = len(impressions)
+		}
+		if err := r.insertBatch(ctx, impressions[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertBatch stores impressions with one multi-row INSERT
+func (r *impressionRepository) insertBatch(ctx context.Context, impressions []*entities.Impression) error {
+	var query strings.Builder
+	query.WriteString(`INSERT INTO impressions (id, banner_id, slot_id, campaign_id, timestamp, ip,
+                                     user_agent, referer, country, device, fraud_score,
+                                     invalid, fraud_reasons)
+              VALUES `)
+
+	args := make([]interface{}, 0, len(impressions)*13)
+	for i, impression := range impressions {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		n := len(args)
+		fmt.Fprintf(&query, "($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+			n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8, n+9, n+10, n+11, n+12, n+13)
+		args = append(args,
+			impression.ID, impression.BannerID, impression.SlotID, impression.CampaignID,
+			impression.Timestamp, impression.IP, impression.UserAgent, impression.Referer,
+			impression.Country, impression.Device, impression.FraudScore,
+			impression.Invalid, joinReasons(impression.FraudReasons),
+		)
+	}
+	query.WriteString(" ON CONFLICT (id) DO NOTHING")
+
+	_, err := r.db.ExecContext(ctx, query.String(), args...)
+	return err
+}
+
 func (r *impressionRepository) CountBySlotID(ctx context.Context, slotID string, since time.Time) (int64, error) {
 	var count int64
 
@@ -59,14 +111,16 @@ func (r *impressionRepository) Exists(ctx context.Context, slotID, userID string
 
 func (r *impressionRepository) FindByImpressionID(ctx context.Context, impressionID string) (*entities.Impression, error) {
 	var i entities.Impression
+	var reasons string
 
 	query := `SELECT id, banner_id, slot_id, campaign_id, timestamp, ip,
-              user_agent, referer, country, device, fraud_score
+              user_agent, referer, country, device, fraud_score, invalid, fraud_reasons
               FROM impressions WHERE id = $1`
 
 	err := r.db.QueryRowContext(ctx, query, impressionID).Scan(
 		&i.ID, &i.BannerID, &i.SlotID, &i.CampaignID, &i.Timestamp,
 		&i.IP, &i.UserAgent, &i.Referer, &i.Country, &i.Device, &i.FraudScore,
+		&i.Invalid, &reasons,
 	)
 
 	if err == sql.ErrNoRows {
@@ -75,6 +129,20 @@ func (r *impressionRepository) FindByImpressionID(ctx context.Context, impressio
 	if err != nil {
 		return nil, err
 	}
+	i.FraudReasons = splitReasons(reasons)
 
 	return &i, nil
 }
+
+// joinReasons stores fraud reasons as one comma-separated column
+func joinReasons(reasons []string) string {
+	return strings.Join(reasons, ",")
+}
+
+// splitReasons reads fraud reasons stored by joinReasons
+func splitReasons(reasons string) []string {
+	if reasons == "" {
+		return nil
+	}
+	return strings.Split(reasons, ",")
+}
@@ -0,0 +1,100 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/fall-out-bug/demo-adserver/src/domain/repositories"
+)
+
+type invalidTrafficRepository struct {
+	db *sql.DB
+}
+
+// NewInvalidTrafficRepository creates a new invalid traffic repository
+func NewInvalidTrafficRepository(db *sql.DB) repositories.InvalidTrafficRepository {
+	return &invalidTrafficRepository{db: db}
+}
+
+// invalidTrafficEvents selects the campaign, invalid flag and fraud reasons
+// of the impressions and clicks in [$1, $2), optionally of campaign $3.
+// Clicks take their campaign from the banner.
+const invalidTrafficEvents = `
+              SELECT 'impression' AS kind, COALESCE(campaign_id::text, '') AS campaign_id, invalid, fraud_reasons
+              FROM impressions
+              WHERE timestamp >= $1 AND timestamp < $2 AND ($3::text = '' OR campaign_id::text = $3)
+              UNION ALL
+              SELECT 'click', COALESCE(b.campaign_id::text, ''), c.invalid, c.fraud_reasons
+              FROM clicks c
+              JOIN banners b ON b.id = c.banner_id
+              WHERE c.timestamp >= $1 AND c.timestamp < $2 AND ($3::text = '' OR b.campaign_id::text = $3)`
+
+func (r *invalidTrafficRepository) Report(ctx context.Context, since, until time.Time, campaignID string) ([]*entities.InvalidTrafficReport, error) {
+	reports := make(map[string]*entities.InvalidTrafficReport)
+	report := func(campaignID string) *entities.InvalidTrafficReport {
+		if reports[campaignID] == nil {
+			reports[campaignID] = &entities.InvalidTrafficReport{CampaignID: campaignID, Reasons: map[string]int64{}}
+		}
+		return reports[campaignID]
+	}
+
+	countsQuery := `SELECT kind, campaign_id, COUNT(*), COUNT(*) FILTER (WHERE invalid)
+              FROM (` + invalidTrafficEvents + `) e
+              GROUP BY kind, campaign_id`
+
+	rows, err := r.db.QueryContext(ctx, countsQuery, since, until, campaignID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var kind, id string
+		var total, invalid int64
+		if err := rows.Scan(&kind, &id, &total, &invalid); err != nil {
+			return nil, err
+		}
+		if kind == "click" {
+			report(id).Clicks, report(id).InvalidClicks = total, invalid
+		} else {
+			report(id).Impressions, report(id).InvalidImpressions = total, invalid
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	reasonsQuery := `SELECT campaign_id, reason, COUNT(*)
+              FROM (` + invalidTrafficEvents + `) e,
+                   unnest(string_to_array(NULLIF(e.fraud_reasons, ''), ',')) AS reason
+              WHERE e.invalid
+              GROUP BY campaign_id, reason`
+
+	reasonRows, err := r.db.QueryContext(ctx, reasonsQuery, since, until, campaignID)
+	if err != nil {
+		return nil, err
+	}
+	defer reasonRows.Close()
+
+	for reasonRows.Next() {
+		var id, reason string
+		var count int64
+		if err := reasonRows.Scan(&id, &reason, &count); err != nil {
+			return nil, err
+		}
+		report(id).Reasons[reason] = count
+	}
+	if err := reasonRows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make([]*entities.InvalidTrafficReport, 0, len(reports))
+	for _, r := range reports {
+		result = append(result, r)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].CampaignID < result[j].CampaignID })
+	return result, nil
+}
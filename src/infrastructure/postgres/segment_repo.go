@@ -0,0 +1,91 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/fall-out-bug/demo-adserver/src/domain/repositories"
+)
+
+// segmentColumns is the column list shared by all segment SELECTs
+const segmentColumns = `id, advertiser_id, name, membership_ttl_seconds, created_at, updated_at`
+
+type segmentRepository struct {
+	db *sql.DB
+}
+
+// NewSegmentRepository creates a new audience segment repository
+func NewSegmentRepository(db *sql.DB) repositories.SegmentRepository {
+	return &segmentRepository{db: db}
+}
+
+// scanSegment reads a segment row selected with segmentColumns
+func scanSegment(row rowScanner) (*entities.Segment, error) {
+	var s entities.Segment
+	var ttlSeconds int64
+
+	if err := row.Scan(&s.ID, &s.AdvertiserID, &s.Name, &ttlSeconds, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		return nil, err
+	}
+	s.MembershipTTL = time.Duration(ttlSeconds) * time.Second
+
+	return &s, nil
+}
+
+func (r *segmentRepository) Create(ctx context.Context, segment *entities.Segment) error {
+	query := `INSERT INTO audience_segments (id, advertiser_id, name, membership_ttl_seconds, created_at, updated_at)
+              VALUES ($1, $2, $3, $4, $5, $6)`
+
+	_, err := r.db.ExecContext(ctx, query,
+		segment.ID, segment.AdvertiserID, segment.Name, int64(segment.MembershipTTL/time.Second),
+		segment.CreatedAt, segment.UpdatedAt,
+	)
+
+	return err
+}
+
+func (r *segmentRepository) FindByID(ctx context.Context, id string) (*entities.Segment, error) {
+	query := `SELECT ` + segmentColumns + ` FROM audience_segments WHERE id = $1`
+
+	s, err := scanSegment(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (r *segmentRepository) FindByAdvertiserID(ctx context.Context, advertiserID string) ([]*entities.Segment, error) {
+	query := `SELECT ` + segmentColumns + `
+              FROM audience_segments WHERE advertiser_id = $1 ORDER BY created_at`
+
+	rows, err := r.db.QueryContext(ctx, query, advertiserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var segments []*entities.Segment
+	for rows.Next() {
+		s, err := scanSegment(rows)
+		if err != nil {
+			return nil, err
+		}
+		segments = append(segments, s)
+	}
+
+	return segments, rows.Err()
+}
+
+func (r *segmentRepository) Delete(ctx context.Context, id string) error {
+	query := `DELETE FROM audience_segments WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, id)
+
+	return err
+}
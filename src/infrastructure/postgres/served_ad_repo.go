@@ -0,0 +1,99 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/fall-out-bug/demo-adserver/src/domain/repositories"
+	"github.com/shopspring/decimal"
+)
+
+// servedAdColumns is the column list shared by all served ad SELECTs
+const servedAdColumns = `id, COALESCE(banner_id::text, ''), COALESCE(campaign_id::text, ''), slot_id, status, price, pricing_model, bid, clearing_price, auction,
+              user_id, ip, user_agent, referer, country, device, served_at, won_at, rendered_at`
+
+type servedAdRepository struct {
+	db *sql.DB
+}
+
+// NewServedAdRepository creates a new served ad ledger
+func NewServedAdRepository(db *sql.DB) repositories.ServedAdRepository {
+	return &servedAdRepository{db: db}
+}
+
+func (r *servedAdRepository) Create(ctx context.Context, ad *entities.ServedAd) error {
+	query := `INSERT INTO served_ads (id, banner_id, campaign_id, slot_id, status, price, pricing_model, bid, clearing_price, auction,
+                                    user_id, ip, user_agent, referer, country, device, served_at)
+              VALUES ($1, NULLIF($2, '')::uuid, NULLIF($3, '')::uuid, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)`
+
+	_, err := r.db.ExecContext(ctx, query,
+		ad.ID, ad.BannerID, ad.CampaignID, ad.SlotID, ad.Status, ad.Price, ad.Pricing, ad.Bid, ad.ClearingPrice, ad.Auction,
+		ad.UserID, ad.IP, ad.UserAgent, ad.Referer, ad.Country, ad.Device, ad.ServedAt,
+	)
+
+	return err
+}
+
+func (r *servedAdRepository) FindByID(ctx context.Context, id string) (*entities.ServedAd, error) {
+	var a entities.ServedAd
+	var wonAt, renderedAt sql.NullTime
+
+	query := `SELECT ` + servedAdColumns + `
+              FROM served_ads WHERE id = $1`
+
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&a.ID, &a.BannerID, &a.CampaignID, &a.SlotID, &a.Status, &a.Price, &a.Pricing, &a.Bid, &a.ClearingPrice, &a.Auction,
+		&a.UserID, &a.IP, &a.UserAgent, &a.Referer, &a.Country, &a.Device, &a.ServedAt, &wonAt, &renderedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if wonAt.Valid {
+		a.WonAt = &wonAt.Time
+	}
+	if renderedAt.Valid {
+		a.RenderedAt = &renderedAt.Time
+	}
+
+	return &a, nil
+}
+
+func (r *servedAdRepository) MarkRendered(ctx context.Context, id string, at time.Time) (bool, error) {
+	query := `UPDATE served_ads SET status = $2, rendered_at = $3
+              WHERE id = $1 AND status = $4`
+
+	result, err := r.db.ExecContext(ctx, query, id, entities.ServedAdRendered, at, entities.ServedAdPending)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+func (r *servedAdRepository) UnmarkRendered(ctx context.Context, id string) error {
+	query := `UPDATE served_ads SET status = $2, rendered_at = NULL
+              WHERE id = $1 AND status = $3`
+
+	_, err := r.db.ExecContext(ctx, query, id, entities.ServedAdPending, entities.ServedAdRendered)
+	return err
+}
+
+func (r *servedAdRepository) MarkWon(ctx context.Context, id string, price decimal.Decimal, at time.Time) (bool, error) {
+	query := `UPDATE served_ads SET clearing_price = $2, won_at = $3
+              WHERE id = $1 AND won_at IS NULL`
+
+	result, err := r.db.ExecContext(ctx, query, id, price, at)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
@@ -0,0 +1,42 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/fall-out-bug/demo-adserver/src/domain/repositories"
+	"github.com/shopspring/decimal"
+)
+
+type spendRepository struct {
+	db *sql.DB
+}
+
+// NewSpendRepository creates a new campaign spend repository
+func NewSpendRepository(db *sql.DB) repositories.SpendRepository {
+	return &spendRepository{db: db}
+}
+
+func (r *spendRepository) SaveDaily(ctx context.Context, campaignID string, day time.Time, amount decimal.Decimal) error {
+	query := `INSERT INTO campaign_spend (campaign_id, day, amount, updated_at)
+              VALUES ($1, $2, $3, NOW())
+              ON CONFLICT (campaign_id, day) DO UPDATE SET amount = EXCLUDED.amount, updated_at = NOW()`
+
+	_, err := r.db.ExecContext(ctx, query, campaignID, day, amount)
+
+	return err
+}
+
+func (r *spendRepository) FindSpend(ctx context.Context, campaignID string, day time.Time) (entities.Spend, error) {
+	var spend entities.Spend
+
+	query := `SELECT COALESCE(SUM(amount), 0),
+                     COALESCE(SUM(amount) FILTER (WHERE day = $2), 0)
+              FROM campaign_spend WHERE campaign_id = $1`
+
+	err := r.db.QueryRowContext(ctx, query, campaignID, day).Scan(&spend.Total, &spend.Today)
+
+	return spend, err
+}
@@ -0,0 +1,30 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/fall-out-bug/demo-adserver/src/domain/repositories"
+)
+
+type videoEventRepository struct {
+	db *sql.DB
+}
+
+// NewVideoEventRepository creates a new video event repository
+func NewVideoEventRepository(db *sql.DB) repositories.VideoEventRepository {
+	return &videoEventRepository{db: db}
+}
+
+func (r *videoEventRepository) Create(ctx context.Context, event *entities.VideoEvent) error {
+	query := `INSERT INTO video_events (id, impression_id, banner_id, campaign_id, slot_id, event, timestamp, ip, user_agent)
+              VALUES ($1, $2, NULLIF($3, '')::uuid, NULLIF($4, '')::uuid, $5, $6, $7, $8, $9)`
+
+	_, err := r.db.ExecContext(ctx, query,
+		event.ID, event.ImpressionID, event.BannerID, event.CampaignID, event.SlotID,
+		event.Event, event.Timestamp, event.IP, event.UserAgent,
+	)
+
+	return err
+}
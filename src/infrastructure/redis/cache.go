@@ -6,10 +6,14 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
 	"github.com/redis/go-redis/v9"
 )
 
-// Cache handles banner caching with 5-minute TTL
+// generationKey holds the current cache generation; bumping it invalidates all slots at once
+const generationKey = "candidates:generation"
+
+// Cache handles slot candidate caching with 5-minute TTL
 type Cache struct {
 	client *redis.Client
 }
@@ -19,19 +23,33 @@ func NewCache(client *redis.Client) *Cache {
 	return &Cache{client: client}
 }
 
-// CachedBanner represents a cached banner response
-type CachedBanner struct {
-	HTML       string `json:"html"`
-	Width      int    `json:"width"`
-	Height     int    `json:"height"`
-	ClickURL   string `json:"click_url"`
-	Impression string `json:"impression_url"`
-	CampaignID string `json:"campaign_id"`
+// CachedCandidates represents the cached candidate set of a slot
+type CachedCandidates struct {
+	Candidates []CachedCandidate `json:"candidates"`
+	Demo       *CachedDemo       `json:"demo,omitempty"`
+}
+
+// CachedCandidate represents a cached campaign with its active banners and delivery history
+type CachedCandidate struct {
+	Campaign *entities.Campaign     `json:"campaign"`
+	Banners  []*entities.Banner     `json:"banners"`
+	Stats    entities.CampaignStats `json:"stats"`
+}
+
+// CachedDemo represents a cached demo fallback creative
+type CachedDemo struct {
+	Type   entities.CreativeType `json:"type,omitempty"`
+	HTML   string                `json:"html"`
+	Width  int                   `json:"width"`
+	Height int                   `json:"height"`
 }
 
-// GetBanner retrieves banner from cache
-func (c *Cache) GetBanner(ctx context.Context, slotID string) (*CachedBanner, error) {
-	key := fmt.Sprintf("banner:%s", slotID)
+// GetCandidates retrieves a slot's candidates from cache
+func (c *Cache) GetCandidates(ctx context.Context, slotID string) (*CachedCandidates, error) {
+	key, err := c.candidatesKey(ctx, slotID)
+	if err != nil {
+		return nil, err
+	}
 
 	data, err := c.client.Get(ctx, key).Bytes()
 	if err == redis.Nil {
@@ -41,19 +59,22 @@ func (c *Cache) GetBanner(ctx context.Context, slotID string) (*CachedBanner, er
 		return nil, err
 	}
 
-	var banner CachedBanner
-	if err := json.Unmarshal(data, &banner); err != nil {
+	var candidates CachedCandidates
+	if err := json.Unmarshal(data, &candidates); err != nil {
 		return nil, err
 	}
 
-	return &banner, nil
+	return &candidates, nil
 }
 
-// SetBanner stores banner in cache with 5-minute TTL
-func (c *Cache) SetBanner(ctx context.Context, slotID string, banner *CachedBanner) error {
-	key := fmt.Sprintf("banner:%s", slotID)
+// SetCandidates stores a slot's candidates in cache with 5-minute TTL
+func (c *Cache) SetCandidates(ctx context.Context, slotID string, candidates *CachedCandidates) error {
+	key, err := c.candidatesKey(ctx, slotID)
+	if err != nil {
+		return err
+	}
 
-	data, err := json.Marshal(banner)
+	data, err := json.Marshal(candidates)
 	if err != nil {
 		return err
 	}
@@ -61,8 +82,26 @@ func (c *Cache) SetBanner(ctx context.Context, slotID string, banner *CachedBann
 	return c.client.Set(ctx, key, data, 5*time.Minute).Err()
 }
 
-// InvalidateBanner removes a banner from cache
-func (c *Cache) InvalidateBanner(ctx context.Context, slotID string) error {
-	key := fmt.Sprintf("banner:%s", slotID)
+// InvalidateSlot removes a slot's candidates from cache
+func (c *Cache) InvalidateSlot(ctx context.Context, slotID string) error {
+	key, err := c.candidatesKey(ctx, slotID)
+	if err != nil {
+		return err
+	}
 	return c.client.Del(ctx, key).Err()
 }
+
+// InvalidateAll invalidates every slot by moving to a new cache generation.
+// Entries of older generations are never read again and expire on their TTL.
+func (c *Cache) InvalidateAll(ctx context.Context) error {
+	return c.client.Incr(ctx, generationKey).Err()
+}
+
+// candidatesKey builds the slot key for the current cache generation
+func (c *Cache) candidatesKey(ctx context.Context, slotID string) (string, error) {
+	generation, err := c.client.Get(ctx, generationKey).Int64()
+	if err != nil && err != redis.Nil {
+		return "", err
+	}
+	return fmt.Sprintf("candidates:%d:%s", generation, slotID), nil
+}
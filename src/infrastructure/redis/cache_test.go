@@ -6,7 +6,9 @@ import (
 	"time"
 
 	"github.com/alicebob/miniredis/v2"
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
 	"github.com/redis/go-redis/v9"
+	"github.com/shopspring/decimal"
 )
 
 func setupTestRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
@@ -22,7 +24,7 @@ func setupTestRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
 	return s, client
 }
 
-func TestCache_GetSetBanner(t *testing.T) {
+func TestCache_GetSetCandidates(t *testing.T) {
 	s, client := setupTestRedis(t)
 	defer s.Close()
 	defer client.Close()
@@ -30,31 +32,45 @@ func TestCache_GetSetBanner(t *testing.T) {
 	ctx := context.Background()
 	cache := NewCache(client)
 
-	banner := &CachedBanner{
-		HTML:       "<div>Test Ad</div>",
-		Width:      300,
-		Height:     250,
-		ClickURL:   "https://example.com",
-		Impression: "https://tracker.example.com/i",
-		CampaignID: "cmp-1",
+	candidates := &CachedCandidates{
+		Candidates: []CachedCandidate{
+			{
+				Campaign: &entities.Campaign{
+					ID:          "cmp-1",
+					Status:      entities.CampaignStatusActive,
+					BudgetTotal: decimal.NewFromInt(1000),
+					Targeting:   entities.Targeting{Geo: []string{"US"}},
+				},
+				Banners: []*entities.Banner{
+					{ID: "ban-1", CampaignID: "cmp-1", HTML: "<div>Test Ad</div>", Weight: 2},
+				},
+			},
+		},
 	}
 
 	// Test Set
-	err := cache.SetBanner(ctx, "slot-1", banner)
+	err := cache.SetCandidates(ctx, "slot-1", candidates)
 	if err != nil {
-		t.Errorf("Failed to set banner: %v", err)
+		t.Errorf("Failed to set candidates: %v", err)
 	}
 
 	// Test Get
-	retrieved, err := cache.GetBanner(ctx, "slot-1")
+	retrieved, err := cache.GetCandidates(ctx, "slot-1")
 	if err != nil {
-		t.Errorf("Failed to get banner: %v", err)
+		t.Errorf("Failed to get candidates: %v", err)
 	}
-	if retrieved == nil {
-		t.Errorf("Expected banner, got nil")
+	if retrieved == nil || len(retrieved.Candidates) != 1 {
+		t.Fatalf("Expected one candidate, got %v", retrieved)
 	}
-	if retrieved.HTML != banner.HTML {
-		t.Errorf("Expected HTML %s, got %s", banner.HTML, retrieved.HTML)
+	got := retrieved.Candidates[0]
+	if got.Campaign.ID != "cmp-1" || got.Campaign.Targeting.Geo[0] != "US" {
+		t.Errorf("Expected campaign to round-trip, got %+v", got.Campaign)
+	}
+	if !got.Campaign.BudgetTotal.Equal(decimal.NewFromInt(1000)) {
+		t.Errorf("Expected budget 1000, got %s", got.Campaign.BudgetTotal)
+	}
+	if len(got.Banners) != 1 || got.Banners[0].HTML != "<div>Test Ad</div>" {
+		t.Errorf("Expected banner to round-trip, got %+v", got.Banners)
 	}
 }
 
@@ -66,12 +82,12 @@ func TestCache_MissReturnsNil(t *testing.T) {
 	ctx := context.Background()
 	cache := NewCache(client)
 
-	banner, err := cache.GetBanner(ctx, "nonexistent")
+	candidates, err := cache.GetCandidates(ctx, "nonexistent")
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
-	if banner != nil {
-		t.Errorf("Expected nil for cache miss, got banner")
+	if candidates != nil {
+		t.Errorf("Expected nil for cache miss, got candidates")
 	}
 }
 
@@ -97,7 +97,7 @@ func TestDeduper_GenerateUserID(t *testing.T) {
 	}
 }
 
-func TestCache_InvalidateBanner(t *testing.T) {
+func TestCache_InvalidateSlot(t *testing.T) {
 	s, client := setupTestRedis(t)
 	defer s.Close()
 	defer client.Close()
@@ -105,26 +105,63 @@ func TestCache_InvalidateBanner(t *testing.T) {
 	ctx := context.Background()
 	cache := NewCache(client)
 
-	banner := &CachedBanner{
-		HTML:     "<div>Test Ad</div>",
-		Width:    300,
-		Height:   250,
-		ClickURL: "https://example.com",
+	candidates := &CachedCandidates{
+		Demo: &CachedDemo{HTML: "<div>Test Ad</div>", Width: 300, Height: 250},
 	}
 
-	// Set banner
-	cache.SetBanner(ctx, "slot-1", banner)
+	// Set candidates for two slots
+	cache.SetCandidates(ctx, "slot-1", candidates)
+	cache.SetCandidates(ctx, "slot-2", candidates)
 
-	// Invalidate
-	err := cache.InvalidateBanner(ctx, "slot-1")
+	// Invalidate one slot
+	err := cache.InvalidateSlot(ctx, "slot-1")
 	if err != nil {
 		t.Errorf("Failed to invalidate: %v", err)
 	}
 
-	// Check it's gone
-	retrieved, _ := cache.GetBanner(ctx, "slot-1")
+	// Check it's gone and the other slot is untouched
+	retrieved, _ := cache.GetCandidates(ctx, "slot-1")
 	if retrieved != nil {
-		t.Errorf("Expected banner to be removed after invalidation")
+		t.Errorf("Expected candidates to be removed after invalidation")
+	}
+	retrieved, _ = cache.GetCandidates(ctx, "slot-2")
+	if retrieved == nil {
+		t.Errorf("Expected other slot to stay cached")
+	}
+}
+
+func TestCache_InvalidateAll(t *testing.T) {
+	s, client := setupTestRedis(t)
+	defer s.Close()
+	defer client.Close()
+
+	ctx := context.Background()
+	cache := NewCache(client)
+
+	candidates := &CachedCandidates{
+		Demo: &CachedDemo{HTML: "<div>Test Ad</div>", Width: 300, Height: 250},
+	}
+	cache.SetCandidates(ctx, "slot-1", candidates)
+	cache.SetCandidates(ctx, "slot-2", candidates)
+
+	if err := cache.InvalidateAll(ctx); err != nil {
+		t.Fatalf("Failed to invalidate all: %v", err)
+	}
+
+	for _, slotID := range []string{"slot-1", "slot-2"} {
+		retrieved, err := cache.GetCandidates(ctx, slotID)
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		if retrieved != nil {
+			t.Errorf("Expected %s to be invalidated", slotID)
+		}
+	}
+
+	// New writes land in the new generation
+	cache.SetCandidates(ctx, "slot-1", candidates)
+	if retrieved, _ := cache.GetCandidates(ctx, "slot-1"); retrieved == nil {
+		t.Errorf("Expected candidates cached after invalidation")
 	}
 }
 
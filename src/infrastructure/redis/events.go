@@ -0,0 +1,231 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/redis/go-redis/v9"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// eventStreamKey is the stream of tracked impressions and clicks
+	eventStreamKey = "events:tracking"
+	// eventDeadLetterKey keeps events that could not be stored, with the reason
+	eventDeadLetterKey = "events:tracking:dead"
+	// eventConsumerGroup is the group of consumers writing events to Postgres
+	eventConsumerGroup = "tracking-writers"
+	// eventReadBlock is how long a read waits for new events
+	eventReadBlock = time.Second
+)
+
+// eventPayload is the wire form of a tracking event in the stream
+type eventPayload struct {
+	ID            string               `json:"id"`
+	Type          entities.EventType   `json:"type"`
+	Impression    *entities.Impression `json:"impression"`
+	Click         *entities.Click      `json:"click,omitempty"`
+	ClearingPrice decimal.Decimal      `json:"clearing_price"`
+}
+
+// encodeEvent returns the wire form of an event
+func encodeEvent(event *entities.TrackingEvent) ([]byte, error) {
+	return json.Marshal(eventPayload{
+		ID:            event.ID,
+		Type:          event.Type,
+		Impression:    event.Impression,
+		Click:         event.Click,
+		ClearingPrice: event.ClearingPrice,
+	})
+}
+
+// EventStream is a durable queue of tracking events on a Redis Stream, read
+// by a consumer group. Events stay pending until acknowledged; events left
+// pending longer than the retry delay are delivered again.
+type EventStream struct {
+	client     *redis.Client
+	maxLen     int64
+	retryDelay time.Duration
+	block      time.Duration
+}
+
+// NewEventStream creates a new event stream capped at about maxLen entries
+// (0 keeps all). Unacknowledged events are retried after retryDelay.
+func NewEventStream(client *redis.Client, maxLen int64, retryDelay time.Duration) *EventStream {
+	return &EventStream{
+		client:     client,
+		maxLen:     maxLen,
+		retryDelay: retryDelay,
+		block:      eventReadBlock,
+	}
+}
+
+// Publish appends events to the stream
+func (s *EventStream) Publish(ctx context.Context, events []*entities.TrackingEvent) error {
+	pipe := s.client.Pipeline()
+	for _, event := range events {
+		payload, err := encodeEvent(event)
+		if err != nil {
+			return err
+		}
+		pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: eventStreamKey,
+			MaxLen: s.maxLen,
+			Approx: true,
+			Values: map[string]interface{}{"event": payload},
+		})
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// EnsureGroup creates the consumer group unless it exists. A new group
+// starts at the beginning of the stream, so events published before the
+// first consumer started are stored as well.
+func (s *EventStream) EnsureGroup(ctx context.Context) error {
+	err := s.client.XGroupCreateMkStream(ctx, eventStreamKey, eventConsumerGroup, "0").Err()
+	if err != nil && strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil
+	}
+	return err
+}
+
+// Read returns up to count events for the consumer. Events left pending
+// longer than the retry delay, by this or a crashed consumer, come first;
+// otherwise it waits briefly for new ones. Malformed entries are moved to
+// the dead letter stream.
+func (s *EventStream) Read(ctx context.Context, consumer string, count int) ([]*entities.TrackingEvent, error) {
+	claimed, _, err := s.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   eventStreamKey,
+		Group:    eventConsumerGroup,
+		Consumer: consumer,
+		MinIdle:  s.retryDelay,
+		Start:    "0-0",
+		Count:    int64(count),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(claimed) > 0 {
+		deliveries, err := s.deliveries(ctx, claimed)
+		if err != nil {
+			return nil, err
+		}
+		return s.decode(ctx, claimed, deliveries)
+	}
+
+	streams, err := s.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    eventConsumerGroup,
+		Consumer: consumer,
+		Streams:  []string{eventStreamKey, ">"},
+		Count:    int64(count),
+		Block:    s.block,
+	}).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil || len(streams) == 0 {
+		return nil, err
+	}
+	return s.decode(ctx, streams[0].Messages, nil)
+}
+
+// Ack acknowledges stored events, so they are not delivered again
+func (s *EventStream) Ack(ctx context.Context, events []*entities.TrackingEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	ids := make([]string, len(events))
+	for i, event := range events {
+		ids[i] = event.StreamID
+	}
+	return s.client.XAck(ctx, eventStreamKey, eventConsumerGroup, ids...).Err()
+}
+
+// DeadLetter moves an event that can't be stored to the dead letter stream
+func (s *EventStream) DeadLetter(ctx context.Context, event *entities.TrackingEvent, reason string) error {
+	payload, err := encodeEvent(event)
+	if err != nil {
+		return err
+	}
+	return s.deadLetter(ctx, event.StreamID, string(payload), reason, event.Deliveries)
+}
+
+// deadLetter adds an entry to the dead letter stream and acknowledges the original
+func (s *EventStream) deadLetter(ctx context.Context, streamID, payload, reason string, deliveries int64) error {
+	pipe := s.client.TxPipeline()
+	pipe.XAdd(ctx, &redis.XAddArgs{
+		Stream: eventDeadLetterKey,
+		Values: map[string]interface{}{
+			"event":      payload,
+			"reason":     reason,
+			"stream_id":  streamID,
+			"deliveries": deliveries,
+		},
+	})
+	pipe.XAck(ctx, eventStreamKey, eventConsumerGroup, streamID)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// deliveries looks up how often claimed entries have been delivered
+func (s *EventStream) deliveries(ctx context.Context, messages []redis.XMessage) (map[string]int64, error) {
+	pipe := s.client.Pipeline()
+	cmds := make([]*redis.XPendingExtCmd, len(messages))
+	for i, msg := range messages {
+		cmds[i] = pipe.XPendingExt(ctx, &redis.XPendingExtArgs{
+			Stream: eventStreamKey,
+			Group:  eventConsumerGroup,
+			Start:  msg.ID,
+			End:    msg.ID,
+			Count:  1,
+		})
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	deliveries := make(map[string]int64, len(messages))
+	for _, cmd := range cmds {
+		for _, pending := range cmd.Val() {
+			deliveries[pending.ID] = pending.RetryCount
+		}
+	}
+	return deliveries, nil
+}
+
+// decode turns stream entries into events; entries without a delivery count
+// are delivered for the first time
+func (s *EventStream) decode(ctx context.Context, messages []redis.XMessage, deliveries map[string]int64) ([]*entities.TrackingEvent, error) {
+	events := make([]*entities.TrackingEvent, 0, len(messages))
+	for _, msg := range messages {
+		raw, _ := msg.Values["event"].(string)
+
+		var payload eventPayload
+		if err := json.Unmarshal([]byte(raw), &payload); err != nil || payload.ID == "" || payload.Impression == nil ||
+			(payload.Type == entities.EventClick && payload.Click == nil) {
+			if err := s.deadLetter(ctx, msg.ID, raw, "malformed event", deliveries[msg.ID]); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		event := &entities.TrackingEvent{
+			ID:            payload.ID,
+			Type:          payload.Type,
+			Impression:    payload.Impression,
+			Click:         payload.Click,
+			ClearingPrice: payload.ClearingPrice,
+			StreamID:      msg.ID,
+			Deliveries:    1,
+		}
+		if count, ok := deliveries[msg.ID]; ok {
+			event.Deliveries = count
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
@@ -0,0 +1,116 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/redis/go-redis/v9"
+	"github.com/shopspring/decimal"
+)
+
+func TestEventStream_PublishReadAck(t *testing.T) {
+	s, client := setupTestRedis(t)
+	defer s.Close()
+	defer client.Close()
+
+	ctx := context.Background()
+	stream := NewEventStream(client, 1000, time.Minute)
+	stream.block = time.Millisecond
+
+	impression := &entities.Impression{ID: "imp-1", BannerID: "ban-1", CampaignID: "cmp-1", SlotID: "sidebar"}
+	click := &entities.Click{ID: "clk-1", ImpressionID: "imp-1", BannerID: "ban-1"}
+	if err := stream.Publish(ctx, []*entities.TrackingEvent{
+		entities.NewImpressionEvent(impression, decimal.NewFromFloat(1.5)),
+		entities.NewClickEvent(click, impression),
+	}); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	// Published before the group existed, still read
+	if err := stream.EnsureGroup(ctx); err != nil {
+		t.Fatalf("Failed to create group: %v", err)
+	}
+	if err := stream.EnsureGroup(ctx); err != nil {
+		t.Fatalf("Expected an existing group to be kept, got %v", err)
+	}
+
+	events, err := stream.Read(ctx, "worker-1", 10)
+	if err != nil {
+		t.Fatalf("Failed to read: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(events))
+	}
+	if events[0].ID != "imp-1" || events[0].Type != entities.EventImpression || !events[0].ClearingPrice.Equal(decimal.NewFromFloat(1.5)) {
+		t.Errorf("Unexpected impression event %+v", events[0])
+	}
+	if events[1].ID != "clk-1" || events[1].Click == nil || events[1].Impression.CampaignID != "cmp-1" {
+		t.Errorf("Unexpected click event %+v", events[1])
+	}
+	if events[0].StreamID == "" || events[0].Deliveries != 1 {
+		t.Errorf("Expected a first delivery with a stream ID, got %+v", events[0])
+	}
+
+	if err := stream.Ack(ctx, events); err != nil {
+		t.Fatalf("Failed to ack: %v", err)
+	}
+	if pending := client.XPending(ctx, eventStreamKey, eventConsumerGroup).Val(); pending.Count != 0 {
+		t.Errorf("Expected no pending events, got %d", pending.Count)
+	}
+}
+
+func TestEventStream_RetryAndDeadLetter(t *testing.T) {
+	s, client := setupTestRedis(t)
+	defer s.Close()
+	defer client.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+	s.SetTime(now)
+
+	stream := NewEventStream(client, 0, time.Minute)
+	stream.block = time.Millisecond
+	stream.EnsureGroup(ctx)
+
+	impression := &entities.Impression{ID: "imp-1", CampaignID: "cmp-1"}
+	stream.Publish(ctx, []*entities.TrackingEvent{entities.NewImpressionEvent(impression, decimal.Zero)})
+	client.XAdd(ctx, &redis.XAddArgs{Stream: eventStreamKey, Values: map[string]interface{}{"event": "{not json"}})
+
+	// The malformed entry goes straight to the dead letters
+	events, _ := stream.Read(ctx, "worker-1", 10)
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(events))
+	}
+
+	// Not acknowledged: nothing to read until the retry delay passed
+	if events, _ := stream.Read(ctx, "worker-1", 10); len(events) != 0 {
+		t.Fatalf("Expected no events before the retry delay, got %d", len(events))
+	}
+
+	// Another consumer takes it over
+	s.SetTime(now.Add(2 * time.Minute))
+	events, err := stream.Read(ctx, "worker-2", 10)
+	if err != nil {
+		t.Fatalf("Failed to read: %v", err)
+	}
+	if len(events) != 1 || events[0].ID != "imp-1" || events[0].Deliveries != 2 {
+		t.Fatalf("Expected imp-1 delivered a second time, got %+v", events)
+	}
+
+	if err := stream.DeadLetter(ctx, events[0], "insert failed"); err != nil {
+		t.Fatalf("Failed to dead-letter: %v", err)
+	}
+
+	dead := client.XRange(ctx, eventDeadLetterKey, "-", "+").Val()
+	if len(dead) != 2 {
+		t.Fatalf("Expected 2 dead letters, got %d", len(dead))
+	}
+	if dead[0].Values["reason"] != "malformed event" || dead[1].Values["reason"] != "insert failed" {
+		t.Errorf("Unexpected dead letters %+v", dead)
+	}
+	if pending := client.XPending(ctx, eventStreamKey, eventConsumerGroup).Val(); pending.Count != 0 {
+		t.Errorf("Expected dead letters acknowledged, got %d pending", pending.Count)
+	}
+}
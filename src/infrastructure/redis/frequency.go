@@ -0,0 +1,64 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// FrequencyCounter keeps per-viewer exposure counts for frequency capping
+type FrequencyCounter struct {
+	client *redis.Client
+}
+
+// NewFrequencyCounter creates a new frequency counter instance
+func NewFrequencyCounter(client *redis.Client) *FrequencyCounter {
+	return &FrequencyCounter{client: client}
+}
+
+// Counts returns the current exposure count of each key
+func (f *FrequencyCounter) Counts(ctx context.Context, keys []string) ([]int64, error) {
+	redisKeys := make([]string, len(keys))
+	for i, key := range keys {
+		redisKeys[i] = frequencyKey(key)
+	}
+
+	values, err := f.client.MGet(ctx, redisKeys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]int64, len(keys))
+	for i, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			continue // Missing or expired key
+		}
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		counts[i] = n
+	}
+
+	return counts, nil
+}
+
+// Record adds one exposure to each key. The expiry is only set on the first
+// exposure, so the window starts when the viewer first saw the ad.
+func (f *FrequencyCounter) Record(ctx context.Context, windows map[string]time.Duration) error {
+	pipe := f.client.TxPipeline()
+	for key, window := range windows {
+		pipe.Incr(ctx, frequencyKey(key))
+		pipe.ExpireNX(ctx, frequencyKey(key), window)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// frequencyKey namespaces a frequency counter key
+func frequencyKey(key string) string {
+	return "freq:" + key
+}
@@ -0,0 +1,56 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFrequencyCounter_RecordAndCount(t *testing.T) {
+	s, client := setupTestRedis(t)
+	defer s.Close()
+	defer client.Close()
+
+	ctx := context.Background()
+	counter := NewFrequencyCounter(client)
+
+	windows := map[string]time.Duration{"campaign:cmp-1:day:alice": 24 * time.Hour}
+	for i := 0; i < 3; i++ {
+		if err := counter.Record(ctx, windows); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	counts, err := counter.Counts(ctx, []string{"campaign:cmp-1:day:alice", "campaign:cmp-1:day:bob"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if counts[0] != 3 || counts[1] != 0 {
+		t.Errorf("Expected counts [3 0], got %v", counts)
+	}
+
+	// The window is anchored at the first exposure
+	if ttl := s.TTL("freq:campaign:cmp-1:day:alice"); ttl != 24*time.Hour {
+		t.Errorf("Expected TTL 24h, got %v", ttl)
+	}
+}
+
+func TestFrequencyCounter_WindowExpires(t *testing.T) {
+	s, client := setupTestRedis(t)
+	defer s.Close()
+	defer client.Close()
+
+	ctx := context.Background()
+	counter := NewFrequencyCounter(client)
+
+	counter.Record(ctx, map[string]time.Duration{"banner:ban-1:hour:alice": time.Hour})
+	s.FastForward(time.Hour + time.Second)
+
+	counts, err := counter.Counts(ctx, []string{"banner:ban-1:hour:alice"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if counts[0] != 0 {
+		t.Errorf("Expected count to reset after the window, got %d", counts[0])
+	}
+}
@@ -70,13 +70,13 @@ func TestCache_InvalidJSON(t *testing.T) {
 	cache := NewCache(client)
 
 	// Manually set invalid JSON
-	err := client.Set(ctx, "banner:slot-bad", "invalid json", 5*time.Minute).Err()
+	err := client.Set(ctx, "candidates:0:slot-bad", "invalid json", 5*time.Minute).Err()
 	if err != nil {
 		t.Fatalf("Failed to set bad data: %v", err)
 	}
 
-	// GetBanner should return error for invalid JSON
-	_, err = cache.GetBanner(ctx, "slot-bad")
+	// GetCandidates should return error for invalid JSON
+	_, err = cache.GetCandidates(ctx, "slot-bad")
 	if err == nil {
 		t.Errorf("Expected error for invalid JSON, got nil")
 	}
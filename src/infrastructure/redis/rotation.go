@@ -0,0 +1,36 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// rotationTTL bounds how long per-viewer storyboard positions are kept
+const rotationTTL = 24 * time.Hour
+
+// RotationCounter keeps banner rotation positions shared across server instances
+type RotationCounter struct {
+	client *redis.Client
+}
+
+// NewRotationCounter creates a new rotation counter instance
+func NewRotationCounter(client *redis.Client) *RotationCounter {
+	return &RotationCounter{client: client}
+}
+
+// Next increments and returns the rotation position for key (starting at 1)
+func (r *RotationCounter) Next(ctx context.Context, key string) (int64, error) {
+	redisKey := fmt.Sprintf("rotation:%s", key)
+
+	pipe := r.client.TxPipeline()
+	incr := pipe.Incr(ctx, redisKey)
+	pipe.Expire(ctx, redisKey, rotationTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+
+	return incr.Val(), nil
+}
@@ -0,0 +1,37 @@
+package redis
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRotationCounter_Next_IncrementsPerKey(t *testing.T) {
+	s, client := setupTestRedis(t)
+	defer s.Close()
+	defer client.Close()
+
+	ctx := context.Background()
+	counter := NewRotationCounter(client)
+
+	for want := int64(1); want <= 3; want++ {
+		got, err := counter.Next(ctx, "even:cmp-1")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if got != want {
+			t.Errorf("Expected position %d, got %d", want, got)
+		}
+	}
+
+	other, err := counter.Next(ctx, "even:cmp-2")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if other != 1 {
+		t.Errorf("Expected independent key to start at 1, got %d", other)
+	}
+
+	if ttl := s.TTL("rotation:even:cmp-1"); ttl != rotationTTL {
+		t.Errorf("Expected TTL %v, got %v", rotationTTL, ttl)
+	}
+}
@@ -0,0 +1,49 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// SegmentStore keeps the audience segments of each viewer. Memberships are
+// members of a per-viewer sorted set scored by their expiry time.
+type SegmentStore struct {
+	client *redis.Client
+	now    func() time.Time
+}
+
+// NewSegmentStore creates a new segment membership store
+func NewSegmentStore(client *redis.Client) *SegmentStore {
+	return &SegmentStore{client: client, now: time.Now}
+}
+
+// Add puts a viewer into a segment for ttl, extending an existing membership
+func (s *SegmentStore) Add(ctx context.Context, userID, segmentID string, ttl time.Duration) error {
+	key := segmentKey(userID)
+	now := s.now()
+
+	pipe := s.client.TxPipeline()
+	pipe.ZAdd(ctx, key, redis.Z{Score: float64(now.Add(ttl).Unix()), Member: segmentID})
+	pipe.ZRemRangeByScore(ctx, key, "-inf", strconv.FormatInt(now.Unix(), 10))
+	// Keep the set as long as its longest membership
+	pipe.ExpireNX(ctx, key, ttl)
+	pipe.ExpireGT(ctx, key, ttl)
+	_, err := pipe.Exec(ctx)
+
+	return err
+}
+
+// Segments returns the segments a viewer currently belongs to
+func (s *SegmentStore) Segments(ctx context.Context, userID string) ([]string, error) {
+	return s.client.ZRangeByScore(ctx, segmentKey(userID), &redis.ZRangeBy{
+		Min: "(" + strconv.FormatInt(s.now().Unix(), 10),
+		Max: "+inf",
+	}).Result()
+}
+
+func segmentKey(userID string) string {
+	return "segments:" + userID
+}
@@ -0,0 +1,50 @@
+package redis
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSegmentStore_AddAndExpire(t *testing.T) {
+	s, client := setupTestRedis(t)
+	defer s.Close()
+	defer client.Close()
+
+	ctx := context.Background()
+	store := NewSegmentStore(client)
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	if err := store.Add(ctx, "alice", "seg-short", time.Hour); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := store.Add(ctx, "alice", "seg-long", 48*time.Hour); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	segments, err := store.Segments(ctx, "alice")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !reflect.DeepEqual(segments, []string{"seg-short", "seg-long"}) {
+		t.Errorf("Expected both segments, got %v", segments)
+	}
+
+	// The key lives as long as the longest membership
+	if ttl := s.TTL("segments:alice"); ttl != 48*time.Hour {
+		t.Errorf("Expected TTL 48h, got %v", ttl)
+	}
+
+	// Memberships expire individually
+	now = now.Add(2 * time.Hour)
+	segments, _ = store.Segments(ctx, "alice")
+	if !reflect.DeepEqual(segments, []string{"seg-long"}) {
+		t.Errorf("Expected only the long membership, got %v", segments)
+	}
+
+	if segments, _ := store.Segments(ctx, "bob"); len(segments) != 0 {
+		t.Errorf("Expected no segments for unknown viewer, got %v", segments)
+	}
+}
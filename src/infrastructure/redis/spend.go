@@ -0,0 +1,139 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// spendDirtyKey holds "campaignID/day" members whose daily spend is not yet in Postgres
+	spendDirtyKey = "spend:dirty"
+	// spendTotalTTL lets idle campaigns drop out; they are reseeded from Postgres
+	spendTotalTTL = 7 * 24 * time.Hour
+	// spendDayTTL keeps yesterday's counter around for late reconciliation
+	spendDayTTL = 48 * time.Hour
+)
+
+// SpendStore keeps live campaign spend counters in micro-units of currency
+type SpendStore struct {
+	client *redis.Client
+}
+
+// NewSpendStore creates a new spend store instance
+func NewSpendStore(client *redis.Client) *SpendStore {
+	return &SpendStore{client: client}
+}
+
+// Add debits micros from the campaign's total and daily counters and marks the day dirty
+func (s *SpendStore) Add(ctx context.Context, campaignID, day string, micros int64) error {
+	totalKey, dayKey := spendKeys(campaignID, day)
+
+	pipe := s.client.TxPipeline()
+	pipe.IncrBy(ctx, totalKey, micros)
+	pipe.Expire(ctx, totalKey, spendTotalTTL)
+	pipe.IncrBy(ctx, dayKey, micros)
+	pipe.Expire(ctx, dayKey, spendDayTTL)
+	pipe.SAdd(ctx, spendDirtyKey, campaignID+"/"+day)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Get returns the campaign's total and daily spend; ok is false when the
+// counters have not been seeded yet
+func (s *SpendStore) Get(ctx context.Context, campaignID, day string) (total, today int64, ok bool, err error) {
+	totalKey, dayKey := spendKeys(campaignID, day)
+
+	values, err := s.client.MGet(ctx, totalKey, dayKey).Result()
+	if err != nil {
+		return 0, 0, false, err
+	}
+	if values[0] == nil {
+		return 0, 0, false, nil
+	}
+
+	if total, err = strconv.ParseInt(values[0].(string), 10, 64); err != nil {
+		return 0, 0, false, err
+	}
+	if values[1] != nil {
+		if today, err = strconv.ParseInt(values[1].(string), 10, 64); err != nil {
+			return 0, 0, false, err
+		}
+	}
+
+	return total, today, true, nil
+}
+
+// GetMany returns the total and daily spend of several campaigns in one
+// round trip; campaigns whose counters have not been seeded yet are missing
+// from totals
+func (s *SpendStore) GetMany(ctx context.Context, campaignIDs []string, day string) (totals, today map[string]int64, err error) {
+	keys := make([]string, 0, 2*len(campaignIDs))
+	for _, id := range campaignIDs {
+		totalKey, dayKey := spendKeys(id, day)
+		keys = append(keys, totalKey, dayKey)
+	}
+
+	values, err := s.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	totals = make(map[string]int64, len(campaignIDs))
+	today = make(map[string]int64, len(campaignIDs))
+	for i, id := range campaignIDs {
+		if values[2*i] == nil {
+			continue
+		}
+		if totals[id], err = strconv.ParseInt(values[2*i].(string), 10, 64); err != nil {
+			return nil, nil, err
+		}
+		if values[2*i+1] != nil {
+			if today[id], err = strconv.ParseInt(values[2*i+1].(string), 10, 64); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	return totals, today, nil
+}
+
+// Seed initializes the counters from durable storage unless another instance already did
+func (s *SpendStore) Seed(ctx context.Context, campaignID, day string, total, today int64) error {
+	totalKey, dayKey := spendKeys(campaignID, day)
+
+	pipe := s.client.TxPipeline()
+	pipe.SetNX(ctx, totalKey, total, spendTotalTTL)
+	pipe.SetNX(ctx, dayKey, today, spendDayTTL)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// TakeDirty removes and returns up to count days awaiting reconciliation
+func (s *SpendStore) TakeDirty(ctx context.Context, count int64) ([]string, error) {
+	members, err := s.client.SPopN(ctx, spendDirtyKey, count).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	return members, err
+}
+
+// MarkDirty queues days for reconciliation again, e.g. after a failed write
+func (s *SpendStore) MarkDirty(ctx context.Context, members ...string) error {
+	if len(members) == 0 {
+		return nil
+	}
+	args := make([]interface{}, len(members))
+	for i, m := range members {
+		args[i] = m
+	}
+	return s.client.SAdd(ctx, spendDirtyKey, args...).Err()
+}
+
+// spendKeys returns the total and daily counter keys of a campaign
+func spendKeys(campaignID, day string) (string, string) {
+	return fmt.Sprintf("spend:%s:total", campaignID), fmt.Sprintf("spend:%s:%s", campaignID, day)
+}
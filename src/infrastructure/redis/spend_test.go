@@ -0,0 +1,92 @@
+package redis
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSpendStore_AddGetSeed(t *testing.T) {
+	s, client := setupTestRedis(t)
+	defer s.Close()
+	defer client.Close()
+
+	ctx := context.Background()
+	store := NewSpendStore(client)
+
+	if _, _, ok, err := store.Get(ctx, "cmp-1", "2024-05-01"); err != nil || ok {
+		t.Fatalf("Expected unseeded counters, got ok=%v err=%v", ok, err)
+	}
+
+	if err := store.Seed(ctx, "cmp-1", "2024-05-01", 1000, 100); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	// A second seed must not overwrite live counters
+	store.Seed(ctx, "cmp-1", "2024-05-01", 0, 0)
+
+	if err := store.Add(ctx, "cmp-1", "2024-05-01", 50); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	total, today, ok, err := store.Get(ctx, "cmp-1", "2024-05-01")
+	if err != nil || !ok {
+		t.Fatalf("Expected seeded counters, got ok=%v err=%v", ok, err)
+	}
+	if total != 1050 || today != 150 {
+		t.Errorf("Expected total 1050 / today 150, got %d / %d", total, today)
+	}
+}
+
+func TestSpendStore_GetMany(t *testing.T) {
+	s, client := setupTestRedis(t)
+	defer s.Close()
+	defer client.Close()
+
+	ctx := context.Background()
+	store := NewSpendStore(client)
+
+	store.Seed(ctx, "cmp-1", "2024-05-01", 1000, 100)
+	store.Seed(ctx, "cmp-2", "2024-04-30", 500, 500)
+
+	totals, today, err := store.GetMany(ctx, []string{"cmp-1", "cmp-2", "cmp-3"}, "2024-05-01")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if totals["cmp-1"] != 1000 || today["cmp-1"] != 100 {
+		t.Errorf("Expected cmp-1 total 1000 / today 100, got %d / %d", totals["cmp-1"], today["cmp-1"])
+	}
+	if totals["cmp-2"] != 500 || today["cmp-2"] != 0 {
+		t.Errorf("Expected cmp-2 total 500 / nothing today, got %d / %d", totals["cmp-2"], today["cmp-2"])
+	}
+	if _, ok := totals["cmp-3"]; ok {
+		t.Errorf("Expected unseeded campaign to be missing")
+	}
+}
+
+func TestSpendStore_Dirty(t *testing.T) {
+	s, client := setupTestRedis(t)
+	defer s.Close()
+	defer client.Close()
+
+	ctx := context.Background()
+	store := NewSpendStore(client)
+
+	store.Add(ctx, "cmp-1", "2024-05-01", 10)
+	store.Add(ctx, "cmp-1", "2024-05-01", 10)
+
+	members, err := store.TakeDirty(ctx, 10)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(members) != 1 || members[0] != "cmp-1/2024-05-01" {
+		t.Errorf("Expected one dirty day, got %v", members)
+	}
+
+	if members, _ := store.TakeDirty(ctx, 10); len(members) != 0 {
+		t.Errorf("Expected dirty set to be drained, got %v", members)
+	}
+
+	store.MarkDirty(ctx, "cmp-2/2024-05-01")
+	if members, _ := store.TakeDirty(ctx, 10); len(members) != 1 {
+		t.Errorf("Expected re-marked day, got %v", members)
+	}
+}
@@ -0,0 +1,35 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// VelocityCounter counts events per key for invalid traffic detection
+type VelocityCounter struct {
+	client *redis.Client
+}
+
+// NewVelocityCounter creates a new velocity counter instance
+func NewVelocityCounter(client *redis.Client) *VelocityCounter {
+	return &VelocityCounter{client: client}
+}
+
+// Increment adds an event to the key and returns its events in the window.
+// The window starts with the first event, like frequency counters.
+func (v *VelocityCounter) Increment(ctx context.Context, key string, window time.Duration) (int64, error) {
+	pipe := v.client.TxPipeline()
+	incr := pipe.Incr(ctx, velocityKey(key))
+	pipe.ExpireNX(ctx, velocityKey(key), window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+// velocityKey namespaces a velocity counter key
+func velocityKey(key string) string {
+	return "ivt:" + key
+}
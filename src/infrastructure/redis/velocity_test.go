@@ -0,0 +1,35 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestVelocityCounter_Increment(t *testing.T) {
+	s, client := setupTestRedis(t)
+	defer s.Close()
+	defer client.Close()
+
+	ctx := context.Background()
+	counter := NewVelocityCounter(client)
+
+	for i := int64(1); i <= 3; i++ {
+		count, err := counter.Increment(ctx, "click:10.0.0.1", time.Minute)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if count != i {
+			t.Errorf("Expected count %d, got %d", i, count)
+		}
+	}
+
+	if ttl := s.TTL("ivt:click:10.0.0.1"); ttl != time.Minute {
+		t.Errorf("Expected TTL 1m, got %v", ttl)
+	}
+
+	s.FastForward(time.Minute + time.Second)
+	if count, _ := counter.Increment(ctx, "click:10.0.0.1", time.Minute); count != 1 {
+		t.Errorf("Expected the count to reset after the window, got %d", count)
+	}
+}
@@ -0,0 +1,92 @@
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+)
+
+// trackingPayload is the wire form of tracking claims, kept short for URLs
+type trackingPayload struct {
+	ImpressionID string `json:"i"`
+	BannerID     string `json:"b"`
+	CampaignID   string `json:"c"`
+	SlotID       string `json:"s"`
+	IssuedAt     int64  `json:"t"`
+}
+
+// TrackingSigner signs and verifies the tokens of tracking URLs with
+// HMAC-SHA256. A token is the base64url payload and signature joined by a dot.
+type TrackingSigner struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+// NewTrackingSigner creates a new signer. Tokens expire ttl after they were issued.
+func NewTrackingSigner(secret string, ttl time.Duration) *TrackingSigner {
+	return &TrackingSigner{
+		secret: []byte(secret),
+		ttl:    ttl,
+	}
+}
+
+// Sign returns the token of the claims
+func (s *TrackingSigner) Sign(claims entities.TrackingClaims) string {
+	payload, _ := json.Marshal(trackingPayload{
+		ImpressionID: claims.ImpressionID,
+		BannerID:     claims.BannerID,
+		CampaignID:   claims.CampaignID,
+		SlotID:       claims.SlotID,
+		IssuedAt:     claims.IssuedAt.Unix(),
+	})
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(s.mac(encoded))
+}
+
+// Verify returns the claims of a token signed by this signer that has not expired
+func (s *TrackingSigner) Verify(token string) (*entities.TrackingClaims, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, s.mac(encoded)) {
+		return nil, ErrInvalidToken
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var payload trackingPayload
+	if err := json.Unmarshal(raw, &payload); err != nil || payload.ImpressionID == "" {
+		return nil, ErrInvalidToken
+	}
+
+	issuedAt := time.Unix(payload.IssuedAt, 0)
+	if time.Since(issuedAt) > s.ttl {
+		return nil, ErrExpiredToken
+	}
+
+	return &entities.TrackingClaims{
+		ImpressionID: payload.ImpressionID,
+		BannerID:     payload.BannerID,
+		CampaignID:   payload.CampaignID,
+		SlotID:       payload.SlotID,
+		IssuedAt:     issuedAt,
+	}, nil
+}
+
+// mac is the HMAC-SHA256 of an encoded payload
+func (s *TrackingSigner) mac(encoded string) []byte {
+	h := hmac.New(sha256.New, s.secret)
+	h.Write([]byte(encoded))
+	return h.Sum(nil)
+}
@@ -0,0 +1,60 @@
+package security
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+)
+
+func TestTrackingSigner_SignVerify(t *testing.T) {
+	signer := NewTrackingSigner("test-secret", time.Hour)
+	claims := entities.TrackingClaims{
+		ImpressionID: "imp-1",
+		BannerID:     "ban-1",
+		CampaignID:   "cmp-1",
+		SlotID:       "slot-1",
+		IssuedAt:     time.Now(),
+	}
+
+	token := signer.Sign(claims)
+	got, err := signer.Verify(token)
+	if err != nil {
+		t.Fatalf("Failed to verify token: %v", err)
+	}
+	if got.ImpressionID != "imp-1" || got.BannerID != "ban-1" || got.CampaignID != "cmp-1" || got.SlotID != "slot-1" {
+		t.Errorf("Expected the signed claims, got %+v", got)
+	}
+}
+
+func TestTrackingSigner_Verify_Rejects(t *testing.T) {
+	signer := NewTrackingSigner("test-secret", time.Hour)
+	claims := entities.TrackingClaims{ImpressionID: "imp-1", BannerID: "ban-1", CampaignID: "cmp-1", IssuedAt: time.Now()}
+	token := signer.Sign(claims)
+
+	// Swap in the payload of another campaign, keeping the signature
+	forged := signer.Sign(entities.TrackingClaims{ImpressionID: "imp-1", BannerID: "ban-1", CampaignID: "cmp-2", IssuedAt: time.Now()})
+	payload, _, _ := strings.Cut(forged, ".")
+	_, signature, _ := strings.Cut(token, ".")
+
+	claims.IssuedAt = time.Now().Add(-2 * time.Hour)
+
+	tests := []struct {
+		name     string
+		token    string
+		expected error
+	}{
+		{"tampered", payload + "." + signature, ErrInvalidToken},
+		{"other secret", NewTrackingSigner("other-secret", time.Hour).Sign(claims), ErrInvalidToken},
+		{"expired", signer.Sign(claims), ErrExpiredToken},
+		{"malformed", "not-a-token", ErrInvalidToken},
+		{"empty", "", ErrInvalidToken},
+	}
+
+	for _, tt := range tests {
+		if _, err := signer.Verify(tt.token); err != tt.expected {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, err)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package useragent
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Device, OS and browser values use the vocabulary of campaign targeting
+const (
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceDesktop = "desktop"
+
+	OSWindows  = "windows"
+	OSMacOS    = "macos"
+	OSIOS      = "ios"
+	OSAndroid  = "android"
+	OSLinux    = "linux"
+	OSChromeOS = "chromeos"
+
+	BrowserChrome  = "chrome"
+	BrowserFirefox = "firefox"
+	BrowserSafari  = "safari"
+	BrowserEdge    = "edge"
+	BrowserOpera   = "opera"
+	BrowserSamsung = "samsung"
+)
+
+// Client describes the device, OS and browser of a viewer; unknown parts are empty
+type Client struct {
+	Device  string
+	OS      string
+	Browser string
+}
+
+// ClientHints holds the raw low-entropy User-Agent Client Hints headers
+type ClientHints struct {
+	Brands   string // Sec-CH-UA
+	Mobile   string // Sec-CH-UA-Mobile
+	Platform string // Sec-CH-UA-Platform
+}
+
+// Parser derives device, OS and browser from the User-Agent and Client Hints
+type Parser struct{}
+
+// NewParser creates a new User-Agent parser
+func NewParser() *Parser {
+	return &Parser{}
+}
+
+// Detect parses the User-Agent and Client Hints headers of a request
+func (p *Parser) Detect(header http.Header) (device, os, browser string) {
+	client := p.Parse(header.Get("User-Agent"), ClientHints{
+		Brands:   header.Get("Sec-CH-UA"),
+		Mobile:   header.Get("Sec-CH-UA-Mobile"),
+		Platform: header.Get("Sec-CH-UA-Platform"),
+	})
+	return client.Device, client.OS, client.Browser
+}
+
+// Parse identifies the client. Client Hints win over the User-Agent string
+// because Chromium freezes the platform details of its reduced User-Agent.
+func (p *Parser) Parse(userAgent string, hints ClientHints) Client {
+	client := Client{
+		Device:  parseDevice(userAgent),
+		OS:      parseOS(userAgent),
+		Browser: parseBrowser(userAgent),
+	}
+
+	if os := platformOS(hints.Platform); os != "" {
+		client.OS = os
+	}
+	if browser := brandBrowser(hints.Brands); browser != "" {
+		client.Browser = browser
+	}
+	// Sec-CH-UA-Mobile does not tell tablets apart, so keep a detected tablet
+	switch hints.Mobile {
+	case "?1":
+		if client.Device != DeviceTablet {
+			client.Device = DeviceMobile
+		}
+	case "?0":
+		if client.Device != DeviceTablet {
+			client.Device = DeviceDesktop
+		}
+	}
+
+	return client
+}
+
+// parseDevice classifies the form factor of a User-Agent
+func parseDevice(ua string) string {
+	switch {
+	case ua == "":
+		return ""
+	case strings.Contains(ua, "iPad") || strings.Contains(ua, "Tablet"):
+		return DeviceTablet
+	case strings.Contains(ua, "Android") && !strings.Contains(ua, "Mobile"):
+		// Android tablets omit the Mobile token
+		return DeviceTablet
+	case strings.Contains(ua, "Mobi") || strings.Contains(ua, "iPhone") || strings.Contains(ua, "iPod"):
+		return DeviceMobile
+	default:
+		return DeviceDesktop
+	}
+}
+
+// parseOS identifies the operating system of a User-Agent
+func parseOS(ua string) string {
+	switch {
+	case strings.Contains(ua, "Windows"):
+		return OSWindows
+	// iOS User-Agents also claim to be "like Mac OS X", so check them first
+	case strings.Contains(ua, "iPhone") || strings.Contains(ua, "iPad") || strings.Contains(ua, "iPod"):
+		return OSIOS
+	case strings.Contains(ua, "Android"):
+		return OSAndroid
+	case strings.Contains(ua, "CrOS"):
+		return OSChromeOS
+	case strings.Contains(ua, "Macintosh") || strings.Contains(ua, "Mac OS X"):
+		return OSMacOS
+	case strings.Contains(ua, "Linux"):
+		return OSLinux
+	default:
+		return ""
+	}
+}
+
+// parseBrowser identifies the browser of a User-Agent. Most browsers embed the
+// tokens of the engines they derive from, so the more specific ones go first.
+func parseBrowser(ua string) string {
+	switch {
+	case containsAny(ua, "Edg/", "EdgA/", "EdgiOS/"):
+		return BrowserEdge
+	case containsAny(ua, "OPR/", "Opera"):
+		return BrowserOpera
+	case strings.Contains(ua, "SamsungBrowser/"):
+		return BrowserSamsung
+	case containsAny(ua, "Firefox/", "FxiOS/"):
+		return BrowserFirefox
+	case containsAny(ua, "Chrome/", "CriOS/", "Chromium/"):
+		return BrowserChrome
+	case strings.Contains(ua, "Safari/") && strings.Contains(ua, "Version/"):
+		return BrowserSafari
+	default:
+		return ""
+	}
+}
+
+// platformOS maps a Sec-CH-UA-Platform value such as "macOS" to an OS
+func platformOS(platform string) string {
+	switch strings.ToLower(strings.Trim(platform, `" `)) {
+	case "windows":
+		return OSWindows
+	case "macos":
+		return OSMacOS
+	case "ios":
+		return OSIOS
+	case "android":
+		return OSAndroid
+	case "chrome os", "chromeos":
+		return OSChromeOS
+	case "linux":
+		return OSLinux
+	default:
+		return ""
+	}
+}
+
+// brandBrowser maps a Sec-CH-UA brand list to a browser, preferring a
+// vendor brand over the generic Chromium one
+func brandBrowser(brands string) string {
+	browser := ""
+	for _, entry := range strings.Split(brands, ",") {
+		name := strings.TrimSpace(entry)
+		if i := strings.Index(name, ";"); i >= 0 {
+			name = name[:i]
+		}
+		switch strings.Trim(name, `" `) {
+		case "Microsoft Edge":
+			return BrowserEdge
+		case "Opera":
+			return BrowserOpera
+		case "Samsung Internet":
+			return BrowserSamsung
+		case "Google Chrome":
+			return BrowserChrome
+		case "Chromium":
+			browser = BrowserChrome
+		}
+	}
+	return browser
+}
+
+func containsAny(s string, substrs ...string) bool {
+	for _, sub := range substrs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
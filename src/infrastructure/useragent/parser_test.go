@@ -0,0 +1,105 @@
+package useragent
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestParser_Parse_UserAgent(t *testing.T) {
+	parser := NewParser()
+
+	tests := []struct {
+		name     string
+		ua       string
+		expected Client
+	}{
+		{
+			name:     "Chrome on Windows",
+			ua:       "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+			expected: Client{Device: DeviceDesktop, OS: OSWindows, Browser: BrowserChrome},
+		},
+		{
+			name:     "Edge on Windows",
+			ua:       "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36 Edg/124.0.2478.51",
+			expected: Client{Device: DeviceDesktop, OS: OSWindows, Browser: BrowserEdge},
+		},
+		{
+			name:     "Safari on macOS",
+			ua:       "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Safari/605.1.15",
+			expected: Client{Device: DeviceDesktop, OS: OSMacOS, Browser: BrowserSafari},
+		},
+		{
+			name:     "Safari on iPhone",
+			ua:       "Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1",
+			expected: Client{Device: DeviceMobile, OS: OSIOS, Browser: BrowserSafari},
+		},
+		{
+			name:     "Chrome on iPad",
+			ua:       "Mozilla/5.0 (iPad; CPU OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/124.0.6367.88 Mobile/15E148 Safari/604.1",
+			expected: Client{Device: DeviceTablet, OS: OSIOS, Browser: BrowserChrome},
+		},
+		{
+			name:     "Samsung Internet on Android phone",
+			ua:       "Mozilla/5.0 (Linux; Android 14; SM-S918B) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/24.0 Chrome/117.0.0.0 Mobile Safari/537.36",
+			expected: Client{Device: DeviceMobile, OS: OSAndroid, Browser: BrowserSamsung},
+		},
+		{
+			name:     "Firefox on Android tablet",
+			ua:       "Mozilla/5.0 (Android 14; Tablet; rv:125.0) Gecko/125.0 Firefox/125.0",
+			expected: Client{Device: DeviceTablet, OS: OSAndroid, Browser: BrowserFirefox},
+		},
+		{
+			name:     "Firefox on Linux",
+			ua:       "Mozilla/5.0 (X11; Linux x86_64; rv:125.0) Gecko/20100101 Firefox/125.0",
+			expected: Client{Device: DeviceDesktop, OS: OSLinux, Browser: BrowserFirefox},
+		},
+		{
+			name:     "Empty User-Agent",
+			ua:       "",
+			expected: Client{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parser.Parse(tt.ua, ClientHints{}); got != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestParser_Parse_ClientHintsWin(t *testing.T) {
+	parser := NewParser()
+
+	// Reduced User-Agent of Edge on Android; only the hints reveal the vendor
+	ua := "Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Mobile Safari/537.36"
+	hints := ClientHints{
+		Brands:   `"Chromium";v="124", "Microsoft Edge";v="124", "Not-A.Brand";v="99"`,
+		Mobile:   "?1",
+		Platform: `"Android"`,
+	}
+
+	expected := Client{Device: DeviceMobile, OS: OSAndroid, Browser: BrowserEdge}
+	if got := parser.Parse(ua, hints); got != expected {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+
+	// Hints alone are enough
+	got := parser.Parse("", ClientHints{Brands: `"Not-A.Brand";v="99", "Chromium";v="124"`, Mobile: "?0", Platform: `"macOS"`})
+	expected = Client{Device: DeviceDesktop, OS: OSMacOS, Browser: BrowserChrome}
+	if got != expected {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+}
+
+func TestParser_Detect_ReadsHeaders(t *testing.T) {
+	header := http.Header{}
+	header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36")
+	header.Set("Sec-CH-UA-Platform", `"Linux"`)
+
+	device, os, browser := NewParser().Detect(header)
+	if device != DeviceDesktop || os != OSLinux || browser != BrowserChrome {
+		t.Errorf("Expected desktop/linux/chrome, got %s/%s/%s", device, os, browser)
+	}
+}
@@ -0,0 +1,114 @@
+package audience
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/fall-out-bug/demo-adserver/src/application/audience"
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/gin-gonic/gin"
+)
+
+// Handler handles audience segment management HTTP requests
+type Handler struct {
+	service *audience.Service
+}
+
+// NewHandler creates a new audience handler
+func NewHandler(service *audience.Service) *Handler {
+	return &Handler{service: service}
+}
+
+// SegmentResponse represents an audience segment
+type SegmentResponse struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	TTLSeconds int64     `json:"ttl_seconds"`
+	PixelURL   string    `json:"pixel_url"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// CreateSegmentRequest represents a create segment request
+type CreateSegmentRequest struct {
+	Name       string `json:"name" binding:"required"`
+	TTLSeconds int64  `json:"ttl_seconds"` // Membership duration; defaults to 30 days
+}
+
+// CreateSegment handles POST /api/v1/segments
+func (h *Handler) CreateSegment(c *gin.Context) {
+	var req CreateSegmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ttl := time.Duration(req.TTLSeconds) * time.Second
+	segment, err := h.service.CreateSegment(c.Request.Context(), c.GetString("user_id"), req.Name, ttl)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, toResponse(segment))
+}
+
+// ListSegments handles GET /api/v1/segments
+func (h *Handler) ListSegments(c *gin.Context) {
+	segments, err := h.service.ListSegments(c.Request.Context(), c.GetString("user_id"))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	response := make([]SegmentResponse, 0, len(segments))
+	for _, s := range segments {
+		response = append(response, toResponse(s))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"segments": response})
+}
+
+// GetSegment handles GET /api/v1/segments/:id
+func (h *Handler) GetSegment(c *gin.Context) {
+	segment, err := h.service.GetSegment(c.Request.Context(), c.GetString("user_id"), c.Param("id"))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toResponse(segment))
+}
+
+// DeleteSegment handles DELETE /api/v1/segments/:id
+func (h *Handler) DeleteSegment(c *gin.Context) {
+	if err := h.service.DeleteSegment(c.Request.Context(), c.GetString("user_id"), c.Param("id")); err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// writeError maps service errors to HTTP status codes
+func (h *Handler) writeError(c *gin.Context, err error) {
+	var domainErr *entities.DomainError
+	switch {
+	case errors.Is(err, audience.ErrSegmentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.As(err, &domainErr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func toResponse(s *entities.Segment) SegmentResponse {
+	return SegmentResponse{
+		ID:         s.ID,
+		Name:       s.Name,
+		TTLSeconds: int64(s.MembershipTTL / time.Second),
+		PixelURL:   "/api/v1/pixel/" + s.ID,
+		CreatedAt:  s.CreatedAt,
+	}
+}
@@ -0,0 +1,126 @@
+package audience
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fall-out-bug/demo-adserver/src/application/audience"
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/gin-gonic/gin"
+)
+
+type mockSegmentRepo struct {
+	segments map[string]*entities.Segment
+}
+
+func (m *mockSegmentRepo) Create(ctx context.Context, segment *entities.Segment) error {
+	m.segments[segment.ID] = segment
+	return nil
+}
+
+func (m *mockSegmentRepo) FindByID(ctx context.Context, id string) (*entities.Segment, error) {
+	return m.segments[id], nil
+}
+
+func (m *mockSegmentRepo) FindByAdvertiserID(ctx context.Context, advertiserID string) ([]*entities.Segment, error) {
+	var result []*entities.Segment
+	for _, s := range m.segments {
+		if s.AdvertiserID == advertiserID {
+			result = append(result, s)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockSegmentRepo) Delete(ctx context.Context, id string) error {
+	delete(m.segments, id)
+	return nil
+}
+
+type nopStore struct{}
+
+func (nopStore) Add(ctx context.Context, userID, segmentID string, ttl time.Duration) error {
+	return nil
+}
+
+func (nopStore) Segments(ctx context.Context, userID string) ([]string, error) {
+	return nil, nil
+}
+
+// setupTestRouter authenticates every request as the advertiser given in the X-Test-User header
+func setupTestRouter() (*gin.Engine, *mockSegmentRepo) {
+	gin.SetMode(gin.TestMode)
+
+	repo := &mockSegmentRepo{segments: map[string]*entities.Segment{}}
+	handler := NewHandler(audience.NewService(repo, nopStore{}))
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", c.GetHeader("X-Test-User"))
+	})
+	router.POST("/api/v1/segments", handler.CreateSegment)
+	router.GET("/api/v1/segments", handler.ListSegments)
+	router.GET("/api/v1/segments/:id", handler.GetSegment)
+	router.DELETE("/api/v1/segments/:id", handler.DeleteSegment)
+
+	return router, repo
+}
+
+func TestCreateSegment(t *testing.T) {
+	router, repo := setupTestRouter()
+
+	body, _ := json.Marshal(map[string]interface{}{"name": "Cart abandoners", "ttl_seconds": 86400})
+	req, _ := http.NewRequest("POST", "/api/v1/segments", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Test-User", "adv-1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response SegmentResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.TTLSeconds != 86400 || response.PixelURL != "/api/v1/pixel/"+response.ID {
+		t.Errorf("Unexpected response %+v", response)
+	}
+	if s := repo.segments[response.ID]; s == nil || s.AdvertiserID != "adv-1" {
+		t.Errorf("Expected segment owned by adv-1 to be stored, got %v", s)
+	}
+}
+
+func TestCreateSegment_InvalidTTL(t *testing.T) {
+	router, _ := setupTestRouter()
+
+	body, _ := json.Marshal(map[string]interface{}{"name": "Forever", "ttl_seconds": 365 * 86400})
+	req, _ := http.NewRequest("POST", "/api/v1/segments", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Test-User", "adv-1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestGetSegment_OtherAdvertiser(t *testing.T) {
+	router, repo := setupTestRouter()
+	segment, _ := entities.NewSegment("adv-1", "Visitors", 0)
+	repo.segments[segment.ID] = segment
+
+	req, _ := http.NewRequest("GET", "/api/v1/segments/"+segment.ID, nil)
+	req.Header.Set("X-Test-User", "adv-2")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+}
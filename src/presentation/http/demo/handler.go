@@ -182,7 +182,7 @@ type UpdateSlotRequest struct {
 	Name     string `json:"name" binding:"required"`
 	Format   string `json:"format" binding:"required"`
 	Width    int    `json:"width" binding:"required,min=1"`
-	Height   int    `json:"height" binding="required,min=1"`
+	Height   int    `json:"height" binding:"required,min=1"`
 	BannerID string `json:"banner_id"`
 }
 
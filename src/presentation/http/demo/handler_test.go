@@ -2,6 +2,7 @@ package demo
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -12,7 +13,7 @@ import (
 	"github.com/stretchr/testify/mock"
 
 	demoApp "github.com/fall-out-bug/demo-adserver/src/application/demo"
-	"github.com/fall_out-bug/demo-adserver/src/domain/entities"
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
 	"github.com/google/uuid"
 )
 
@@ -21,12 +22,12 @@ type MockDemoBannerRepository struct {
 	mock.Mock
 }
 
-func (m *MockDemoBannerRepository) Create(ctx interface{}, banner *entities.DemoBanner) error {
+func (m *MockDemoBannerRepository) Create(ctx context.Context, banner *entities.DemoBanner) error {
 	args := m.Called(ctx, banner)
 	return args.Error(0)
 }
 
-func (m *MockDemoBannerRepository) GetByID(ctx interface{}, id uuid.UUID) (*entities.DemoBanner, error) {
+func (m *MockDemoBannerRepository) GetByID(ctx context.Context, id uuid.UUID) (*entities.DemoBanner, error) {
 	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -34,7 +35,7 @@ func (m *MockDemoBannerRepository) GetByID(ctx interface{}, id uuid.UUID) (*enti
 	return args.Get(0).(*entities.DemoBanner), args.Error(1)
 }
 
-func (m *MockDemoBannerRepository) GetAll(ctx interface{}) ([]*entities.DemoBanner, error) {
+func (m *MockDemoBannerRepository) GetAll(ctx context.Context) ([]*entities.DemoBanner, error) {
 	args := m.Called(ctx)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -42,7 +43,7 @@ func (m *MockDemoBannerRepository) GetAll(ctx interface{}) ([]*entities.DemoBann
 	return args.Get(0).([]*entities.DemoBanner), args.Error(1)
 }
 
-func (m *MockDemoBannerRepository) GetActive(ctx interface{}) ([]*entities.DemoBanner, error) {
+func (m *MockDemoBannerRepository) GetActive(ctx context.Context) ([]*entities.DemoBanner, error) {
 	args := m.Called(ctx)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -50,7 +51,7 @@ func (m *MockDemoBannerRepository) GetActive(ctx interface{}) ([]*entities.DemoB
 	return args.Get(0).([]*entities.DemoBanner), args.Error(1)
 }
 
-func (m *MockDemoBannerRepository) GetByFormat(ctx interface{}, format string) ([]*entities.DemoBanner, error) {
+func (m *MockDemoBannerRepository) GetByFormat(ctx context.Context, format string) ([]*entities.DemoBanner, error) {
 	args := m.Called(ctx, format)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -58,17 +59,17 @@ func (m *MockDemoBannerRepository) GetByFormat(ctx interface{}, format string) (
 	return args.Get(0).([]*entities.DemoBanner), args.Error(1)
 }
 
-func (m *MockDemoBannerRepository) Update(ctx interface{}, banner *entities.DemoBanner) error {
+func (m *MockDemoBannerRepository) Update(ctx context.Context, banner *entities.DemoBanner) error {
 	args := m.Called(ctx, banner)
 	return args.Error(0)
 }
 
-func (m *MockDemoBannerRepository) Delete(ctx interface{}, id uuid.UUID) error {
+func (m *MockDemoBannerRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
-func (m *MockDemoBannerRepository) ExistsBySlotID(ctx interface{}, bannerID uuid.UUID) (bool, error) {
+func (m *MockDemoBannerRepository) ExistsBySlotID(ctx context.Context, bannerID uuid.UUID) (bool, error) {
 	args := m.Called(ctx, bannerID)
 	return args.Bool(0), args.Error(1)
 }
@@ -77,12 +78,12 @@ type MockDemoSlotRepository struct {
 	mock.Mock
 }
 
-func (m *MockDemoSlotRepository) Create(ctx interface{}, slot *entities.DemoSlot) error {
+func (m *MockDemoSlotRepository) Create(ctx context.Context, slot *entities.DemoSlot) error {
 	args := m.Called(ctx, slot)
 	return args.Error(0)
 }
 
-func (m *MockDemoSlotRepository) GetByID(ctx interface{}, id uuid.UUID) (*entities.DemoSlot, error) {
+func (m *MockDemoSlotRepository) GetByID(ctx context.Context, id uuid.UUID) (*entities.DemoSlot, error) {
 	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -90,7 +91,7 @@ func (m *MockDemoSlotRepository) GetByID(ctx interface{}, id uuid.UUID) (*entiti
 	return args.Get(0).(*entities.DemoSlot), args.Error(1)
 }
 
-func (m *MockDemoSlotRepository) GetBySlotID(ctx interface{}, slotID string) (*entities.DemoSlot, error) {
+func (m *MockDemoSlotRepository) GetBySlotID(ctx context.Context, slotID string) (*entities.DemoSlot, error) {
 	args := m.Called(ctx, slotID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -98,7 +99,7 @@ func (m *MockDemoSlotRepository) GetBySlotID(ctx interface{}, slotID string) (*e
 	return args.Get(0).(*entities.DemoSlot), args.Error(1)
 }
 
-func (m *MockDemoSlotRepository) GetAll(ctx interface{}) ([]*entities.DemoSlot, error) {
+func (m *MockDemoSlotRepository) GetAll(ctx context.Context) ([]*entities.DemoSlot, error) {
 	args := m.Called(ctx)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -106,17 +107,17 @@ func (m *MockDemoSlotRepository) GetAll(ctx interface{}) ([]*entities.DemoSlot,
 	return args.Get(0).([]*entities.DemoSlot), args.Error(1)
 }
 
-func (m *MockDemoSlotRepository) Update(ctx interface{}, slot *entities.DemoSlot) error {
+func (m *MockDemoSlotRepository) Update(ctx context.Context, slot *entities.DemoSlot) error {
 	args := m.Called(ctx, slot)
 	return args.Error(0)
 }
 
-func (m *MockDemoSlotRepository) Delete(ctx interface{}, id uuid.UUID) error {
+func (m *MockDemoSlotRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
-func (m *MockDemoSlotRepository) GetAllActive(ctx interface{}) ([]*entities.DemoSlot, error) {
+func (m *MockDemoSlotRepository) GetAllActive(ctx context.Context) ([]*entities.DemoSlot, error) {
 	args := m.Called(ctx)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -124,6 +125,11 @@ func (m *MockDemoSlotRepository) GetAllActive(ctx interface{}) ([]*entities.Demo
 	return args.Get(0).([]*entities.DemoSlot), args.Error(1)
 }
 
+// optionalString returns a pointer to s
+func optionalString(s string) *string {
+	return &s
+}
+
 func setupTestRouter(handler *Handler) *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
@@ -188,7 +194,7 @@ func TestGetSlotBanner_Found(t *testing.T) {
 		ID:     bannerID,
 		Name:   "Test Banner",
 		Format: "leaderboard",
-		HTML:   `<div>Test Ad</div>`,
+		HTML:   optionalString(`<div>Test Ad</div>`),
 		Active: true,
 	}
 	slot := &entities.DemoSlot{
@@ -203,6 +209,7 @@ func TestGetSlotBanner_Found(t *testing.T) {
 	}
 
 	mockSlotRepo.On("GetBySlotID", mock.Anything, "demo-leaderboard").Return(slot, nil)
+	mockBannerRepo.On("GetByID", mock.Anything, bannerID).Return(banner, nil)
 
 	demoService := demoApp.NewService(mockBannerRepo, mockSlotRepo)
 	handler := NewHandler(demoService)
@@ -216,7 +223,7 @@ func TestGetSlotBanner_Found(t *testing.T) {
 
 	var response map[string]interface{}
 	json.Unmarshal(w.Body.Bytes(), &response)
-	assert.Equal(t, "Test Ad", response["html"])
+	assert.Equal(t, "<div>Test Ad</div>", response["html"])
 	mockSlotRepo.AssertExpectations(t)
 }
 
@@ -270,9 +277,9 @@ func TestListBanners(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, w.Code)
 
-	var response []map[string]interface{}
+	var response map[string][]map[string]interface{}
 	json.Unmarshal(w.Body.Bytes(), &response)
-	assert.Len(t, response, 2)
+	assert.Len(t, response["banners"], 2)
 	mockBannerRepo.AssertExpectations(t)
 }
 
@@ -301,13 +308,8 @@ func TestCreateBanner_ValidRequest(t *testing.T) {
 	mockSlotRepo := new(MockDemoSlotRepository)
 	mockBannerRepo := new(MockDemoBannerRepository)
 
-	bannerID := uuid.New()
 	mockBannerRepo.On("Create", mock.Anything, mock.AnythingOfType("*entities.DemoBanner")).
 		Return(nil)
-	mockBannerRepo.On("GetByID", mock.Anything, mock.Anything).Return(
-		&entities.DemoBanner{ID: bannerID, Name: "Test Banner", Format: "leaderboard", Active: true},
-		nil,
-	)
 
 	demoService := demoApp.NewService(mockBannerRepo, mockSlotRepo)
 	handler := NewHandler(demoService)
@@ -2,17 +2,26 @@ package http
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/fall-out-bug/demo-adserver/src/application/audience"
 	"github.com/fall-out-bug/demo-adserver/src/application/delivery"
 	"github.com/fall-out-bug/demo-adserver/src/application/tracking"
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/shopspring/decimal"
 )
 
 // DeliveryService defines the interface for banner delivery
 type DeliveryService interface {
 	DeliverBanner(ctx context.Context, slotID string, req *delivery.DeliveryRequest) (*delivery.GetBannerResponse, error)
+	DeliverBatch(ctx context.Context, slotIDs []string, req *delivery.DeliveryRequest) (*delivery.BatchResponse, error)
+	DeliverBid(ctx context.Context, slotID string, req *delivery.DeliveryRequest) (*delivery.Bid, error)
 }
 
 // ImpressionService defines the interface for impression tracking
@@ -20,14 +29,78 @@ type ImpressionService interface {
 	Track(ctx context.Context, req *tracking.TrackRequest) *tracking.TrackResponse
 }
 
+// ImpressionQueue hands impressions to asynchronous tracking
+type ImpressionQueue interface {
+	Enqueue(ctx context.Context, req *tracking.TrackRequest) error
+}
+
 // ClickService defines the interface for click tracking
 type ClickService interface {
-	TrackClick(ctx context.Context, impressionID string) *tracking.ClickResponse
+	TrackClick(ctx context.Context, req *tracking.ClickRequest) *tracking.ClickResponse
+}
+
+// ConversionService defines the interface for conversion tracking
+type ConversionService interface {
+	TrackConversion(ctx context.Context, advertiserID, impressionID string) *tracking.TrackResponse
+}
+
+// VideoEventService defines the interface for video playback event tracking
+type VideoEventService interface {
+	TrackVideoEvent(ctx context.Context, req *tracking.VideoEventRequest) *tracking.TrackResponse
+}
+
+// AuctionService defines the interface for the win and billing notices of exchanges
+type AuctionService interface {
+	Win(ctx context.Context, impressionID string, price decimal.Decimal) *tracking.TrackResponse
+	Bill(ctx context.Context, impressionID string, price decimal.Decimal) *tracking.TrackResponse
+}
+
+// TrackingVerifier verifies the signed tokens of tracking URLs
+type TrackingVerifier interface {
+	Verify(token string) (*entities.TrackingClaims, error)
+}
+
+// TrackingTokenParam is the query parameter carrying the signed token of tracking URLs
+const TrackingTokenParam = "t"
+
+// UserIdentifier derives a viewer identifier from request fingerprints
+type UserIdentifier interface {
+	GenerateUserID(ip, userAgent string) string
+}
+
+// ClientDetector derives the viewer's device, OS and browser from request headers
+type ClientDetector interface {
+	Detect(header http.Header) (device, os, browser string)
+}
+
+// GeoResolver resolves a client IP to its location
+type GeoResolver interface {
+	Locate(ip string) (country, region, city, timezone string)
 }
 
+// PageKeywordsHeader carries comma-separated page keywords for server-side integrations
+const PageKeywordsHeader = "X-Page-Keywords"
+
+// AudienceService defines the interface for audience segment tracking
+type AudienceService interface {
+	Track(ctx context.Context, segmentID, userID string) error
+}
+
+// UserIDCookie is the first-party cookie carrying a stable viewer ID
+const UserIDCookie = "uid"
+
 // DeliveryHandler handles delivery requests
 type DeliveryHandler struct {
-	service DeliveryService
+	service    DeliveryService
+	identifier UserIdentifier
+	detector   ClientDetector
+	geo        GeoResolver
+	// countryHeader is trusted to carry the country when geo lookup has no answer
+	countryHeader string
+	// pathKeywords derives page keywords from the page URL when none are passed
+	pathKeywords bool
+	// publicURL is the external base URL for absolute tracking URLs in VAST and ad tags
+	publicURL string
 }
 
 // NewDeliveryHandler creates a new delivery handler
@@ -35,33 +108,234 @@ func NewDeliveryHandler(service DeliveryService) *DeliveryHandler {
 	return &DeliveryHandler{service: service}
 }
 
-// Handle handles GET /api/v1/delivery/:slot_id
+// WithUserIdentifier sets the fallback used to identify viewers without a uid cookie
+func (h *DeliveryHandler) WithUserIdentifier(identifier UserIdentifier) *DeliveryHandler {
+	h.identifier = identifier
+	return h
+}
+
+// WithClientDetector sets the parser used to fill device, OS and browser targeting fields
+func (h *DeliveryHandler) WithClientDetector(detector ClientDetector) *DeliveryHandler {
+	h.detector = detector
+	return h
+}
+
+// WithGeoResolver sets the resolver used to locate viewers by IP
+func (h *DeliveryHandler) WithGeoResolver(geo GeoResolver) *DeliveryHandler {
+	h.geo = geo
+	return h
+}
+
+// WithTrustedCountryHeader sets a header, set by a trusted proxy, to fall back to
+// when the viewer cannot be located by IP. Empty ignores client-supplied headers.
+func (h *DeliveryHandler) WithTrustedCountryHeader(header string) *DeliveryHandler {
+	h.countryHeader = header
+	return h
+}
+
+// WithPathKeywords enables deriving page keywords from the page URL path
+// for requests that pass no keywords
+func (h *DeliveryHandler) WithPathKeywords(enabled bool) *DeliveryHandler {
+	h.pathKeywords = enabled
+	return h
+}
+
+// Handle handles GET /api/v1/delivery/:slot_id. The format query parameter
+// asks for other creatives: "native" for plain JSON assets, "openrtb-native"
+// for an OpenRTB Native 1.2 response, "video" for video media as JSON.
 func (h *DeliveryHandler) Handle(c *gin.Context) {
 	slotID := c.Param("slot_id")
+	req := h.newRequest(c, slotID, pageURL(c), nil)
+	req.Sizes = slotSizes(c)
+	req.Format = c.Query("format")
+	if !delivery.IsValidFormat(req.Format) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported format"})
+		return
+	}
 
+	response, err := h.service.DeliverBanner(c.Request.Context(), slotID, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// BatchDeliveryRequest lists the slots of a page. Referer and keywords
+// describe the page and take precedence over the query and headers.
+type BatchDeliveryRequest struct {
+	Slots    []string `json:"slots" binding:"required,min=1"`
+	Referer  string   `json:"referer"`
+	Keywords []string `json:"keywords"`
+}
+
+// HandleBatch handles POST /api/v1/delivery/batch. All slots of the page are
+// filled in one response; a campaign fills at most one of them and competing
+// advertisers are kept apart.
+func (h *DeliveryHandler) HandleBatch(c *gin.Context) {
+	var body BatchDeliveryRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(body.Slots) > delivery.MaxBatchSlots {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("at most %d slots per request", delivery.MaxBatchSlots)})
+		return
+	}
+
+	page := body.Referer
+	if page == "" {
+		page = pageURL(c)
+	}
+	req := h.newRequest(c, "", page, body.Keywords)
+
+	response, err := h.service.DeliverBatch(c.Request.Context(), body.Slots, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// newRequest describes the viewer and page of a delivery request
+func (h *DeliveryHandler) newRequest(c *gin.Context, slotID, page string, keywords []string) *delivery.DeliveryRequest {
 	req := &delivery.DeliveryRequest{
 		SlotID:    slotID,
+		UserID:    viewerID(c, h.identifier),
 		IP:        c.ClientIP(),
 		UserAgent: c.GetHeader("User-Agent"),
-		Country:   c.GetHeader("X-Country"),
-		Device:    c.GetHeader("X-Device"),
-		OS:        c.GetHeader("X-OS"),
-		Referer:   c.GetHeader("Referer"),
+		Referer:   page,
 		Timestamp: time.Now(),
 	}
+	req.Keywords = entities.NormalizeKeywords(keywords)
+	if len(req.Keywords) == 0 {
+		req.Keywords = h.pageKeywords(c, req.Referer)
+	}
+	if h.geo != nil {
+		req.Country, req.Region, req.City, req.Timezone = h.geo.Locate(req.IP)
+	}
+	if req.Country == "" && h.countryHeader != "" {
+		req.Country = strings.ToUpper(c.GetHeader(h.countryHeader))
+	}
+	if h.detector != nil {
+		req.Device, req.OS, req.Browser = h.detector.Detect(c.Request.Header)
+	}
+	return req
+}
 
-	response, err := h.service.DeliverBanner(c.Request.Context(), slotID, req)
+// pageURL returns the page the ad is requested for. The SDK passes it as the
+// referer query parameter because browsers trim cross-origin Referer headers
+// down to the origin.
+func pageURL(c *gin.Context) string {
+	if page := c.Query("referer"); page != "" {
+		return page
+	}
+	return c.GetHeader("Referer")
+}
+
+// slotSizes reads the sizes a slot accepts: a multi-size list in the sizes query
+// parameter ("300x250,336x280", comma-separated or repeated), or else the width
+// and height parameters. Only one of width and height makes a responsive slot.
+func slotSizes(c *gin.Context) []entities.Size {
+	var sizes []entities.Size
+	for _, value := range c.QueryArray("sizes") {
+		for _, part := range strings.Split(value, ",") {
+			if size, err := entities.ParseSize(part); err == nil {
+				sizes = append(sizes, size)
+			}
+		}
+	}
+	if len(sizes) > 0 {
+		return sizes
+	}
+
+	width, _ := strconv.Atoi(c.Query("width"))
+	height, _ := strconv.Atoi(c.Query("height"))
+	if width <= 0 && height <= 0 {
+		return nil
+	}
+	return []entities.Size{{Width: max(width, 0), Height: max(height, 0)}}
+}
+
+// pageKeywords reads page keywords from the keywords query parameter (comma-separated
+// or repeated) or the X-Page-Keywords header, optionally falling back to the page URL
+func (h *DeliveryHandler) pageKeywords(c *gin.Context, page string) []string {
+	raw := c.QueryArray("keywords")
+	if header := c.GetHeader(PageKeywordsHeader); header != "" {
+		raw = append(raw, header)
+	}
+
+	keywords := entities.NormalizeKeywords(raw)
+	if len(keywords) == 0 && h.pathKeywords {
+		keywords = entities.KeywordsFromURL(page)
+	}
+	return keywords
+}
+
+// viewerID identifies the viewer by uid cookie, falling back to an IP + User-Agent fingerprint
+func viewerID(c *gin.Context, identifier UserIdentifier) string {
+	if uid, err := c.Cookie(UserIDCookie); err == nil && uid != "" {
+		return uid
+	}
+	if identifier != nil {
+		return identifier.GenerateUserID(c.ClientIP(), c.GetHeader("User-Agent"))
+	}
+	return ""
+}
+
+// transparentGIF is a 1x1 transparent GIF returned by pixel endpoints
+var transparentGIF = []byte{
+	0x47, 0x49, 0x46, 0x38, 0x39, 0x61, 0x01, 0x00, 0x01, 0x00, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0xff, 0xff, 0xff, 0x21, 0xf9, 0x04, 0x01, 0x00, 0x00, 0x00, 0x00, 0x2c, 0x00, 0x00, 0x00, 0x00,
+	0x01, 0x00, 0x01, 0x00, 0x00, 0x02, 0x02, 0x44, 0x01, 0x00, 0x3b,
+}
+
+// PixelHandler adds viewers to audience segments from advertiser sites
+type PixelHandler struct {
+	service    AudienceService
+	identifier UserIdentifier
+}
+
+// NewPixelHandler creates a new segment pixel handler
+func NewPixelHandler(service AudienceService) *PixelHandler {
+	return &PixelHandler{service: service}
+}
+
+// WithUserIdentifier sets the fallback used to identify viewers without a uid cookie
+func (h *PixelHandler) WithUserIdentifier(identifier UserIdentifier) *PixelHandler {
+	h.identifier = identifier
+	return h
+}
+
+// Handle handles GET /api/v1/pixel/:segment_id
+func (h *PixelHandler) Handle(c *gin.Context) {
+	userID := viewerID(c, h.identifier)
+	if userID == "" {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	err := h.service.Track(c.Request.Context(), c.Param("segment_id"), userID)
+	if errors.Is(err, audience.ErrSegmentNotFound) {
+		c.Status(http.StatusNotFound)
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Status(http.StatusInternalServerError)
 		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "image/gif", transparentGIF)
 }
 
 // ImpressionHandler handles impression tracking
 type ImpressionHandler struct {
-	service ImpressionService
+	service  ImpressionService
+	verifier TrackingVerifier
+	queue    ImpressionQueue
 }
 
 // NewImpressionHandler creates a new impression handler
@@ -69,26 +343,95 @@ func NewImpressionHandler(service ImpressionService) *ImpressionHandler {
 	return &ImpressionHandler{service: service}
 }
 
-// Handle handles POST /api/v1/track/impression
+// WithVerifier requires signed tracking tokens and takes the banner, campaign
+// and slot only from them
+func (h *ImpressionHandler) WithVerifier(verifier TrackingVerifier) *ImpressionHandler {
+	h.verifier = verifier
+	return h
+}
+
+// WithQueue tracks impressions asynchronously through the queue instead of
+// during the request
+func (h *ImpressionHandler) WithQueue(queue ImpressionQueue) *ImpressionHandler {
+	h.queue = queue
+	return h
+}
+
+// Handle handles POST /api/v1/track/impression. With signed tracking the
+// token is the t parameter of the impression URL, as sent by beacons posting
+// the URL without a body, or the body is {"token": "<t>"}; the viewer is the
+// caller.
 func (h *ImpressionHandler) Handle(c *gin.Context) {
 	var req tracking.TrackRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if h.verifier != nil {
+		token := c.Query(TrackingTokenParam)
+		if token == "" {
+			var body struct {
+				Token string `json:"token" binding:"required"`
+			}
+			if err := c.ShouldBindJSON(&body); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			token = body.Token
+		}
+		claims, err := verifyTracking(h.verifier, token, "")
+		if err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		req = *trackRequest(c, claims)
+	} else if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Fire-and-forget (return immediately)
-	go func() {
-		ctx := context.Background()
-		h.service.Track(ctx, &req)
-	}()
+	if !submitImpression(c, h.service, h.queue, &req) {
+		return
+	}
 
 	c.Status(http.StatusAccepted)
 }
 
+// HandlePixel handles GET /api/v1/track/impression, the image pixel form of
+// the impression URL in delivery responses used by ad tags
+func (h *ImpressionHandler) HandlePixel(c *gin.Context) {
+	var req tracking.TrackRequest
+	if h.verifier != nil {
+		claims, err := verifyTracking(h.verifier, c.Query(TrackingTokenParam), "")
+		if err != nil {
+			c.Status(http.StatusForbidden)
+			return
+		}
+		req = *trackRequest(c, claims)
+	} else {
+		req = tracking.TrackRequest{
+			ImpressionID: c.Query("id"),
+			SlotID:       c.Query("slot_id"),
+			BannerID:     c.Query("banner_id"),
+			CampaignID:   c.Query("campaign_id"),
+			IP:           c.ClientIP(),
+			UserAgent:    c.GetHeader("User-Agent"),
+			Referer:      c.GetHeader("Referer"),
+		}
+	}
+	if req.ImpressionID == "" {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	if !submitImpression(c, h.service, h.queue, &req) {
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "image/gif", transparentGIF)
+}
+
 // ClickHandler handles click tracking
 type ClickHandler struct {
-	service ClickService
+	service  ClickService
+	verifier TrackingVerifier
 }
 
 // NewClickHandler creates a new click handler
@@ -96,11 +439,28 @@ func NewClickHandler(service ClickService) *ClickHandler {
 	return &ClickHandler{service: service}
 }
 
+// WithVerifier requires click URLs to carry a signed token for their impression
+func (h *ClickHandler) WithVerifier(verifier TrackingVerifier) *ClickHandler {
+	h.verifier = verifier
+	return h
+}
+
 // Handle handles GET /api/v1/track/click/:impression_id
 func (h *ClickHandler) Handle(c *gin.Context) {
 	impressionID := c.Param("impression_id")
+	if h.verifier != nil {
+		if _, err := verifyTracking(h.verifier, c.Query(TrackingTokenParam), impressionID); err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+	}
 
-	response := h.service.TrackClick(c.Request.Context(), impressionID)
+	response := h.service.TrackClick(c.Request.Context(), &tracking.ClickRequest{
+		ImpressionID: impressionID,
+		IP:           c.ClientIP(),
+		UserAgent:    c.GetHeader("User-Agent"),
+		Referer:      c.GetHeader("Referer"),
+	})
 	if !response.Success {
 		c.JSON(http.StatusNotFound, gin.H{"error": response.Message})
 		return
@@ -110,6 +470,146 @@ func (h *ClickHandler) Handle(c *gin.Context) {
 	c.Redirect(http.StatusFound, response.RedirectURL)
 }
 
+// VideoEventHandler handles the tracking URLs of VAST responses
+type VideoEventHandler struct {
+	impressions ImpressionService
+	events      VideoEventService
+	verifier    TrackingVerifier
+	queue       ImpressionQueue
+}
+
+// NewVideoEventHandler creates a new video event handler
+func NewVideoEventHandler(impressions ImpressionService, events VideoEventService) *VideoEventHandler {
+	return &VideoEventHandler{impressions: impressions, events: events}
+}
+
+// WithVerifier requires signed tracking tokens and takes the banner, campaign
+// and slot only from them
+func (h *VideoEventHandler) WithVerifier(verifier TrackingVerifier) *VideoEventHandler {
+	h.verifier = verifier
+	return h
+}
+
+// WithQueue tracks video impressions asynchronously through the queue
+func (h *VideoEventHandler) WithQueue(queue ImpressionQueue) *VideoEventHandler {
+	h.queue = queue
+	return h
+}
+
+// Handle handles GET /api/v1/track/video/:impression_id?event=<event>. The
+// impression event records the impression; the others are playback events.
+func (h *VideoEventHandler) Handle(c *gin.Context) {
+	impressionID := c.Param("impression_id")
+	event := c.Query("event")
+
+	claims := &entities.TrackingClaims{
+		ImpressionID: impressionID,
+		SlotID:       c.Query("slot_id"),
+		BannerID:     c.Query("banner_id"),
+		CampaignID:   c.Query("campaign_id"),
+	}
+	if h.verifier != nil {
+		var err error
+		if claims, err = verifyTracking(h.verifier, c.Query(TrackingTokenParam), impressionID); err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if event == string(entities.EventImpression) {
+		if !submitImpression(c, h.impressions, h.queue, trackRequest(c, claims)) {
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	response := h.events.TrackVideoEvent(c.Request.Context(), &tracking.VideoEventRequest{
+		ImpressionID: impressionID,
+		SlotID:       claims.SlotID,
+		BannerID:     claims.BannerID,
+		CampaignID:   claims.CampaignID,
+		Event:        entities.VideoEventType(event),
+		IP:           c.ClientIP(),
+		UserAgent:    c.GetHeader("User-Agent"),
+	})
+	if !response.Success {
+		c.JSON(http.StatusBadRequest, gin.H{"error": response.Message})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// submitImpression queues an impression, or tracks it right away without a
+// queue. A saturated queue answers 503 so clients can retry later; the
+// caller responds only when it returns true.
+func submitImpression(c *gin.Context, service ImpressionService, queue ImpressionQueue, req *tracking.TrackRequest) bool {
+	if queue == nil {
+		service.Track(c.Request.Context(), req)
+		return true
+	}
+
+	if err := queue.Enqueue(c.Request.Context(), req); err != nil {
+		c.Header("Retry-After", "1")
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+// verifyTracking verifies the token of a tracking URL. A non-empty
+// impressionID must match the impression the token was issued for.
+func verifyTracking(verifier TrackingVerifier, token, impressionID string) (*entities.TrackingClaims, error) {
+	claims, err := verifier.Verify(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tracking token: %w", err)
+	}
+	if impressionID != "" && claims.ImpressionID != impressionID {
+		return nil, errors.New("invalid tracking token: issued for another impression")
+	}
+	return claims, nil
+}
+
+// trackRequest is the impression request of tracking claims; the viewer
+// is whoever fetches the tracking URL
+func trackRequest(c *gin.Context, claims *entities.TrackingClaims) *tracking.TrackRequest {
+	return &tracking.TrackRequest{
+		ImpressionID: claims.ImpressionID,
+		SlotID:       claims.SlotID,
+		BannerID:     claims.BannerID,
+		CampaignID:   claims.CampaignID,
+		IP:           c.ClientIP(),
+		UserAgent:    c.GetHeader("User-Agent"),
+		Referer:      c.GetHeader("Referer"),
+	}
+}
+
+// ConversionHandler handles conversion postbacks
+type ConversionHandler struct {
+	service ConversionService
+}
+
+// NewConversionHandler creates a new conversion handler
+func NewConversionHandler(service ConversionService) *ConversionHandler {
+	return &ConversionHandler{service: service}
+}
+
+// Handle handles GET /api/v1/track/conversion/:impression_id, the postback
+// of an authenticated advertiser
+func (h *ConversionHandler) Handle(c *gin.Context) {
+	impressionID := c.Param("impression_id")
+
+	response := h.service.TrackConversion(c.Request.Context(), c.GetString("user_id"), impressionID)
+	if !response.Success {
+		c.JSON(http.StatusNotFound, gin.H{"error": response.Message})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
 // HealthHandler handles health checks
 type HealthHandler struct{}
 
@@ -2,12 +2,19 @@ package http
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
+	"github.com/fall-out-bug/demo-adserver/src/application/audience"
 	"github.com/fall-out-bug/demo-adserver/src/application/delivery"
+	"github.com/fall-out-bug/demo-adserver/src/application/tracking"
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
 )
 
 // Mock service for testing
@@ -27,6 +34,19 @@ func (m *mockDeliveryService) DeliverBanner(ctx context.Context, slotID string,
 	}, nil
 }
 
+func (m *mockDeliveryService) DeliverBatch(ctx context.Context, slotIDs []string, req *delivery.DeliveryRequest) (*delivery.BatchResponse, error) {
+	resp := &delivery.BatchResponse{}
+	for _, slotID := range slotIDs {
+		banner, _ := m.DeliverBanner(ctx, slotID, req)
+		resp.Slots = append(resp.Slots, delivery.BatchSlotResponse{SlotID: slotID, GetBannerResponse: banner})
+	}
+	return resp, nil
+}
+
+func (m *mockDeliveryService) DeliverBid(ctx context.Context, slotID string, req *delivery.DeliveryRequest) (*delivery.Bid, error) {
+	return nil, nil
+}
+
 func TestDeliveryHandler_Handle_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -65,3 +85,484 @@ func TestHealthHandler_Handle_Success(t *testing.T) {
 		t.Errorf("Expected status 200, got %d", w.Code)
 	}
 }
+
+type capturingDeliveryService struct {
+	req     *delivery.DeliveryRequest
+	slotIDs []string
+	bidReqs []*delivery.DeliveryRequest
+	bid     *delivery.Bid
+}
+
+func (m *capturingDeliveryService) DeliverBanner(ctx context.Context, slotID string, req *delivery.DeliveryRequest) (*delivery.GetBannerResponse, error) {
+	m.req = req
+	return &delivery.GetBannerResponse{}, nil
+}
+
+func (m *capturingDeliveryService) DeliverBatch(ctx context.Context, slotIDs []string, req *delivery.DeliveryRequest) (*delivery.BatchResponse, error) {
+	m.req = req
+	m.slotIDs = slotIDs
+	return &delivery.BatchResponse{}, nil
+}
+
+func (m *capturingDeliveryService) DeliverBid(ctx context.Context, slotID string, req *delivery.DeliveryRequest) (*delivery.Bid, error) {
+	m.bidReqs = append(m.bidReqs, req)
+	return m.bid, nil
+}
+
+type stubUserIdentifier struct{}
+
+func (s *stubUserIdentifier) GenerateUserID(ip, userAgent string) string {
+	return "fp:" + userAgent
+}
+
+func TestDeliveryHandler_Handle_UserID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	service := &capturingDeliveryService{}
+	handler := NewDeliveryHandler(service).WithUserIdentifier(&stubUserIdentifier{})
+
+	router := gin.New()
+	router.GET("/api/v1/delivery/:slot_id", handler.Handle)
+
+	// Cookie wins over the fingerprint
+	req, _ := http.NewRequest("GET", "/api/v1/delivery/slot-1", nil)
+	req.Header.Set("User-Agent", "test-agent")
+	req.AddCookie(&http.Cookie{Name: UserIDCookie, Value: "cookie-123"})
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	if service.req.UserID != "cookie-123" {
+		t.Errorf("Expected cookie user ID, got %q", service.req.UserID)
+	}
+
+	req, _ = http.NewRequest("GET", "/api/v1/delivery/slot-1", nil)
+	req.Header.Set("User-Agent", "test-agent")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	if service.req.UserID != "fp:test-agent" {
+		t.Errorf("Expected fingerprint user ID, got %q", service.req.UserID)
+	}
+}
+
+type stubClientDetector struct{}
+
+func (s *stubClientDetector) Detect(header http.Header) (device, os, browser string) {
+	return "mobile", "ios", header.Get("User-Agent")
+}
+
+func TestDeliveryHandler_Handle_DetectsClient(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	service := &capturingDeliveryService{}
+	handler := NewDeliveryHandler(service).WithClientDetector(&stubClientDetector{})
+
+	router := gin.New()
+	router.GET("/api/v1/delivery/:slot_id", handler.Handle)
+
+	req, _ := http.NewRequest("GET", "/api/v1/delivery/slot-1", nil)
+	req.Header.Set("User-Agent", "safari")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	if service.req.Device != "mobile" || service.req.OS != "ios" || service.req.Browser != "safari" {
+		t.Errorf("Expected detected client on the request, got %q/%q/%q", service.req.Device, service.req.OS, service.req.Browser)
+	}
+}
+
+type stubGeoResolver struct{}
+
+func (s *stubGeoResolver) Locate(ip string) (country, region, city, timezone string) {
+	if ip == "81.2.69.142" {
+		return "GB", "ENG", "London", "Europe/London"
+	}
+	return "", "", "", ""
+}
+
+func TestDeliveryHandler_Handle_Geo(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	service := &capturingDeliveryService{}
+	router := gin.New()
+	router.GET("/untrusted/:slot_id", NewDeliveryHandler(service).WithGeoResolver(&stubGeoResolver{}).Handle)
+	router.GET("/trusted/:slot_id", NewDeliveryHandler(service).
+		WithGeoResolver(&stubGeoResolver{}).
+		WithTrustedCountryHeader("CF-IPCountry").Handle)
+
+	// The database answer wins over any header
+	req, _ := http.NewRequest("GET", "/trusted/slot-1", nil)
+	req.RemoteAddr = "81.2.69.142:1234"
+	req.Header.Set("CF-IPCountry", "us")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	if service.req.Country != "GB" || service.req.Region != "ENG" || service.req.City != "London" || service.req.Timezone != "Europe/London" {
+		t.Errorf("Expected location from geo database, got %q/%q/%q/%q", service.req.Country, service.req.Region, service.req.City, service.req.Timezone)
+	}
+
+	// Unknown IP falls back to the trusted header
+	req, _ = http.NewRequest("GET", "/trusted/slot-1", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("CF-IPCountry", "us")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	if service.req.Country != "US" {
+		t.Errorf("Expected country from trusted header, got %q", service.req.Country)
+	}
+
+	// Client headers are ignored unless trusted
+	req, _ = http.NewRequest("GET", "/untrusted/slot-1", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("CF-IPCountry", "us")
+	req.Header.Set("X-Country", "us")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	if service.req.Country != "" {
+		t.Errorf("Expected untrusted headers to be ignored, got %q", service.req.Country)
+	}
+}
+
+func TestDeliveryHandler_Handle_PageURL(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	service := &capturingDeliveryService{}
+	router := gin.New()
+	router.GET("/api/v1/delivery/:slot_id", NewDeliveryHandler(service).Handle)
+
+	// The SDK's referer parameter carries the full page URL
+	req, _ := http.NewRequest("GET", "/api/v1/delivery/slot-1?referer="+url.QueryEscape("https://example.com/sports/1"), nil)
+	req.Header.Set("Referer", "https://example.com/")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	if service.req.Referer != "https://example.com/sports/1" {
+		t.Errorf("Expected page URL from query, got %q", service.req.Referer)
+	}
+
+	req, _ = http.NewRequest("GET", "/api/v1/delivery/slot-1", nil)
+	req.Header.Set("Referer", "https://example.com/news/2")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	if service.req.Referer != "https://example.com/news/2" {
+		t.Errorf("Expected page URL from Referer header, got %q", service.req.Referer)
+	}
+}
+
+func TestDeliveryHandler_Handle_Keywords(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	service := &capturingDeliveryService{}
+	router := gin.New()
+	router.GET("/api/v1/delivery/:slot_id", NewDeliveryHandler(service).WithPathKeywords(true).Handle)
+
+	req, _ := http.NewRequest("GET", "/api/v1/delivery/slot-1?keywords=Sports,football&keywords=tennis", nil)
+	req.Header.Set(PageKeywordsHeader, "golf")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	expected := []string{"sports", "football", "tennis", "golf"}
+	if !reflect.DeepEqual(service.req.Keywords, expected) {
+		t.Errorf("Expected keywords %v, got %v", expected, service.req.Keywords)
+	}
+
+	// Without explicit keywords they come from the page URL
+	req, _ = http.NewRequest("GET", "/api/v1/delivery/slot-1", nil)
+	req.Header.Set("Referer", "https://example.com/travel/beach-holidays")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	expected = []string{"travel", "beach", "holidays"}
+	if !reflect.DeepEqual(service.req.Keywords, expected) {
+		t.Errorf("Expected keywords %v, got %v", expected, service.req.Keywords)
+	}
+}
+
+func TestDeliveryHandler_Handle_SlotSizes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	service := &capturingDeliveryService{}
+	router := gin.New()
+	router.GET("/api/v1/delivery/:slot_id", NewDeliveryHandler(service).Handle)
+
+	tests := []struct {
+		query    string
+		expected []entities.Size
+	}{
+		{"width=300&height=250", []entities.Size{{Width: 300, Height: 250}}},
+		{"width=320", []entities.Size{{Width: 320}}},
+		{"sizes=300x250,336x280&sizes=bogus&width=728&height=90", []entities.Size{{Width: 300, Height: 250}, {Width: 336, Height: 280}}},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		req, _ := http.NewRequest("GET", "/api/v1/delivery/slot-1?"+tt.query, nil)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+
+		if !reflect.DeepEqual(service.req.Sizes, tt.expected) {
+			t.Errorf("%q: expected sizes %v, got %v", tt.query, tt.expected, service.req.Sizes)
+		}
+	}
+}
+
+func TestDeliveryHandler_Handle_Format(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	service := &capturingDeliveryService{}
+	router := gin.New()
+	router.GET("/api/v1/delivery/:slot_id", NewDeliveryHandler(service).Handle)
+
+	req, _ := http.NewRequest("GET", "/api/v1/delivery/slot-1?format=openrtb-native", nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	if service.req.Format != delivery.FormatOpenRTBNative {
+		t.Errorf("Expected OpenRTB native format, got %q", service.req.Format)
+	}
+
+	req, _ = http.NewRequest("GET", "/api/v1/delivery/slot-1?format=audio", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown format, got %d", w.Code)
+	}
+}
+
+func TestDeliveryHandler_HandleBatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	service := &capturingDeliveryService{}
+	router := gin.New()
+	router.POST("/api/v1/delivery/batch", NewDeliveryHandler(service).HandleBatch)
+
+	body := `{"slots":["top","sidebar"],"referer":"https://example.com/sports/1","keywords":["Football"]}`
+	req, _ := http.NewRequest("POST", "/api/v1/delivery/batch", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if !reflect.DeepEqual(service.slotIDs, []string{"top", "sidebar"}) {
+		t.Errorf("Expected slots in request order, got %v", service.slotIDs)
+	}
+	if service.req.Referer != "https://example.com/sports/1" {
+		t.Errorf("Expected page URL from body, got %q", service.req.Referer)
+	}
+	if !reflect.DeepEqual(service.req.Keywords, []string{"football"}) {
+		t.Errorf("Expected keywords from body, got %v", service.req.Keywords)
+	}
+
+	// Empty and oversized slot lists are rejected
+	slots := make([]string, delivery.MaxBatchSlots+1)
+	for i := range slots {
+		slots[i] = fmt.Sprintf("%q", fmt.Sprintf("slot-%d", i))
+	}
+	for _, body := range []string{`{"slots":[]}`, `{"slots":[` + strings.Join(slots, ",") + `]}`} {
+		req, _ = http.NewRequest("POST", "/api/v1/delivery/batch", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+	}
+}
+
+type mockAudienceService struct {
+	tracked map[string]string
+}
+
+func (m *mockAudienceService) Track(ctx context.Context, segmentID, userID string) error {
+	if segmentID != "seg-1" {
+		return audience.ErrSegmentNotFound
+	}
+	m.tracked[userID] = segmentID
+	return nil
+}
+
+func TestPixelHandler_Handle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	service := &mockAudienceService{tracked: map[string]string{}}
+	router := gin.New()
+	router.GET("/api/v1/pixel/:segment_id", NewPixelHandler(service).WithUserIdentifier(&stubUserIdentifier{}).Handle)
+
+	req, _ := http.NewRequest("GET", "/api/v1/pixel/seg-1", nil)
+	req.Header.Set("User-Agent", "test-agent")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/gif" {
+		t.Errorf("Expected GIF response, got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	if service.tracked["fp:test-agent"] != "seg-1" {
+		t.Errorf("Expected viewer to join the segment, got %v", service.tracked)
+	}
+
+	req, _ = http.NewRequest("GET", "/api/v1/pixel/seg-404", nil)
+	req.Header.Set("User-Agent", "test-agent")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for unknown segment, got %d", w.Code)
+	}
+}
+
+type mockConversionService struct{}
+
+func (m *mockConversionService) TrackConversion(ctx context.Context, advertiserID, impressionID string) *tracking.TrackResponse {
+	if advertiserID == "adv-1" && impressionID == "imp-1" {
+		return &tracking.TrackResponse{Success: true}
+	}
+	return &tracking.TrackResponse{Success: false, Message: "impression not found"}
+}
+
+func TestConversionHandler_Handle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	// Stands in for the advertiser auth middleware
+	router.Use(func(c *gin.Context) { c.Set("user_id", "adv-1") })
+	router.GET("/api/v1/track/conversion/:impression_id", NewConversionHandler(&mockConversionService{}).Handle)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/track/conversion/imp-1", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected status 204, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/v1/track/conversion/unknown", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+}
+
+func TestImpressionHandler_HandlePixel(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	service := &recordingImpressionService{tracked: make(chan *tracking.TrackRequest, 1)}
+	router := gin.New()
+	router.GET("/api/v1/track/impression", NewImpressionHandler(service).HandlePixel)
+
+	req, _ := http.NewRequest("GET", "/api/v1/track/impression?id=imp-1&banner_id=ban-1&campaign_id=cmp-1&slot_id=sidebar", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/gif" {
+		t.Errorf("Expected a GIF pixel, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	tracked := <-service.tracked
+	if tracked.ImpressionID != "imp-1" || tracked.BannerID != "ban-1" || tracked.CampaignID != "cmp-1" || tracked.SlotID != "sidebar" {
+		t.Errorf("Unexpected impression %+v", tracked)
+	}
+
+	req, _ = http.NewRequest("GET", "/api/v1/track/impression", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without an impression ID, got %d", w.Code)
+	}
+}
+
+type stubTrackingVerifier struct{}
+
+func (s *stubTrackingVerifier) Verify(token string) (*entities.TrackingClaims, error) {
+	if token != "signed-imp-1" {
+		return nil, fmt.Errorf("bad signature")
+	}
+	return &entities.TrackingClaims{ImpressionID: "imp-1", BannerID: "ban-1", CampaignID: "cmp-1", SlotID: "sidebar"}, nil
+}
+
+func TestImpressionHandler_SignedTracking(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	service := &recordingImpressionService{tracked: make(chan *tracking.TrackRequest, 2)}
+	handler := NewImpressionHandler(service).WithVerifier(&stubTrackingVerifier{})
+	router := gin.New()
+	router.GET("/api/v1/track/impression", handler.HandlePixel)
+	router.POST("/api/v1/track/impression", handler.Handle)
+
+	// Plain parameters are ignored in favour of the signed claims
+	req, _ := http.NewRequest("GET", "/api/v1/track/impression?t=signed-imp-1&campaign_id=cmp-x", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected a GIF pixel, got %d", w.Code)
+	}
+	if tracked := <-service.tracked; tracked.ImpressionID != "imp-1" || tracked.CampaignID != "cmp-1" || tracked.SlotID != "sidebar" {
+		t.Errorf("Expected the signed impression, got %+v", tracked)
+	}
+
+	req, _ = http.NewRequest("POST", "/api/v1/track/impression", strings.NewReader(`{"token": "signed-imp-1", "CampaignID": "cmp-x"}`))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d", w.Code)
+	}
+	if tracked := <-service.tracked; tracked.CampaignID != "cmp-1" {
+		t.Errorf("Expected the signed campaign, got %+v", tracked)
+	}
+
+	// Beacons post the impression URL without a body
+	req, _ = http.NewRequest("POST", "/api/v1/track/impression?t=signed-imp-1", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202 for an empty beacon, got %d", w.Code)
+	}
+	if tracked := <-service.tracked; tracked.ImpressionID != "imp-1" || tracked.CampaignID != "cmp-1" {
+		t.Errorf("Expected the signed impression, got %+v", tracked)
+	}
+
+	for _, tt := range []struct {
+		method, url, body string
+	}{
+		{"GET", "/api/v1/track/impression?id=imp-1&banner_id=ban-1&campaign_id=cmp-1&slot_id=sidebar", ""},
+		{"GET", "/api/v1/track/impression?t=forged", ""},
+		{"POST", "/api/v1/track/impression", `{"token": "forged"}`},
+		{"POST", "/api/v1/track/impression?t=forged", ""},
+	} {
+		req, _ := http.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s %s: expected status 403, got %d", tt.method, tt.url, w.Code)
+		}
+	}
+}
+
+type stubClickService struct{}
+
+func (s *stubClickService) TrackClick(ctx context.Context, req *tracking.ClickRequest) *tracking.ClickResponse {
+	return &tracking.ClickResponse{Success: true, RedirectURL: "https://shop.example.com"}
+}
+
+func TestClickHandler_SignedTracking(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.GET("/api/v1/track/click/:impression_id", NewClickHandler(&stubClickService{}).WithVerifier(&stubTrackingVerifier{}).Handle)
+
+	tests := []struct {
+		url      string
+		expected int
+	}{
+		{"/api/v1/track/click/imp-1?t=signed-imp-1", http.StatusFound},
+		{"/api/v1/track/click/imp-2?t=signed-imp-1", http.StatusForbidden}, // Token of another impression
+		{"/api/v1/track/click/imp-1", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		req, _ := http.NewRequest("GET", tt.url, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tt.expected {
+			t.Errorf("%s: expected status %d, got %d", tt.url, tt.expected, w.Code)
+		}
+	}
+}
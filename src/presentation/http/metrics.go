@@ -0,0 +1,70 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/fall-out-bug/demo-adserver/src/application/tracking"
+	"github.com/gin-gonic/gin"
+)
+
+// ImpressionPipeline is the asynchronous impression queue with its stats
+type ImpressionPipeline interface {
+	ImpressionQueue
+	Stats() tracking.PipelineStats
+}
+
+// PipelineStatsProvider reports the state of the impression pipeline
+type PipelineStatsProvider interface {
+	Stats() tracking.PipelineStats
+}
+
+// LedgerStatsProvider reports how delivery fares writing the served-ad ledger
+type LedgerStatsProvider interface {
+	LedgerFailures() uint64
+}
+
+// MetricsHandler serves metrics in the Prometheus text format
+type MetricsHandler struct {
+	pipeline PipelineStatsProvider
+	ledger   LedgerStatsProvider
+}
+
+// NewMetricsHandler creates a new metrics handler
+func NewMetricsHandler(pipeline PipelineStatsProvider) *MetricsHandler {
+	return &MetricsHandler{pipeline: pipeline}
+}
+
+// WithLedgerStats adds the failed writes of the served-ad ledger
+func (h *MetricsHandler) WithLedgerStats(ledger LedgerStatsProvider) *MetricsHandler {
+	h.ledger = ledger
+	return h
+}
+
+// Handle handles GET /metrics
+func (h *MetricsHandler) Handle(c *gin.Context) {
+	var b strings.Builder
+
+	if h.pipeline != nil {
+		stats := h.pipeline.Stats()
+		writeMetric(&b, "adserver_event_queue_depth", "gauge", "Impressions waiting in the event queue.", uint64(stats.Depth))
+		writeMetric(&b, "adserver_event_queue_capacity", "gauge", "Size of the event queue.", uint64(stats.Capacity))
+		writeMetric(&b, "adserver_events_enqueued_total", "counter", "Impressions accepted into the event queue.", stats.Enqueued)
+		writeMetric(&b, "adserver_events_dropped_total", "counter", "Impressions dropped because the event queue was full.", stats.Dropped)
+		writeMetric(&b, "adserver_events_skipped_total", "counter", "Queued impressions not stored as duplicates or unknown.", stats.Skipped)
+		writeMetric(&b, "adserver_events_stored_total", "counter", "Impressions written to the database or the event stream.", stats.Stored)
+		writeMetric(&b, "adserver_events_failed_total", "counter", "Impressions lost to failed batch inserts.", stats.Failed)
+		writeMetric(&b, "adserver_event_batches_total", "counter", "Batch inserts run.", stats.Batches)
+	}
+	if h.ledger != nil {
+		writeMetric(&b, "adserver_ledger_failures_total", "counter", "Ads not served because their ledger entry could not be written.", h.ledger.LedgerFailures())
+	}
+
+	c.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", []byte(b.String()))
+}
+
+// writeMetric writes one metric with its HELP and TYPE lines
+func writeMetric(b *strings.Builder, name, kind, help string, value uint64) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n%s %d\n", name, help, name, kind, name, value)
+}
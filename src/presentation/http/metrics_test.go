@@ -0,0 +1,103 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fall-out-bug/demo-adserver/src/application/tracking"
+	"github.com/gin-gonic/gin"
+)
+
+// stubPipeline accepts up to capacity impressions
+type stubPipeline struct {
+	queued   []*tracking.TrackRequest
+	capacity int
+	dropped  uint64
+}
+
+func (s *stubPipeline) Enqueue(ctx context.Context, req *tracking.TrackRequest) error {
+	if len(s.queued) >= s.capacity {
+		s.dropped++
+		return tracking.ErrQueueFull
+	}
+	s.queued = append(s.queued, req)
+	return nil
+}
+
+func (s *stubPipeline) Stats() tracking.PipelineStats {
+	return tracking.PipelineStats{
+		Depth:    len(s.queued),
+		Capacity: s.capacity,
+		Enqueued: uint64(len(s.queued)),
+		Dropped:  s.dropped,
+	}
+}
+
+func TestImpressionHandler_Queue(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	service := &recordingImpressionService{tracked: make(chan *tracking.TrackRequest, 1)}
+	pipeline := &stubPipeline{capacity: 1}
+	router := gin.New()
+	router.GET("/api/v1/track/impression", NewImpressionHandler(service).WithQueue(pipeline).HandlePixel)
+
+	req, _ := http.NewRequest("GET", "/api/v1/track/impression?id=imp-1&banner_id=ban-1&campaign_id=cmp-1&slot_id=sidebar", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected a GIF pixel, got %d", w.Code)
+	}
+	if len(pipeline.queued) != 1 || pipeline.queued[0].ImpressionID != "imp-1" {
+		t.Errorf("Expected the impression queued, got %+v", pipeline.queued)
+	}
+	if len(service.tracked) != 0 {
+		t.Error("Expected the impression left to the pipeline")
+	}
+
+	// A full queue pushes back
+	req, _ = http.NewRequest("GET", "/api/v1/track/impression?id=imp-2&banner_id=ban-1&campaign_id=cmp-1&slot_id=sidebar", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") == "" {
+		t.Errorf("Expected status 503 with Retry-After, got %d", w.Code)
+	}
+}
+
+type stubLedgerStats struct{}
+
+func (s *stubLedgerStats) LedgerFailures() uint64 {
+	return 4
+}
+
+func TestMetricsHandler_Handle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	pipeline := &stubPipeline{queued: []*tracking.TrackRequest{{}, {}}, capacity: 10, dropped: 3}
+	router := gin.New()
+	router.GET("/metrics", NewMetricsHandler(pipeline).WithLedgerStats(&stubLedgerStats{}).Handle)
+
+	req, _ := http.NewRequest("GET", "/metrics", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	for _, line := range []string{
+		"# TYPE adserver_event_queue_depth gauge",
+		"adserver_event_queue_depth 2",
+		"adserver_event_queue_capacity 10",
+		"# TYPE adserver_events_dropped_total counter",
+		"adserver_events_dropped_total 3",
+		"adserver_ledger_failures_total 4",
+	} {
+		if !strings.Contains(w.Body.String(), line+"\n") {
+			t.Errorf("Expected %q in:\n%s", line, w.Body.String())
+		}
+	}
+}
@@ -17,6 +17,14 @@ func (m *mockFailingDeliveryService) DeliverBanner(ctx context.Context, slotID s
 	return nil, errors.New("service error")
 }
 
+func (m *mockFailingDeliveryService) DeliverBatch(ctx context.Context, slotIDs []string, req *delivery.DeliveryRequest) (*delivery.BatchResponse, error) {
+	return nil, errors.New("service error")
+}
+
+func (m *mockFailingDeliveryService) DeliverBid(ctx context.Context, slotID string, req *delivery.DeliveryRequest) (*delivery.Bid, error) {
+	return nil, errors.New("service error")
+}
+
 func TestDeliveryHandler_Handle_Error(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
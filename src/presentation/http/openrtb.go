@@ -0,0 +1,450 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/fall-out-bug/demo-adserver/src/application/delivery"
+	"github.com/fall-out-bug/demo-adserver/src/application/tracking"
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+// OpenRTBVersion is the OpenRTB version of the bid endpoint
+const OpenRTBVersion = "2.6"
+
+// BidCurrency is the currency of bids and bid floors
+const BidCurrency = "USD"
+
+// AuctionPriceMacro is replaced by exchanges with the clearing price in win and billing notices
+const AuctionPriceMacro = "${AUCTION_PRICE}"
+
+// OpenRTB 2.6 markup types of bids
+const (
+	openRTBMarkupBanner = 1
+	openRTBMarkupVideo  = 2
+	openRTBMarkupNative = 4
+)
+
+// OpenRTB 2.6 device types that map to targeting devices
+const (
+	openRTBDevicePC     = 2
+	openRTBDevicePhone  = 4
+	openRTBDeviceTablet = 5
+)
+
+// BidRequest is an OpenRTB 2.6 bid request, limited to the fields used for selection
+type BidRequest struct {
+	ID     string         `json:"id"`
+	Imp    []OpenRTBImp   `json:"imp"`
+	Site   *OpenRTBSite   `json:"site,omitempty"`
+	Device *OpenRTBDevice `json:"device,omitempty"`
+	User   *OpenRTBUser   `json:"user,omitempty"`
+	Cur    []string       `json:"cur,omitempty"`
+	BCat   []string       `json:"bcat,omitempty"` // Blocked advertiser categories
+	Test   int            `json:"test,omitempty"`
+}
+
+// OpenRTBImp is an impression offered in a bid request. TagID names the slot.
+type OpenRTBImp struct {
+	ID          string            `json:"id"`
+	TagID       string            `json:"tagid"`
+	Banner      *OpenRTBBanner    `json:"banner,omitempty"`
+	Video       *OpenRTBVideo     `json:"video,omitempty"`
+	Native      *OpenRTBImpNative `json:"native,omitempty"`
+	BidFloor    float64           `json:"bidfloor,omitempty"`
+	BidFloorCur string            `json:"bidfloorcur,omitempty"`
+}
+
+// OpenRTBBanner is a display placement with its accepted sizes
+type OpenRTBBanner struct {
+	W      int             `json:"w,omitempty"`
+	H      int             `json:"h,omitempty"`
+	Format []OpenRTBFormat `json:"format,omitempty"`
+}
+
+// OpenRTBFormat is one size a display placement accepts
+type OpenRTBFormat struct {
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+// OpenRTBVideo is a video placement
+type OpenRTBVideo struct {
+	MIMEs []string `json:"mimes,omitempty"`
+	W     int      `json:"w,omitempty"`
+	H     int      `json:"h,omitempty"`
+}
+
+// OpenRTBImpNative is a native placement; its asset request is not inspected
+type OpenRTBImpNative struct {
+	Request string `json:"request,omitempty"`
+	Ver     string `json:"ver,omitempty"`
+}
+
+// OpenRTBSite is the page the impression is on
+type OpenRTBSite struct {
+	ID       string `json:"id,omitempty"`
+	Domain   string `json:"domain,omitempty"`
+	Page     string `json:"page,omitempty"`
+	Keywords string `json:"keywords,omitempty"`
+}
+
+// OpenRTBDevice is the viewer's device
+type OpenRTBDevice struct {
+	UA         string      `json:"ua,omitempty"`
+	IP         string      `json:"ip,omitempty"`
+	IPv6       string      `json:"ipv6,omitempty"`
+	Geo        *OpenRTBGeo `json:"geo,omitempty"`
+	DeviceType int         `json:"devicetype,omitempty"`
+}
+
+// OpenRTBGeo is a location; Country is ISO 3166-1 alpha-3
+type OpenRTBGeo struct {
+	Country string `json:"country,omitempty"`
+	Region  string `json:"region,omitempty"`
+	City    string `json:"city,omitempty"`
+}
+
+// OpenRTBUser is the viewer. BuyerUID is our viewer ID as synced with the exchange.
+type OpenRTBUser struct {
+	ID       string      `json:"id,omitempty"`
+	BuyerUID string      `json:"buyeruid,omitempty"`
+	Keywords string      `json:"keywords,omitempty"`
+	Geo      *OpenRTBGeo `json:"geo,omitempty"`
+}
+
+// BidResponse is an OpenRTB 2.6 bid response
+type BidResponse struct {
+	ID      string           `json:"id"`
+	SeatBid []OpenRTBSeatBid `json:"seatbid"`
+	Cur     string           `json:"cur"`
+}
+
+// OpenRTBSeatBid groups the bids of a seat
+type OpenRTBSeatBid struct {
+	Bid []OpenRTBBid `json:"bid"`
+}
+
+// OpenRTBBid is a bid on one impression of the request
+type OpenRTBBid struct {
+	ID      string   `json:"id"`
+	ImpID   string   `json:"impid"`
+	Price   float64  `json:"price"`
+	NURL    string   `json:"nurl"`
+	BURL    string   `json:"burl"`
+	AdM     string   `json:"adm"`
+	AdID    string   `json:"adid"`
+	CID     string   `json:"cid"`
+	CrID    string   `json:"crid"`
+	ADomain []string `json:"adomain,omitempty"`
+	Cat     []string `json:"cat,omitempty"`
+	W       int      `json:"w,omitempty"`
+	H       int      `json:"h,omitempty"`
+	MType   int      `json:"mtype"`
+}
+
+// HandleOpenRTB handles POST /api/v1/openrtb/bid. Every impression is filled
+// like a slot request (TagID is the slot) and bid for at the winning campaign's
+// eCPM. Impressions of one request share page exclusions like batch delivery.
+// Without any bid the response is 204 No Content, as exchanges expect.
+func (h *DeliveryHandler) HandleOpenRTB(c *gin.Context) {
+	var bidReq BidRequest
+	if err := c.ShouldBindJSON(&bidReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if bidReq.ID == "" || len(bidReq.Imp) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bid request needs an id and impressions"})
+		return
+	}
+	c.Header("X-OpenRTB-Version", OpenRTBVersion)
+
+	if !acceptsCurrency(bidReq.Cur) {
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	base := h.openRTBRequest(&bidReq)
+	base.Page = delivery.NewPageExclusions()
+	base.Page.ExcludeCategories(bidReq.BCat...)
+
+	var bids []OpenRTBBid
+	for _, imp := range bidReq.Imp {
+		req, ok := openRTBImpRequest(imp, base)
+		if !ok {
+			continue
+		}
+
+		bid, err := h.service.DeliverBid(c.Request.Context(), req.SlotID, req)
+		if err != nil || bid == nil {
+			continue
+		}
+		// A bid without markup can't be shown; pass on the impression
+		out, err := h.openRTBBid(c, imp, bid)
+		if err != nil {
+			continue
+		}
+		bids = append(bids, out)
+	}
+
+	if len(bids) == 0 {
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	c.JSON(http.StatusOK, BidResponse{
+		ID:      bidReq.ID,
+		SeatBid: []OpenRTBSeatBid{{Bid: bids}},
+		Cur:     BidCurrency,
+	})
+}
+
+// openRTBRequest describes the viewer and page of a bid request. The exchange
+// calls server to server, so the viewer comes from the device object rather
+// than the HTTP request.
+func (h *DeliveryHandler) openRTBRequest(bidReq *BidRequest) *delivery.DeliveryRequest {
+	req := &delivery.DeliveryRequest{Timestamp: time.Now()}
+
+	var geo *OpenRTBGeo
+	if d := bidReq.Device; d != nil {
+		req.IP = d.IP
+		if req.IP == "" {
+			req.IP = d.IPv6
+		}
+		req.UserAgent = d.UA
+		geo = d.Geo
+	}
+
+	var keywords []string
+	if u := bidReq.User; u != nil {
+		req.UserID = u.BuyerUID
+		keywords = append(keywords, u.Keywords)
+		if geo == nil {
+			geo = u.Geo
+		}
+	}
+	if req.UserID == "" && h.identifier != nil && req.IP != "" {
+		req.UserID = h.identifier.GenerateUserID(req.IP, req.UserAgent)
+	}
+
+	if s := bidReq.Site; s != nil {
+		req.Referer = s.Page
+		keywords = append(keywords, s.Keywords)
+	}
+	req.Keywords = entities.NormalizeKeywords(keywords)
+	if len(req.Keywords) == 0 && h.pathKeywords {
+		req.Keywords = entities.KeywordsFromURL(req.Referer)
+	}
+
+	// Our geo database wins; the exchange's location fills the gaps
+	if h.geo != nil && req.IP != "" {
+		req.Country, req.Region, req.City, req.Timezone = h.geo.Locate(req.IP)
+	}
+	if geo != nil && req.Country == "" {
+		req.Country = countryAlpha2[strings.ToUpper(geo.Country)]
+		if req.Country != "" {
+			req.Region = strings.ToUpper(geo.Region)
+			req.City = geo.City
+		}
+	}
+
+	if h.detector != nil && req.UserAgent != "" {
+		req.Device, req.OS, req.Browser = h.detector.Detect(http.Header{"User-Agent": {req.UserAgent}})
+	}
+	if bidReq.Device != nil {
+		switch bidReq.Device.DeviceType {
+		case openRTBDevicePC:
+			req.Device = "desktop"
+		case openRTBDevicePhone:
+			req.Device = "mobile"
+		case openRTBDeviceTablet:
+			req.Device = "tablet"
+		}
+	}
+
+	return req
+}
+
+// openRTBImpRequest derives the slot request of an impression. Impressions
+// without a tag ID or with a floor in another currency are not bid on.
+func openRTBImpRequest(imp OpenRTBImp, base *delivery.DeliveryRequest) (*delivery.DeliveryRequest, bool) {
+	if imp.TagID == "" || (imp.BidFloorCur != "" && !strings.EqualFold(imp.BidFloorCur, BidCurrency)) {
+		return nil, false
+	}
+
+	req := *base
+	req.SlotID = imp.TagID
+	req.BidFloor = decimal.NewFromFloat(imp.BidFloor)
+
+	switch {
+	case imp.Video != nil:
+		req.Format = delivery.FormatVideo
+	case imp.Native != nil:
+		req.Format = delivery.FormatOpenRTBNative
+	case imp.Banner != nil:
+		req.Format = delivery.FormatDisplay
+		for _, f := range imp.Banner.Format {
+			req.Sizes = append(req.Sizes, entities.Size{Width: f.W, Height: f.H})
+		}
+		if len(req.Sizes) == 0 && (imp.Banner.W > 0 || imp.Banner.H > 0) {
+			req.Sizes = []entities.Size{{Width: imp.Banner.W, Height: imp.Banner.H}}
+		}
+	default:
+		return nil, false
+	}
+
+	return &req, true
+}
+
+// openRTBBid describes a delivery bid as an OpenRTB bid with its markup and notices
+func (h *DeliveryHandler) openRTBBid(c *gin.Context, imp OpenRTBImp, bid *delivery.Bid) (OpenRTBBid, error) {
+	creative := bid.Response.Creative
+
+	out := OpenRTBBid{
+		ID:    bid.ImpressionID,
+		ImpID: imp.ID,
+		Price: bid.Price.InexactFloat64(),
+		NURL:  withAuctionPrice(absoluteURL(c, h.publicURL, bid.WinURL)),
+		BURL:  withAuctionPrice(absoluteURL(c, h.publicURL, bid.BillURL)),
+		AdID:  bid.Banner.ID,
+		CID:   bid.Campaign.ID,
+		CrID:  bid.Banner.ID,
+		W:     creative.Width,
+		H:     creative.Height,
+	}
+	if landing, err := url.Parse(bid.Banner.ClickURL); err == nil && landing.Hostname() != "" {
+		out.ADomain = []string{strings.TrimPrefix(strings.ToLower(landing.Hostname()), "www.")}
+	}
+	if bid.Campaign.Category != "" {
+		out.Cat = []string{bid.Campaign.Category}
+	}
+
+	var err error
+	switch {
+	case creative.Video != nil:
+		out.MType = openRTBMarkupVideo
+		out.AdM, err = h.vastMarkup(c, bid.Response)
+	case creative.OpenRTB != nil:
+		out.MType = openRTBMarkupNative
+		out.AdM, err = h.nativeMarkup(c, creative.OpenRTB)
+	default:
+		out.MType = openRTBMarkupBanner
+		var doc bytes.Buffer
+		err = tagDocument.Execute(&doc, h.tagView(c, bid.Response))
+		out.AdM = doc.String()
+	}
+	if err != nil {
+		return OpenRTBBid{}, err
+	}
+
+	return out, nil
+}
+
+// withAuctionPrice adds the clearing price macro to a notice URL. The macro
+// must stay unescaped for exchanges to substitute it, so it is not encoded
+// with the rest of the query.
+func withAuctionPrice(notice string) string {
+	separator := "?"
+	if strings.Contains(notice, "?") {
+		separator = "&"
+	}
+	return notice + separator + "price=" + AuctionPriceMacro
+}
+
+// vastMarkup renders a video bid as a VAST document
+func (h *DeliveryHandler) vastMarkup(c *gin.Context, response *delivery.GetBannerResponse) (string, error) {
+	doc := &VAST{Version: VASTVersion, XMLNS: "http://www.iab.com/VAST", Ads: []VASTAd{h.vastAd(c, response)}}
+	out, err := xml.Marshal(doc)
+	if err != nil {
+		return "", err
+	}
+	return xml.Header + string(out), nil
+}
+
+// nativeMarkup renders a native bid as an OpenRTB Native response with absolute trackers
+func (h *DeliveryHandler) nativeMarkup(c *gin.Context, native *delivery.OpenRTBNativeResponse) (string, error) {
+	resp := *native
+	resp.Native.Link.URL = absoluteURL(c, h.publicURL, native.Native.Link.URL)
+	resp.Native.EventTrackers = make([]delivery.OpenRTBEventTracker, len(native.Native.EventTrackers))
+	for i, tracker := range native.Native.EventTrackers {
+		tracker.URL = absoluteURL(c, h.publicURL, tracker.URL)
+		resp.Native.EventTrackers[i] = tracker
+	}
+	out, err := json.Marshal(resp)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// acceptsCurrency reports whether bids may be placed in BidCurrency; no list allows any
+func acceptsCurrency(currencies []string) bool {
+	if len(currencies) == 0 {
+		return true
+	}
+	for _, cur := range currencies {
+		if strings.EqualFold(cur, BidCurrency) {
+			return true
+		}
+	}
+	return false
+}
+
+// AuctionNoticeHandler handles the nurl and burl notices of bids
+type AuctionNoticeHandler struct {
+	service  AuctionService
+	verifier TrackingVerifier
+}
+
+// NewAuctionNoticeHandler creates a new auction notice handler
+func NewAuctionNoticeHandler(service AuctionService) *AuctionNoticeHandler {
+	return &AuctionNoticeHandler{service: service}
+}
+
+// WithVerifier requires notice URLs to carry a signed token for their impression
+func (h *AuctionNoticeHandler) WithVerifier(verifier TrackingVerifier) *AuctionNoticeHandler {
+	h.verifier = verifier
+	return h
+}
+
+// HandleWin handles GET /api/v1/track/win/:impression_id?t=<token>&price=<clearing CPM>
+func (h *AuctionNoticeHandler) HandleWin(c *gin.Context) {
+	h.handle(c, h.service.Win)
+}
+
+// HandleBill handles GET /api/v1/track/bill/:impression_id?t=<token>&price=<clearing CPM>
+func (h *AuctionNoticeHandler) HandleBill(c *gin.Context) {
+	h.handle(c, h.service.Bill)
+}
+
+func (h *AuctionNoticeHandler) handle(c *gin.Context, notify func(context.Context, string, decimal.Decimal) *tracking.TrackResponse) {
+	impressionID := c.Param("impression_id")
+	if h.verifier != nil {
+		if _, err := verifyTracking(h.verifier, c.Query(TrackingTokenParam), impressionID); err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	price, err := decimal.NewFromString(c.Query("price"))
+	if err != nil || price.IsNegative() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid price"})
+		return
+	}
+
+	response := notify(c.Request.Context(), impressionID, price)
+	if !response.Success {
+		c.JSON(http.StatusNotFound, gin.H{"error": response.Message})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
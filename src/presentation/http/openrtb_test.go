@@ -0,0 +1,208 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fall-out-bug/demo-adserver/src/application/delivery"
+	"github.com/fall-out-bug/demo-adserver/src/application/tracking"
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+const testBidRequest = `{
+	"id": "req-1",
+	"cur": ["USD"],
+	"bcat": ["IAB25"],
+	"imp": [
+		{"id": "1", "tagid": "sidebar", "bidfloor": 1.5, "banner": {"format": [{"w": 300, "h": 250}, {"w": 300, "h": 600}]}},
+		{"id": "2", "banner": {"w": 728, "h": 90}},
+		{"id": "3", "tagid": "preroll", "bidfloor": 2, "bidfloorcur": "EUR", "video": {"mimes": ["video/mp4"]}}
+	],
+	"site": {"page": "https://news.example.com/sports/tennis", "keywords": "Tennis,Open"},
+	"device": {"ua": "Mozilla/5.0 (iPad)", "ip": "203.0.113.7", "devicetype": 5, "geo": {"country": "DEU", "region": "be", "city": "Berlin"}},
+	"user": {"buyeruid": "uid-42"}
+}`
+
+func TestDeliveryHandler_HandleOpenRTB(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	service := &capturingDeliveryService{bid: &delivery.Bid{
+		ImpressionID: "imp-1",
+		Campaign:     &entities.Campaign{ID: "cmp-1", Category: "IAB17"},
+		Banner:       &entities.Banner{ID: "ban-1", ClickURL: "https://www.shop.example.com/landing"},
+		Price:        decimal.NewFromFloat(2.25),
+		Response: &delivery.GetBannerResponse{
+			Creative: &delivery.Creative{HTML: "<div>Ad</div>", Width: 300, Height: 250},
+			Tracking: &delivery.TrackingInfo{ImpressionID: "imp-1", Click: "/api/v1/track/click/imp-1?t=signed-imp-1"},
+		},
+		WinURL:  "/api/v1/track/win/imp-1?t=signed-imp-1",
+		BillURL: "/api/v1/track/bill/imp-1?t=signed-imp-1",
+	}}
+	handler := NewDeliveryHandler(service).
+		WithGeoResolver(&stubGeoResolver{}).
+		WithClientDetector(&stubClientDetector{}).
+		WithPublicURL("https://ads.example.com")
+
+	router := gin.New()
+	router.POST("/api/v1/openrtb/bid", handler.HandleOpenRTB)
+
+	req, _ := http.NewRequest("POST", "/api/v1/openrtb/bid", strings.NewReader(testBidRequest))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Only the first impression names a slot with a floor in dollars
+	if len(service.bidReqs) != 1 {
+		t.Fatalf("Expected one impression to be bid on, got %d", len(service.bidReqs))
+	}
+	got := service.bidReqs[0]
+	if got.SlotID != "sidebar" || len(got.Sizes) != 2 || got.Sizes[1].String() != "300x600" || !got.BidFloor.Equal(decimal.NewFromFloat(1.5)) {
+		t.Errorf("Expected sidebar with two sizes and floor 1.5, got %+v", got)
+	}
+	if got.IP != "203.0.113.7" || got.UserID != "uid-42" || got.Referer != "https://news.example.com/sports/tennis" {
+		t.Errorf("Expected the viewer from the bid request, got %+v", got)
+	}
+	if got.Country != "DE" || got.Region != "BE" || got.City != "Berlin" {
+		t.Errorf("Expected the exchange's location, got %q/%q/%q", got.Country, got.Region, got.City)
+	}
+	if got.Device != "tablet" || got.Browser != "Mozilla/5.0 (iPad)" {
+		t.Errorf("Expected the device type to override detection, got %q/%q", got.Device, got.Browser)
+	}
+	if strings.Join(got.Keywords, ",") != "tennis,open" {
+		t.Errorf("Expected site keywords, got %v", got.Keywords)
+	}
+	if got.Page == nil {
+		t.Errorf("Expected page exclusions for the blocked categories")
+	}
+
+	var resp BidResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.ID != "req-1" || resp.Cur != "USD" || len(resp.SeatBid) != 1 || len(resp.SeatBid[0].Bid) != 1 {
+		t.Fatalf("Expected one bid for req-1, got %+v", resp)
+	}
+	bid := resp.SeatBid[0].Bid[0]
+	if bid.ImpID != "1" || bid.Price != 2.25 || bid.CrID != "ban-1" || bid.CID != "cmp-1" || bid.MType != 1 {
+		t.Errorf("Unexpected bid %+v", bid)
+	}
+	if bid.NURL != "https://ads.example.com/api/v1/track/win/imp-1?t=signed-imp-1&price=${AUCTION_PRICE}" {
+		t.Errorf("Expected win notice with the price macro, got %q", bid.NURL)
+	}
+	if bid.BURL != "https://ads.example.com/api/v1/track/bill/imp-1?t=signed-imp-1&price=${AUCTION_PRICE}" {
+		t.Errorf("Expected billing notice with the price macro, got %q", bid.BURL)
+	}
+	if len(bid.ADomain) != 1 || bid.ADomain[0] != "shop.example.com" || len(bid.Cat) != 1 || bid.Cat[0] != "IAB17" {
+		t.Errorf("Expected advertiser domain and category, got %v %v", bid.ADomain, bid.Cat)
+	}
+	if !strings.Contains(bid.AdM, "<div>Ad</div>") || strings.Contains(bid.AdM, "<img") {
+		t.Errorf("Expected tag markup without an impression pixel, got %q", bid.AdM)
+	}
+}
+
+func TestDeliveryHandler_HandleOpenRTB_NoBid(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.POST("/api/v1/openrtb/bid", NewDeliveryHandler(&capturingDeliveryService{}).HandleOpenRTB)
+
+	tests := []struct {
+		name     string
+		body     string
+		expected int
+	}{
+		{"no campaign", testBidRequest, http.StatusNoContent},
+		{"other currency", `{"id": "req-1", "cur": ["EUR"], "imp": [{"id": "1", "tagid": "sidebar", "banner": {}}]}`, http.StatusNoContent},
+		{"no impressions", `{"id": "req-1", "imp": []}`, http.StatusBadRequest},
+		{"malformed", `{"id":`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		req, _ := http.NewRequest("POST", "/api/v1/openrtb/bid", bytes.NewBufferString(tt.body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tt.expected {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.expected, w.Code)
+		}
+	}
+}
+
+type mockAuctionService struct {
+	notices []string
+}
+
+func (m *mockAuctionService) Win(ctx context.Context, impressionID string, price decimal.Decimal) *tracking.TrackResponse {
+	return m.notice("win", impressionID, price)
+}
+
+func (m *mockAuctionService) Bill(ctx context.Context, impressionID string, price decimal.Decimal) *tracking.TrackResponse {
+	return m.notice("bill", impressionID, price)
+}
+
+func (m *mockAuctionService) notice(kind, impressionID string, price decimal.Decimal) *tracking.TrackResponse {
+	if impressionID != "imp-1" {
+		return &tracking.TrackResponse{Success: false, Message: "impression not found"}
+	}
+	m.notices = append(m.notices, kind+":"+price.String())
+	return &tracking.TrackResponse{Success: true}
+}
+
+func TestAuctionNoticeHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	service := &mockAuctionService{}
+	handler := NewAuctionNoticeHandler(service).WithVerifier(&stubTrackingVerifier{})
+	router := gin.New()
+	router.GET("/api/v1/track/win/:impression_id", handler.HandleWin)
+	router.GET("/api/v1/track/bill/:impression_id", handler.HandleBill)
+
+	tests := []struct {
+		url      string
+		expected int
+	}{
+		{"/api/v1/track/win/imp-1?t=signed-imp-1&price=1.75", http.StatusNoContent},
+		{"/api/v1/track/bill/imp-1?t=signed-imp-1&price=1.75", http.StatusNoContent},
+		{"/api/v1/track/win/imp-1?price=1.75", http.StatusForbidden},
+		{"/api/v1/track/bill/imp-1?t=forged&price=1.75", http.StatusForbidden},
+		{"/api/v1/track/win/imp-2?t=signed-imp-1&price=1.75", http.StatusForbidden},
+		{"/api/v1/track/win/imp-1?t=signed-imp-1&price=${AUCTION_PRICE}", http.StatusBadRequest},
+		{"/api/v1/track/bill/imp-1?t=signed-imp-1&price=-1", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		req, _ := http.NewRequest("GET", tt.url, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tt.expected {
+			t.Errorf("%s: expected status %d, got %d", tt.url, tt.expected, w.Code)
+		}
+	}
+
+	if strings.Join(service.notices, ",") != "win:1.75,bill:1.75" {
+		t.Errorf("Expected win and billing notices at 1.75, got %v", service.notices)
+	}
+}
+
+func TestWithAuctionPrice(t *testing.T) {
+	tests := map[string]string{
+		"https://ads.example.com/api/v1/track/win/imp-1":          "https://ads.example.com/api/v1/track/win/imp-1?price=${AUCTION_PRICE}",
+		"https://ads.example.com/api/v1/track/win/imp-1?t=signed": "https://ads.example.com/api/v1/track/win/imp-1?t=signed&price=${AUCTION_PRICE}",
+	}
+	for notice, expected := range tests {
+		if got := withAuctionPrice(notice); got != expected {
+			t.Errorf("%s: expected %q, got %q", notice, expected, got)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package placement
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/fall-out-bug/demo-adserver/src/application/placement"
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/gin-gonic/gin"
+)
+
+// Handler handles campaign slot assignment HTTP requests
+type Handler struct {
+	service *placement.Service
+}
+
+// NewHandler creates a new placement handler
+func NewHandler(service *placement.Service) *Handler {
+	return &Handler{service: service}
+}
+
+// AssignmentResponse represents a campaign slot assignment
+type AssignmentResponse struct {
+	CampaignID string    `json:"campaign_id"`
+	SlotID     string    `json:"slot_id"`
+	Rule       string    `json:"rule"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// AssignSlotRequest represents an assign slot request
+type AssignSlotRequest struct {
+	Rule string `json:"rule" binding:"required,oneof=include exclude"`
+}
+
+// ListSlots handles GET /api/v1/campaigns/:id/slots
+func (h *Handler) ListSlots(c *gin.Context) {
+	advertiserID, ok := advertiserScope(c)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+
+	assignments, err := h.service.ListAssignments(c.Request.Context(), advertiserID, c.Param("id"))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	response := make([]AssignmentResponse, 0, len(assignments))
+	for _, a := range assignments {
+		response = append(response, toResponse(a))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"slots": response})
+}
+
+// AssignSlot handles PUT /api/v1/campaigns/:id/slots/:slot_id
+func (h *Handler) AssignSlot(c *gin.Context) {
+	advertiserID, ok := advertiserScope(c)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+
+	var req AssignSlotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	assignment, err := h.service.Assign(c.Request.Context(), advertiserID, c.Param("id"), c.Param("slot_id"), entities.SlotRule(req.Rule))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toResponse(assignment))
+}
+
+// UnassignSlot handles DELETE /api/v1/campaigns/:id/slots/:slot_id
+func (h *Handler) UnassignSlot(c *gin.Context) {
+	advertiserID, ok := advertiserScope(c)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+
+	if err := h.service.Unassign(c.Request.Context(), advertiserID, c.Param("id"), c.Param("slot_id")); err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// advertiserScope returns the advertiser whose campaigns the caller may
+// manage, empty for admins, who manage every campaign. Callers that are
+// neither aren't allowed.
+func advertiserScope(c *gin.Context) (string, bool) {
+	switch c.GetString("user_type") {
+	case "admin":
+		return "", true
+	case "advertiser":
+		advertiserID := c.GetString("user_id")
+		return advertiserID, advertiserID != ""
+	default:
+		return "", false
+	}
+}
+
+// writeError maps service errors to HTTP status codes
+func (h *Handler) writeError(c *gin.Context, err error) {
+	var domainErr *entities.DomainError
+	switch {
+	case errors.Is(err, placement.ErrCampaignNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.As(err, &domainErr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func toResponse(a *entities.CampaignSlot) AssignmentResponse {
+	return AssignmentResponse{
+		CampaignID: a.CampaignID,
+		SlotID:     a.SlotID,
+		Rule:       string(a.Rule),
+		CreatedAt:  a.CreatedAt,
+	}
+}
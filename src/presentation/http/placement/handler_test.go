@@ -0,0 +1,229 @@
+package placement
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fall-out-bug/demo-adserver/src/application/placement"
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/gin-gonic/gin"
+)
+
+type mockCampaignRepo struct {
+	campaigns map[string]*entities.Campaign
+}
+
+func (m *mockCampaignRepo) FindByID(ctx context.Context, id string) (*entities.Campaign, error) {
+	return m.campaigns[id], nil
+}
+
+func (m *mockCampaignRepo) FindActive(ctx context.Context) ([]*entities.Campaign, error) {
+	return nil, nil
+}
+
+func (m *mockCampaignRepo) FindBySlotID(ctx context.Context, slotID string) ([]*entities.Campaign, error) {
+	return nil, nil
+}
+
+func (m *mockCampaignRepo) Create(ctx context.Context, campaign *entities.Campaign) error {
+	return nil
+}
+
+func (m *mockCampaignRepo) Update(ctx context.Context, campaign *entities.Campaign) error {
+	return nil
+}
+
+type mockCampaignSlotRepo struct {
+	assignments map[string]*entities.CampaignSlot
+}
+
+func (m *mockCampaignSlotRepo) FindByCampaignID(ctx context.Context, campaignID string) ([]*entities.CampaignSlot, error) {
+	var result []*entities.CampaignSlot
+	for _, a := range m.assignments {
+		if a.CampaignID == campaignID {
+			result = append(result, a)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockCampaignSlotRepo) FindBySlotID(ctx context.Context, slotID string) ([]*entities.CampaignSlot, error) {
+	return nil, nil
+}
+
+func (m *mockCampaignSlotRepo) Assign(ctx context.Context, assignment *entities.CampaignSlot) error {
+	m.assignments[assignment.CampaignID+":"+assignment.SlotID] = assignment
+	return nil
+}
+
+func (m *mockCampaignSlotRepo) Unassign(ctx context.Context, campaignID, slotID string) error {
+	delete(m.assignments, campaignID+":"+slotID)
+	return nil
+}
+
+type mockInvalidator struct {
+	slots []string
+}
+
+func (m *mockInvalidator) InvalidateSlot(ctx context.Context, slotID string) error {
+	m.slots = append(m.slots, slotID)
+	return nil
+}
+
+func (m *mockInvalidator) InvalidateAll(ctx context.Context) error {
+	m.slots = append(m.slots, entities.AllSlots)
+	return nil
+}
+
+func setupTestRouter() (*gin.Engine, *mockCampaignSlotRepo, *mockInvalidator) {
+	gin.SetMode(gin.TestMode)
+
+	campaignRepo := &mockCampaignRepo{campaigns: map[string]*entities.Campaign{
+		"cmp-1": {ID: "cmp-1", Name: "Test Campaign", AdvertiserID: "adv-1"},
+	}}
+	slotRepo := &mockCampaignSlotRepo{assignments: map[string]*entities.CampaignSlot{}}
+	invalidator := &mockInvalidator{}
+	handler := NewHandler(placement.NewService(campaignRepo, slotRepo, invalidator))
+
+	router := gin.New()
+	// Stands in for the auth middleware: callers are admins unless the test says otherwise
+	router.Use(func(c *gin.Context) {
+		c.Set("user_type", "admin")
+		if advertiserID := c.GetHeader("X-Test-Advertiser"); advertiserID != "" {
+			c.Set("user_type", "advertiser")
+			c.Set("user_id", advertiserID)
+		}
+		c.Next()
+	})
+	router.GET("/api/v1/campaigns/:id/slots", handler.ListSlots)
+	router.PUT("/api/v1/campaigns/:id/slots/:slot_id", handler.AssignSlot)
+	router.DELETE("/api/v1/campaigns/:id/slots/:slot_id", handler.UnassignSlot)
+
+	return router, slotRepo, invalidator
+}
+
+func TestAssignSlot_Include(t *testing.T) {
+	router, slotRepo, invalidator := setupTestRouter()
+
+	body, _ := json.Marshal(map[string]string{"rule": "include"})
+	req, _ := http.NewRequest("PUT", "/api/v1/campaigns/cmp-1/slots/demo-leaderboard", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if a := slotRepo.assignments["cmp-1:demo-leaderboard"]; a == nil || a.Rule != entities.SlotRuleInclude {
+		t.Errorf("Expected include assignment to be stored, got %v", a)
+	}
+	if len(invalidator.slots) != 1 || invalidator.slots[0] != "demo-leaderboard" {
+		t.Errorf("Expected slot cache to be invalidated, got %v", invalidator.slots)
+	}
+}
+
+func TestAssignSlot_InvalidRule(t *testing.T) {
+	router, _, _ := setupTestRouter()
+
+	body, _ := json.Marshal(map[string]string{"rule": "sometimes"})
+	req, _ := http.NewRequest("PUT", "/api/v1/campaigns/cmp-1/slots/demo-leaderboard", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestAssignSlot_ExcludeAllSlots(t *testing.T) {
+	router, _, _ := setupTestRouter()
+
+	body, _ := json.Marshal(map[string]string{"rule": "exclude"})
+	req, _ := http.NewRequest("PUT", "/api/v1/campaigns/cmp-1/slots/*", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestListSlots_UnknownCampaign(t *testing.T) {
+	router, _, _ := setupTestRouter()
+
+	req, _ := http.NewRequest("GET", "/api/v1/campaigns/cmp-404/slots", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+}
+
+func TestUnassignSlot(t *testing.T) {
+	router, slotRepo, invalidator := setupTestRouter()
+	slotRepo.assignments["cmp-1:demo-leaderboard"] = &entities.CampaignSlot{
+		CampaignID: "cmp-1", SlotID: "demo-leaderboard", Rule: entities.SlotRuleInclude,
+	}
+
+	req, _ := http.NewRequest("DELETE", "/api/v1/campaigns/cmp-1/slots/demo-leaderboard", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected status 204, got %d", w.Code)
+	}
+	if len(slotRepo.assignments) != 0 {
+		t.Errorf("Expected assignment to be removed")
+	}
+	if len(invalidator.slots) != 1 {
+		t.Errorf("Expected slot cache to be invalidated, got %v", invalidator.slots)
+	}
+}
+
+func TestAssignSlot_OtherAdvertiser(t *testing.T) {
+	router, slotRepo, _ := setupTestRouter()
+	slotRepo.assignments["cmp-1:demo-sidebar"] = &entities.CampaignSlot{
+		CampaignID: "cmp-1", SlotID: "demo-sidebar", Rule: entities.SlotRuleInclude,
+	}
+
+	body, _ := json.Marshal(map[string]string{"rule": "include"})
+	for _, tt := range []struct {
+		method, url string
+		body        []byte
+	}{
+		{"GET", "/api/v1/campaigns/cmp-1/slots", nil},
+		{"PUT", "/api/v1/campaigns/cmp-1/slots/demo-leaderboard", body},
+		{"DELETE", "/api/v1/campaigns/cmp-1/slots/demo-sidebar", nil},
+	} {
+		req, _ := http.NewRequest(tt.method, tt.url, bytes.NewBuffer(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Test-Advertiser", "adv-2")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s %s: expected status 404 for another advertiser's campaign, got %d", tt.method, tt.url, w.Code)
+		}
+	}
+	if len(slotRepo.assignments) != 1 || slotRepo.assignments["cmp-1:demo-leaderboard"] != nil {
+		t.Errorf("Expected the assignments unchanged, got %v", slotRepo.assignments)
+	}
+
+	// The campaign's own advertiser may book it
+	req, _ := http.NewRequest("PUT", "/api/v1/campaigns/cmp-1/slots/demo-leaderboard", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Test-Advertiser", "adv-1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 for the owning advertiser, got %d", w.Code)
+	}
+}
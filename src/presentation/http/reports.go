@@ -0,0 +1,101 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/fall-out-bug/demo-adserver/src/application/fraud"
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultReportPeriod is the period of reports requested without one
+const defaultReportPeriod = 7 * 24 * time.Hour
+
+// InvalidTrafficReportService defines the interface for invalid traffic reports
+type InvalidTrafficReportService interface {
+	Report(ctx context.Context, since, until time.Time, campaignID string) ([]*entities.InvalidTrafficReport, error)
+}
+
+// invalidTrafficRow is a campaign's row of the invalid traffic report
+type invalidTrafficRow struct {
+	*entities.InvalidTrafficReport
+	InvalidRate float64 `json:"invalid_rate"`
+}
+
+// InvalidTrafficHandler serves invalid traffic reports
+type InvalidTrafficHandler struct {
+	service InvalidTrafficReportService
+}
+
+// NewInvalidTrafficHandler creates a new invalid traffic report handler
+func NewInvalidTrafficHandler(service InvalidTrafficReportService) *InvalidTrafficHandler {
+	return &InvalidTrafficHandler{service: service}
+}
+
+// Handle handles GET /api/v1/reports/invalid-traffic. The period is given by
+// since and until as RFC 3339 times or dates, until a date including the
+// whole day, and defaults to the last 7 days; campaign_id narrows the report
+// to one campaign.
+func (h *InvalidTrafficHandler) Handle(c *gin.Context) {
+	until := time.Now().UTC()
+	if value := c.Query("until"); value != "" {
+		t, err := parseReportTime(value, true)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid until: " + err.Error()})
+			return
+		}
+		until = t
+	}
+
+	since := until.Add(-defaultReportPeriod)
+	if value := c.Query("since"); value != "" {
+		t, err := parseReportTime(value, false)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid since: " + err.Error()})
+			return
+		}
+		since = t
+	}
+
+	reports, err := h.service.Report(c.Request.Context(), since, until, c.Query("campaign_id"))
+	if errors.Is(err, fraud.ErrInvalidPeriod) {
+		maxDays := int(fraud.MaxReportPeriod / (24 * time.Hour))
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("period must be positive and at most %d days", maxDays)})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build report"})
+		return
+	}
+
+	rows := make([]invalidTrafficRow, len(reports))
+	for i, report := range reports {
+		rows[i] = invalidTrafficRow{InvalidTrafficReport: report, InvalidRate: report.InvalidRate()}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"since":     since,
+		"until":     until,
+		"campaigns": rows,
+	})
+}
+
+// parseReportTime parses an RFC 3339 time or a date; endOfDay makes a date
+// mean the end of that day
+func parseReportTime(value string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, errors.New("expected an RFC 3339 time or YYYY-MM-DD")
+	}
+	if endOfDay {
+		t = t.Add(24 * time.Hour)
+	}
+	return t, nil
+}
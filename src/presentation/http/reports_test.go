@@ -0,0 +1,83 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fall-out-bug/demo-adserver/src/application/fraud"
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/gin-gonic/gin"
+)
+
+// stubReportService records the requested period
+type stubReportService struct {
+	since, until time.Time
+	campaignID   string
+}
+
+func (s *stubReportService) Report(ctx context.Context, since, until time.Time, campaignID string) ([]*entities.InvalidTrafficReport, error) {
+	s.since, s.until, s.campaignID = since, until, campaignID
+	if !until.After(since) {
+		return nil, fraud.ErrInvalidPeriod
+	}
+	return []*entities.InvalidTrafficReport{{
+		CampaignID:         "cmp-1",
+		Impressions:        90,
+		InvalidImpressions: 15,
+		Clicks:             10,
+		InvalidClicks:      5,
+		Reasons:            map[string]int64{entities.FraudBotUserAgent: 20},
+	}}, nil
+}
+
+func TestInvalidTrafficHandler_Handle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	service := &stubReportService{}
+	router := gin.New()
+	router.GET("/api/v1/reports/invalid-traffic", NewInvalidTrafficHandler(service).Handle)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/reports/invalid-traffic?since=2026-10-01&until=2026-10-07&campaign_id=cmp-1", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// A date until includes the whole day
+	if !service.since.Equal(time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)) || !service.until.Equal(time.Date(2026, 10, 8, 0, 0, 0, 0, time.UTC)) || service.campaignID != "cmp-1" {
+		t.Errorf("Unexpected report request %v - %v for %q", service.since, service.until, service.campaignID)
+	}
+
+	var body struct {
+		Campaigns []struct {
+			CampaignID    string           `json:"campaign_id"`
+			InvalidClicks int64            `json:"invalid_clicks"`
+			InvalidRate   float64          `json:"invalid_rate"`
+			Reasons       map[string]int64 `json:"reasons"`
+		} `json:"campaigns"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Expected JSON, got %s", w.Body.String())
+	}
+	if len(body.Campaigns) != 1 || body.Campaigns[0].InvalidRate != 0.2 || body.Campaigns[0].InvalidClicks != 5 || body.Campaigns[0].Reasons[entities.FraudBotUserAgent] != 20 {
+		t.Errorf("Unexpected report %s", w.Body.String())
+	}
+
+	// The default period is the last 7 days
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/reports/invalid-traffic", nil))
+	if w.Code != http.StatusOK || service.until.Sub(service.since) != 7*24*time.Hour {
+		t.Errorf("Expected a 7 day report, got %d for %v - %v", w.Code, service.since, service.until)
+	}
+
+	for _, query := range []string{"?since=yesterday", "?since=2026-10-07&until=2026-10-01"} {
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/reports/invalid-traffic"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", query, w.Code)
+		}
+	}
+}
@@ -2,70 +2,158 @@ package http
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/fall-out-bug/demo-adserver/src/application/audience"
 	"github.com/fall-out-bug/demo-adserver/src/application/auth"
 	"github.com/fall-out-bug/demo-adserver/src/application/demo"
+	"github.com/fall-out-bug/demo-adserver/src/application/placement"
+	audienceHandler "github.com/fall-out-bug/demo-adserver/src/presentation/http/audience"
 	httpAuth "github.com/fall-out-bug/demo-adserver/src/presentation/http/auth"
 	demoHandler "github.com/fall-out-bug/demo-adserver/src/presentation/http/demo"
 	"github.com/fall-out-bug/demo-adserver/src/presentation/http/middleware"
+	placementHandler "github.com/fall-out-bug/demo-adserver/src/presentation/http/placement"
 )
 
+// Handlers holds the services and settings SetupRoutes wires into the handlers
+type Handlers struct {
+	DeliveryService       DeliveryService
+	ImpressionService     ImpressionService
+	ClickService          ClickService
+	ConversionService     ConversionService
+	PublisherService      *auth.PublisherService
+	AdvertiserService     *auth.AdvertiserService
+	DemoService           *demo.Service
+	PlacementService      *placement.Service
+	AudienceService       *audience.Service
+	UserIdentifier        UserIdentifier
+	ClientDetector        ClientDetector
+	GeoResolver           GeoResolver
+	TrustedCountryHeader  string
+	KeywordsFromURL       bool
+	VideoEventService     VideoEventService
+	AuctionService        AuctionService
+	TrackingVerifier      TrackingVerifier
+	ImpressionPipeline    ImpressionPipeline
+	LedgerStats           LedgerStatsProvider
+	InvalidTrafficService InvalidTrafficReportService
+	PublicURL             string
+	JWTAuthenticator      middleware.JWTAuthenticator
+}
+
 // SetupRoutes configures all HTTP routes
-func SetupRoutes(
-	router *gin.Engine,
-	deliveryService DeliveryService,
-	impressionService ImpressionService,
-	clickService ClickService,
-	publisherService *auth.PublisherService,
-	advertiserService *auth.AdvertiserService,
-	demoService *demo.Service,
-	jwtAuthenticator middleware.JWTAuthenticator,
-) {
+func SetupRoutes(router *gin.Engine, h Handlers) {
 	// Health check
 	healthHandler := NewHealthHandler()
 	router.GET("/health", healthHandler.Handle)
+	router.GET("/metrics", NewMetricsHandler(h.ImpressionPipeline).WithLedgerStats(h.LedgerStats).Handle)
 
 	// Delivery API
-	deliveryHandler := NewDeliveryHandler(deliveryService)
+	deliveryHandler := NewDeliveryHandler(h.DeliveryService).
+		WithUserIdentifier(h.UserIdentifier).
+		WithClientDetector(h.ClientDetector).
+		WithGeoResolver(h.GeoResolver).
+		WithTrustedCountryHeader(h.TrustedCountryHeader).
+		WithPathKeywords(h.KeywordsFromURL).
+		WithPublicURL(h.PublicURL)
 	router.GET("/api/v1/delivery/:slot_id", deliveryHandler.Handle)
+	router.POST("/api/v1/delivery/batch", deliveryHandler.HandleBatch)
+	router.GET("/api/v1/vast/:slot_id", deliveryHandler.HandleVAST)
+
+	// OpenRTB 2.6 bidding for exchanges, with win (nurl) and billing (burl) notices
+	router.POST("/api/v1/openrtb/bid", deliveryHandler.HandleOpenRTB)
+	auctionHandler := NewAuctionNoticeHandler(h.AuctionService).WithVerifier(h.TrackingVerifier)
+	router.GET("/api/v1/track/win/:impression_id", auctionHandler.HandleWin)
+	router.GET("/api/v1/track/bill/:impression_id", auctionHandler.HandleBill)
+
+	// Ad tags (/serve/:slot_id.html and /serve/:slot_id.js) for pages without the SDK
+	router.GET("/serve/:tag", deliveryHandler.HandleTag)
 
-	// Tracking APIs
-	impressionHandler := NewImpressionHandler(impressionService)
+	// Tracking APIs; impressions are stored in batches by the pipeline
+	impressionHandler := NewImpressionHandler(h.ImpressionService).
+		WithVerifier(h.TrackingVerifier).
+		WithQueue(h.ImpressionPipeline)
 	router.POST("/api/v1/track/impression", impressionHandler.Handle)
+	router.GET("/api/v1/track/impression", impressionHandler.HandlePixel)
 
-	clickHandler := NewClickHandler(clickService)
+	clickHandler := NewClickHandler(h.ClickService).WithVerifier(h.TrackingVerifier)
 	router.GET("/api/v1/track/click/:impression_id", clickHandler.Handle)
 
+	videoEventHandler := NewVideoEventHandler(h.ImpressionService, h.VideoEventService).
+		WithVerifier(h.TrackingVerifier).
+		WithQueue(h.ImpressionPipeline)
+	router.GET("/api/v1/track/video/:impression_id", videoEventHandler.Handle)
+
+	// Conversion postbacks are server-to-server calls of the campaign's advertiser
+	conversionHandler := NewConversionHandler(h.ConversionService)
+	conversionAuth := middleware.NewAuthMiddleware(h.JWTAuthenticator, []string{"advertiser"})
+	router.GET("/api/v1/track/conversion/:impression_id", conversionAuth.RequireAuth(), conversionHandler.Handle)
+
+	// Audience segment pixel (placed on advertiser sites)
+	pixelHandler := NewPixelHandler(h.AudienceService).WithUserIdentifier(h.UserIdentifier)
+	router.GET("/api/v1/pixel/:segment_id", pixelHandler.Handle)
+
 	// Publisher API
-	publisherHandler := httpAuth.NewPublisherHandler(publisherService, nil)
+	publisherHandler := httpAuth.NewPublisherHandler(h.PublisherService, nil)
 	router.POST("/api/v1/publishers/register", publisherHandler.Register)
 	router.POST("/api/v1/publishers/login", publisherHandler.Login)
 
-	publisherAuth := middleware.NewAuthMiddleware(jwtAuthenticator, []string{"publisher"})
+	publisherAuth := middleware.NewAuthMiddleware(h.JWTAuthenticator, []string{"publisher"})
 	publisherGroup := router.Group("/api/v1/publishers")
 	publisherGroup.Use(publisherAuth.RequireAuth())
 	{
 		publisherGroup.GET("/me", publisherHandler.GetMe)
+		publisherGroup.POST("/tags", NewTagHandler(h.PublicURL).Generate)
 	}
 
 	// Advertiser API
-	advertiserHandler := httpAuth.NewAdvertiserHandler(advertiserService, nil)
+	advertiserHandler := httpAuth.NewAdvertiserHandler(h.AdvertiserService, nil)
 	router.POST("/api/v1/advertisers/register", advertiserHandler.Register)
 	router.POST("/api/v1/advertisers/login", advertiserHandler.Login)
 
-	advertiserAuth := middleware.NewAuthMiddleware(jwtAuthenticator, []string{"advertiser"})
+	advertiserAuth := middleware.NewAuthMiddleware(h.JWTAuthenticator, []string{"advertiser"})
 	advertiserGroup := router.Group("/api/v1/advertisers")
 	advertiserGroup.Use(advertiserAuth.RequireAuth())
 	{
 		advertiserGroup.GET("/me", advertiserHandler.GetMe)
 	}
 
+	// Campaign placement API (slot bookings - JWT protected)
+	placementH := placementHandler.NewHandler(h.PlacementService)
+	campaignAuth := middleware.NewAuthMiddleware(h.JWTAuthenticator, []string{"admin", "advertiser"})
+	campaignGroup := router.Group("/api/v1/campaigns")
+	campaignGroup.Use(campaignAuth.RequireAuth())
+	{
+		campaignGroup.GET("/:id/slots", placementH.ListSlots)
+		campaignGroup.PUT("/:id/slots/:slot_id", placementH.AssignSlot)
+		campaignGroup.DELETE("/:id/slots/:slot_id", placementH.UnassignSlot)
+	}
+
+	// Audience segment API (advertisers manage their own segments)
+	audienceH := audienceHandler.NewHandler(h.AudienceService)
+	segmentAuth := middleware.NewAuthMiddleware(h.JWTAuthenticator, []string{"advertiser"})
+	segmentGroup := router.Group("/api/v1/segments")
+	segmentGroup.Use(segmentAuth.RequireAuth())
+	{
+		segmentGroup.POST("", audienceH.CreateSegment)
+		segmentGroup.GET("", audienceH.ListSegments)
+		segmentGroup.GET("/:id", audienceH.GetSegment)
+		segmentGroup.DELETE("/:id", audienceH.DeleteSegment)
+	}
+
+	// Reports API (admin only)
+	reportAuth := middleware.NewAuthMiddleware(h.JWTAuthenticator, []string{"admin"})
+	reportGroup := router.Group("/api/v1/reports")
+	reportGroup.Use(reportAuth.RequireAuth())
+	{
+		reportGroup.GET("/invalid-traffic", NewInvalidTrafficHandler(h.InvalidTrafficService).Handle)
+	}
+
 	// Demo API (public endpoints)
-	demoH := demoHandler.NewHandler(demoService)
+	demoH := demoHandler.NewHandler(h.DemoService)
 	router.GET("/api/v1/demo/slots", demoH.ListSlots)
 	router.GET("/api/v1/demo/slots/:slot_id/banner", demoH.GetSlotBanner)
 
 	// Demo API (admin endpoints - JWT protected)
-	adminAuth := middleware.NewAuthMiddleware(jwtAuthenticator, []string{"admin", "publisher", "advertiser"})
+	adminAuth := middleware.NewAuthMiddleware(h.JWTAuthenticator, []string{"admin", "publisher", "advertiser"})
 	demoAdminGroup := router.Group("/api/v1/demo")
 	demoAdminGroup.Use(adminAuth.RequireAuth())
 	{
@@ -0,0 +1,237 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"html/template"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/fall-out-bug/demo-adserver/src/application/delivery"
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/gin-gonic/gin"
+)
+
+// tagDocument is the HTML document of iframe tags. Clicks anywhere on a link
+// or image go through the click tracker; the pixel records the impression.
+var tagDocument = template.Must(template.New("tag").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<base target="_blank">
+<style>html,body{margin:0;padding:0;overflow:hidden;background:transparent}</style>
+</head>
+<body>
+{{- if .Markup}}
+<div style="width:{{.Width}};height:{{.Height}}">{{.Markup}}</div>
+{{- end}}
+{{- if .Impression}}
+<img src="{{.Impression}}" width="1" height="1" alt="" style="position:absolute;left:-9999px">
+{{- end}}
+{{- if .Click}}
+<script>
+document.addEventListener("click", function (e) {
+  if (!e.target.closest || !e.target.closest("a, img")) return;
+  e.preventDefault();
+  window.open({{.Click}}, "_blank");
+}, true);
+</script>
+{{- end}}
+</body>
+</html>
+`))
+
+// tagView is the data of a tag document
+type tagView struct {
+	Markup     template.HTML // Creative markup, trusted like in SDK rendering
+	Width      string
+	Height     string
+	Impression string
+	Click      string
+}
+
+// HandleTag handles GET /serve/:slot_id.html and GET /serve/:slot_id.js, the
+// ad tags of publishers without the web SDK. The .html form is a complete
+// document for an iframe; the .js form writes an iframe holding the same
+// document next to its script element. Slot sizes are read like in Handle.
+func (h *DeliveryHandler) HandleTag(c *gin.Context) {
+	tag := c.Param("tag")
+	ext := path.Ext(tag)
+	slotID := strings.TrimSuffix(tag, ext)
+	if slotID == "" || (ext != ".html" && ext != ".js") {
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	req := h.newRequest(c, slotID, pageURL(c), nil)
+	req.Sizes = slotSizes(c)
+
+	response, err := h.service.DeliverBanner(c.Request.Context(), slotID, req)
+	if err != nil {
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	view := h.tagView(c, response)
+	var doc bytes.Buffer
+	if err := tagDocument.Execute(&doc, view); err != nil {
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	if ext == ".html" {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", doc.Bytes())
+		return
+	}
+	if view.Markup == "" {
+		// Nothing to render; leave the page untouched
+		c.Data(http.StatusOK, "application/javascript; charset=utf-8", nil)
+		return
+	}
+	c.Data(http.StatusOK, "application/javascript; charset=utf-8", []byte(tagScript(doc.String(), view.Width, view.Height)))
+}
+
+// tagView prepares a delivered creative for the tag document. Tracking URLs
+// are absolute because the document of JS tags has the publisher's origin.
+func (h *DeliveryHandler) tagView(c *gin.Context, response *delivery.GetBannerResponse) tagView {
+	var view tagView
+	if response.Creative == nil {
+		return view
+	}
+
+	view.Markup = template.HTML(response.Creative.HTML)
+	view.Width = cssLength(response.Creative.Width)
+	view.Height = cssLength(response.Creative.Height)
+
+	if tracking := response.Tracking; tracking != nil && tracking.ImpressionID != "" {
+		view.Impression = absoluteURL(c, h.publicURL, tracking.Impression)
+		view.Click = absoluteURL(c, h.publicURL, tracking.Click)
+	}
+	return view
+}
+
+// tagScript is the JavaScript of JS tags: it inserts an iframe holding the
+// document after the script element that loaded it
+func tagScript(doc, width, height string) string {
+	quoted, _ := json.Marshal(doc) // Escapes <, > and & as well
+	return fmt.Sprintf(`(function () {
+  var script = document.currentScript;
+  if (!script || !script.parentNode) return;
+  var frame = document.createElement("iframe");
+  frame.setAttribute("frameborder", "0");
+  frame.setAttribute("scrolling", "no");
+  frame.setAttribute("title", "Advertisement");
+  frame.style.border = "0";
+  frame.style.width = %q;
+  frame.style.height = %q;
+  frame.srcdoc = %s;
+  script.parentNode.insertBefore(frame, script.nextSibling);
+})();
+`, width, height, quoted)
+}
+
+// cssLength is a creative dimension in CSS; 0 fills the slot
+func cssLength(pixels int) string {
+	if pixels <= 0 {
+		return "100%"
+	}
+	return strconv.Itoa(pixels) + "px"
+}
+
+// TagHandler generates the ad tags publishers paste into their pages
+type TagHandler struct {
+	publicURL string
+}
+
+// NewTagHandler creates a new tag generator. publicURL is the external base
+// URL of the ad server; without it tags point at the host of the request.
+func NewTagHandler(publicURL string) *TagHandler {
+	return &TagHandler{publicURL: strings.TrimRight(publicURL, "/")}
+}
+
+// TagRequest describes the slot to generate tags for. Sizes ("300x250")
+// take precedence over width and height; leave both 0 for a responsive slot.
+type TagRequest struct {
+	SlotID   string   `json:"slot_id" binding:"required"`
+	Width    int      `json:"width" binding:"min=0"`
+	Height   int      `json:"height" binding:"min=0"`
+	Sizes    []string `json:"sizes"`
+	Keywords []string `json:"keywords"`
+}
+
+// TagResponse holds the tags of a slot
+type TagResponse struct {
+	SlotID    string `json:"slot_id"`
+	IframeURL string `json:"iframe_url"`
+	ScriptURL string `json:"script_url"`
+	Iframe    string `json:"iframe"` // <iframe> tag for the page
+	Script    string `json:"script"` // <script> tag for the page
+}
+
+// Generate handles POST /api/v1/publishers/tags
+func (h *TagHandler) Generate(c *gin.Context) {
+	var req TagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := url.Values{}
+	size := entities.Size{Width: req.Width, Height: req.Height}
+	if len(req.Sizes) > 0 {
+		sizes := make([]string, 0, len(req.Sizes))
+		for i, s := range req.Sizes {
+			parsed, err := entities.ParseSize(s)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid size %q", s)})
+				return
+			}
+			if i == 0 {
+				size = parsed // The iframe takes the first size
+			}
+			sizes = append(sizes, parsed.String())
+		}
+		query.Set("sizes", strings.Join(sizes, ","))
+	} else {
+		if req.Width > 0 {
+			query.Set("width", strconv.Itoa(req.Width))
+		}
+		if req.Height > 0 {
+			query.Set("height", strconv.Itoa(req.Height))
+		}
+	}
+	if keywords := entities.NormalizeKeywords(req.Keywords); len(keywords) > 0 {
+		query.Set("keywords", strings.Join(keywords, ","))
+	}
+
+	base := absoluteURL(c, h.publicURL, "/serve/"+url.PathEscape(req.SlotID))
+	suffix := ""
+	if len(query) > 0 {
+		suffix = "?" + query.Encode()
+	}
+	iframeURL := base + ".html" + suffix
+	scriptURL := base + ".js" + suffix
+
+	c.JSON(http.StatusOK, TagResponse{
+		SlotID:    req.SlotID,
+		IframeURL: iframeURL,
+		ScriptURL: scriptURL,
+		Iframe:    iframeTag(iframeURL, size),
+		Script:    fmt.Sprintf(`<script async src="%s"></script>`, html.EscapeString(scriptURL)),
+	})
+}
+
+// iframeTag is the <iframe> element of a slot; a responsive dimension fills the container
+func iframeTag(src string, size entities.Size) string {
+	return fmt.Sprintf(
+		`<iframe src="%s" style="border:0;width:%s;height:%s" frameborder="0" scrolling="no" title="Advertisement"></iframe>`,
+		html.EscapeString(src), cssLength(size.Width), cssLength(size.Height),
+	)
+}
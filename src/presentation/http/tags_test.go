@@ -0,0 +1,138 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fall-out-bug/demo-adserver/src/application/delivery"
+	"github.com/gin-gonic/gin"
+)
+
+func TestDeliveryHandler_HandleTag(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	service := &videoDeliveryService{response: &delivery.GetBannerResponse{
+		Creative: &delivery.Creative{HTML: `<a href="https://shop.example.com"><img src="https://cdn.example.com/ad.png"></a>`, Width: 300, Height: 250},
+		Tracking: &delivery.TrackingInfo{
+			ImpressionID: "imp-1",
+			Impression:   "/api/v1/track/impression?id=imp-1&banner_id=ban-1&campaign_id=cmp-1&slot_id=sidebar",
+			Click:        "/api/v1/track/click/imp-1",
+		},
+	}}
+	router := gin.New()
+	router.GET("/serve/:tag", NewDeliveryHandler(service).WithPublicURL("https://ads.example.com").HandleTag)
+
+	req, _ := http.NewRequest("GET", "/serve/sidebar.html?width=300&height=250", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("Expected an HTML document, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	if service.req.SlotID != "sidebar" || len(service.req.Sizes) != 1 || service.req.Sizes[0].String() != "300x250" {
+		t.Errorf("Expected a 300x250 request for sidebar, got %+v", service.req)
+	}
+	doc := w.Body.String()
+	for _, want := range []string{
+		`<img src="https://cdn.example.com/ad.png">`,
+		`<div style="width:300px;height:250px">`,
+		`<img src="https://ads.example.com/api/v1/track/impression?id=imp-1&amp;banner_id=ban-1&amp;campaign_id=cmp-1&amp;slot_id=sidebar"`,
+		`window.open("https://ads.example.com/api/v1/track/click/imp-1", "_blank")`,
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("Expected document to contain %s, got %s", want, doc)
+		}
+	}
+
+	req, _ = http.NewRequest("GET", "/serve/sidebar.js", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	script := w.Body.String()
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "application/javascript") {
+		t.Errorf("Expected JavaScript, got %s", w.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(script, "document.currentScript") || !strings.Contains(script, `frame.style.width = "300px"`) {
+		t.Errorf("Expected a script inserting a 300px iframe, got %s", script)
+	}
+	if !strings.Contains(script, `frame.srcdoc = "\u003c!DOCTYPE html\u003e`) || strings.Contains(script, "</script>") {
+		t.Errorf("Expected the document as an escaped string, got %s", script)
+	}
+
+	req, _ = http.NewRequest("GET", "/serve/sidebar.txt", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown tag type, got %d", w.Code)
+	}
+}
+
+func TestDeliveryHandler_HandleTag_NoAd(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	service := &videoDeliveryService{response: &delivery.GetBannerResponse{Fallback: &delivery.FallbackInfo{Enabled: true}}}
+	router := gin.New()
+	router.GET("/serve/:tag", NewDeliveryHandler(service).HandleTag)
+
+	req, _ := http.NewRequest("GET", "/serve/sidebar.js", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || w.Body.Len() != 0 {
+		t.Errorf("Expected an empty script, got %d %s", w.Code, w.Body.String())
+	}
+
+	req, _ = http.NewRequest("GET", "/serve/sidebar.html", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), "<img") || strings.Contains(w.Body.String(), "<script") {
+		t.Errorf("Expected an empty document, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestTagHandler_Generate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.POST("/api/v1/publishers/tags", NewTagHandler("https://ads.example.com/").Generate)
+
+	body, _ := json.Marshal(TagRequest{SlotID: "sidebar", Sizes: []string{"300x250", "336X280"}, Keywords: []string{"Travel"}})
+	req, _ := http.NewRequest("POST", "/api/v1/publishers/tags", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response TagResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	if response.IframeURL != "https://ads.example.com/serve/sidebar.html?keywords=travel&sizes=300x250%2C336x280" {
+		t.Errorf("Unexpected iframe URL %s", response.IframeURL)
+	}
+	if response.ScriptURL != "https://ads.example.com/serve/sidebar.js?keywords=travel&sizes=300x250%2C336x280" {
+		t.Errorf("Unexpected script URL %s", response.ScriptURL)
+	}
+	if !strings.Contains(response.Iframe, `src="https://ads.example.com/serve/sidebar.html?keywords=travel&amp;sizes=`) || !strings.Contains(response.Iframe, "width:300px;height:250px") {
+		t.Errorf("Unexpected iframe tag %s", response.Iframe)
+	}
+	if response.Script != `<script async src="https://ads.example.com/serve/sidebar.js?keywords=travel&amp;sizes=300x250%2C336x280"></script>` {
+		t.Errorf("Unexpected script tag %s", response.Script)
+	}
+
+	body, _ = json.Marshal(TagRequest{SlotID: "sidebar", Sizes: []string{"wide"}})
+	req, _ = http.NewRequest("POST", "/api/v1/publishers/tags", bytes.NewReader(body))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid size, got %d", w.Code)
+	}
+}
@@ -0,0 +1,190 @@
+package http
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/fall-out-bug/demo-adserver/src/application/delivery"
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/gin-gonic/gin"
+)
+
+// VASTVersion is the VAST version of video responses
+const VASTVersion = "4.2"
+
+// VAST is a VAST 4 document with at most one inline linear ad
+type VAST struct {
+	XMLName xml.Name `xml:"VAST"`
+	Version string   `xml:"version,attr"`
+	XMLNS   string   `xml:"xmlns,attr"`
+	Ads     []VASTAd `xml:"Ad"`
+}
+
+// VASTAd is an ad of a VAST response
+type VASTAd struct {
+	ID     string     `xml:"id,attr"`
+	InLine VASTInLine `xml:"InLine"`
+}
+
+// VASTInLine is an ad served directly rather than through a wrapper
+type VASTInLine struct {
+	AdSystem    string         `xml:"AdSystem"`
+	AdTitle     string         `xml:"AdTitle"`
+	AdServingID string         `xml:"AdServingId"`
+	Impressions []VASTURL      `xml:"Impression"`
+	Creatives   []VASTCreative `xml:"Creatives>Creative"`
+}
+
+// VASTURL is a URL wrapped in CDATA
+type VASTURL struct {
+	ID  string `xml:"id,attr,omitempty"`
+	URL string `xml:",cdata"`
+}
+
+// VASTCreative is the creative of an inline ad
+type VASTCreative struct {
+	UniversalAdID VASTUniversalAdID `xml:"UniversalAdId"`
+	Linear        VASTLinear        `xml:"Linear"`
+}
+
+// VASTUniversalAdID identifies the creative across systems
+type VASTUniversalAdID struct {
+	IDRegistry string `xml:"idRegistry,attr"`
+	Value      string `xml:",chardata"`
+}
+
+// VASTLinear is a linear video creative
+type VASTLinear struct {
+	SkipOffset     string          `xml:"skipoffset,attr,omitempty"`
+	Duration       string          `xml:"Duration"`
+	TrackingEvents []VASTTracking  `xml:"TrackingEvents>Tracking"`
+	ClickThrough   *VASTURL        `xml:"VideoClicks>ClickThrough,omitempty"`
+	MediaFiles     []VASTMediaFile `xml:"MediaFiles>MediaFile"`
+}
+
+// VASTTracking is a playback event tracker
+type VASTTracking struct {
+	Event string `xml:"event,attr"`
+	URL   string `xml:",cdata"`
+}
+
+// VASTMediaFile is one rendition of the video
+type VASTMediaFile struct {
+	Delivery string `xml:"delivery,attr"`
+	Type     string `xml:"type,attr"`
+	Width    int    `xml:"width,attr"`
+	Height   int    `xml:"height,attr"`
+	Bitrate  int    `xml:"bitrate,attr,omitempty"`
+	URL      string `xml:",cdata"`
+}
+
+// WithPublicURL sets the external base URL (e.g. https://ads.example.com) of
+// the tracking URLs in VAST responses and ad tags. Without it they are built
+// from the request.
+func (h *DeliveryHandler) WithPublicURL(publicURL string) *DeliveryHandler {
+	h.publicURL = strings.TrimRight(publicURL, "/")
+	return h
+}
+
+// HandleVAST handles GET /api/v1/vast/:slot_id. It returns a VAST 4 document
+// with the slot's video ad, or one without ads when no video campaign matches.
+func (h *DeliveryHandler) HandleVAST(c *gin.Context) {
+	slotID := c.Param("slot_id")
+	req := h.newRequest(c, slotID, pageURL(c), nil)
+	req.Format = delivery.FormatVideo
+
+	doc := &VAST{Version: VASTVersion, XMLNS: "http://www.iab.com/VAST"}
+
+	response, err := h.service.DeliverBanner(c.Request.Context(), slotID, req)
+	if err != nil {
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	if response.Creative != nil && response.Creative.Video != nil && response.Tracking != nil {
+		doc.Ads = []VASTAd{h.vastAd(c, response)}
+	}
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "application/xml; charset=utf-8", append([]byte(xml.Header), out...))
+}
+
+// vastAd describes a delivered video creative as an inline VAST ad
+func (h *DeliveryHandler) vastAd(c *gin.Context, response *delivery.GetBannerResponse) VASTAd {
+	video := response.Creative.Video
+	tracking := response.Tracking
+	adID := tracking.ImpressionID
+
+	linear := VASTLinear{
+		Duration: vastDuration(video.Duration),
+	}
+	if video.SkipOffset > 0 {
+		linear.SkipOffset = vastDuration(video.SkipOffset)
+	}
+	for _, event := range entities.VideoEventTypes {
+		if url := tracking.Video[string(event)]; url != "" {
+			linear.TrackingEvents = append(linear.TrackingEvents, VASTTracking{Event: string(event), URL: absoluteURL(c, h.publicURL, url)})
+		}
+	}
+	if tracking.Click != "" {
+		linear.ClickThrough = &VASTURL{URL: absoluteURL(c, h.publicURL, tracking.Click)}
+	}
+	for _, m := range video.MediaFiles {
+		linear.MediaFiles = append(linear.MediaFiles, VASTMediaFile{
+			Delivery: "progressive",
+			Type:     m.Type,
+			Width:    m.Width,
+			Height:   m.Height,
+			Bitrate:  m.Bitrate,
+			URL:      m.URL,
+		})
+	}
+
+	ad := VASTAd{
+		ID: adID,
+		InLine: VASTInLine{
+			AdSystem:    "demo-adserver",
+			AdTitle:     "Video ad",
+			AdServingID: adID,
+			Creatives: []VASTCreative{{
+				UniversalAdID: VASTUniversalAdID{IDRegistry: "unknown", Value: "unknown"},
+				Linear:        linear,
+			}},
+		},
+	}
+	// Bids have no impression tracker: the billing notice counts them
+	if tracking.Impression != "" {
+		ad.InLine.Impressions = []VASTURL{{ID: adID, URL: absoluteURL(c, h.publicURL, tracking.Impression)}}
+	}
+	return ad
+}
+
+// absoluteURL turns a server-relative path into an absolute URL under the
+// public base URL, or the request's host without one. Players and ad tags
+// fetch trackers from the publisher's page.
+func absoluteURL(c *gin.Context, publicURL, path string) string {
+	if !strings.HasPrefix(path, "/") {
+		return path
+	}
+	if publicURL != "" {
+		return publicURL + path
+	}
+
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host + path
+}
+
+// vastDuration formats seconds as the HH:MM:SS of VAST durations and offsets
+func vastDuration(seconds int) string {
+	return fmt.Sprintf("%02d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+}
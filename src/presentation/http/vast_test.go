@@ -0,0 +1,153 @@
+package http
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fall-out-bug/demo-adserver/src/application/delivery"
+	"github.com/fall-out-bug/demo-adserver/src/application/tracking"
+	"github.com/fall-out-bug/demo-adserver/src/domain/entities"
+	"github.com/gin-gonic/gin"
+)
+
+type videoDeliveryService struct {
+	capturingDeliveryService
+	response *delivery.GetBannerResponse
+}
+
+func (m *videoDeliveryService) DeliverBanner(ctx context.Context, slotID string, req *delivery.DeliveryRequest) (*delivery.GetBannerResponse, error) {
+	m.req = req
+	return m.response, nil
+}
+
+func TestDeliveryHandler_HandleVAST(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	service := &videoDeliveryService{response: &delivery.GetBannerResponse{
+		Creative: &delivery.Creative{
+			Type: entities.CreativeVideo,
+			Video: &entities.VideoAssets{
+				Duration:   30,
+				SkipOffset: 5,
+				MediaFiles: []entities.MediaFile{{URL: "https://cdn.example.com/ad.mp4", Type: "video/mp4", Width: 1280, Height: 720, Bitrate: 2000}},
+			},
+		},
+		Tracking: &delivery.TrackingInfo{
+			ImpressionID: "imp-1",
+			Impression:   "/api/v1/track/video/imp-1?event=impression",
+			Click:        "/api/v1/track/click/imp-1",
+			Video: map[string]string{
+				"start":    "/api/v1/track/video/imp-1?event=start",
+				"midpoint": "/api/v1/track/video/imp-1?event=midpoint",
+			},
+		},
+	}}
+	router := gin.New()
+	router.GET("/api/v1/vast/:slot_id", NewDeliveryHandler(service).WithPublicURL("https://ads.example.com/").HandleVAST)
+
+	req, _ := http.NewRequest("GET", "/api/v1/vast/preroll", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if service.req.Format != delivery.FormatVideo {
+		t.Errorf("Expected a video request, got format %q", service.req.Format)
+	}
+
+	var doc VAST
+	if err := xml.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("Expected valid XML, got %v", err)
+	}
+	if doc.Version != VASTVersion || len(doc.Ads) != 1 {
+		t.Fatalf("Expected VAST %s with one ad, got %+v", VASTVersion, doc)
+	}
+
+	inline := doc.Ads[0].InLine
+	if inline.Impressions[0].URL != "https://ads.example.com/api/v1/track/video/imp-1?event=impression" {
+		t.Errorf("Expected absolute impression URL, got %q", inline.Impressions[0].URL)
+	}
+
+	linear := inline.Creatives[0].Linear
+	if linear.Duration != "00:00:30" || linear.SkipOffset != "00:00:05" {
+		t.Errorf("Expected 30s ad skippable after 5s, got %q / %q", linear.Duration, linear.SkipOffset)
+	}
+	if len(linear.TrackingEvents) != 2 || linear.TrackingEvents[0].Event != "start" || linear.TrackingEvents[1].Event != "midpoint" {
+		t.Errorf("Expected start and midpoint trackers in playback order, got %+v", linear.TrackingEvents)
+	}
+	if linear.ClickThrough == nil || linear.ClickThrough.URL != "https://ads.example.com/api/v1/track/click/imp-1" {
+		t.Errorf("Expected click through the tracker, got %+v", linear.ClickThrough)
+	}
+	if len(linear.MediaFiles) != 1 || linear.MediaFiles[0].URL != "https://cdn.example.com/ad.mp4" || linear.MediaFiles[0].Width != 1280 {
+		t.Errorf("Expected media file, got %+v", linear.MediaFiles)
+	}
+
+	// No video campaign: an empty VAST document
+	service.response = &delivery.GetBannerResponse{Fallback: &delivery.FallbackInfo{Enabled: true}}
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `<VAST version="4.2"`) || strings.Contains(w.Body.String(), "<Ad") {
+		t.Errorf("Expected empty VAST response, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+type mockVideoEventService struct {
+	req *tracking.VideoEventRequest
+}
+
+func (m *mockVideoEventService) TrackVideoEvent(ctx context.Context, req *tracking.VideoEventRequest) *tracking.TrackResponse {
+	m.req = req
+	return &tracking.TrackResponse{Success: req.Event.IsValid()}
+}
+
+type recordingImpressionService struct {
+	tracked chan *tracking.TrackRequest
+}
+
+func (m *recordingImpressionService) Track(ctx context.Context, req *tracking.TrackRequest) *tracking.TrackResponse {
+	m.tracked <- req
+	return &tracking.TrackResponse{Success: true}
+}
+
+func TestVideoEventHandler_Handle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	impressions := &recordingImpressionService{tracked: make(chan *tracking.TrackRequest, 1)}
+	events := &mockVideoEventService{}
+	router := gin.New()
+	router.GET("/api/v1/track/video/:impression_id", NewVideoEventHandler(impressions, events).Handle)
+
+	req, _ := http.NewRequest("GET", "/api/v1/track/video/imp-1?event=firstQuartile&banner_id=ban-1&campaign_id=cmp-1&slot_id=preroll", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected status 204, got %d", w.Code)
+	}
+	if events.req.ImpressionID != "imp-1" || events.req.Event != entities.VideoFirstQuartile || events.req.CampaignID != "cmp-1" {
+		t.Errorf("Unexpected video event %+v", events.req)
+	}
+
+	// The impression event records the impression
+	req, _ = http.NewRequest("GET", "/api/v1/track/video/imp-1?event=impression&banner_id=ban-1&campaign_id=cmp-1&slot_id=preroll", nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	tracked := <-impressions.tracked
+	if tracked.ImpressionID != "imp-1" || tracked.BannerID != "ban-1" || tracked.SlotID != "preroll" {
+		t.Errorf("Unexpected impression %+v", tracked)
+	}
+
+	req, _ = http.NewRequest("GET", "/api/v1/track/video/imp-1?event=rewind", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for unknown event, got %d", w.Code)
+	}
+}
@@ -96,6 +96,25 @@ describe('Client', () => {
       );
     });
 
+    it('should include keywords parameter', async () => {
+      mockFetch.mockResolvedValueOnce({
+        ok: true,
+        json: async () => mockResponse,
+      });
+
+      const request: DeliveryRequest = {
+        slotID: 'slot-1',
+        keywords: ['sports', 'football'],
+      };
+
+      await fetchBanner(request);
+
+      expect(mockFetch).toHaveBeenCalledWith(
+        expect.stringContaining('keywords=sports%2Cfootball'),
+        expect.any(Object)
+      );
+    });
+
     it('should handle HTTP errors', async () => {
       mockFetch.mockResolvedValueOnce({
         ok: false,
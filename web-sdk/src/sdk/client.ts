@@ -10,6 +10,7 @@ export interface DeliveryRequest {
   width?: number;
   height?: number;
   referer?: string;
+  keywords?: string[];
 }
 
 export interface DeliveryResponse {
@@ -89,6 +90,7 @@ export async function fetchBanner(
   if (request.width) url.searchParams.set('width', request.width.toString());
   if (request.height) url.searchParams.set('height', request.height.toString());
   if (request.referer) url.searchParams.set('referer', request.referer);
+  if (request.keywords?.length) url.searchParams.set('keywords', request.keywords.join(','));
 
   let lastError: Error | null = null;
 
@@ -192,5 +194,6 @@ export function createDeliveryRequest(
     width: options?.width,
     height: options?.height,
     referer: options?.referer || window.location.href,
+    keywords: options?.keywords,
   };
 }
@@ -13,8 +13,9 @@ export function injectDirect(container: HTMLElement, banner: CachedBanner): void
   // Create wrapper div
   const wrapper = document.createElement('div');
   wrapper.className = 'adserver-banner';
-  wrapper.style.width = `${banner.width}px`;
-  wrapper.style.height = `${banner.height}px`;
+  // Responsive creatives report 0 for the dimensions the slot leaves fluid
+  wrapper.style.width = banner.width ? `${banner.width}px` : '100%';
+  wrapper.style.height = banner.height ? `${banner.height}px` : 'auto';
   wrapper.style.display = 'inline-block';
   wrapper.style.position = 'relative';
 
@@ -42,8 +43,8 @@ export async function injectInIframe(
   return new Promise((resolve) => {
     const iframe = document.createElement('iframe');
     iframe.className = 'adserver-banner-iframe';
-    iframe.width = banner.width.toString();
-    iframe.height = banner.height.toString();
+    iframe.width = banner.width ? banner.width.toString() : '100%';
+    iframe.height = banner.height ? banner.height.toString() : '100%';
     iframe.style.border = 'none';
     iframe.style.overflow = 'hidden';
     iframe.style.display = 'block';